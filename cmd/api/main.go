@@ -25,11 +25,14 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // fusos IANA mesmo em imagens sem zoneinfo
 
+	"api-go/internal/jobs"
+	"api-go/internal/scheduler"
 	"api-go/internal/server"
 )
 
-func gracefulShutdown(apiServer *http.Server, done chan bool) {
+func gracefulShutdown(apiServer *http.Server, workers *jobs.Runner, tasks *scheduler.Scheduler, done chan bool) {
 	// Create context that listens for the interrupt signal from the OS.
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -48,6 +51,21 @@ func gracefulShutdown(apiServer *http.Server, done chan bool) {
 		log.Printf("Server forced to shutdown with error: %v", err)
 	}
 
+	// Para de agendar tarefas e aguarda as que estão rodando.
+	tasksCtx, cancelTasks := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelTasks()
+	if err := tasks.Shutdown(tasksCtx); err != nil {
+		log.Printf("Scheduler forced to shutdown with error: %v", err)
+	}
+
+	// Aguarda os jobs em andamento. Os que não terminarem a tempo são
+	// cancelados e voltam para a fila do outbox.
+	workersCtx, cancelWorkers := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelWorkers()
+	if err := workers.Shutdown(workersCtx); err != nil {
+		log.Printf("Workers forced to shutdown with error: %v", err)
+	}
+
 	log.Println("Server exiting")
 
 	// Notify the main goroutine that the shutdown is complete
@@ -56,13 +74,13 @@ func gracefulShutdown(apiServer *http.Server, done chan bool) {
 
 func main() {
 
-	server := server.NewServer()
+	server, workers, tasks := server.NewServer()
 
 	// Create a done channel to signal when the shutdown is complete
 	done := make(chan bool, 1)
 
 	// Run graceful shutdown in a separate goroutine
-	go gracefulShutdown(server, done)
+	go gracefulShutdown(server, workers, tasks, done)
 
 	err := server.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
@@ -0,0 +1,355 @@
+// Package accounts cuida do fim do ciclo de vida das contas: a exportação
+// dos dados do usuário e a exclusão da conta com período de carência, em que
+// ela ainda pode ser desfeita.
+package accounts
+
+import (
+	"api-go/internal/audit"
+	"api-go/internal/events"
+	"api-go/internal/jobs"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/storage"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrDeletionScheduled é retornado ao pedir a exclusão de uma conta que já
+// tem exclusão agendada.
+var ErrDeletionScheduled = errors.New("account deletion already scheduled")
+
+// ErrNoDeletionScheduled é retornado ao desfazer uma exclusão inexistente.
+var ErrNoDeletionScheduled = errors.New("no account deletion scheduled")
+
+type Service struct {
+	UserRepository         *repository.UserRepository
+	RoomsRepository        *repository.RoomsRepository
+	OwnershipRepository    *repository.OwnershipRepository
+	NotesRepository        *repository.NotesRepository
+	ReservationsRepository *repository.ReservationsRepository
+	WaitlistRepository     *repository.WaitlistRepository
+	WebhooksRepository     *repository.WebhooksRepository
+	ExportsRepository      *repository.ExportsRepository
+	Outbox                 *jobs.Outbox
+	BlobStore              storage.BlobStore
+	Audit                  *audit.Log
+
+	// DeletionGrace é o prazo para desfazer a exclusão da conta.
+	DeletionGrace time.Duration
+
+	// DeletedContent é a política de conteúdo usada quando o pedido de
+	// exclusão não escolhe uma (models.DeletedContentAnonymize ou
+	// models.DeletedContentDelete).
+	DeletedContent string
+
+	// ExportTTL é por quanto tempo o link de uma exportação pronta vale.
+	ExportTTL time.Duration
+}
+
+// Register registra o job que gera as exportações.
+func (s *Service) Register(runner *jobs.Runner) {
+	runner.Register(KindBuildExport, s.buildExport, jobs.RetryPolicy{MaxAttempts: 3})
+}
+
+// ScheduleDeletion agenda a exclusão da conta para o fim do período de
+// carência. content vazio usa a política padrão.
+func (s *Service) ScheduleDeletion(userID uint, content string) (time.Time, string, error) {
+	if content == "" {
+		content = s.DeletedContent
+	}
+	at := time.Now().Add(s.DeletionGrace)
+
+	scheduled, err := s.UserRepository.ScheduleDeletion(userID, at, content)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	if !scheduled {
+		return time.Time{}, "", ErrDeletionScheduled
+	}
+	return at, content, nil
+}
+
+// CancelDeletion desfaz a exclusão agendada da conta.
+func (s *Service) CancelDeletion(userID uint) error {
+	cancelled, err := s.UserRepository.CancelDeletion(userID)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return ErrNoDeletionScheduled
+	}
+	return nil
+}
+
+// FinalizeDeletions conclui as exclusões cujo período de carência terminou.
+// É executada periodicamente pelo agendador.
+func (s *Service) FinalizeDeletions(ctx context.Context) error {
+	users, err := s.UserRepository.WithContext(ctx).GetDueDeletions(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.finalize(ctx, &user); err != nil {
+			log.Printf("failed to delete account of user %d: %v", user.ID, err)
+		}
+	}
+	return nil
+}
+
+// finalize exclui a conta de vez numa única transação: as salas do usuário
+// passam para o admin mais antigo (ou, sem admins, para o membro mais
+// antigo) e as que ficam sem ninguém são arquivadas, as reservas futuras
+// são canceladas, ele sai das salas e das listas de espera, os webhooks
+// dele são removidos, as notas seguem a política escolhida e os dados
+// pessoais são apagados.
+func (s *Service) finalize(ctx context.Context, user *models.User) error {
+	now := time.Now()
+	var exportKeys []string
+
+	err := s.Outbox.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		roomsRepo := s.RoomsRepository.WithTx(tx)
+		rooms, err := roomsRepo.GetOwnedRooms(user.ID)
+		if err != nil {
+			return err
+		}
+		for i := range rooms {
+			successor, err := roomsRepo.GetSuccessor(rooms[i].ID, user.ID)
+			if err != nil {
+				return err
+			}
+			if successor == nil {
+				if err := s.archiveOwnerless(tx, &rooms[i]); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := roomsRepo.TransferOwnership(rooms[i].ID, user.ID, successor.UserID); err != nil {
+				return err
+			}
+			err = s.Audit.Record(tx, audit.Meta{}, audit.Entry{
+				Action:     audit.ActionOwnershipTransferred,
+				TargetType: models.AuditTargetRoom,
+				TargetID:   rooms[i].ID,
+				RoomID:     rooms[i].ID,
+				Before:     map[string]uint{"owner_id": user.ID},
+				After:      map[string]uint{"owner_id": successor.UserID},
+			})
+			if err != nil {
+				return err
+			}
+			err = s.Outbox.Publish(tx, events.Event{
+				Type:    events.RoomOwnershipTransferred,
+				ActorID: user.ID,
+				RoomID:  rooms[i].ID,
+				UserID:  successor.UserID,
+				Data: map[string]any{
+					"room_name":           rooms[i].Name,
+					"new_owner_name":      successor.User.Name,
+					"previous_owner_name": user.Name,
+				},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if err := s.OwnershipRepository.WithTx(tx).CancelForUser(user.ID, now); err != nil {
+			return err
+		}
+
+		if err := s.cancelReservations(tx, user, now); err != nil {
+			return err
+		}
+		if err := s.WaitlistRepository.WithTx(tx).CancelForUser(user.ID); err != nil {
+			return err
+		}
+		if err := s.WebhooksRepository.WithTx(tx).DeleteByUserID(user.ID); err != nil {
+			return err
+		}
+
+		if user.DeletionContent == models.DeletedContentDelete {
+			if err := s.deleteNotes(tx, user); err != nil {
+				return err
+			}
+		}
+
+		if exportKeys, err = s.ExportsRepository.WithTx(tx).DeleteByUserID(user.ID); err != nil {
+			return err
+		}
+
+		// A anonimização tira o usuário das salas, diretamente e pelos
+		// grupos, em todas as organizações.
+		memberships, err := roomsRepo.GetEffectiveMemberships([]uint{user.ID}, nil)
+		if err != nil {
+			return err
+		}
+		anonymized, err := s.UserRepository.WithTx(tx).Anonymize(user.ID, now)
+		if err != nil {
+			return err
+		}
+		if !anonymized {
+			// A exclusão foi desfeita enquanto o resto era preparado.
+			return errDeletionCancelled
+		}
+		if err := s.publishDepartures(tx, memberships); err != nil {
+			return err
+		}
+		err = s.Outbox.Publish(tx, events.Event{
+			Type:   events.UserSessionsRevoked,
+			UserID: user.ID,
+		})
+		if err != nil {
+			return err
+		}
+		return s.Audit.Record(tx, audit.Meta{}, audit.Entry{
+			Action:     audit.ActionUserDeleted,
+			TargetType: models.AuditTargetUser,
+			TargetID:   user.ID,
+			After:      map[string]string{"content": user.DeletionContent},
+		})
+	})
+	if errors.Is(err, errDeletionCancelled) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, key := range exportKeys {
+		if err := s.BlobStore.Delete(ctx, key); err != nil {
+			log.Printf("failed to delete export blob %s: %v", key, err)
+		}
+	}
+	log.Printf("account of user %d deleted (content: %s)", user.ID, user.DeletionContent)
+	return nil
+}
+
+var errDeletionCancelled = errors.New("account deletion cancelled")
+
+// publishDepartures registra na trilha e avisa as salas de que o usuário
+// excluído saiu delas.
+func (s *Service) publishDepartures(tx *gorm.DB, memberships []repository.EffectiveMembership) error {
+	for _, m := range memberships {
+		err := s.Audit.Record(tx, audit.Meta{}, audit.Entry{
+			Action:     audit.ActionMemberLeft,
+			TargetType: models.AuditTargetUser,
+			TargetID:   m.UserID,
+			RoomID:     m.RoomID,
+			Before:     map[string]string{"role": m.Role},
+		})
+		if err != nil {
+			return err
+		}
+		err = s.Outbox.Publish(tx, events.Event{
+			Type:    events.RoomMemberLeft,
+			ActorID: m.UserID,
+			RoomID:  m.RoomID,
+			UserID:  m.UserID,
+			Data: map[string]any{
+				"actor_name": m.UserName,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cancelReservations cancela as reservas do usuário que ainda não
+// terminaram, liberando os lugares para a lista de espera.
+func (s *Service) cancelReservations(tx *gorm.DB, user *models.User, now time.Time) error {
+	reservationsRepo := s.ReservationsRepository.WithTx(tx)
+	reservations, err := reservationsRepo.GetUpcomingByUserID(user.ID, now)
+	if err != nil {
+		return err
+	}
+
+	for _, reservation := range reservations {
+		if err := reservationsRepo.Delete(reservation.ID); err != nil {
+			return err
+		}
+		room, err := s.RoomsRepository.WithTx(tx).FindByID(reservation.RoomID)
+		if err != nil {
+			return err
+		}
+		if room == nil {
+			continue
+		}
+		err = s.Outbox.Publish(tx, events.Event{
+			Type:          events.ReservationCancelled,
+			ActorID:       user.ID,
+			RoomID:        room.ID,
+			ReservationID: reservation.ID,
+			UserID:        reservation.UserID,
+			Data: map[string]any{
+				"actor_name": user.Name,
+				"room_name":  room.Name,
+				"time_zone":  room.Location().String(),
+				"start_time": reservation.StartTime.Format(time.RFC3339),
+				"end_time":   reservation.EndTime.Format(time.RFC3339),
+				"status":     reservation.Status,
+				"reason":     "account_deleted",
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archiveOwnerless arquiva a sala que ficou sem sucessor, para que ela não
+// continue ativa com um dono removido. A entrada na trilha, sem autor e com
+// o motivo, é o que os administradores usam para encontrá-la.
+func (s *Service) archiveOwnerless(tx *gorm.DB, room *models.Room) error {
+	if room.IsArchived() {
+		return nil
+	}
+	if err := s.RoomsRepository.WithTx(tx).SetArchived(room.ID, true); err != nil {
+		return err
+	}
+	return s.Audit.Record(tx, audit.Meta{}, audit.Entry{
+		Action:     audit.ActionRoomArchived,
+		TargetType: models.AuditTargetRoom,
+		TargetID:   room.ID,
+		RoomID:     room.ID,
+		After:      map[string]string{"reason": "owner_deleted"},
+	})
+}
+
+// deleteNotes move as notas do usuário para a lixeira e avisa as salas.
+func (s *Service) deleteNotes(tx *gorm.DB, user *models.User) error {
+	notesRepo := s.NotesRepository.WithTx(tx)
+	notes, err := notesRepo.GetByUserID(user.ID)
+	if err != nil {
+		return err
+	}
+	if err := notesRepo.DeleteByUserID(user.ID); err != nil {
+		return err
+	}
+
+	for _, note := range notes {
+		err := s.Outbox.Publish(tx, events.Event{
+			Type:    events.NoteDeleted,
+			ActorID: user.ID,
+			RoomID:  note.RoomID,
+			NoteID:  note.ID,
+			Data: map[string]any{
+				"actor_name": user.Name,
+				"note_title": note.Title,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package accounts
+
+import (
+	"fmt"
+	"time"
+)
+
+// archive é o conteúdo de uma exportação. No formato JSON ele é gravado
+// inteiro; no ZIP, cada parte vira um arquivo.
+type archive struct {
+	ExportedAt   time.Time            `json:"exported_at"`
+	Profile      archiveProfile       `json:"profile"`
+	Rooms        []archiveRoom        `json:"rooms"`
+	Memberships  []archiveMembership  `json:"memberships"`
+	Notes        []archiveNote        `json:"notes"`
+	Reservations []archiveReservation `json:"reservations"`
+}
+
+type archiveProfile struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	TimeZone  string    `json:"time_zone"`
+	NoShows   int       `json:"no_shows"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// archiveRoom é uma sala criada pelo usuário.
+type archiveRoom struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Subject     string     `json:"subject"`
+	Capacity    int        `json:"capacity"`
+	TimeZone    string     `json:"time_zone"`
+	Visibility  string     `json:"visibility"`
+	ArchivedAt  *time.Time `json:"archived_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type archiveMembership struct {
+	RoomID   uint      `json:"room_id"`
+	RoomName string    `json:"room_name"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type archiveNote struct {
+	ID        uint      `json:"id"`
+	RoomID    uint      `json:"room_id"`
+	RoomName  string    `json:"room_name"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	Format    string    `json:"format"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type archiveReservation struct {
+	ID          uint       `json:"id"`
+	RoomID      uint       `json:"room_id"`
+	StartTime   time.Time  `json:"start_time"`
+	EndTime     time.Time  `json:"end_time"`
+	Status      string     `json:"status"`
+	CheckedInAt *time.Time `json:"checked_in_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// collect reúne os dados do usuário para a exportação.
+func (s *Service) collect(userID uint) (*archive, error) {
+	user, err := s.UserRepository.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("loading profile: %w", err)
+	}
+
+	data := &archive{
+		ExportedAt: time.Now(),
+		Profile: archiveProfile{
+			ID:        user.ID,
+			Name:      user.Name,
+			Email:     user.Email,
+			TimeZone:  user.TimeZone,
+			NoShows:   user.NoShows,
+			CreatedAt: user.CreatedAt,
+			UpdatedAt: user.UpdatedAt,
+		},
+		Rooms:        []archiveRoom{},
+		Memberships:  []archiveMembership{},
+		Notes:        []archiveNote{},
+		Reservations: []archiveReservation{},
+	}
+
+	rooms, err := s.RoomsRepository.GetOwnedRooms(userID)
+	if err != nil {
+		return nil, fmt.Errorf("loading rooms: %w", err)
+	}
+	for _, room := range rooms {
+		data.Rooms = append(data.Rooms, archiveRoom{
+			ID:          room.ID,
+			Name:        room.Name,
+			Description: room.Description,
+			Subject:     room.Subject,
+			Capacity:    room.Capacity,
+			TimeZone:    room.TimeZone,
+			Visibility:  room.Visibility,
+			ArchivedAt:  room.ArchivedAt,
+			CreatedAt:   room.CreatedAt,
+		})
+	}
+
+	memberships, err := s.RoomsRepository.GetMemberships(userID)
+	if err != nil {
+		return nil, fmt.Errorf("loading memberships: %w", err)
+	}
+	for _, membership := range memberships {
+		data.Memberships = append(data.Memberships, archiveMembership{
+			RoomID:   membership.RoomID,
+			RoomName: membership.Room.Name,
+			Role:     membership.Role,
+			JoinedAt: membership.CreatedAt,
+		})
+	}
+
+	notes, err := s.NotesRepository.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("loading notes: %w", err)
+	}
+	for _, note := range notes {
+		data.Notes = append(data.Notes, archiveNote{
+			ID:        note.ID,
+			RoomID:    note.RoomID,
+			RoomName:  note.Room.Name,
+			Title:     note.Title,
+			Content:   note.Content,
+			Format:    note.Format,
+			CreatedAt: note.CreatedAt,
+			UpdatedAt: note.UpdatedAt,
+		})
+	}
+
+	reservations, err := s.ReservationsRepository.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("loading reservations: %w", err)
+	}
+	for _, reservation := range reservations {
+		data.Reservations = append(data.Reservations, archiveReservation{
+			ID:          reservation.ID,
+			RoomID:      reservation.RoomID,
+			StartTime:   reservation.StartTime,
+			EndTime:     reservation.EndTime,
+			Status:      reservation.Status,
+			CheckedInAt: reservation.CheckedInAt,
+			CreatedAt:   reservation.CreatedAt,
+		})
+	}
+	return data, nil
+}
@@ -0,0 +1,273 @@
+package accounts
+
+import (
+	"api-go/internal/events"
+	"api-go/internal/jobs"
+	"api-go/internal/models"
+	"api-go/internal/storage"
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// KindBuildExport é o job que gera o arquivo de uma exportação.
+const KindBuildExport = "account.export"
+
+var (
+	ErrInvalidExportFormat = errors.New("invalid export format")
+	ErrExportInProgress    = errors.New("an export is already being generated")
+	ErrExportNotFound      = errors.New("export not found")
+	ErrExportNotReady      = errors.New("export is not ready yet")
+	ErrExportUnavailable   = errors.New("export link was already used or has expired")
+)
+
+type exportPayload struct {
+	ExportID uint `json:"export_id"`
+}
+
+// RequestExport cria o pedido de exportação e enfileira a geração do
+// arquivo. Retorna também o token do link de download, que não fica
+// guardado e só é mostrado agora.
+func (s *Service) RequestExport(userID uint, format string) (*models.DataExport, string, error) {
+	if format == "" {
+		format = models.ExportFormatZIP
+	}
+	if format != models.ExportFormatZIP && format != models.ExportFormatJSON {
+		return nil, "", ErrInvalidExportFormat
+	}
+
+	pending, err := s.ExportsRepository.HasPending(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if pending {
+		return nil, "", ErrExportInProgress
+	}
+
+	token, err := newExportToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	export := models.DataExport{
+		UserID:    userID,
+		Format:    format,
+		Status:    models.ExportPending,
+		TokenHash: HashExportToken(token),
+	}
+	err = s.Outbox.Transaction(func(tx *gorm.DB) error {
+		if err := s.ExportsRepository.WithTx(tx).Create(&export); err != nil {
+			return err
+		}
+		return s.Outbox.Enqueue(tx, jobs.Job{
+			Kind:           KindBuildExport,
+			Payload:        exportPayload{ExportID: export.ID},
+			IdempotencyKey: fmt.Sprintf("%s:%d", KindBuildExport, export.ID),
+		})
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return &export, token, nil
+}
+
+// OpenExport gasta o link de uso único e abre o arquivo da exportação. Quem
+// chama fecha o blob e depois chama DiscardExport.
+func (s *Service) OpenExport(ctx context.Context, token string) (*models.DataExport, storage.Blob, error) {
+	export, err := s.ExportsRepository.GetByTokenHash(HashExportToken(token))
+	if err != nil {
+		return nil, nil, err
+	}
+	if export == nil {
+		return nil, nil, ErrExportNotFound
+	}
+	if export.Status == models.ExportPending {
+		return nil, nil, ErrExportNotReady
+	}
+	if export.Status != models.ExportReady {
+		return nil, nil, ErrExportUnavailable
+	}
+
+	blob, err := s.BlobStore.Get(ctx, export.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	consumed, err := s.ExportsRepository.Consume(export.ID, time.Now())
+	if err != nil || !consumed {
+		blob.Close()
+		if err == nil {
+			err = ErrExportUnavailable
+		}
+		return nil, nil, err
+	}
+	return export, blob, nil
+}
+
+// DiscardExport remove do storage o arquivo de uma exportação já baixada.
+func (s *Service) DiscardExport(ctx context.Context, export *models.DataExport) {
+	if err := s.BlobStore.Delete(ctx, export.StorageKey); err != nil {
+		log.Printf("failed to delete export blob %s: %v", export.StorageKey, err)
+	}
+}
+
+// PurgeExpiredExports encerra as exportações cujo link expirou sem download
+// e remove os arquivos delas. É executada periodicamente pelo agendador.
+func (s *Service) PurgeExpiredExports(ctx context.Context) error {
+	exports, err := s.ExportsRepository.WithContext(ctx).GetExpired(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		expired, err := s.ExportsRepository.WithContext(ctx).Expire(export.ID)
+		if err != nil {
+			log.Printf("failed to expire export %d: %v", export.ID, err)
+			continue
+		}
+		if expired {
+			s.DiscardExport(ctx, &export)
+		}
+	}
+	return nil
+}
+
+// HashExportToken retorna o hash guardado no lugar do token do link.
+func HashExportToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newExportToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// buildExport gera o arquivo da exportação e avisa o usuário quando ele
+// fica pronto. Na última tentativa, a falha encerra a exportação.
+func (s *Service) buildExport(ctx context.Context, job *models.OutboxJob) error {
+	var payload exportPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return fmt.Errorf("%w: %v", jobs.ErrPermanent, err)
+	}
+
+	export, err := s.ExportsRepository.GetByID(payload.ExportID)
+	if err != nil {
+		return err
+	}
+	if export == nil || export.Status != models.ExportPending {
+		return nil
+	}
+
+	err = s.writeExport(ctx, export)
+	if err != nil && (job.Attempts >= job.MaxAttempts || errors.Is(err, jobs.ErrPermanent)) {
+		if markErr := s.ExportsRepository.MarkFailed(export.ID, err.Error()); markErr != nil {
+			log.Printf("failed to mark export %d as failed: %v", export.ID, markErr)
+		}
+	}
+	return err
+}
+
+func (s *Service) writeExport(ctx context.Context, export *models.DataExport) error {
+	data, err := s.collect(export.UserID)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	contentType := "application/json"
+	if export.Format == models.ExportFormatZIP {
+		contentType = "application/zip"
+		err = writeZIP(&body, data)
+	} else {
+		encoder := json.NewEncoder(&body)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(data)
+	}
+	if err != nil {
+		return err
+	}
+
+	suffix, err := newExportToken()
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("exports/%d/%d-%s.%s", export.UserID, export.ID, suffix[:16], export.Format)
+	size := int64(body.Len())
+	if err := s.BlobStore.Put(ctx, key, &body, size, contentType); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.ExportTTL)
+	ready := false
+	err = s.Outbox.Transaction(func(tx *gorm.DB) error {
+		var err error
+		ready, err = s.ExportsRepository.WithTx(tx).MarkReady(export.ID, key, size, now, expiresAt)
+		if err != nil || !ready {
+			return err
+		}
+		return s.Outbox.Publish(tx, events.Event{
+			Type:   events.AccountExportReady,
+			UserID: export.UserID,
+			Data: map[string]any{
+				"export_id":  export.ID,
+				"format":     export.Format,
+				"expires_at": expiresAt.Format(time.RFC3339),
+			},
+		})
+	})
+	if err != nil || !ready {
+		// O arquivo não foi associado à exportação; não pode ficar órfão.
+		if deleteErr := s.BlobStore.Delete(ctx, key); deleteErr != nil {
+			log.Printf("failed to delete export blob %s: %v", key, deleteErr)
+		}
+	}
+	return err
+}
+
+// writeZIP grava cada parte da exportação num arquivo JSON próprio.
+func writeZIP(w *bytes.Buffer, data *archive) error {
+	archiveWriter := zip.NewWriter(w)
+	files := []struct {
+		name    string
+		content any
+	}{
+		{"profile.json", data.Profile},
+		{"rooms.json", data.Rooms},
+		{"memberships.json", data.Memberships},
+		{"notes.json", data.Notes},
+		{"reservations.json", data.Reservations},
+	}
+	for _, file := range files {
+		entry, err := archiveWriter.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: data.ExportedAt,
+		})
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(entry)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.content); err != nil {
+			return err
+		}
+	}
+	return archiveWriter.Close()
+}
@@ -0,0 +1,139 @@
+// Package audit grava a trilha de auditoria das ações de segurança e de
+// administração: quem fez o quê, em qual alvo, de onde e como o alvo estava
+// antes e depois. As entradas são gravadas na transação da mudança auditada,
+// então uma não existe sem a outra.
+package audit
+
+import (
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// Ações registradas na trilha.
+const (
+	ActionLogin       = "auth.login"
+	ActionLoginFailed = "auth.login_failed"
+
+	ActionPasswordChanged       = "user.password_changed"
+	ActionPasswordReset         = "user.password_reset"
+	ActionPasswordResetRequired = "user.password_reset_required"
+	ActionUserRoleChanged       = "user.role_changed"
+	ActionUserSuspended         = "user.suspended"
+	ActionUserReactivated       = "user.reactivated"
+	ActionUserDeleted           = "user.deleted"
+
+	ActionRoomUpdated          = "room.updated"
+	ActionRoomArchived         = "room.archived"
+	ActionRoomUnarchived       = "room.unarchived"
+	ActionRoomDeleted          = "room.deleted"
+	ActionRoomRestored         = "room.restored"
+	ActionRoomPurged           = "room.purged"
+	ActionOwnershipTransferred = "room.ownership_transferred"
+
+	ActionMemberJoined      = "room.member_joined"
+	ActionMemberLeft        = "room.member_left"
+	ActionMemberRoleChanged = "room.member_role_changed"
+
+	ActionNoteDeleted  = "note.deleted"
+	ActionNoteRestored = "note.restored"
+	ActionNotePurged   = "note.purged"
+
+	ActionOrgMemberAdded       = "organization.member_added"
+	ActionOrgMemberRoleChanged = "organization.member_role_changed"
+	ActionOrgMemberRemoved     = "organization.member_removed"
+
+	ActionGroupDeleted       = "group.deleted"
+	ActionGroupMemberAdded   = "group.member_added"
+	ActionGroupMemberRemoved = "group.member_removed"
+
+	ActionRoomGroupGranted     = "room.group_granted"
+	ActionRoomGroupRoleChanged = "room.group_role_changed"
+	ActionRoomGroupRevoked     = "room.group_revoked"
+)
+
+// Meta identifica quem fez a ação e de onde. Fica zerada nas ações do
+// sistema, como as tarefas agendadas.
+type Meta struct {
+	ActorID   *uint
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+// Entry descreve a ação auditada. Before e After são serializados em JSON;
+// nil omite a fotografia.
+type Entry struct {
+	Action     string
+	TargetType string
+	TargetID   uint
+	// RoomID é a sala a que a ação se refere, ou zero.
+	RoomID uint
+	Before any
+	After  any
+}
+
+// Log grava as entradas da trilha.
+type Log struct {
+	Repository *repository.AuditRepository
+}
+
+// Record grava a entrada usando tx, que deve ser a transação da mudança
+// auditada. Com tx nil, a entrada é gravada numa transação própria.
+func (l *Log) Record(tx *gorm.DB, meta Meta, entry Entry) error {
+	before, err := snapshot(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := snapshot(entry.After)
+	if err != nil {
+		return err
+	}
+
+	record := models.AuditEntry{
+		ActorID:    meta.ActorID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Before:     before,
+		After:      after,
+		IP:         clean(meta.IP, 64),
+		UserAgent:  clean(meta.UserAgent, 512),
+		RequestID:  clean(meta.RequestID, 128),
+	}
+	if entry.RoomID != 0 {
+		roomID := entry.RoomID
+		record.RoomID = &roomID
+	}
+
+	repo := l.Repository
+	if tx != nil {
+		repo = repo.WithTx(tx)
+	}
+	return repo.Append(&record)
+}
+
+func snapshot(value any) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// clean prepara um texto vindo do cliente para ser gravado: o Postgres não
+// aceita bytes nulos nem UTF-8 inválido, e o tamanho é limitado.
+func clean(text string, max int) string {
+	text = strings.ReplaceAll(strings.ToValidUTF8(text, "�"), "\x00", "")
+	if utf8.RuneCountInString(text) > max {
+		text = string([]rune(text)[:max])
+	}
+	return text
+}
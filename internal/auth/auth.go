@@ -16,17 +16,32 @@ type Claims struct {
 	UserID uint   `json:"user_id"`
 	Name   string `json:"name"`
 	Email  string `json:"email"`
+	Role   string `json:"role"`
+
+	// OrganizationID é a organização ativa do token, usada quando a
+	// requisição não indica outra.
+	OrganizationID uint `json:"org,omitempty"`
 
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(user *models.User) (string, error) {
+// IsAdmin informa se o token é de um administrador global.
+func (c *Claims) IsAdmin() bool {
+	return c.Role == models.UserRoleAdmin
+}
+
+// GenerateToken gera o token do usuário com orgID como organização ativa.
+// Zero deixa o token sem organização.
+func GenerateToken(user *models.User, orgID uint) (string, error) {
 	claims := Claims{
-		UserID: user.ID,
-		Name:   user.Name,
-		Email:  user.Email,
+		UserID:         user.ID,
+		Name:           user.Name,
+		Email:          user.Email,
+		Role:           user.Role,
+		OrganizationID: orgID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    "api-go",
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expirationTime) * time.Second)),
 		},
 	}
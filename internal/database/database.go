@@ -8,7 +8,7 @@ import (
 	"strconv"
 	"time"
 
-	"api-go/internal/models"
+	"api-go/internal/tenant"
 
 	// Autoloads .env file
 	"gorm.io/driver/postgres"
@@ -55,22 +55,13 @@ func New() Service {
 		host, username, password, database, port, schema)
 
 	// Open a new GORM database connection.
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	db, err := Open(dsn, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info), // Configure logger
 	})
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		log.Fatalf("Failed to open database: %v", err)
 	}
 
-	log.Println("Database connection established successfully.")
-
-	log.Println("Running database migrations...")
-	err = db.AutoMigrate(&models.User{}, &models.Room{}, &models.Reservation{}, &models.RoomMember{}, &models.Note{})
-	if err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
-	}
-	log.Println("Migrations completed.")
-
 	// Create the singleton instance.
 	dbInstance = &service{
 		db: db,
@@ -78,6 +69,28 @@ func New() Service {
 	return dbInstance
 }
 
+// Open conecta ao banco do dsn, registra os callbacks que restringem as
+// consultas à organização da requisição e roda as migrações.
+func Open(dsn string, config *gorm.Config) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(dsn), config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	log.Println("Database connection established successfully.")
+
+	// Restringe as consultas à organização da requisição.
+	if err := tenant.Register(db); err != nil {
+		return nil, fmt.Errorf("failed to register tenant callbacks: %w", err)
+	}
+
+	log.Println("Running database migrations...")
+	if err := migrate(db); err != nil {
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+	log.Println("Migrations completed.")
+	return db, nil
+}
+
 func (s *service) Health() map[string]string {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
@@ -0,0 +1,411 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"api-go/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	gormschema "gorm.io/gorm/schema"
+)
+
+// migratedModels lista os modelos migrados pelo AutoMigrate. As tabelas
+// referenciadas vêm antes das que as referenciam.
+var migratedModels = []any{
+	&models.User{},
+	&models.DataExport{},
+	&models.Organization{},
+	&models.OrganizationMember{},
+	&models.Site{},
+	&models.Building{},
+	&models.Floor{},
+	&models.Room{},
+	&models.RoomAmenity{},
+	&models.Reservation{},
+	&models.RoomMember{},
+	&models.UserGroup{},
+	&models.UserGroupMember{},
+	&models.RoomGroupGrant{},
+	&models.Note{},
+	&models.Attachment{},
+	&models.Mention{},
+	&models.Notification{},
+	&models.NotificationPreference{},
+	&models.Webhook{},
+	&models.WebhookDelivery{},
+	&models.OutboxJob{},
+	&models.WaitlistEntry{},
+	&models.RoomBlackout{},
+	&models.RoomInvite{},
+	&models.RoomJoinRequest{},
+	&models.RoomOwnershipTransfer{},
+	&models.AuditEntry{},
+}
+
+// migrate prepara o banco existente para as chaves estrangeiras e os índices
+// únicos dos modelos e depois roda o AutoMigrate. Tudo acontece numa única
+// transação: se algum passo falhar, o banco fica como estava.
+func migrate(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := backfillOrganizations(tx); err != nil {
+			return err
+		}
+		if err := backfillLocationOrganizations(tx); err != nil {
+			return err
+		}
+		constraints, err := modelConstraints(tx)
+		if err != nil {
+			return err
+		}
+		if err := cleanOrphans(tx, constraints); err != nil {
+			return err
+		}
+		if err := dedupeRoomMembers(tx); err != nil {
+			return err
+		}
+		if err := fixRoomMembersPrimaryKey(tx); err != nil {
+			return err
+		}
+		if err := dropOutdatedConstraints(tx, constraints); err != nil {
+			return err
+		}
+		if err := markRedeliveries(tx); err != nil {
+			return err
+		}
+		if err := tx.AutoMigrate(migratedModels...); err != nil {
+			return err
+		}
+		if _, err := defaultOrganization(tx); err != nil {
+			return err
+		}
+		return protectAuditEntries(tx)
+	})
+}
+
+// backfillOrganizations prepara os bancos anteriores às organizações: cria a
+// organização padrão, passa para ela todas as salas e põe nela todos os
+// usuários, com os administradores globais como donos. Só roda enquanto
+// rooms ainda não tem a coluna organization_id, que o AutoMigrate não
+// conseguiria criar como NOT NULL com as salas existentes.
+func backfillOrganizations(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&models.Room{}) || migrator.HasColumn(&models.Room{}, "OrganizationID") {
+		return nil
+	}
+
+	if err := migrator.AutoMigrate(&models.Organization{}, &models.OrganizationMember{}); err != nil {
+		return err
+	}
+	org, err := defaultOrganization(db)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Moving existing rooms and users to organization %q", org.Slug)
+	if err := db.Exec("ALTER TABLE rooms ADD COLUMN organization_id bigint").Error; err != nil {
+		return err
+	}
+	if err := db.Exec("UPDATE rooms SET organization_id = ?", org.ID).Error; err != nil {
+		return fmt.Errorf("moving rooms to the default organization: %w", err)
+	}
+
+	// Os bancos muito antigos ainda não têm o papel global nem a anonimização.
+	role := clause.Expr{SQL: "?", Vars: []any{models.OrgRoleMember}}
+	if migrator.HasColumn(&models.User{}, "Role") {
+		role = clause.Expr{
+			SQL:  "CASE WHEN role = ? THEN ? ELSE ? END",
+			Vars: []any{models.UserRoleAdmin, models.OrgRoleOwner, models.OrgRoleMember},
+		}
+	}
+	active := clause.Expr{SQL: "deleted_at IS NULL"}
+	if migrator.HasColumn(&models.User{}, "AnonymizedAt") {
+		active = clause.Expr{SQL: "deleted_at IS NULL AND anonymized_at IS NULL"}
+	}
+	err = db.Exec(`INSERT INTO organization_members (created_at, updated_at, organization_id, user_id, role)
+		SELECT NOW(), NOW(), ?, id, ? FROM users WHERE ?
+		ON CONFLICT DO NOTHING`, org.ID, role, active).Error
+	if err != nil {
+		return fmt.Errorf("adding users to the default organization: %w", err)
+	}
+	return nil
+}
+
+// backfillLocationOrganizations passa os locais, prédios e andares
+// existentes, que eram compartilhados, para uma organização: a da primeira
+// sala posicionada no local ou, sem salas, a organização padrão. Os prédios
+// e os andares seguem o local, e as salas de outras organizações que
+// estavam nele ficam sem localização. Como backfillOrganizations, só roda
+// enquanto sites ainda não tem a coluna organization_id.
+func backfillLocationOrganizations(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&models.Site{}) || migrator.HasColumn(&models.Site{}, "OrganizationID") {
+		return nil
+	}
+
+	org, err := defaultOrganization(db)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Moving existing locations to the organizations of their rooms")
+	statements := []string{
+		"ALTER TABLE sites ADD COLUMN organization_id bigint",
+		`UPDATE sites SET organization_id = COALESCE((SELECT MIN(rooms.organization_id) FROM rooms
+			JOIN floors ON floors.id = rooms.floor_id
+			JOIN buildings ON buildings.id = floors.building_id
+			WHERE buildings.site_id = sites.id), @org)`,
+		"ALTER TABLE buildings ADD COLUMN organization_id bigint",
+		"UPDATE buildings SET organization_id = COALESCE((SELECT organization_id FROM sites WHERE sites.id = buildings.site_id), @org)",
+		"ALTER TABLE floors ADD COLUMN organization_id bigint",
+		"UPDATE floors SET organization_id = COALESCE((SELECT organization_id FROM buildings WHERE buildings.id = floors.building_id), @org)",
+		`UPDATE rooms SET floor_id = NULL, plan_x = NULL, plan_y = NULL
+			WHERE EXISTS (SELECT 1 FROM floors WHERE floors.id = rooms.floor_id AND floors.organization_id <> rooms.organization_id)`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement, sql.Named("org", org.ID)).Error; err != nil {
+			return fmt.Errorf("moving locations to organizations: %w", err)
+		}
+	}
+	return nil
+}
+
+// defaultOrganization retorna a organização padrão, criando-a se preciso.
+func defaultOrganization(db *gorm.DB) (*models.Organization, error) {
+	org := models.Organization{Name: "Default", Slug: models.DefaultOrganizationSlug}
+	if err := db.Where("slug = ?", org.Slug).FirstOrCreate(&org).Error; err != nil {
+		return nil, fmt.Errorf("creating the default organization: %w", err)
+	}
+	return &org, nil
+}
+
+// modelConstraints retorna as chaves estrangeiras declaradas nos modelos,
+// agrupadas pela tabela que guarda a coluna, na ordem de migratedModels.
+func modelConstraints(db *gorm.DB) ([][]*gormschema.Constraint, error) {
+	byTable := make(map[string][]*gormschema.Constraint)
+	seen := make(map[string]bool)
+	for _, model := range migratedModels {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		for _, rel := range stmt.Schema.Relationships.Relations {
+			constraint := rel.ParseConstraint()
+			if constraint == nil || len(constraint.ForeignKeys) != 1 {
+				continue
+			}
+			key := constraint.Schema.Table + "." + constraint.Name
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			byTable[constraint.Schema.Table] = append(byTable[constraint.Schema.Table], constraint)
+		}
+	}
+
+	grouped := make([][]*gormschema.Constraint, 0, len(byTable))
+	for _, model := range migratedModels {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		if constraints, ok := byTable[stmt.Schema.Table]; ok {
+			grouped = append(grouped, constraints)
+		}
+	}
+	return grouped, nil
+}
+
+// cleanOrphans trata as linhas que apontam para registros que não existem
+// mais, o que impediria a criação das chaves estrangeiras. Seguem a mesma
+// política do ON DELETE: a referência é anulada quando a política é SET NULL
+// e a linha é removida nos demais casos. As tabelas são percorridas das
+// referenciadas para as dependentes, então a remoção de uma linha órfã já
+// alcança as que dependiam dela.
+func cleanOrphans(db *gorm.DB, grouped [][]*gormschema.Constraint) error {
+	migrator := db.Migrator()
+	for _, constraints := range grouped {
+		for _, constraint := range constraints {
+			table := constraint.Schema.Table
+			column := constraint.ForeignKeys[0].DBName
+			parent := constraint.ReferenceSchema.Table
+			if !migrator.HasTable(table) || !migrator.HasTable(parent) || !migrator.HasColumn(table, column) {
+				continue
+			}
+
+			orphan := clause.Expr{
+				SQL: "? IS NOT NULL AND NOT EXISTS (SELECT 1 FROM ? WHERE ? = ?)",
+				Vars: []any{
+					clause.Column{Table: table, Name: column},
+					clause.Table{Name: parent},
+					clause.Column{Table: parent, Name: constraint.References[0].DBName},
+					clause.Column{Table: table, Name: column},
+				},
+			}
+
+			var result *gorm.DB
+			if strings.EqualFold(constraint.OnDelete, "SET NULL") {
+				result = db.Exec("UPDATE ? SET ? = NULL WHERE ?", clause.Table{Name: table}, clause.Column{Name: column}, orphan)
+			} else {
+				result = db.Exec("DELETE FROM ? WHERE ?", clause.Table{Name: table}, orphan)
+			}
+			if result.Error != nil {
+				return fmt.Errorf("cleaning orphans in %s.%s: %w", table, column, result.Error)
+			}
+			if result.RowsAffected > 0 {
+				log.Printf("Cleaned %d orphan rows in %s.%s", result.RowsAffected, table, column)
+			}
+		}
+	}
+	return nil
+}
+
+// dedupeRoomMembers exclui logicamente as participações ativas repetidas de
+// um mesmo usuário numa sala, mantendo a mais antiga, para que o índice
+// único idx_room_member possa ser criado. A participação mantida fica como
+// admin se alguma das repetidas era.
+func dedupeRoomMembers(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&models.RoomMember{}) {
+		return nil
+	}
+
+	duplicate := "EXISTS (SELECT 1 FROM room_members other WHERE other.deleted_at IS NULL" +
+		" AND other.room_id = room_members.room_id AND other.user_id = room_members.user_id AND other.id %s room_members.id%s)"
+
+	err := db.Model(&models.RoomMember{}).
+		Where("role <> ?", "admin").
+		Where(fmt.Sprintf(duplicate, "<>", " AND other.role = 'admin'")).
+		Update("role", "admin").Error
+	if err != nil {
+		return fmt.Errorf("merging duplicate room members: %w", err)
+	}
+
+	result := db.Where(fmt.Sprintf(duplicate, "<", "")).Delete(&models.RoomMember{})
+	if result.Error != nil {
+		return fmt.Errorf("removing duplicate room members: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Removed %d duplicate room memberships", result.RowsAffected)
+	}
+	return nil
+}
+
+// markRedeliveries prepara os bancos anteriores à coluna redelivery das
+// entregas de webhook: das entregas de um mesmo evento para o mesmo webhook,
+// só a mais antiga fica como primeira entrega, para que o índice único
+// idx_webhook_delivery_event possa ser criado.
+func markRedeliveries(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&models.WebhookDelivery{}) || migrator.HasColumn(&models.WebhookDelivery{}, "Redelivery") {
+		return nil
+	}
+
+	if err := db.Exec("ALTER TABLE webhook_deliveries ADD COLUMN redelivery boolean NOT NULL DEFAULT false").Error; err != nil {
+		return err
+	}
+	err := db.Exec(`UPDATE webhook_deliveries SET redelivery = true
+		WHERE EXISTS (SELECT 1 FROM webhook_deliveries first
+			WHERE first.webhook_id = webhook_deliveries.webhook_id AND first.event_id = webhook_deliveries.event_id
+			AND first.id < webhook_deliveries.id)`).Error
+	if err != nil {
+		return fmt.Errorf("marking webhook redeliveries: %w", err)
+	}
+	return nil
+}
+
+// fixRoomMembersPrimaryKey troca a chave primária composta (id, user_id,
+// room_id) das versões antigas de room_members pela chave só em id.
+func fixRoomMembersPrimaryKey(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&models.RoomMember{}) {
+		return nil
+	}
+
+	var primaryKey struct {
+		Name        string
+		ColumnCount int
+	}
+	err := db.Raw(`SELECT tc.constraint_name AS name, COUNT(*) AS column_count
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+			ON kcu.constraint_name = tc.constraint_name AND kcu.constraint_schema = tc.constraint_schema
+		WHERE tc.table_schema = CURRENT_SCHEMA() AND tc.table_name = ? AND tc.constraint_type = 'PRIMARY KEY'
+		GROUP BY tc.constraint_name`, "room_members").Scan(&primaryKey).Error
+	if err != nil {
+		return err
+	}
+	if primaryKey.ColumnCount <= 1 {
+		return nil
+	}
+
+	log.Println("Changing room_members primary key to id")
+	if err := db.Exec("ALTER TABLE room_members DROP CONSTRAINT ?", clause.Table{Name: primaryKey.Name}).Error; err != nil {
+		return err
+	}
+	return db.Exec("ALTER TABLE room_members ADD PRIMARY KEY (id)").Error
+}
+
+// dropOutdatedConstraints remove as chaves estrangeiras existentes cujo
+// ON DELETE difere do declarado no modelo. O AutoMigrate só cria as que
+// faltam, então as removidas são recriadas logo em seguida com a política
+// nova.
+func dropOutdatedConstraints(db *gorm.DB, grouped [][]*gormschema.Constraint) error {
+	for _, constraints := range grouped {
+		for _, constraint := range constraints {
+			table := constraint.Schema.Table
+
+			var rules []string
+			err := db.Raw(`SELECT rc.delete_rule
+				FROM information_schema.referential_constraints rc
+				JOIN information_schema.table_constraints tc
+					ON tc.constraint_name = rc.constraint_name AND tc.constraint_schema = rc.constraint_schema
+				WHERE tc.table_schema = CURRENT_SCHEMA() AND tc.table_name = ? AND tc.constraint_name = ?`,
+				table, constraint.Name).Scan(&rules).Error
+			if err != nil {
+				return err
+			}
+
+			want := strings.ToUpper(constraint.OnDelete)
+			if want == "" {
+				want = "NO ACTION"
+			}
+			if len(rules) == 0 || rules[0] == want {
+				continue
+			}
+
+			log.Printf("Recreating constraint %s on %s with ON DELETE %s", constraint.Name, table, want)
+			if err := db.Exec("ALTER TABLE ? DROP CONSTRAINT ?", clause.Table{Name: table}, clause.Table{Name: constraint.Name}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// protectAuditEntries cria o gatilho que torna audit_entries somente de
+// inserção: alterações e remoções falham, mesmo fora da API.
+func protectAuditEntries(db *gorm.DB) error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION audit_entries_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_entries is append-only';
+		END;
+		$$ LANGUAGE plpgsql`,
+		"DROP TRIGGER IF EXISTS audit_entries_append_only ON audit_entries",
+		`CREATE TRIGGER audit_entries_append_only
+			BEFORE UPDATE OR DELETE ON audit_entries
+			FOR EACH ROW EXECUTE FUNCTION audit_entries_append_only()`,
+		"DROP TRIGGER IF EXISTS audit_entries_no_truncate ON audit_entries",
+		`CREATE TRIGGER audit_entries_no_truncate
+			BEFORE TRUNCATE ON audit_entries
+			FOR EACH STATEMENT EXECUTE FUNCTION audit_entries_append_only()`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("protecting audit_entries: %w", err)
+		}
+	}
+	return nil
+}
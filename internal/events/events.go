@@ -0,0 +1,230 @@
+// Package events implementa o barramento interno de eventos de domínio. Os
+// handlers HTTP publicam eventos e os interessados (notificações, tempo real,
+// integrações) se inscrevem neles, sem acoplamento direto.
+package events
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Tipos de evento publicados pela API.
+const (
+	RoomMemberJoined      = "room.member_joined"
+	RoomMemberLeft        = "room.member_left"
+	RoomMemberRoleChanged = "room.member_role_changed"
+	RoomJoinRequested     = "room.join_requested"
+	RoomJoinApproved      = "room.join_approved"
+	RoomJoinDenied        = "room.join_denied"
+
+	RoomOwnershipOffered     = "room.ownership_offered"
+	RoomOwnershipDeclined    = "room.ownership_declined"
+	RoomOwnershipTransferred = "room.ownership_transferred"
+
+	NoteCreated   = "note.created"
+	NoteUpdated   = "note.updated"
+	NoteDeleted   = "note.deleted"
+	NoteMentioned = "note.mentioned"
+
+	ReservationCreated   = "reservation.created"
+	ReservationUpdated   = "reservation.updated"
+	ReservationCancelled = "reservation.cancelled"
+	ReservationCheckedIn = "reservation.checked_in"
+	ReservationApproved  = "reservation.approved"
+	ReservationRejected  = "reservation.rejected"
+	ReservationExpired   = "reservation.expired"
+	ReservationReminder  = "reservation.reminder"
+
+	WaitlistOffered = "waitlist.offered"
+
+	AccountExportReady = "account.export_ready"
+
+	UserSuspended             = "user.suspended"
+	UserSessionsRevoked       = "user.sessions_revoked"
+	OrganizationMemberRemoved = "organization.member_removed"
+)
+
+// SessionEventTypes encerram as conexões em tempo real do usuário afetado
+// (UserID). Ao reconectar ele passa de novo pelas verificações da conta e
+// recarrega as salas de que participa.
+var SessionEventTypes = []string{
+	UserSuspended,
+	UserSessionsRevoked,
+	OrganizationMemberRemoved,
+}
+
+// RoomEventTypes são os eventos que dizem respeito a todos os membros da sala,
+// repassados aos clientes em tempo real e às integrações.
+var RoomEventTypes = []string{
+	RoomMemberJoined,
+	RoomMemberLeft,
+	RoomMemberRoleChanged,
+	RoomOwnershipTransferred,
+	NoteCreated,
+	NoteUpdated,
+	NoteDeleted,
+	ReservationCreated,
+	ReservationUpdated,
+	ReservationCancelled,
+	ReservationCheckedIn,
+	ReservationApproved,
+	ReservationRejected,
+	ReservationExpired,
+}
+
+// IsRoomEvent informa se o tipo está em RoomEventTypes.
+func IsRoomEvent(eventType string) bool {
+	for _, t := range RoomEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// EndsSession informa se o tipo está em SessionEventTypes.
+func EndsSession(eventType string) bool {
+	for _, t := range SessionEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type Event struct {
+	ID            string         `json:"id,omitempty"`
+	Type          string         `json:"type"`
+	ActorID       uint           `json:"actor_id,omitempty"`
+	RoomID        uint           `json:"room_id,omitempty"`
+	NoteID        uint           `json:"note_id,omitempty"`
+	ReservationID uint           `json:"reservation_id,omitempty"`
+	UserID        uint           `json:"user_id,omitempty"` // usuário afetado pelo evento, quando houver
+	Data          map[string]any `json:"data,omitempty"`
+	OccurredAt    time.Time      `json:"occurred_at"`
+}
+
+// String lê um valor textual de Data, retornando "" se ele não existir.
+func (e Event) String(key string) string {
+	value, _ := e.Data[key].(string)
+	return value
+}
+
+// Handler trata um evento. O erro devolvido faz o outbox entregar o evento
+// de novo a todos os inscritos, então o handler precisa tolerar repetições.
+type Handler func(Event) error
+
+// Bus entrega os eventos de forma assíncrona, em ordem de publicação, para
+// os handlers inscritos.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+	all      []Handler
+
+	queue     chan Event
+	closeOnce sync.Once
+	closed    chan struct{}
+	done      chan struct{}
+}
+
+func NewBus(buffer int) *Bus {
+	b := &Bus{
+		handlers: make(map[string][]Handler),
+		queue:    make(chan Event, buffer),
+		closed:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// Subscribe registra um handler para um tipo de evento.
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// SubscribeAll registra um handler para todos os tipos de evento.
+func (b *Bus) SubscribeAll(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.all = append(b.all, handler)
+}
+
+// Publish enfileira o evento para entrega. Eventos publicados depois do
+// Close são descartados.
+func (b *Bus) Publish(event Event) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	select {
+	case <-b.closed:
+		log.Printf("event bus closed, dropping %s event", event.Type)
+	case b.queue <- event:
+	}
+}
+
+// Close para de aceitar eventos e aguarda a entrega dos que já estavam na fila.
+func (b *Bus) Close() {
+	b.closeOnce.Do(func() { close(b.closed) })
+	<-b.done
+}
+
+func (b *Bus) run() {
+	defer close(b.done)
+	for {
+		select {
+		case event := <-b.queue:
+			b.dispatchLogged(event)
+		case <-b.closed:
+			for {
+				select {
+				case event := <-b.queue:
+					b.dispatchLogged(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Dispatch entrega o evento imediatamente, na goroutine de quem chama, e
+// devolve os erros de todos os handlers juntos. É usado pelo outbox, que já
+// executa fora da requisição e tenta de novo quando há erro.
+func (b *Bus) Dispatch(event Event) error {
+	b.mu.RLock()
+	handlers := append(append([]Handler{}, b.handlers[event.Type]...), b.all...)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := dispatchTo(handler, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// dispatchLogged entrega um evento publicado direto no barramento, que não
+// tem como ser repetido: os erros só são logados.
+func (b *Bus) dispatchLogged(event Event) {
+	if err := b.Dispatch(event); err != nil {
+		log.Printf("failed to handle %s event: %v", event.Type, err)
+	}
+}
+
+// dispatchTo chama o handler, transformando um panic em erro.
+func dispatchTo(handler Handler, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("event handler for %s panicked: %v", event.Type, r)
+		}
+	}()
+	return handler(event)
+}
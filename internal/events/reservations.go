@@ -0,0 +1,30 @@
+package events
+
+import (
+	"api-go/internal/models"
+	"time"
+)
+
+// ForReservation monta o evento de uma reserva publicado pelas tarefas do
+// sistema, sem autor. Data traz a sala, o fuso, o período e o estado da
+// reserva, mais os campos extras de data.
+func ForReservation(eventType string, room *models.Room, reservation *models.Reservation, data map[string]any) Event {
+	payload := map[string]any{
+		"room_name":  room.Name,
+		"time_zone":  room.Location().String(),
+		"start_time": reservation.StartTime.Format(time.RFC3339),
+		"end_time":   reservation.EndTime.Format(time.RFC3339),
+		"status":     reservation.Status,
+	}
+	for key, value := range data {
+		payload[key] = value
+	}
+
+	return Event{
+		Type:          eventType,
+		RoomID:        room.ID,
+		ReservationID: reservation.ID,
+		UserID:        reservation.UserID,
+		Data:          payload,
+	}
+}
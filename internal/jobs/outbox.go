@@ -0,0 +1,128 @@
+package jobs
+
+import (
+	"api-go/internal/events"
+	"api-go/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// KindPublishEvent entrega um evento de domínio aos inscritos no barramento.
+const KindPublishEvent = "event.publish"
+
+// ErrPermanent indica uma falha que não adianta tentar de novo. O handler
+// deve envolvê-la: fmt.Errorf("...: %w", jobs.ErrPermanent).
+var ErrPermanent = errors.New("permanent job failure")
+
+// Job descreve um job a ser gravado no outbox.
+type Job struct {
+	Kind    string
+	Payload any
+
+	// IdempotencyKey, se definida, impede que o mesmo job seja enfileirado
+	// duas vezes: a segunda gravação é ignorada.
+	IdempotencyKey string
+
+	// RunAt adia a execução. Zero executa assim que possível.
+	RunAt time.Time
+}
+
+// Outbox grava jobs junto com as mudanças de domínio.
+type Outbox struct {
+	DB     *gorm.DB
+	Runner *Runner
+}
+
+// WithContext retorna uma cópia do outbox cujas transações usam o contexto
+// dado.
+func (o *Outbox) WithContext(ctx context.Context) *Outbox {
+	return &Outbox{DB: o.DB.WithContext(ctx), Runner: o.Runner}
+}
+
+// Transaction executa fn numa transação e, após o commit, acorda os workers
+// para processarem os jobs enfileirados nela.
+func (o *Outbox) Transaction(fn func(tx *gorm.DB) error) error {
+	if err := o.DB.Transaction(fn); err != nil {
+		return err
+	}
+	o.Runner.Notify()
+	return nil
+}
+
+// Enqueue grava o job usando tx, que deve ser a transação da mudança que
+// o originou.
+func (o *Outbox) Enqueue(tx *gorm.DB, job Job) error {
+	payload, err := json.Marshal(job.Payload)
+	if err != nil {
+		return err
+	}
+
+	runAt := job.RunAt
+	if runAt.IsZero() {
+		runAt = time.Now()
+	}
+
+	record := models.OutboxJob{
+		Kind:        job.Kind,
+		Payload:     string(payload),
+		Status:      models.JobPending,
+		RunAt:       runAt,
+		MaxAttempts: o.Runner.maxAttempts(job.Kind),
+	}
+	if job.IdempotencyKey != "" {
+		record.IdempotencyKey = &job.IdempotencyKey
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "idempotency_key"}},
+		DoNothing: true,
+	}).Create(&record).Error
+}
+
+// Publish grava o evento no outbox. Ele só chega aos inscritos depois do
+// commit, e chega mesmo que o processo caia logo em seguida.
+func (o *Outbox) Publish(tx *gorm.DB, event events.Event) error {
+	if event.ID == "" {
+		id, err := newToken()
+		if err != nil {
+			return err
+		}
+		event.ID = id
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	return o.Enqueue(tx, Job{
+		Kind:           KindPublishEvent,
+		Payload:        event,
+		IdempotencyKey: KindPublishEvent + ":" + event.ID,
+	})
+}
+
+// RegisterEvents faz o runner entregar os eventos do outbox ao barramento.
+// Se algum inscrito falhar, o job falha e o evento é entregue de novo a
+// todos eles, que descartam o que já tinham feito.
+func (r *Runner) RegisterEvents(bus *events.Bus) {
+	r.Register(KindPublishEvent, func(ctx context.Context, job *models.OutboxJob) error {
+		var event events.Event
+		if err := json.Unmarshal([]byte(job.Payload), &event); err != nil {
+			return errors.Join(ErrPermanent, err)
+		}
+		return bus.Dispatch(event)
+	}, RetryPolicy{})
+}
+
+func (r *Runner) maxAttempts(kind string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if registration, ok := r.handlers[kind]; ok {
+		return registration.policy.MaxAttempts
+	}
+	return defaultMaxAttempts
+}
@@ -0,0 +1,272 @@
+// Package jobs implementa o outbox transacional e o pool de workers que
+// executa os jobs gravados nele.
+package jobs
+
+import (
+	"api-go/internal/models"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultMaxAttempts = 5
+
+	pollInterval = time.Second
+
+	// Tempo máximo de execução de um job.
+	jobTimeout = time.Minute
+
+	// Um job "running" travado há mais tempo que isso é considerado
+	// abandonado (o worker caiu) e volta a ser executado.
+	lockTimeout = 5 * time.Minute
+)
+
+// Handler executa um job. Como a entrega é pelo menos uma vez, o handler
+// precisa ser idempotente.
+type Handler func(ctx context.Context, job *models.OutboxJob) error
+
+// RetryPolicy define quantas vezes um tipo de job é tentado e quanto esperar
+// entre as tentativas.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     func(attempts int) time.Duration
+}
+
+// DefaultBackoff espera 2^tentativas segundos, até no máximo uma hora.
+func DefaultBackoff(attempts int) time.Duration {
+	delay := time.Second
+	for i := 0; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	return min(delay, time.Hour)
+}
+
+type registration struct {
+	handler Handler
+	policy  RetryPolicy
+}
+
+// Runner é o pool de workers. Vários processos podem rodar ao mesmo tempo:
+// cada job é reservado com SELECT ... FOR UPDATE SKIP LOCKED.
+type Runner struct {
+	DB      *gorm.DB
+	Workers int
+
+	mu       sync.RWMutex
+	handlers map[string]registration
+
+	wake     chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+
+	// ctx é cancelado quando o prazo do shutdown acaba, interrompendo os
+	// jobs em andamento.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewRunner(db *gorm.DB, workers int) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{
+		DB:       db,
+		Workers:  max(workers, 1),
+		handlers: make(map[string]registration),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Register associa um tipo de job ao handler que o executa.
+func (r *Runner) Register(kind string, handler Handler, policy RetryPolicy) {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = defaultMaxAttempts
+	}
+	if policy.Backoff == nil {
+		policy.Backoff = DefaultBackoff
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[kind] = registration{handler: handler, policy: policy}
+}
+
+// Start inicia os workers.
+func (r *Runner) Start() {
+	for i := 0; i < r.Workers; i++ {
+		r.wg.Add(1)
+		go r.work()
+	}
+}
+
+// Notify acorda um worker para buscar jobs sem esperar o próximo poll.
+func (r *Runner) Notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Shutdown para de buscar jobs e aguarda os que estão em andamento. Se o
+// contexto expirar antes, os jobs são cancelados e voltam para a fila.
+func (r *Runner) Shutdown(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		r.cancel()
+		return nil
+	case <-ctx.Done():
+		r.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (r *Runner) work() {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+
+		job, token, err := r.claim()
+		if err != nil {
+			log.Printf("failed to claim job: %v", err)
+		}
+		if job != nil {
+			r.run(job, token)
+			continue
+		}
+
+		select {
+		case <-r.stop:
+			return
+		case <-r.wake:
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// claim reserva o próximo job disponível para este worker.
+func (r *Runner) claim() (*models.OutboxJob, string, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var job *models.OutboxJob
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var jobs []models.OutboxJob
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_at < ?)",
+				models.JobPending, now, models.JobRunning, now.Add(-lockTimeout)).
+			Order("run_at").
+			Limit(1).
+			Find(&jobs).Error
+		if err != nil || len(jobs) == 0 {
+			return err
+		}
+
+		job = &jobs[0]
+		job.Status = models.JobRunning
+		job.Attempts++
+		job.LockedAt = &now
+		job.LockedBy = token
+		return tx.Model(job).Select("status", "attempts", "locked_at", "locked_by").Updates(job).Error
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return job, token, nil
+}
+
+func (r *Runner) run(job *models.OutboxJob, token string) {
+	r.mu.RLock()
+	registration, ok := r.handlers[job.Kind]
+	r.mu.RUnlock()
+
+	if !ok {
+		r.finish(job, token, models.JobDead, nil, fmt.Errorf("no handler for job kind %q", job.Kind))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.ctx, jobTimeout)
+	defer cancel()
+
+	err := safeRun(ctx, registration.handler, job)
+	switch {
+	case err == nil:
+		r.finish(job, token, models.JobDone, nil, nil)
+	case job.Attempts >= registration.policy.MaxAttempts || errors.Is(err, ErrPermanent):
+		log.Printf("job %d (%s) failed permanently: %v", job.ID, job.Kind, err)
+		r.finish(job, token, models.JobDead, nil, err)
+	default:
+		runAt := time.Now().Add(registration.policy.Backoff(job.Attempts))
+		r.finish(job, token, models.JobPending, &runAt, err)
+	}
+}
+
+// finish grava o resultado. A condição em locked_by impede que um worker
+// cujo job foi considerado abandonado sobrescreva o resultado de outro.
+func (r *Runner) finish(job *models.OutboxJob, token, status string, runAt *time.Time, jobErr error) {
+	updates := map[string]any{
+		"status":     status,
+		"locked_at":  nil,
+		"locked_by":  "",
+		"last_error": "",
+	}
+	if jobErr != nil {
+		updates["last_error"] = jobErr.Error()
+	}
+	if runAt != nil {
+		updates["run_at"] = *runAt
+	}
+	if status == models.JobDone {
+		updates["completed_at"] = time.Now()
+	}
+
+	result := r.DB.Model(&models.OutboxJob{}).Where("id = ? AND locked_by = ?", job.ID, token).Updates(updates)
+	if result.Error != nil {
+		log.Printf("failed to save result of job %d: %v", job.ID, result.Error)
+	}
+}
+
+// safeRun converte um panic do handler em erro, para o job ser tentado de novo.
+func safeRun(ctx context.Context, handler Handler, job *models.OutboxJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,173 @@
+// Package markdown renderiza o conteúdo das notas para HTML seguro.
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Version identifica a configuração do renderizador. Alterá-la invalida o
+// HTML em cache de todas as notas.
+const Version = "2"
+
+// HeadingIDPrefix prefixa os ids dos títulos, no HTML e no sumário. Sem ele,
+// um título como "# location" viraria id="location" e poderia sobrescrever
+// globais da página (DOM clobbering).
+const HeadingIDPrefix = "user-content-"
+
+const (
+	// excerptLength é o tamanho máximo, em caracteres, do resumo em texto puro.
+	excerptLength = 200
+
+	// Uma nota só ganha sumário quando é longa e tem títulos suficientes.
+	tocMinLength   = 1000
+	tocMinHeadings = 2
+)
+
+type Heading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id"`
+}
+
+type Rendered struct {
+	HTML    string
+	Excerpt string
+	TOC     []Heading
+}
+
+var (
+	md = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	)
+
+	policy = newPolicy()
+
+	whitespace = regexp.MustCompile(`\s+`)
+)
+
+// newPolicy parte da política para conteúdo de usuários do bluemonday e
+// libera apenas o necessário para os âncoras do sumário e as task lists.
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+// Markdown converte o conteúdo em HTML sanitizado e extrai o resumo e o sumário.
+func Markdown(content string) (*Rendered, error) {
+	source := []byte(content)
+	doc := md.Parser().Parse(text.NewReader(source))
+
+	var headings []Heading
+	var plain strings.Builder
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Heading:
+			heading := Heading{Level: node.Level, Text: nodeText(node, source)}
+			if id, ok := node.AttributeString("id"); ok {
+				if b, ok := id.([]byte); ok {
+					heading.ID = HeadingIDPrefix + string(b)
+					node.SetAttributeString("id", []byte(heading.ID))
+				}
+			}
+			headings = append(headings, heading)
+			plain.WriteString(heading.Text + " ")
+			return ast.WalkSkipChildren, nil
+		case *ast.Paragraph, *ast.TextBlock:
+			plain.WriteString(nodeText(node, source) + " ")
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+
+	// Os ids dos títulos são prefixados acima, antes de renderizar.
+	var buf bytes.Buffer
+	if err := md.Renderer().Render(&buf, source, doc); err != nil {
+		return nil, err
+	}
+
+	rendered := &Rendered{
+		HTML:    policy.Sanitize(buf.String()),
+		Excerpt: excerpt(plain.String()),
+	}
+	if utf8.RuneCountInString(content) >= tocMinLength && len(headings) >= tocMinHeadings {
+		rendered.TOC = headings
+	}
+	return rendered, nil
+}
+
+// Plain renderiza texto puro: o conteúdo é escapado e cada bloco separado
+// por linha em branco vira um parágrafo.
+func Plain(content string) *Rendered {
+	var buf strings.Builder
+	for _, block := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n\n") {
+		block = strings.TrimSpace(block)
+		if block == "" {
+			continue
+		}
+		buf.WriteString("<p>")
+		buf.WriteString(strings.ReplaceAll(html.EscapeString(block), "\n", "<br>"))
+		buf.WriteString("</p>")
+	}
+
+	return &Rendered{
+		HTML:    buf.String(),
+		Excerpt: excerpt(content),
+	}
+}
+
+// nodeText concatena o texto de todos os descendentes do nó.
+func nodeText(n ast.Node, source []byte) string {
+	var buf strings.Builder
+	ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := child.(type) {
+		case *ast.Text:
+			buf.Write(node.Segment.Value(source))
+			if node.SoftLineBreak() || node.HardLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(node.Value)
+		case *ast.AutoLink:
+			buf.Write(node.Label(source))
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(buf.String())
+}
+
+func excerpt(s string) string {
+	s = strings.TrimSpace(whitespace.ReplaceAllString(s, " "))
+	if utf8.RuneCountInString(s) <= excerptLength {
+		return s
+	}
+
+	runes := []rune(s)[:excerptLength]
+	cut := string(runes)
+	// Evita cortar uma palavra no meio.
+	if i := strings.LastIndex(cut, " "); i > excerptLength/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " .,;:") + "…"
+}
@@ -0,0 +1,185 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestMarkdownSanitizes(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		reject  []string
+	}{
+		{
+			name:    "script tag",
+			content: "<script>alert(1)</script>\n\nhello",
+			want:    []string{"<p>hello</p>"},
+			reject:  []string{"<script", "alert(1)"},
+		},
+		{
+			name:    "javascript link",
+			content: "[click](javascript:alert(1))",
+			want:    []string{"click"},
+			reject:  []string{"javascript:", "href"},
+		},
+		{
+			name:    "data link",
+			content: "[click](data:text/html;base64,PHNjcmlwdD4=)",
+			want:    []string{"click"},
+			reject:  []string{"data:", "href"},
+		},
+		{
+			name:    "external link",
+			content: "[site](https://example.com)",
+			want:    []string{`href="https://example.com"`, `rel="nofollow noopener"`, `target="_blank"`},
+		},
+		{
+			name:    "event handler attributes",
+			content: `<a href="https://example.com" onclick="steal()">a</a> <b onmouseover="steal()">b</b>`,
+			reject:  []string{"onclick", "onmouseover", "steal"},
+		},
+		{
+			name:    "image with onerror",
+			content: "<img src=x onerror=alert(1)>",
+			reject:  []string{"onerror", "alert", "<img"},
+		},
+		{
+			name:    "markdown image",
+			content: "![logo](https://example.com/logo.png)",
+			want:    []string{`<img src="https://example.com/logo.png" alt="logo">`},
+		},
+		{
+			name:    "raw html block",
+			content: "<div>\n<iframe src=\"https://evil.example\"></iframe>\n<style>body{display:none}</style>\n</div>",
+			reject:  []string{"<div", "<iframe", "<style", "evil.example"},
+		},
+		{
+			name:    "task list",
+			content: "- [x] done\n- [ ] todo",
+			want:    []string{`<input checked="" disabled="" type="checkbox"> done`, `<input disabled="" type="checkbox"> todo`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := Markdown(tt.content)
+			if err != nil {
+				t.Fatalf("Markdown() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(rendered.HTML, want) {
+					t.Errorf("HTML %q does not contain %q", rendered.HTML, want)
+				}
+			}
+			for _, reject := range tt.reject {
+				if strings.Contains(rendered.HTML, reject) {
+					t.Errorf("HTML %q contains %q", rendered.HTML, reject)
+				}
+			}
+		})
+	}
+}
+
+// O goldmark já omite o HTML cru; a política é a segunda barreira e precisa
+// se sustentar sozinha.
+func TestPolicy(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"checkbox", `<input type="checkbox" checked disabled>`, `<input type="checkbox" checked="" disabled="">`},
+		{"text input", `<input type="text" value="x">`, ``},
+		{"input handler", `<input type="checkbox" onfocus="steal()" autofocus>`, `<input type="checkbox">`},
+		{"javascript href", `<a href="javascript:steal()">a</a>`, `a`},
+	}
+	for _, tt := range tests {
+		if got := policy.Sanitize(tt.html); got != tt.want {
+			t.Errorf("%s: Sanitize() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHeadingIDsArePrefixed(t *testing.T) {
+	content := "# location\n\n" + strings.Repeat("palavra ", 150) + "\n\n## Second part\n"
+	rendered, err := Markdown(content)
+	if err != nil {
+		t.Fatalf("Markdown() error = %v", err)
+	}
+	if !strings.Contains(rendered.HTML, `<h1 id="user-content-location">`) || strings.Contains(rendered.HTML, `id="location"`) {
+		t.Errorf("HTML %q does not prefix the heading id", rendered.HTML)
+	}
+	want := []Heading{
+		{Level: 1, Text: "location", ID: "user-content-location"},
+		{Level: 2, Text: "Second part", ID: "user-content-second-part"},
+	}
+	if len(rendered.TOC) != len(want) {
+		t.Fatalf("TOC = %v, want %v", rendered.TOC, want)
+	}
+	for i := range want {
+		if rendered.TOC[i] != want[i] {
+			t.Errorf("TOC[%d] = %+v, want %+v", i, rendered.TOC[i], want[i])
+		}
+	}
+}
+
+func TestTOCThresholds(t *testing.T) {
+	// filler completa o conteúdo até n caracteres.
+	filler := func(prefix string, n int) string {
+		return prefix + strings.Repeat("a", n-utf8.RuneCountInString(prefix))
+	}
+	twoHeadings := "# One\n\n## Two\n\n"
+
+	tests := []struct {
+		name     string
+		content  string
+		headings int
+	}{
+		{"short note with headings", twoHeadings + "text", 0},
+		{"long note with one heading", filler("# One\n\n", tocMinLength), 0},
+		{"one character short", filler(twoHeadings, tocMinLength-1), 0},
+		{"long note with headings", filler(twoHeadings, tocMinLength), 2},
+	}
+	for _, tt := range tests {
+		rendered, err := Markdown(tt.content)
+		if err != nil {
+			t.Fatalf("%s: Markdown() error = %v", tt.name, err)
+		}
+		if len(rendered.TOC) != tt.headings {
+			t.Errorf("%s: TOC has %d headings, want %d", tt.name, len(rendered.TOC), tt.headings)
+		}
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"short", "# Title\n\nSome   *body*\ntext.", "Title Some body text."},
+		{"exact length", strings.Repeat("a", excerptLength), strings.Repeat("a", excerptLength)},
+		{"cut at a word", strings.Repeat("word ", 60), strings.TrimSpace(strings.Repeat("word ", 40)) + "…"},
+		{"long word", strings.Repeat("a", excerptLength+10), strings.Repeat("a", excerptLength) + "…"},
+	}
+	for _, tt := range tests {
+		rendered, err := Markdown(tt.content)
+		if err != nil {
+			t.Fatalf("%s: Markdown() error = %v", tt.name, err)
+		}
+		if rendered.Excerpt != tt.want {
+			t.Errorf("%s: Excerpt = %q, want %q", tt.name, rendered.Excerpt, tt.want)
+		}
+	}
+}
+
+func TestPlainEscapes(t *testing.T) {
+	rendered := Plain("<script>alert(1)</script>\nline\n\nsecond")
+	want := "<p>&lt;script&gt;alert(1)&lt;/script&gt;<br>line</p><p>second</p>"
+	if rendered.HTML != want {
+		t.Errorf("Plain().HTML = %q, want %q", rendered.HTML, want)
+	}
+}
@@ -0,0 +1,91 @@
+// Package mentions extrai menções (@nome ou @email) do conteúdo das notas.
+package mentions
+
+import (
+	"api-go/internal/models"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// mentionPattern aceita @email ou @nome. O caractere anterior não pode ser
+// parte de uma palavra, para que endereços de email soltos no texto não
+// sejam confundidos com menções.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@])@([\p{L}\p{N}_.+-]+@[\p{L}\p{N}-]+(?:\.[\p{L}\p{N}-]+)+|[\p{L}\p{N}_.-]+)`)
+
+// Parse retorna os identificadores mencionados no conteúdo, sem repetição.
+func Parse(content string) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		token := strings.TrimRight(match[1], ".-")
+		key := strings.ToLower(token)
+		if token == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// Resolve associa os identificadores aos membros da sala. Um identificador
+// casa com o email do membro, com o nome completo sem espaços/pontuação
+// (@GabrielViana, @gabriel.viana) ou com o primeiro nome, desde que ele seja
+// único na sala. Identificadores ambíguos ou desconhecidos são ignorados.
+func Resolve(tokens []string, members []models.RoomMember) []uint {
+	firstNames := make(map[string][]uint)
+	for _, member := range members {
+		if fields := strings.Fields(member.User.Name); len(fields) > 0 {
+			key := normalize(fields[0])
+			firstNames[key] = append(firstNames[key], member.UserID)
+		}
+	}
+
+	seen := make(map[uint]bool)
+	var userIDs []uint
+	for _, token := range tokens {
+		userID, ok := resolveToken(token, members, firstNames)
+		if !ok || seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs
+}
+
+func resolveToken(token string, members []models.RoomMember, firstNames map[string][]uint) (uint, bool) {
+	if strings.Contains(token, "@") {
+		for _, member := range members {
+			if strings.EqualFold(member.User.Email, token) {
+				return member.UserID, true
+			}
+		}
+		return 0, false
+	}
+
+	key := normalize(token)
+	for _, member := range members {
+		if normalize(member.User.Name) == key {
+			return member.UserID, true
+		}
+	}
+	if ids := firstNames[key]; len(ids) == 1 {
+		return ids[0], true
+	}
+	return 0, false
+}
+
+// normalize deixa apenas letras e números em minúsculas e sem acentos, para
+// que @JoaoPedro case com "João Pedro".
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, norm.NFD.String(s))
+}
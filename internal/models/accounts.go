@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Formatos da exportação de dados.
+const (
+	ExportFormatZIP  = "zip"
+	ExportFormatJSON = "json"
+)
+
+// Estados de uma exportação de dados.
+const (
+	ExportPending    = "pending"
+	ExportReady      = "ready"
+	ExportDownloaded = "downloaded" // o link de uso único já foi usado
+	ExportExpired    = "expired"    // o prazo do link passou antes do download
+	ExportFailed     = "failed"
+)
+
+// O que acontece com o conteúdo criado pelo usuário quando a conta é
+// excluída de vez.
+const (
+	DeletedContentAnonymize = "anonymize" // as notas ficam, atribuídas a um usuário anônimo
+	DeletedContentDelete    = "delete"    // as notas vão para a lixeira junto com os anexos
+)
+
+// IsValidDeletedContent informa se a política de conteúdo é conhecida.
+func IsValidDeletedContent(content string) bool {
+	return content == DeletedContentAnonymize || content == DeletedContentDelete
+}
+
+// DataExport é um pedido de exportação dos dados do usuário. O arquivo é
+// gerado em background e baixado uma única vez pelo link entregue no pedido;
+// só o hash do token do link fica no banco.
+type DataExport struct {
+	gorm.Model
+	UserID       uint       `json:"user_id" gorm:"not null;index"`
+	Format       string     `json:"format" gorm:"not null"`
+	Status       string     `json:"status" gorm:"not null;default:'pending';index"`
+	TokenHash    string     `json:"-" gorm:"not null;uniqueIndex"`
+	StorageKey   string     `json:"-"`
+	Size         int64      `json:"size"`
+	Error        string     `json:"error"`
+	ReadyAt      *time.Time `json:"ready_at"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	DownloadedAt *time.Time `json:"downloaded_at"`
+
+	User User `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
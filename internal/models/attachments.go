@@ -0,0 +1,16 @@
+package models
+
+import "gorm.io/gorm"
+
+type Attachment struct {
+	gorm.Model
+	NoteID      uint   `json:"note_id" gorm:"index"`
+	UserID      uint   `json:"user_id"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Checksum    string `json:"checksum" gorm:"index"` // SHA-256 em hexadecimal
+	StorageKey  string `json:"-" gorm:"index"`
+
+	User User `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
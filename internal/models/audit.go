@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Tipos de alvo das entradas de auditoria.
+const (
+	AuditTargetUser = "user"
+	AuditTargetRoom = "room"
+	AuditTargetNote = "note"
+
+	AuditTargetOrganization = "organization"
+	AuditTargetGroup        = "group"
+)
+
+// AuditEntry é uma entrada da trilha de auditoria. A tabela só aceita
+// inserções (um gatilho no banco rejeita UPDATE, DELETE e TRUNCATE) e cada
+// entrada guarda o hash da anterior da mesma cadeia (a da sala, ou a das
+// entradas sem sala), então a remoção ou a alteração de uma entrada quebra
+// a cadeia a partir dela.
+//
+// As referências a usuários e salas não têm chave estrangeira: a entrada
+// continua existindo depois que o alvo é removido.
+type AuditEntry struct {
+	ID uint `json:"id" gorm:"primaryKey;index:idx_audit_entries_chain,priority:2"`
+
+	// ActorID é nulo nas ações do sistema e nas tentativas de login com um
+	// email desconhecido.
+	ActorID    *uint  `json:"actor_id" gorm:"index"`
+	Action     string `json:"action" gorm:"not null;index"`
+	TargetType string `json:"target_type" gorm:"not null;index:idx_audit_entries_target"`
+	TargetID   uint   `json:"target_id" gorm:"not null;index:idx_audit_entries_target"`
+
+	// RoomID é a sala a que a ação se refere, para a consulta pelo dono.
+	// Também define a cadeia da entrada.
+	RoomID *uint `json:"room_id" gorm:"index:idx_audit_entries_chain,priority:1"`
+
+	// Before e After são fotografias em JSON do alvo antes e depois da ação.
+	// Ficam como texto para que o hash confira com os bytes gravados.
+	Before string `json:"before" gorm:"type:text"`
+	After  string `json:"after" gorm:"type:text"`
+
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+	RequestID string `json:"request_id"`
+
+	PrevHash  string    `json:"prev_hash" gorm:"not null"`
+	Hash      string    `json:"hash" gorm:"not null;uniqueIndex"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null;index"`
+}
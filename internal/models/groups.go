@@ -0,0 +1,56 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UserGroup é um grupo de usuários da organização, como um time. O grupo
+// pode receber acesso a salas (RoomGroupGrant), e os membros dele herdam a
+// participação nelas enquanto estiverem no grupo.
+type UserGroup struct {
+	gorm.Model
+	OrganizationID uint   `json:"organization_id" gorm:"not null;index;uniqueIndex:idx_user_group_name,priority:1,where:deleted_at IS NULL"`
+	Name           string `json:"name" gorm:"not null;uniqueIndex:idx_user_group_name,priority:2,where:deleted_at IS NULL"`
+	Description    string `json:"description"`
+	CreatedBy      uint   `json:"created_by"`
+
+	Organization *Organization     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Members      []UserGroupMember `json:"members" gorm:"foreignKey:GroupID;constraint:OnDelete:CASCADE"`
+}
+
+// UserGroupMember é a participação de um usuário num grupo.
+type UserGroupMember struct {
+	gorm.Model
+	GroupID uint `json:"group_id" gorm:"not null;uniqueIndex:idx_user_group_member,priority:1,where:deleted_at IS NULL"`
+	UserID  uint `json:"user_id" gorm:"not null;index;uniqueIndex:idx_user_group_member,priority:2,where:deleted_at IS NULL"`
+	User    User `json:"user" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// RoomGroupGrant dá aos membros do grupo a participação na sala, com o
+// papel Role (RoomRoleMember ou RoomRoleAdmin).
+type RoomGroupGrant struct {
+	gorm.Model
+	RoomID    uint   `json:"room_id" gorm:"not null;uniqueIndex:idx_room_group_grant,priority:1,where:deleted_at IS NULL"`
+	GroupID   uint   `json:"group_id" gorm:"not null;index;uniqueIndex:idx_room_group_grant,priority:2,where:deleted_at IS NULL"`
+	Role      string `json:"role" gorm:"not null;default:'member'"`
+	GrantedBy uint   `json:"granted_by"`
+
+	Room  Room      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Group UserGroup `json:"group" gorm:"foreignKey:GroupID;constraint:OnDelete:CASCADE"`
+}
+
+func (UserGroup) TenantScope(orgID uint) clause.Expression {
+	return clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "organization_id"}, Value: orgID}
+}
+
+func (UserGroupMember) TenantScope(orgID uint) clause.Expression {
+	return clause.Expr{
+		SQL:  "?.group_id IN (SELECT id FROM user_groups WHERE organization_id = ?)",
+		Vars: []any{clause.Table{Name: clause.CurrentTable}, orgID},
+	}
+}
+
+func (RoomGroupGrant) TenantScope(orgID uint) clause.Expression {
+	return inTenantRooms(orgID)
+}
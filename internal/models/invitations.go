@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Visibilidade da sala. Salas públicas aparecem na listagem e aceitam
+// qualquer usuário; as não listadas ficam fora da listagem, mas aceitam quem
+// conhece o ID; nas privadas só se entra por convite ou pedido aprovado.
+const (
+	RoomPublic   = "public"
+	RoomUnlisted = "unlisted"
+	RoomPrivate  = "private"
+)
+
+func IsRoomVisibility(visibility string) bool {
+	return visibility == RoomPublic || visibility == RoomUnlisted || visibility == RoomPrivate
+}
+
+// RoomInvite é um código que dá entrada na sala com um papel definido.
+type RoomInvite struct {
+	gorm.Model
+	RoomID uint   `json:"room_id" gorm:"not null;index"`
+	Code   string `json:"code" gorm:"not null;uniqueIndex"`
+	Role   string `json:"role" gorm:"not null;default:'member'"`
+	// MaxUses limita quantas vezes o convite pode ser usado; 0 não limita.
+	MaxUses   int        `json:"max_uses" gorm:"not null;default:0"`
+	Uses      int        `json:"uses" gorm:"not null;default:0"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedBy uint       `json:"created_by"`
+
+	Room Room `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// Estados de um pedido de entrada.
+const (
+	JoinRequestPending  = "pending"
+	JoinRequestApproved = "approved"
+	JoinRequestDenied   = "denied"
+)
+
+// RoomJoinRequest é o pedido de um usuário para entrar numa sala que não é
+// pública, decidido por um administrador da sala.
+type RoomJoinRequest struct {
+	gorm.Model
+	RoomID    uint       `json:"room_id" gorm:"not null;index"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	Message   string     `json:"message"`
+	Status    string     `json:"status" gorm:"not null;default:'pending';index"`
+	DecidedBy *uint      `json:"decided_by"`
+	DecidedAt *time.Time `json:"decided_at"`
+
+	User User `json:"user" gorm:"constraint:OnDelete:CASCADE"`
+	Room Room `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+const (
+	JobPending = "pending"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobDead    = "dead" // esgotou as tentativas
+)
+
+// OutboxJob é um efeito colateral gravado na mesma transação da mudança que
+// o originou e executado depois pelo pool de workers.
+type OutboxJob struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	Kind           string     `json:"kind" gorm:"not null;index"`
+	Payload        string     `json:"payload" gorm:"type:text;not null"`
+	IdempotencyKey *string    `json:"idempotency_key" gorm:"uniqueIndex"`
+	Status         string     `json:"status" gorm:"not null;default:'pending';index:idx_outbox_jobs_status_run_at"`
+	RunAt          time.Time  `json:"run_at" gorm:"not null;index:idx_outbox_jobs_status_run_at"`
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts    int        `json:"max_attempts" gorm:"not null"`
+	LockedAt       *time.Time `json:"locked_at"`
+	LockedBy       string     `json:"-"`
+	LastError      string     `json:"last_error" gorm:"type:text"`
+	CompletedAt    *time.Time `json:"completed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import "gorm.io/gorm"
+
+// Site é um endereço da organização, com um ou mais prédios.
+type Site struct {
+	gorm.Model
+	// OrganizationID é a organização dona do local. Os prédios e os andares
+	// repetem a do local, para que cada consulta se restrinja sozinha.
+	OrganizationID uint          `json:"organization_id" gorm:"not null;index"`
+	Organization   *Organization `json:"-"`
+
+	Name      string `json:"name" gorm:"not null"`
+	Address   string `json:"address"`
+	CreatedBy uint   `json:"created_by"`
+
+	Buildings []Building `json:"buildings,omitempty" gorm:"foreignKey:SiteID;constraint:OnDelete:CASCADE"`
+}
+
+type Building struct {
+	gorm.Model
+	OrganizationID uint          `json:"organization_id" gorm:"not null;index"`
+	Organization   *Organization `json:"-"`
+
+	SiteID    uint   `json:"site_id" gorm:"not null;index"`
+	Name      string `json:"name" gorm:"not null"`
+	CreatedBy uint   `json:"created_by"`
+
+	Floors []Floor `json:"floors,omitempty" gorm:"foreignKey:BuildingID;constraint:OnDelete:CASCADE"`
+}
+
+type Floor struct {
+	gorm.Model
+	OrganizationID uint          `json:"organization_id" gorm:"not null;index"`
+	Organization   *Organization `json:"-"`
+
+	BuildingID uint   `json:"building_id" gorm:"not null;index"`
+	Name       string `json:"name" gorm:"not null"`
+	// Level ordena os andares do prédio; negativo para subsolos.
+	Level int `json:"level" gorm:"not null;default:0"`
+	// PlanURL é a imagem da planta do andar, onde as salas são posicionadas.
+	PlanURL   string `json:"plan_url"`
+	CreatedBy uint   `json:"created_by"`
+}
+
+// Recursos que uma sala pode oferecer.
+const (
+	AmenityProjector        = "projector"
+	AmenityWhiteboard       = "whiteboard"
+	AmenityDisplay          = "display"
+	AmenityVideoConference  = "video_conference"
+	AmenitySpeakerphone     = "speakerphone"
+	AmenityWheelchairAccess = "wheelchair_access"
+	AmenityHearingLoop      = "hearing_loop"
+	AmenityAdjustableDesk   = "adjustable_desk"
+)
+
+var Amenities = []string{
+	AmenityProjector,
+	AmenityWhiteboard,
+	AmenityDisplay,
+	AmenityVideoConference,
+	AmenitySpeakerphone,
+	AmenityWheelchairAccess,
+	AmenityHearingLoop,
+	AmenityAdjustableDesk,
+}
+
+func IsAmenity(amenity string) bool {
+	for _, a := range Amenities {
+		if a == amenity {
+			return true
+		}
+	}
+	return false
+}
+
+// RoomAmenity associa um recurso a uma sala.
+type RoomAmenity struct {
+	RoomID  uint   `json:"room_id" gorm:"primaryKey"`
+	Amenity string `json:"amenity" gorm:"primaryKey"`
+}
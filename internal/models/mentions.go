@@ -0,0 +1,12 @@
+package models
+
+import "gorm.io/gorm"
+
+type Mention struct {
+	gorm.Model
+	NoteID        uint `json:"note_id" gorm:"index"`
+	UserID        uint `json:"user_id" gorm:"index"` // usuário mencionado
+	MentionedByID uint `json:"mentioned_by_id"`
+	Note          Note `json:"note" gorm:"constraint:OnDelete:CASCADE"`
+	User          User `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
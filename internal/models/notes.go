@@ -2,12 +2,33 @@ package models
 
 import "gorm.io/gorm"
 
+const (
+	NoteFormatPlain    = "plain"
+	NoteFormatMarkdown = "markdown"
+)
+
 type Note struct {
 	gorm.Model
 	UserID  uint   `json:"user_id"`
 	RoomID  uint   `json:"room_id"`
 	Title   string `json:"title"`
 	Content string `json:"content" gorm:"type:text"`
-	User    User   `json:"user"`
-	Room    Room   `json:"room"`
-}
\ No newline at end of file
+	Format  string `json:"format" gorm:"default:'plain'"` // plain, markdown
+
+	// Cache do conteúdo renderizado. RenderedChecksum identifica o conteúdo
+	// e a versão do renderizador que geraram o cache.
+	ContentHTML      string        `json:"content_html" gorm:"type:text"`
+	Excerpt          string        `json:"excerpt"`
+	TableOfContents  []NoteHeading `json:"table_of_contents" gorm:"type:text;serializer:json"`
+	RenderedChecksum string        `json:"-"`
+
+	User        User         `json:"user" gorm:"constraint:OnDelete:CASCADE"`
+	Room        Room         `json:"room"`
+	Attachments []Attachment `json:"attachments" gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"`
+}
+
+type NoteHeading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tipos de notificação. Os valores coincidem com os tipos de evento que as
+// originam e são usados também nas preferências do usuário.
+const (
+	NotificationTypeMemberJoined    = "room.member_joined"
+	NotificationTypeRoleChanged     = "room.member_role_changed"
+	NotificationTypeNoteCreated     = "note.created"
+	NotificationTypeMention         = "note.mentioned"
+	NotificationTypeReservationNear = "reservation.nearby"
+	NotificationTypeReminder        = "reservation.reminder"
+	NotificationTypeReleased        = "reservation.released"
+	NotificationTypeApprovalNeeded  = "reservation.pending"
+	NotificationTypeDecision        = "reservation.decided"
+	NotificationTypeWaitlistOffer   = "waitlist.offered"
+	NotificationTypeJoinRequest     = "room.join_requested"
+	NotificationTypeJoinDecision    = "room.join_decided"
+	NotificationTypeOwnership       = "room.ownership"
+	NotificationTypeExportReady     = "account.export_ready"
+)
+
+// NotificationTypes lista os tipos que o usuário pode configurar.
+var NotificationTypes = []string{
+	NotificationTypeMemberJoined,
+	NotificationTypeRoleChanged,
+	NotificationTypeNoteCreated,
+	NotificationTypeMention,
+	NotificationTypeReservationNear,
+	NotificationTypeReminder,
+	NotificationTypeReleased,
+	NotificationTypeApprovalNeeded,
+	NotificationTypeDecision,
+	NotificationTypeWaitlistOffer,
+	NotificationTypeJoinRequest,
+	NotificationTypeJoinDecision,
+	NotificationTypeOwnership,
+	NotificationTypeExportReady,
+}
+
+type Notification struct {
+	gorm.Model
+	UserID        uint       `json:"user_id" gorm:"index;uniqueIndex:idx_notification_event"`
+	Type          string     `json:"type" gorm:"uniqueIndex:idx_notification_event"`
+	Title         string     `json:"title"`
+	Message       string     `json:"message"`
+	ActorID       *uint      `json:"actor_id"`
+	RoomID        *uint      `json:"room_id"`
+	NoteID        *uint      `json:"note_id"`
+	ReservationID *uint      `json:"reservation_id"`
+	ReadAt        *time.Time `json:"read_at"`
+
+	// EventID é o evento que originou a notificação. O índice único impede
+	// que uma nova entrega do evento notifique o usuário de novo; o tipo
+	// entra nele porque um mesmo evento pode gerar notificações diferentes
+	// para a mesma pessoa (uma reserva próxima e uma aprovação pendente).
+	EventID *string `json:"-" gorm:"uniqueIndex:idx_notification_event"`
+
+	// Relações usadas só pelas chaves estrangeiras: a notificação continua
+	// no histórico mesmo depois que a sala, a nota ou a reserva somem.
+	User        User         `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Actor       *User        `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	Room        *Room        `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	Note        *Note        `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	Reservation *Reservation `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+}
+
+// NotificationPreference desativa (ou reativa) um tipo de notificação para
+// o usuário. A ausência de preferência significa que o tipo está ativo.
+type NotificationPreference struct {
+	gorm.Model
+	UserID  uint   `json:"user_id" gorm:"uniqueIndex:idx_notification_preference"`
+	Type    string `json:"type" gorm:"uniqueIndex:idx_notification_preference"`
+	Enabled bool   `json:"enabled"`
+
+	User User `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Papéis do usuário numa organização.
+const (
+	OrgRoleOwner  = "owner"  // gerencia a organização e os demais donos
+	OrgRoleAdmin  = "admin"  // gerencia os membros, exceto os donos
+	OrgRoleMember = "member" // usa as salas da organização
+)
+
+// DefaultOrganizationSlug é a organização criada pela migração, que recebe
+// as salas e os usuários anteriores às organizações.
+const DefaultOrganizationSlug = "default"
+
+// IsValidOrgRole informa se role é um papel de organização conhecido.
+func IsValidOrgRole(role string) bool {
+	return role == OrgRoleOwner || role == OrgRoleAdmin || role == OrgRoleMember
+}
+
+// Organization é um espaço de trabalho isolado: as salas, e por meio delas
+// as notas e as reservas, pertencem a uma organização e só aparecem para
+// quem está nela.
+type Organization struct {
+	gorm.Model
+	Name string `json:"name" gorm:"not null"`
+	// Slug identifica a organização no header X-Organization e no
+	// subdomínio.
+	Slug string `json:"slug" gorm:"not null;uniqueIndex"`
+}
+
+// OrganizationMember é a participação de um usuário numa organização. Um
+// usuário pode participar de várias.
+type OrganizationMember struct {
+	gorm.Model
+	OrganizationID uint   `json:"organization_id" gorm:"not null;uniqueIndex:idx_organization_member,priority:1,where:deleted_at IS NULL"`
+	UserID         uint   `json:"user_id" gorm:"not null;index;uniqueIndex:idx_organization_member,priority:2,where:deleted_at IS NULL"`
+	Role           string `json:"role" gorm:"not null;default:'member'"` // owner, admin, member
+
+	Organization Organization `json:"organization" gorm:"constraint:OnDelete:CASCADE"`
+	User         User         `json:"user" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// TenantScoped é implementado pelos modelos que pertencem a uma
+// organização. TenantScope retorna a condição que restringe as consultas do
+// modelo à organização orgID.
+type TenantScoped interface {
+	TenantScope(orgID uint) clause.Expression
+}
+
+// inTenantRooms restringe os modelos que pertencem a uma sala às salas da
+// organização.
+func inTenantRooms(orgID uint) clause.Expression {
+	return clause.Expr{
+		SQL:  "?.room_id IN (SELECT id FROM rooms WHERE organization_id = ?)",
+		Vars: []any{clause.Table{Name: clause.CurrentTable}, orgID},
+	}
+}
+
+// inTenantNotes restringe os modelos que pertencem a uma nota às notas das
+// salas da organização.
+func inTenantNotes(orgID uint) clause.Expression {
+	return clause.Expr{
+		SQL:  "?.note_id IN (SELECT notes.id FROM notes JOIN rooms ON rooms.id = notes.room_id WHERE rooms.organization_id = ?)",
+		Vars: []any{clause.Table{Name: clause.CurrentTable}, orgID},
+	}
+}
+
+func (Room) TenantScope(orgID uint) clause.Expression {
+	return clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "organization_id"}, Value: orgID}
+}
+
+func (Site) TenantScope(orgID uint) clause.Expression {
+	return clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "organization_id"}, Value: orgID}
+}
+
+func (Building) TenantScope(orgID uint) clause.Expression {
+	return clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "organization_id"}, Value: orgID}
+}
+
+func (Floor) TenantScope(orgID uint) clause.Expression {
+	return clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "organization_id"}, Value: orgID}
+}
+
+func (Note) TenantScope(orgID uint) clause.Expression {
+	return inTenantRooms(orgID)
+}
+
+func (Reservation) TenantScope(orgID uint) clause.Expression {
+	return inTenantRooms(orgID)
+}
+
+func (RoomMember) TenantScope(orgID uint) clause.Expression {
+	return inTenantRooms(orgID)
+}
+
+func (RoomAmenity) TenantScope(orgID uint) clause.Expression {
+	return inTenantRooms(orgID)
+}
+
+func (RoomBlackout) TenantScope(orgID uint) clause.Expression {
+	return inTenantRooms(orgID)
+}
+
+func (RoomInvite) TenantScope(orgID uint) clause.Expression {
+	return inTenantRooms(orgID)
+}
+
+func (RoomJoinRequest) TenantScope(orgID uint) clause.Expression {
+	return inTenantRooms(orgID)
+}
+
+func (RoomOwnershipTransfer) TenantScope(orgID uint) clause.Expression {
+	return inTenantRooms(orgID)
+}
+
+func (WaitlistEntry) TenantScope(orgID uint) clause.Expression {
+	return inTenantRooms(orgID)
+}
+
+// Os webhooks sem sala são do usuário e valem para todas as organizações.
+func (Webhook) TenantScope(orgID uint) clause.Expression {
+	return clause.Or(
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "room_id"}, Value: nil},
+		inTenantRooms(orgID),
+	)
+}
+
+func (Attachment) TenantScope(orgID uint) clause.Expression {
+	return inTenantNotes(orgID)
+}
+
+func (Mention) TenantScope(orgID uint) clause.Expression {
+	return inTenantNotes(orgID)
+}
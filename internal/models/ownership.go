@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Estados de uma transferência de propriedade.
+const (
+	TransferPending   = "pending"
+	TransferAccepted  = "accepted"
+	TransferDeclined  = "declined"
+	TransferCancelled = "cancelled"
+)
+
+// RoomOwnershipTransfer é a oferta do dono da sala (Room.CreatedBy) para
+// passá-la a outro membro, que só vale depois de aceita por ele.
+type RoomOwnershipTransfer struct {
+	gorm.Model
+	RoomID     uint       `json:"room_id" gorm:"not null;index"`
+	FromUserID uint       `json:"from_user_id" gorm:"not null;index"`
+	ToUserID   uint       `json:"to_user_id" gorm:"not null;index"`
+	Status     string     `json:"status" gorm:"not null;default:'pending';index"`
+	DecidedAt  *time.Time `json:"decided_at"`
+
+	FromUser User `json:"from_user" gorm:"foreignKey:FromUserID;constraint:OnDelete:CASCADE"`
+	ToUser   User `json:"to_user" gorm:"foreignKey:ToUserID;constraint:OnDelete:CASCADE"`
+	Room     Room `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BookingPolicy reúne as regras de reserva da sala. Valores zero significam
+// "sem limite".
+type BookingPolicy struct {
+	// OpeningHours lista os horários em que a sala pode ser reservada. Sem
+	// nenhum horário, a sala pode ser reservada a qualquer hora.
+	OpeningHours []OpeningHours `json:"opening_hours" gorm:"type:text;serializer:json"`
+
+	MinDurationMinutes int `json:"min_duration_minutes" gorm:"not null;default:0"`
+	MaxDurationMinutes int `json:"max_duration_minutes" gorm:"not null;default:0"`
+
+	// Antecedência mínima e máxima da reserva em relação ao momento do pedido.
+	MinNoticeMinutes int `json:"min_notice_minutes" gorm:"not null;default:0"`
+	MaxAdvanceDays   int `json:"max_advance_days" gorm:"not null;default:0"`
+
+	// BufferMinutes é o intervalo livre exigido entre reservas da sala.
+	BufferMinutes int `json:"buffer_minutes" gorm:"not null;default:0"`
+
+	// Cotas por usuário na sala.
+	MaxHoursPerWeek       int `json:"max_hours_per_week" gorm:"not null;default:0"`
+	MaxActiveReservations int `json:"max_active_reservations" gorm:"not null;default:0"`
+}
+
+// OpeningHours é uma faixa de horário, no formato "HH:MM", em que a sala
+// pode ser reservada num dia da semana (0 = domingo). Close pode ser "24:00".
+type OpeningHours struct {
+	Weekday time.Weekday `json:"weekday"`
+	Open    string       `json:"open"`
+	Close   string       `json:"close"`
+}
+
+// RoomBlackout é um período em que a sala não pode ser reservada, como um
+// feriado ou uma manutenção.
+type RoomBlackout struct {
+	gorm.Model
+	RoomID    uint      `json:"room_id" gorm:"index"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Reason    string    `json:"reason"`
+	CreatedBy uint      `json:"created_by"`
+
+	Room Room `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
@@ -6,10 +6,39 @@ import (
 	"gorm.io/gorm"
 )
 
+// Estados de uma reserva. Nas salas que exigem aprovação, a reserva começa
+// pendente e só vale depois de aprovada por um administrador.
+const (
+	ReservationPending  = "pending"
+	ReservationApproved = "approved"
+	ReservationRejected = "rejected"
+	ReservationExpired  = "expired" // pendente ou provisória por tempo demais, sem decisão
+
+	// ReservationHeld é a reserva provisória oferecida a quem estava na
+	// lista de espera, que precisa ser confirmada dentro do prazo.
+	ReservationHeld = "held"
+)
+
+// ReservationHolds são os estados que ocupam um lugar na sala.
+var ReservationHolds = []string{ReservationPending, ReservationApproved, ReservationHeld}
+
 type Reservation struct {
 	gorm.Model
-	UserID    uint `gorm:"foreignKey:UserID"`
-	RoomID    uint `gorm:"foreignKey:RoomID"`
+	UserID    uint `gorm:"index"`
+	RoomID    uint `gorm:"index"`
 	StartTime time.Time
 	EndTime   time.Time
+
+	Status         string `gorm:"not null;default:'approved';index"`
+	DecidedBy      *uint
+	DecidedAt      *time.Time
+	DecisionReason string
+
+	// Momento em que o lembrete da reserva foi enviado.
+	ReminderSentAt *time.Time
+	// Momento em que o usuário fez check-in na sala.
+	CheckedInAt *time.Time
+
+	User User `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Room Room `json:"-" gorm:"constraint:OnDelete:CASCADE"`
 }
@@ -2,11 +2,30 @@ package models
 
 import "gorm.io/gorm"
 
+const (
+	RoomRoleMember = "member"
+	RoomRoleAdmin  = "admin"
+)
+
+// RoomMember é a participação de um usuário numa sala. Cada usuário tem no
+// máximo uma participação ativa por sala; as excluídas logicamente (saídas
+// da sala) não contam.
 type RoomMember struct {
 	gorm.Model
-	UserID uint `json:"user_id" gorm:"primaryKey"`
-	RoomID uint `json:"room_id" gorm:"primaryKey"`
+	UserID uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_room_member,priority:2,where:deleted_at IS NULL"`
+	RoomID uint   `json:"room_id" gorm:"not null;uniqueIndex:idx_room_member,priority:1,where:deleted_at IS NULL"`
 	Role   string `json:"role" gorm:"default:'member'"` // member, admin
-	User   User `json:"user"`
-	Room   Room `json:"room"`
-}
\ No newline at end of file
+	User   User   `json:"user" gorm:"constraint:OnDelete:CASCADE"`
+	Room   Room   `json:"room"`
+
+	// GroupIDs são os grupos pelos quais o usuário participa da sala. Só é
+	// preenchido pela lista de membros efetivos, em que as participações
+	// herdadas de grupos aparecem com ID zero.
+	GroupIDs []uint `json:"group_ids,omitempty" gorm:"-"`
+}
+
+// IsDirect informa se a participação foi feita diretamente na sala, e não
+// só herdada de um grupo.
+func (m RoomMember) IsDirect() bool {
+	return m.ID != 0
+}
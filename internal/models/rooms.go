@@ -1,14 +1,59 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Room struct {
 	gorm.Model
-	Name        string       `json:"name"`
-	Description string       `json:"description"`
-	Subject     string       `json:"subject"`
-	Capacity    int          `json:"capacity"`
-	CreatedBy   uint         `json:"created_by"`
-	Members     []RoomMember `json:"members" gorm:"foreignKey:RoomID"`
-	Notes       []Note       `json:"notes" gorm:"foreignKey:RoomID"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Subject     string `json:"subject"`
+	Capacity    int    `json:"capacity"`
+	CreatedBy   uint   `json:"created_by"`
+
+	// OrganizationID é a organização dona da sala. As notas e as reservas
+	// pertencem à organização da sala.
+	OrganizationID uint          `json:"organization_id" gorm:"not null;index"`
+	Organization   *Organization `json:"-"`
+
+	// TimeZone é o fuso IANA onde a sala fica. Os horários de funcionamento
+	// e os horários de parede das reservas são interpretados nele.
+	TimeZone string `json:"time_zone" gorm:"not null;default:'UTC'"`
+
+	// Visibility controla quem vê a sala na listagem e como se entra nela.
+	Visibility string `json:"visibility" gorm:"not null;default:'public';index"`
+
+	// ArchivedAt marca a sala como arquivada: fora da listagem e só para
+	// leitura, mas sem ser excluída.
+	ArchivedAt *time.Time `json:"archived_at" gorm:"index"`
+
+	// CheckInRequired faz as reservas sem check-in serem liberadas depois
+	// do prazo.
+	CheckInRequired bool `json:"check_in_required" gorm:"not null;default:false"`
+
+	// RequiresApproval faz as reservas começarem pendentes, aguardando a
+	// decisão de um administrador da sala.
+	RequiresApproval bool `json:"requires_approval" gorm:"not null;default:false"`
+
+	Policy BookingPolicy `json:"policy" gorm:"embedded;embeddedPrefix:policy_"`
+
+	// FloorID posiciona a sala na hierarquia local → prédio → andar. PlanX e
+	// PlanY são a posição na planta do andar, de 0 a 1 a partir do canto
+	// superior esquerdo.
+	FloorID   *uint         `json:"floor_id" gorm:"index"`
+	PlanX     *float64      `json:"plan_x"`
+	PlanY     *float64      `json:"plan_y"`
+	Amenities []RoomAmenity `json:"amenities" gorm:"foreignKey:RoomID;constraint:OnDelete:CASCADE"`
+
+	Members []RoomMember `json:"members" gorm:"foreignKey:RoomID;constraint:OnDelete:CASCADE"`
+	Notes   []Note       `json:"notes" gorm:"foreignKey:RoomID;constraint:OnDelete:CASCADE"`
+	Floor   *Floor       `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+}
+
+// IsArchived informa se a sala foi arquivada.
+func (r Room) IsArchived() bool {
+	return r.ArchivedAt != nil
 }
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultTimeZone é o fuso das salas criadas sem um.
+const DefaultTimeZone = "UTC"
+
+var locations sync.Map
+
+// IsValidTimeZone informa se name é um fuso IANA conhecido, como
+// "America/Sao_Paulo".
+func IsValidTimeZone(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+// LoadLocation retorna o fuso pelo nome, ou UTC se ele estiver vazio ou for
+// desconhecido.
+func LoadLocation(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	locations.Store(name, loc)
+	return loc
+}
+
+// Location é o fuso onde a sala fica.
+func (r Room) Location() *time.Location {
+	return LoadLocation(r.TimeZone)
+}
+
+// Location é o fuso preferido do usuário, ou nil se ele não escolheu um.
+func (u User) Location() *time.Location {
+	if u.TimeZone == "" {
+		return nil
+	}
+	return LoadLocation(u.TimeZone)
+}
@@ -1,12 +1,59 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// Papéis globais do usuário. O papel na sala fica em RoomMember.
+const (
+	UserRoleUser  = "user"
+	UserRoleAdmin = "admin" // administra o sistema todo: usuários, salas e notas
+)
+
 type User struct {
 	gorm.Model
 	Name     string `json:"name"`
 	Email    string `json:"email" gorm:"unique"`
 	Password string `json:"password"`
+
+	// TimeZone é o fuso IANA preferido do usuário. Vazio segue o fuso da sala.
+	TimeZone string `json:"time_zone" gorm:"not null;default:''"`
+
+	// NoShows conta as reservas liberadas por falta de check-in.
+	NoShows int `json:"no_shows" gorm:"not null;default:0"`
+
+	// DeletionScheduledAt é quando a exclusão pedida pelo usuário será
+	// concluída. Até lá ela pode ser desfeita. DeletionContent guarda o que
+	// fazer com as notas dele (DeletedContentAnonymize ou DeletedContentDelete).
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at" gorm:"index"`
+	DeletionContent     string     `json:"deletion_content"`
+
+	// AnonymizedAt marca as contas excluídas. A linha continua no banco, sem
+	// dados pessoais, para manter a autoria anônima das notas.
+	AnonymizedAt *time.Time `json:"anonymized_at" gorm:"index"`
+
+	// Role é o papel global (UserRoleUser ou UserRoleAdmin).
+	Role string `json:"role" gorm:"not null;default:'user';index"`
+
+	// SuspendedAt marca as contas suspensas por um administrador. Enquanto
+	// suspensa, a conta não entra nem usa os tokens que já tinha.
+	SuspendedAt      *time.Time `json:"suspended_at" gorm:"index"`
+	SuspensionReason string     `json:"suspension_reason"`
+
+	// PasswordResetRequired bloqueia o login até que a senha seja trocada com
+	// o token de redefinição gerado pelo administrador, do qual só o hash
+	// fica guardado.
+	PasswordResetRequired  bool       `json:"password_reset_required" gorm:"not null;default:false"`
+	PasswordResetTokenHash string     `json:"-" gorm:"index"`
+	PasswordResetExpiresAt *time.Time `json:"-"`
+
+	// TokensValidAfter invalida os tokens emitidos antes dele.
+	TokensValidAfter *time.Time `json:"-"`
+}
+
+// IsValidUserRole informa se o papel global é conhecido.
+func IsValidUserRole(role string) bool {
+	return role == UserRoleUser || role == UserRoleAdmin
 }
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Estados de uma entrada na lista de espera.
+const (
+	WaitlistWaiting   = "waiting"
+	WaitlistOffered   = "offered"   // recebeu uma reserva provisória e precisa confirmá-la
+	WaitlistAccepted  = "accepted"  // confirmou a reserva provisória
+	WaitlistExpired   = "expired"   // o prazo da oferta ou o horário passou
+	WaitlistCancelled = "cancelled" // o usuário saiu da lista
+)
+
+// WaitlistEntry é um pedido para ser avisado quando um lugar na sala ficar
+// livre no período. As entradas são atendidas por ordem de chegada.
+type WaitlistEntry struct {
+	gorm.Model
+	UserID    uint      `json:"user_id" gorm:"index"`
+	RoomID    uint      `json:"room_id" gorm:"index:idx_waitlist_room_status"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Status    string    `json:"status" gorm:"not null;default:'waiting';index:idx_waitlist_room_status"`
+
+	// Reserva provisória criada quando a entrada foi promovida.
+	ReservationID  *uint      `json:"reservation_id" gorm:"index"`
+	OfferedAt      *time.Time `json:"offered_at"`
+	OfferExpiresAt *time.Time `json:"offer_expires_at"`
+
+	User        User         `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Room        Room         `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Reservation *Reservation `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+}
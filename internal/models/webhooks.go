@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryDead      = "dead" // esgotou as tentativas
+)
+
+// Webhook envia os eventos das salas para uma URL externa. Sem RoomID ele
+// recebe os eventos de todas as salas de que o dono participa.
+type Webhook struct {
+	gorm.Model
+	UserID uint     `json:"user_id" gorm:"not null;index"`
+	RoomID *uint    `json:"room_id" gorm:"index"`
+	URL    string   `json:"url" gorm:"not null"`
+	Secret string   `json:"-" gorm:"not null"`
+	Events []string `json:"events" gorm:"type:text;serializer:json"` // vazio recebe todos
+	Active bool     `json:"active" gorm:"default:true"`
+
+	User User  `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Room *Room `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// Accepts informa se o webhook está inscrito no tipo de evento.
+func (w *Webhook) Accepts(eventType string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, t := range w.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDelivery struct {
+	gorm.Model
+	WebhookID      uint       `json:"webhook_id" gorm:"not null;index;uniqueIndex:idx_webhook_delivery_event,where:redelivery = false"`
+	EventID        string     `json:"event_id" gorm:"not null;index;uniqueIndex:idx_webhook_delivery_event"` // o mesmo em todas as reentregas do evento
+	Redelivery     bool       `json:"redelivery" gorm:"not null;default:false"`                              // pedida pela API; a primeira entrega é única por evento
+	EventType      string     `json:"event_type" gorm:"not null"`
+	Payload        string     `json:"payload" gorm:"type:text;not null"`
+	Status         string     `json:"status" gorm:"not null;default:'pending';index"`
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at" gorm:"index"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	ResponseStatus int        `json:"response_status"`
+	LastError      string     `json:"last_error"`
+
+	Webhook Webhook `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,349 @@
+// Package notifications transforma eventos de domínio em notificações
+// individuais para os usuários interessados.
+package notifications
+
+import (
+	"api-go/internal/events"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// nearbyWindow define quão perto de uma reserva existente uma nova reserva
+// precisa estar para gerar notificação.
+const nearbyWindow = 30 * time.Minute
+
+type Service struct {
+	NotificationsRepository *repository.NotificationsRepository
+	RoomsRepository         *repository.RoomsRepository
+	ReservationsRepository  *repository.ReservationsRepository
+
+	// OnCreate, se definido, é chamado para cada notificação criada. É por
+	// onde a entrega em tempo real fica sabendo das notificações.
+	OnCreate func(models.Notification)
+}
+
+// Register inscreve o serviço nos eventos que geram notificações.
+func (s *Service) Register(bus *events.Bus) {
+	bus.Subscribe(events.RoomMemberJoined, s.onMemberJoined)
+	bus.Subscribe(events.RoomMemberRoleChanged, s.onRoleChanged)
+	bus.Subscribe(events.NoteCreated, s.onNoteCreated)
+	bus.Subscribe(events.NoteMentioned, s.onNoteMentioned)
+	bus.Subscribe(events.ReservationCreated, s.onReservationCreated)
+	bus.Subscribe(events.ReservationReminder, s.onReservationReminder)
+	bus.Subscribe(events.ReservationCancelled, s.onReservationCancelled)
+	bus.Subscribe(events.ReservationCreated, s.onReservationPending)
+	bus.Subscribe(events.ReservationUpdated, s.onReservationPending)
+	bus.Subscribe(events.ReservationApproved, s.onReservationDecided)
+	bus.Subscribe(events.ReservationRejected, s.onReservationDecided)
+	bus.Subscribe(events.ReservationExpired, s.onReservationDecided)
+	bus.Subscribe(events.WaitlistOffered, s.onWaitlistOffered)
+	bus.Subscribe(events.RoomJoinRequested, s.onJoinRequested)
+	bus.Subscribe(events.RoomJoinApproved, s.onJoinDecided)
+	bus.Subscribe(events.RoomJoinDenied, s.onJoinDecided)
+	bus.Subscribe(events.RoomOwnershipOffered, s.onOwnershipOffered)
+	bus.Subscribe(events.RoomOwnershipDeclined, s.onOwnershipDeclined)
+	bus.Subscribe(events.RoomOwnershipTransferred, s.onOwnershipTransferred)
+	bus.Subscribe(events.AccountExportReady, s.onExportReady)
+}
+
+func (s *Service) onMemberJoined(e events.Event) error {
+	adminIDs, err := s.RoomsRepository.GetAdminIDs(e.RoomID)
+	if err != nil {
+		return fmt.Errorf("loading admins of room %d: %w", e.RoomID, err)
+	}
+
+	return s.notify(e, adminIDs, models.Notification{
+		Type:    models.NotificationTypeMemberJoined,
+		Title:   "Novo membro na sala",
+		Message: fmt.Sprintf("%s entrou na sala \"%s\"", e.String("actor_name"), e.String("room_name")),
+	})
+}
+
+func (s *Service) onRoleChanged(e events.Event) error {
+	return s.notify(e, []uint{e.UserID}, models.Notification{
+		Type:    models.NotificationTypeRoleChanged,
+		Title:   "Seu papel na sala mudou",
+		Message: fmt.Sprintf("Agora você é %s na sala \"%s\"", e.String("role"), e.String("room_name")),
+	})
+}
+
+func (s *Service) onNoteCreated(e events.Event) error {
+	members, err := s.RoomsRepository.GetMembers(e.RoomID)
+	if err != nil {
+		return fmt.Errorf("loading members of room %d: %w", e.RoomID, err)
+	}
+
+	userIDs := make([]uint, len(members))
+	for i, member := range members {
+		userIDs[i] = member.UserID
+	}
+
+	return s.notify(e, userIDs, models.Notification{
+		Type:    models.NotificationTypeNoteCreated,
+		Title:   "Nova nota na sala",
+		Message: fmt.Sprintf("%s publicou \"%s\"", e.String("actor_name"), e.String("note_title")),
+	})
+}
+
+func (s *Service) onNoteMentioned(e events.Event) error {
+	return s.notify(e, []uint{e.UserID}, models.Notification{
+		Type:    models.NotificationTypeMention,
+		Title:   fmt.Sprintf("%s mencionou você", e.String("actor_name")),
+		Message: fmt.Sprintf("Você foi mencionado na nota \"%s\"", e.String("note_title")),
+	})
+}
+
+func (s *Service) onReservationCreated(e events.Event) error {
+	reservation, err := s.ReservationsRepository.GetByID(e.ReservationID)
+	if err != nil {
+		return fmt.Errorf("loading reservation %d: %w", e.ReservationID, err)
+	}
+	if reservation == nil {
+		return nil // cancelada antes do evento ser entregue
+	}
+
+	nearby, err := s.ReservationsRepository.GetNearby(reservation.RoomID, reservation.StartTime, reservation.EndTime, nearbyWindow)
+	if err != nil {
+		return fmt.Errorf("loading reservations near %d: %w", reservation.ID, err)
+	}
+
+	var userIDs []uint
+	for _, other := range nearby {
+		if other.ID != reservation.ID {
+			userIDs = append(userIDs, other.UserID)
+		}
+	}
+
+	return s.notify(e, userIDs, models.Notification{
+		Type:  models.NotificationTypeReservationNear,
+		Title: "Nova reserva próxima da sua",
+		Message: fmt.Sprintf("%s reservou a sala \"%s\" de %s a %s",
+			e.String("actor_name"), e.String("room_name"),
+			reservation.StartTime.In(eventLocation(e)).Format("02/01 15:04"), reservation.EndTime.In(eventLocation(e)).Format("15:04")),
+	})
+}
+
+func (s *Service) onReservationReminder(e events.Event) error {
+	reservation, err := s.ReservationsRepository.GetByID(e.ReservationID)
+	if err != nil {
+		return fmt.Errorf("loading reservation %d: %w", e.ReservationID, err)
+	}
+	if reservation == nil {
+		return nil // cancelada depois do lembrete ser agendado
+	}
+
+	return s.notify(e, []uint{reservation.UserID}, models.Notification{
+		Type:  models.NotificationTypeReminder,
+		Title: "Sua reserva está chegando",
+		Message: fmt.Sprintf("Sua reserva na sala \"%s\" começa às %s",
+			e.String("room_name"), reservation.StartTime.In(eventLocation(e)).Format("15:04")),
+	})
+}
+
+// onReservationCancelled avisa o usuário quando a reserva dele foi liberada
+// por falta de check-in. Os cancelamentos feitos pelo próprio usuário ou por
+// um administrador não geram notificação.
+func (s *Service) onReservationCancelled(e events.Event) error {
+	if e.String("reason") != "not_checked_in" {
+		return nil
+	}
+
+	return s.notify(e, []uint{e.UserID}, models.Notification{
+		Type:    models.NotificationTypeReleased,
+		Title:   "Sua reserva foi liberada",
+		Message: fmt.Sprintf("Sua reserva na sala \"%s\" foi cancelada porque o check-in não foi feito a tempo", e.String("room_name")),
+	})
+}
+
+// onReservationPending avisa os administradores da sala de que há uma
+// reserva aguardando aprovação.
+func (s *Service) onReservationPending(e events.Event) error {
+	if e.String("status") != models.ReservationPending {
+		return nil
+	}
+
+	adminIDs, err := s.RoomsRepository.GetAdminIDs(e.RoomID)
+	if err != nil {
+		return fmt.Errorf("loading admins of room %d: %w", e.RoomID, err)
+	}
+
+	return s.notify(e, adminIDs, models.Notification{
+		Type:    models.NotificationTypeApprovalNeeded,
+		Title:   "Reserva aguardando aprovação",
+		Message: fmt.Sprintf("%s pediu para reservar a sala \"%s\"", e.String("actor_name"), e.String("room_name")),
+	})
+}
+
+// onReservationDecided avisa quem pediu a reserva da decisão tomada.
+func (s *Service) onReservationDecided(e events.Event) error {
+	var title, message string
+	switch e.Type {
+	case events.ReservationApproved:
+		title = "Reserva aprovada"
+		message = fmt.Sprintf("Sua reserva na sala \"%s\" foi aprovada", e.String("room_name"))
+	case events.ReservationRejected:
+		title = "Reserva recusada"
+		message = fmt.Sprintf("Sua reserva na sala \"%s\" foi recusada: %s", e.String("room_name"), e.String("reason"))
+	case events.ReservationExpired:
+		if e.String("reason") == "hold_expired" {
+			return nil // quem estava na lista de espera já foi avisado do prazo
+		}
+		fallthrough
+	default:
+		title = "Reserva expirada"
+		message = fmt.Sprintf("Sua reserva na sala \"%s\" expirou sem ser aprovada", e.String("room_name"))
+	}
+
+	return s.notify(e, []uint{e.UserID}, models.Notification{
+		Type:    models.NotificationTypeDecision,
+		Title:   title,
+		Message: message,
+	})
+}
+
+// onWaitlistOffered avisa o usuário da lista de espera de que há um lugar
+// reservado provisoriamente para ele.
+func (s *Service) onWaitlistOffered(e events.Event) error {
+	deadline := e.String("expires_at")
+	if expiresAt, err := time.Parse(time.RFC3339, deadline); err == nil {
+		deadline = expiresAt.In(eventLocation(e)).Format("02/01 15:04")
+	}
+
+	return s.notify(e, []uint{e.UserID}, models.Notification{
+		Type:    models.NotificationTypeWaitlistOffer,
+		Title:   "Um lugar ficou livre",
+		Message: fmt.Sprintf("Abriu uma vaga na sala \"%s\" no período que você esperava. Confirme até %s", e.String("room_name"), deadline),
+	})
+}
+
+// onJoinRequested avisa os administradores da sala de que alguém pediu
+// para entrar.
+func (s *Service) onJoinRequested(e events.Event) error {
+	adminIDs, err := s.RoomsRepository.GetAdminIDs(e.RoomID)
+	if err != nil {
+		return fmt.Errorf("loading admins of room %d: %w", e.RoomID, err)
+	}
+
+	return s.notify(e, adminIDs, models.Notification{
+		Type:    models.NotificationTypeJoinRequest,
+		Title:   "Pedido de entrada na sala",
+		Message: fmt.Sprintf("%s pediu para entrar na sala \"%s\"", e.String("actor_name"), e.String("room_name")),
+	})
+}
+
+// onJoinDecided avisa quem pediu para entrar da decisão tomada.
+func (s *Service) onJoinDecided(e events.Event) error {
+	title := "Pedido de entrada recusado"
+	message := fmt.Sprintf("Seu pedido para entrar na sala \"%s\" foi recusado", e.String("room_name"))
+	if e.Type == events.RoomJoinApproved {
+		title = "Pedido de entrada aprovado"
+		message = fmt.Sprintf("Seu pedido para entrar na sala \"%s\" foi aprovado", e.String("room_name"))
+	}
+
+	return s.notify(e, []uint{e.UserID}, models.Notification{
+		Type:    models.NotificationTypeJoinDecision,
+		Title:   title,
+		Message: message,
+	})
+}
+
+// onOwnershipOffered avisa o membro de que o dono quer passar a sala para ele.
+func (s *Service) onOwnershipOffered(e events.Event) error {
+	return s.notify(e, []uint{e.UserID}, models.Notification{
+		Type:    models.NotificationTypeOwnership,
+		Title:   "Transferência de sala",
+		Message: fmt.Sprintf("%s quer transferir a sala \"%s\" para você", e.String("actor_name"), e.String("room_name")),
+	})
+}
+
+// onOwnershipDeclined avisa o dono de que a transferência foi recusada.
+func (s *Service) onOwnershipDeclined(e events.Event) error {
+	return s.notify(e, []uint{e.UserID}, models.Notification{
+		Type:    models.NotificationTypeOwnership,
+		Title:   "Transferência recusada",
+		Message: fmt.Sprintf("%s recusou a transferência da sala \"%s\"", e.String("actor_name"), e.String("room_name")),
+	})
+}
+
+// onOwnershipTransferred avisa os administradores da sala de que ela mudou
+// de dono.
+func (s *Service) onOwnershipTransferred(e events.Event) error {
+	adminIDs, err := s.RoomsRepository.GetAdminIDs(e.RoomID)
+	if err != nil {
+		return fmt.Errorf("loading admins of room %d: %w", e.RoomID, err)
+	}
+
+	return s.notify(e, adminIDs, models.Notification{
+		Type:    models.NotificationTypeOwnership,
+		Title:   "Sala com novo dono",
+		Message: fmt.Sprintf("%s agora é o dono da sala \"%s\"", e.String("new_owner_name"), e.String("room_name")),
+	})
+}
+
+// onExportReady avisa o usuário que a exportação de dados dele pode ser
+// baixada pelo link recebido ao pedi-la.
+func (s *Service) onExportReady(e events.Event) error {
+	return s.notify(e, []uint{e.UserID}, models.Notification{
+		Type:    models.NotificationTypeExportReady,
+		Title:   "Sua exportação de dados está pronta",
+		Message: "Use o link recebido ao pedir a exportação para baixar o arquivo. Ele funciona uma única vez e expira em breve",
+	})
+}
+
+// notify cria uma cópia da notificação para cada usuário, exceto o autor do
+// evento e quem desativou o tipo nas preferências. A notificação guarda o id
+// do evento, então uma nova entrega dele não a duplica.
+func (s *Service) notify(e events.Event, userIDs []uint, template models.Notification) error {
+	var recipients []uint
+	seen := make(map[uint]bool)
+	for _, id := range userIDs {
+		if id == 0 || id == e.ActorID || seen[id] {
+			continue
+		}
+		seen[id] = true
+		recipients = append(recipients, id)
+	}
+
+	recipients, err := s.NotificationsRepository.FilterEnabled(template.Type, recipients)
+	if err != nil {
+		return fmt.Errorf("loading notification preferences for %s: %w", template.Type, err)
+	}
+
+	var errs []error
+	for _, userID := range recipients {
+		notification := template
+		notification.UserID = userID
+		notification.ActorID = optionalID(e.ActorID)
+		notification.RoomID = optionalID(e.RoomID)
+		notification.NoteID = optionalID(e.NoteID)
+		notification.ReservationID = optionalID(e.ReservationID)
+		if e.ID != "" {
+			notification.EventID = &e.ID
+		}
+		created, err := s.NotificationsRepository.Create(&notification)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("creating %s notification for user %d: %w", template.Type, userID, err))
+			continue
+		}
+		if created && s.OnCreate != nil {
+			s.OnCreate(notification)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func optionalID(id uint) *uint {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// eventLocation é o fuso da sala do evento, em que os horários das
+// mensagens são escritos.
+func eventLocation(e events.Event) *time.Location {
+	return models.LoadLocation(e.String("time_zone"))
+}
@@ -0,0 +1,189 @@
+// Package policy valida uma reserva contra as regras de reserva da sala
+// (models.BookingPolicy), devolvendo todas as regras violadas de uma vez.
+package policy
+
+import (
+	"api-go/internal/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Regras que podem ser violadas.
+const (
+	RuleOpeningHours       = "opening_hours"
+	RuleBlackout           = "blackout"
+	RuleMinDuration        = "min_duration"
+	RuleMaxDuration        = "max_duration"
+	RuleMinNotice          = "min_notice"
+	RuleMaxAdvance         = "max_advance"
+	RuleWeeklyHours        = "max_hours_per_week"
+	RuleActiveReservations = "max_active_reservations"
+)
+
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Usage é o uso da sala pelo usuário, sem contar a reserva sendo validada.
+type Usage struct {
+	// WeekHours soma as reservas do usuário na semana da reserva validada.
+	WeekHours time.Duration
+	// Active conta as reservas do usuário que ainda não terminaram.
+	Active int
+}
+
+// Request é a reserva a validar. Os horários são interpretados em Location.
+type Request struct {
+	StartTime time.Time
+	EndTime   time.Time
+	Now       time.Time
+	Location  *time.Location
+
+	Blackouts []models.RoomBlackout
+	Usage     Usage
+}
+
+// Check retorna as regras da política violadas pela reserva.
+func Check(p models.BookingPolicy, req Request) []Violation {
+	var violations []Violation
+	add := func(rule, format string, args ...any) {
+		violations = append(violations, Violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	loc := req.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	duration := req.EndTime.Sub(req.StartTime)
+
+	if len(p.OpeningHours) > 0 && !withinOpeningHours(p.OpeningHours, req.StartTime.In(loc), req.EndTime.In(loc)) {
+		add(RuleOpeningHours, "Reservation must fit within the room's opening hours on %s", req.StartTime.In(loc).Weekday())
+	}
+
+	for _, blackout := range req.Blackouts {
+		if req.StartTime.Before(blackout.EndTime) && req.EndTime.After(blackout.StartTime) {
+			reason := blackout.Reason
+			if reason == "" {
+				reason = "room unavailable"
+			}
+			add(RuleBlackout, "Room cannot be booked from %s to %s: %s",
+				blackout.StartTime.In(loc).Format(time.RFC3339), blackout.EndTime.In(loc).Format(time.RFC3339), reason)
+		}
+	}
+
+	if p.MinDurationMinutes > 0 && duration < minutes(p.MinDurationMinutes) {
+		add(RuleMinDuration, "Reservation must last at least %d minutes", p.MinDurationMinutes)
+	}
+	if p.MaxDurationMinutes > 0 && duration > minutes(p.MaxDurationMinutes) {
+		add(RuleMaxDuration, "Reservation must last at most %d minutes", p.MaxDurationMinutes)
+	}
+
+	if p.MinNoticeMinutes > 0 && req.StartTime.Before(req.Now.Add(minutes(p.MinNoticeMinutes))) {
+		add(RuleMinNotice, "Reservation must be made at least %d minutes in advance", p.MinNoticeMinutes)
+	}
+	if p.MaxAdvanceDays > 0 && req.StartTime.After(req.Now.AddDate(0, 0, p.MaxAdvanceDays)) {
+		add(RuleMaxAdvance, "Reservation cannot start more than %d days ahead", p.MaxAdvanceDays)
+	}
+
+	if p.MaxHoursPerWeek > 0 && req.Usage.WeekHours+duration > time.Duration(p.MaxHoursPerWeek)*time.Hour {
+		add(RuleWeeklyHours, "Reservation exceeds the quota of %d hours per week in this room (%s already booked)",
+			p.MaxHoursPerWeek, req.Usage.WeekHours.Round(time.Minute))
+	}
+	if p.MaxActiveReservations > 0 && req.Usage.Active >= p.MaxActiveReservations {
+		add(RuleActiveReservations, "User already has %d active reservations in this room (maximum %d)",
+			req.Usage.Active, p.MaxActiveReservations)
+	}
+
+	return violations
+}
+
+// Occupied retorna o período que a reserva ocupa na sala: o intervalo livre
+// exigido entre reservas conta como ocupado dos dois lados.
+func Occupied(p models.BookingPolicy, start, end time.Time) (time.Time, time.Time) {
+	buffer := minutes(p.BufferMinutes)
+	return start.Add(-buffer), end.Add(buffer)
+}
+
+// ValidateOpeningHours confere o formato das faixas de horário.
+func ValidateOpeningHours(hours []models.OpeningHours) error {
+	for _, h := range hours {
+		if h.Weekday < time.Sunday || h.Weekday > time.Saturday {
+			return fmt.Errorf("invalid weekday %d", h.Weekday)
+		}
+		open, err := parseClock(h.Open)
+		if err != nil {
+			return err
+		}
+		closeAt, err := parseClock(h.Close)
+		if err != nil {
+			return err
+		}
+		if closeAt <= open {
+			return fmt.Errorf("close must be after open on %s", h.Weekday)
+		}
+	}
+	return nil
+}
+
+// WeekBounds retorna o início (segunda-feira, 00:00) e o fim da semana que
+// contém t, no fuso loc.
+func WeekBounds(t time.Time, loc *time.Location) (time.Time, time.Time) {
+	if loc == nil {
+		loc = time.Local
+	}
+	t = t.In(loc)
+	offset := (int(t.Weekday()) + 6) % 7
+	start := time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, loc)
+	return start, start.AddDate(0, 0, 7)
+}
+
+// withinOpeningHours informa se o período cabe inteiro numa faixa do dia em
+// que começa.
+func withinOpeningHours(hours []models.OpeningHours, start, end time.Time) bool {
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	for _, h := range hours {
+		if h.Weekday != start.Weekday() {
+			continue
+		}
+		open, err := parseClock(h.Open)
+		if err != nil {
+			continue
+		}
+		closeAt, err := parseClock(h.Close)
+		if err != nil {
+			continue
+		}
+
+		// AddDate e Date respeitam as mudanças de horário de verão.
+		opensAt := time.Date(day.Year(), day.Month(), day.Day(), 0, open, 0, 0, day.Location())
+		closesAt := time.Date(day.Year(), day.Month(), day.Day(), 0, closeAt, 0, 0, day.Location())
+		if !start.Before(opensAt) && !end.After(closesAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock converte "HH:MM" em minutos desde a meia-noite.
+func parseClock(value string) (int, error) {
+	hours, mins, ok := strings.Cut(value, ":")
+	if !ok || len(hours) != 2 || len(mins) != 2 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	h, err := strconv.Atoi(hours)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	m, err := strconv.Atoi(mins)
+	if err != nil || m < 0 || m > 59 || h < 0 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return h*60 + m, nil
+}
+
+func minutes(n int) time.Duration {
+	return time.Duration(n) * time.Minute
+}
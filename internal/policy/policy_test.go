@@ -0,0 +1,301 @@
+package policy
+
+import (
+	"api-go/internal/models"
+	"slices"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%q) error = %v", name, err)
+	}
+	return loc
+}
+
+func TestCheck(t *testing.T) {
+	saoPaulo := loadLocation(t, "America/Sao_Paulo")
+
+	// at é um horário da semana de 3 de junho de 2024 (segunda-feira) em
+	// São Paulo, que não tinha horário de verão.
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2024, time.June, day, hour, min, 0, 0, saoPaulo)
+	}
+	now := at(1, 12, 0) // sábado anterior
+	businessHours := []models.OpeningHours{
+		{Weekday: time.Monday, Open: "08:00", Close: "18:00"},
+	}
+	allDay := []models.OpeningHours{
+		{Weekday: time.Monday, Open: "00:00", Close: "24:00"},
+		{Weekday: time.Tuesday, Open: "00:00", Close: "24:00"},
+	}
+	blackout := models.RoomBlackout{StartTime: at(3, 12, 0), EndTime: at(3, 14, 0), Reason: "maintenance"}
+
+	tests := []struct {
+		name      string
+		policy    models.BookingPolicy
+		start     time.Time
+		end       time.Time
+		now       time.Time
+		location  *time.Location
+		blackouts []models.RoomBlackout
+		usage     Usage
+		want      []string
+	}{
+		{
+			name:  "no rules",
+			start: at(3, 2, 0),
+			end:   at(3, 23, 0),
+		},
+		{
+			name:   "within opening hours",
+			policy: models.BookingPolicy{OpeningHours: businessHours},
+			start:  at(3, 8, 0),
+			end:    at(3, 18, 0),
+		},
+		{
+			name:   "before opening",
+			policy: models.BookingPolicy{OpeningHours: businessHours},
+			start:  at(3, 7, 30),
+			end:    at(3, 9, 0),
+			want:   []string{RuleOpeningHours},
+		},
+		{
+			name:   "after closing",
+			policy: models.BookingPolicy{OpeningHours: businessHours},
+			start:  at(3, 17, 0),
+			end:    at(3, 18, 30),
+			want:   []string{RuleOpeningHours},
+		},
+		{
+			name:   "closed weekday",
+			policy: models.BookingPolicy{OpeningHours: businessHours},
+			start:  at(4, 9, 0),
+			end:    at(4, 10, 0),
+			want:   []string{RuleOpeningHours},
+		},
+		{
+			name:   "until midnight",
+			policy: models.BookingPolicy{OpeningHours: allDay},
+			start:  at(3, 22, 0),
+			end:    at(4, 0, 0),
+		},
+		{
+			// A reserva precisa caber numa faixa do dia em que começa,
+			// mesmo que o dia seguinte também esteja aberto.
+			name:   "across midnight",
+			policy: models.BookingPolicy{OpeningHours: allDay},
+			start:  at(3, 23, 0),
+			end:    at(4, 1, 0),
+			want:   []string{RuleOpeningHours},
+		},
+		{
+			// 10:00 UTC são 07:00 em São Paulo, antes da abertura.
+			name:     "room time zone",
+			policy:   models.BookingPolicy{OpeningHours: businessHours},
+			start:    time.Date(2024, time.June, 3, 10, 0, 0, 0, time.UTC),
+			end:      time.Date(2024, time.June, 3, 11, 0, 0, 0, time.UTC),
+			location: saoPaulo,
+			want:     []string{RuleOpeningHours},
+		},
+		{
+			name:     "utc room",
+			policy:   models.BookingPolicy{OpeningHours: businessHours},
+			start:    time.Date(2024, time.June, 3, 10, 0, 0, 0, time.UTC),
+			end:      time.Date(2024, time.June, 3, 11, 0, 0, 0, time.UTC),
+			location: time.UTC,
+		},
+		{
+			name:      "overlapping blackout",
+			start:     at(3, 13, 0),
+			end:       at(3, 15, 0),
+			blackouts: []models.RoomBlackout{blackout},
+			want:      []string{RuleBlackout},
+		},
+		{
+			name:      "adjacent blackout",
+			start:     at(3, 14, 0),
+			end:       at(3, 15, 0),
+			blackouts: []models.RoomBlackout{blackout},
+		},
+		{
+			name:   "too short",
+			policy: models.BookingPolicy{MinDurationMinutes: 30},
+			start:  at(3, 9, 0),
+			end:    at(3, 9, 15),
+			want:   []string{RuleMinDuration},
+		},
+		{
+			name:   "minimum duration",
+			policy: models.BookingPolicy{MinDurationMinutes: 30, MaxDurationMinutes: 60},
+			start:  at(3, 9, 0),
+			end:    at(3, 9, 30),
+		},
+		{
+			name:   "too long",
+			policy: models.BookingPolicy{MaxDurationMinutes: 60},
+			start:  at(3, 9, 0),
+			end:    at(3, 10, 30),
+			want:   []string{RuleMaxDuration},
+		},
+		{
+			name:   "short notice",
+			policy: models.BookingPolicy{MinNoticeMinutes: 60},
+			start:  now.Add(30 * time.Minute),
+			end:    now.Add(90 * time.Minute),
+			want:   []string{RuleMinNotice},
+		},
+		{
+			name:   "too far ahead",
+			policy: models.BookingPolicy{MaxAdvanceDays: 7},
+			start:  now.AddDate(0, 0, 8),
+			end:    now.AddDate(0, 0, 8).Add(time.Hour),
+			want:   []string{RuleMaxAdvance},
+		},
+		{
+			name:   "within lead time",
+			policy: models.BookingPolicy{MinNoticeMinutes: 60, MaxAdvanceDays: 7},
+			start:  at(3, 9, 0),
+			end:    at(3, 10, 0),
+		},
+		{
+			name:   "weekly quota exceeded",
+			policy: models.BookingPolicy{MaxHoursPerWeek: 4},
+			start:  at(3, 9, 0),
+			end:    at(3, 11, 0),
+			usage:  Usage{WeekHours: 3 * time.Hour},
+			want:   []string{RuleWeeklyHours},
+		},
+		{
+			name:   "weekly quota filled exactly",
+			policy: models.BookingPolicy{MaxHoursPerWeek: 4},
+			start:  at(3, 9, 0),
+			end:    at(3, 10, 0),
+			usage:  Usage{WeekHours: 3 * time.Hour},
+		},
+		{
+			name:   "active reservations quota",
+			policy: models.BookingPolicy{MaxActiveReservations: 2},
+			start:  at(3, 9, 0),
+			end:    at(3, 10, 0),
+			usage:  Usage{Active: 2},
+			want:   []string{RuleActiveReservations},
+		},
+		{
+			name:   "below active reservations quota",
+			policy: models.BookingPolicy{MaxActiveReservations: 2},
+			start:  at(3, 9, 0),
+			end:    at(3, 10, 0),
+			usage:  Usage{Active: 1},
+		},
+		{
+			name: "every violation at once",
+			policy: models.BookingPolicy{
+				OpeningHours:          businessHours,
+				MinDurationMinutes:    30,
+				MinNoticeMinutes:      60,
+				MaxHoursPerWeek:       1,
+				MaxActiveReservations: 1,
+			},
+			start:     at(3, 13, 0),
+			end:       at(3, 13, 10),
+			now:       at(3, 12, 30),
+			blackouts: []models.RoomBlackout{blackout},
+			usage:     Usage{WeekHours: time.Hour, Active: 1},
+			want:      []string{RuleBlackout, RuleMinDuration, RuleMinNotice, RuleWeeklyHours, RuleActiveReservations},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := Request{
+				StartTime: tt.start,
+				EndTime:   tt.end,
+				Now:       tt.now,
+				Location:  tt.location,
+				Blackouts: tt.blackouts,
+				Usage:     tt.usage,
+			}
+			if req.Now.IsZero() {
+				req.Now = now
+			}
+			if req.Location == nil {
+				req.Location = saoPaulo
+			}
+
+			var rules []string
+			for _, violation := range Check(tt.policy, req) {
+				rules = append(rules, violation.Rule)
+			}
+			if !slices.Equal(rules, tt.want) {
+				t.Errorf("Check() rules = %v, want %v", rules, tt.want)
+			}
+		})
+	}
+}
+
+func TestOccupied(t *testing.T) {
+	start := time.Date(2024, time.June, 3, 9, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		buffer    int
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{"no buffer", 0, start, end},
+		{"buffer on both sides", 15, start.Add(-15 * time.Minute), end.Add(15 * time.Minute)},
+	}
+	for _, tt := range tests {
+		from, until := Occupied(models.BookingPolicy{BufferMinutes: tt.buffer}, start, end)
+		if !from.Equal(tt.wantStart) || !until.Equal(tt.wantEnd) {
+			t.Errorf("%s: Occupied() = %s, %s, want %s, %s", tt.name, from, until, tt.wantStart, tt.wantEnd)
+		}
+	}
+}
+
+func TestWeekBounds(t *testing.T) {
+	saoPaulo := loadLocation(t, "America/Sao_Paulo")
+	monday := time.Date(2024, time.June, 3, 0, 0, 0, 0, saoPaulo)
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want time.Time
+	}{
+		{"monday midnight", monday, monday},
+		{"sunday night", time.Date(2024, time.June, 9, 23, 59, 0, 0, saoPaulo), monday},
+		// 01:00 UTC de segunda ainda é domingo em São Paulo.
+		{"utc instant in the room time zone", time.Date(2024, time.June, 3, 1, 0, 0, 0, time.UTC), monday.AddDate(0, 0, -7)},
+	}
+	for _, tt := range tests {
+		start, end := WeekBounds(tt.at, saoPaulo)
+		if !start.Equal(tt.want) || !end.Equal(tt.want.AddDate(0, 0, 7)) {
+			t.Errorf("%s: WeekBounds() = %s, %s, want %s", tt.name, start, end, tt.want)
+		}
+	}
+}
+
+func TestValidateOpeningHours(t *testing.T) {
+	tests := []struct {
+		name    string
+		hours   []models.OpeningHours
+		wantErr bool
+	}{
+		{"valid", []models.OpeningHours{{Weekday: time.Monday, Open: "08:00", Close: "24:00"}}, false},
+		{"invalid weekday", []models.OpeningHours{{Weekday: 7, Open: "08:00", Close: "18:00"}}, true},
+		{"invalid format", []models.OpeningHours{{Weekday: time.Monday, Open: "8:00", Close: "18:00"}}, true},
+		{"past midnight", []models.OpeningHours{{Weekday: time.Monday, Open: "08:00", Close: "24:30"}}, true},
+		{"closes before opening", []models.OpeningHours{{Weekday: time.Monday, Open: "18:00", Close: "08:00"}}, true},
+	}
+	for _, tt := range tests {
+		if err := ValidateOpeningHours(tt.hours); (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateOpeningHours() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package realtime
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// Tempo máximo para escrever uma mensagem no cliente.
+	writeWait = 10 * time.Second
+
+	// Sem pong nesse intervalo a conexão é considerada morta.
+	pongWait = 60 * time.Second
+
+	// Os pings precisam sair antes do prazo do pong.
+	pingPeriod = (pongWait * 9) / 10
+
+	// Tamanho máximo das mensagens enviadas pelo cliente.
+	maxMessageSize = 4096
+
+	// Mensagens enfileiradas por cliente. Um cliente que não consome a
+	// fila a tempo é desconectado para não atrasar os demais.
+	sendBuffer = 64
+)
+
+const (
+	ActionSubscribe   = "subscribe"
+	ActionUnsubscribe = "unsubscribe"
+)
+
+// Command é a mensagem enviada pelo cliente para entrar ou sair de uma sala.
+type Command struct {
+	Action string `json:"action"`
+	RoomID uint   `json:"room_id"`
+}
+
+type Client struct {
+	UserID uint
+	Name   string
+
+	hub   *Hub
+	conn  *websocket.Conn
+	queue chan []byte
+	check SessionCheck
+
+	// rooms é protegido por hub.mu.
+	rooms map[uint]bool
+
+	closeOnce sync.Once
+	done      chan struct{}
+	// Código e motivo do frame de fechamento, definidos antes de done fechar.
+	closeCode   int
+	closeReason string
+}
+
+// Serve faz o upgrade da requisição e mantém a conexão até o cliente sair
+// ou a sessão deixar de valer.
+func (h *Hub) Serve(w http.ResponseWriter, r *http.Request, userID uint, name string, check SessionCheck) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// O upgrader já respondeu com o erro.
+		return
+	}
+
+	client := &Client{
+		UserID: userID,
+		Name:   name,
+		hub:    h,
+		conn:   conn,
+		queue:  make(chan []byte, sendBuffer),
+		check:  check,
+		rooms:  make(map[uint]bool),
+		done:   make(chan struct{}),
+	}
+
+	if !h.add(client) {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+			time.Now().Add(writeWait))
+		conn.Close()
+		return
+	}
+
+	go client.writePump()
+	go client.watchSession()
+	client.readPump()
+}
+
+func (c *Client) send(message Message) {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("failed to encode %s message: %v", message.Type, err)
+		return
+	}
+	c.enqueue(payload)
+}
+
+// enqueue nunca bloqueia: se a fila estiver cheia o cliente é desconectado.
+func (c *Client) enqueue(payload []byte) {
+	select {
+	case <-c.done:
+	case c.queue <- payload:
+	default:
+		log.Printf("websocket client of user %d is too slow, disconnecting", c.UserID)
+		c.close()
+	}
+}
+
+func (c *Client) close() {
+	c.closeWith(websocket.CloseGoingAway, "")
+}
+
+func (c *Client) closeWith(code int, reason string) {
+	c.closeOnce.Do(func() {
+		// O writePump envia o frame de fechamento e encerra a conexão.
+		c.closeCode = code
+		c.closeReason = reason
+		close(c.done)
+	})
+}
+
+// watchSession fecha a conexão quando a sessão deixa de valer.
+func (c *Client) watchSession() {
+	ticker := time.NewTicker(sessionCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if !c.check() {
+				c.closeWith(websocket.ClosePolicyViolation, "session ended")
+				return
+			}
+		}
+	}
+}
+
+func (c *Client) readPump() {
+	defer func() {
+		c.hub.remove(c)
+		c.close()
+	}()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, payload, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("websocket read error for user %d: %v", c.UserID, err)
+			}
+			return
+		}
+
+		var command Command
+		if err := json.Unmarshal(payload, &command); err != nil {
+			c.send(Message{Type: MessageError, Message: "Invalid message"})
+			continue
+		}
+
+		switch command.Action {
+		case ActionSubscribe:
+			c.hub.subscribe(c, command.RoomID)
+		case ActionUnsubscribe:
+			c.hub.unsubscribe(c, command.RoomID)
+		default:
+			c.send(Message{Type: MessageError, Message: "Unknown action: " + command.Action})
+		}
+	}
+}
+
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.close()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case <-c.done:
+			c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(c.closeCode, c.closeReason),
+				time.Now().Add(writeWait))
+			return
+		case payload := <-c.queue:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
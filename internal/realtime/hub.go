@@ -0,0 +1,284 @@
+// Package realtime envia os eventos das salas para os clientes conectados por
+// WebSocket e mantém a presença de quem está vendo cada sala.
+package realtime
+
+import (
+	"api-go/internal/events"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Intervalo em que cada conexão confere se a sessão que a abriu ainda vale.
+// Os eventos de SessionEventTypes encerram as conexões na hora; a
+// verificação periódica cobre o que não passa por eles.
+const sessionCheckInterval = time.Minute
+
+// Tipos de mensagem enviados pelo servidor, além dos próprios eventos.
+const (
+	MessagePresence     = "presence"
+	MessageSubscribed   = "subscribed"
+	MessageUnsubscribed = "unsubscribed"
+	MessageError        = "error"
+)
+
+type PresenceUser struct {
+	UserID uint   `json:"user_id"`
+	Name   string `json:"name"`
+}
+
+// Message é o envelope de tudo que o servidor envia pelo WebSocket.
+type Message struct {
+	Type    string         `json:"type"`
+	RoomID  uint           `json:"room_id,omitempty"`
+	Event   *events.Event  `json:"event,omitempty"`
+	Users   []PresenceUser `json:"users,omitempty"`
+	Message string         `json:"message,omitempty"`
+}
+
+// Authorizer informa se o usuário pode acompanhar a sala.
+type Authorizer func(userID, roomID uint) bool
+
+// SessionCheck informa se a sessão que abriu a conexão ainda vale. A conta
+// pode ser suspensa ou ter as sessões encerradas depois do handshake.
+type SessionCheck func() bool
+
+type Hub struct {
+	authorize Authorizer
+	upgrader  websocket.Upgrader
+
+	mu      sync.Mutex
+	rooms   map[uint]map[*Client]struct{}
+	clients map[*Client]struct{}
+	closed  bool
+}
+
+// NewHub cria o hub. O handshake só é aceito das origens em
+// allowedOrigins ("*" aceita qualquer uma); sem nenhuma, só da própria
+// origem da API.
+func NewHub(authorize Authorizer, allowedOrigins []string) *Hub {
+	return &Hub{
+		authorize: authorize,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     checkOrigin(allowedOrigins),
+		},
+		rooms:   make(map[uint]map[*Client]struct{}),
+		clients: make(map[*Client]struct{}),
+	}
+}
+
+// checkOrigin barra o handshake vindo de páginas de outras origens. O
+// navegador envia o token da query string de qualquer página, então a
+// origem precisa ser conferida mesmo sem cookies.
+func checkOrigin(allowedOrigins []string) func(r *http.Request) bool {
+	if len(allowedOrigins) == 0 {
+		// O padrão do gorilla: só a própria origem.
+		return nil
+	}
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			// Clientes que não são navegadores não enviam Origin.
+			return true
+		}
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		origin = strings.ToLower(u.Scheme + "://" + u.Host)
+		for _, allowed := range allowedOrigins {
+			if allowed == "*" || strings.ToLower(strings.TrimSuffix(allowed, "/")) == origin {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Register inscreve o hub no barramento de eventos.
+func (h *Hub) Register(bus *events.Bus) {
+	bus.SubscribeAll(h.onEvent)
+}
+
+// Close desconecta todos os clientes. Conexões WebSocket são sequestradas do
+// http.Server, então o Shutdown não as encerra sozinho.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	h.closed = true
+	clients := make([]*Client, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.Unlock()
+
+	for _, client := range clients {
+		client.close()
+	}
+}
+
+// onEvent repassa o evento às conexões abertas. A entrega em tempo real é de
+// melhor esforço e não falha: numa nova entrega do outbox, os clientes podem
+// receber o mesmo evento de novo e o descartam pelo id.
+func (h *Hub) onEvent(e events.Event) error {
+	if events.EndsSession(e.Type) {
+		h.disconnectUser(e.UserID)
+		return nil
+	}
+	if !events.IsRoomEvent(e.Type) || e.RoomID == 0 {
+		return nil
+	}
+
+	h.broadcast(e.RoomID, Message{Type: e.Type, RoomID: e.RoomID, Event: &e})
+
+	// Quem saiu da sala deixa de recebê-la em todas as conexões.
+	if e.Type == events.RoomMemberLeft {
+		h.mu.Lock()
+		var removed []*Client
+		for client := range h.rooms[e.RoomID] {
+			if client.UserID == e.UserID {
+				removed = append(removed, client)
+			}
+		}
+		for _, client := range removed {
+			h.removeFromRoom(client, e.RoomID)
+		}
+		h.mu.Unlock()
+
+		for _, client := range removed {
+			client.send(Message{Type: MessageUnsubscribed, RoomID: e.RoomID})
+		}
+		if len(removed) > 0 {
+			h.broadcastPresence(e.RoomID)
+		}
+	}
+	return nil
+}
+
+// disconnectUser encerra todas as conexões do usuário.
+func (h *Hub) disconnectUser(userID uint) {
+	h.mu.Lock()
+	var clients []*Client
+	for client := range h.clients {
+		if client.UserID == userID {
+			clients = append(clients, client)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, client := range clients {
+		client.closeWith(websocket.ClosePolicyViolation, "session ended")
+	}
+}
+
+func (h *Hub) add(client *Client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	h.clients[client] = struct{}{}
+	return true
+}
+
+// remove tira o cliente de todas as salas e atualiza a presença delas.
+func (h *Hub) remove(client *Client) {
+	h.mu.Lock()
+	delete(h.clients, client)
+	var rooms []uint
+	for roomID := range client.rooms {
+		h.removeFromRoom(client, roomID)
+		rooms = append(rooms, roomID)
+	}
+	h.mu.Unlock()
+
+	for _, roomID := range rooms {
+		h.broadcastPresence(roomID)
+	}
+}
+
+func (h *Hub) subscribe(client *Client, roomID uint) {
+	if roomID == 0 || !h.authorize(client.UserID, roomID) {
+		client.send(Message{Type: MessageError, RoomID: roomID, Message: "You are not a member of this room"})
+		return
+	}
+
+	h.mu.Lock()
+	if h.rooms[roomID] == nil {
+		h.rooms[roomID] = make(map[*Client]struct{})
+	}
+	h.rooms[roomID][client] = struct{}{}
+	client.rooms[roomID] = true
+	h.mu.Unlock()
+
+	client.send(Message{Type: MessageSubscribed, RoomID: roomID})
+	h.broadcastPresence(roomID)
+}
+
+func (h *Hub) unsubscribe(client *Client, roomID uint) {
+	h.mu.Lock()
+	_, subscribed := h.rooms[roomID][client]
+	h.removeFromRoom(client, roomID)
+	h.mu.Unlock()
+
+	client.send(Message{Type: MessageUnsubscribed, RoomID: roomID})
+	if subscribed {
+		h.broadcastPresence(roomID)
+	}
+}
+
+// removeFromRoom deve ser chamado com h.mu travado.
+func (h *Hub) removeFromRoom(client *Client, roomID uint) {
+	delete(client.rooms, roomID)
+	if subscribers, ok := h.rooms[roomID]; ok {
+		delete(subscribers, client)
+		if len(subscribers) == 0 {
+			delete(h.rooms, roomID)
+		}
+	}
+}
+
+// broadcastPresence envia para a sala a lista de usuários conectados a ela.
+// Um usuário com várias abas aparece uma única vez.
+func (h *Hub) broadcastPresence(roomID uint) {
+	h.mu.Lock()
+	seen := make(map[uint]bool)
+	users := []PresenceUser{}
+	for client := range h.rooms[roomID] {
+		if !seen[client.UserID] {
+			seen[client.UserID] = true
+			users = append(users, PresenceUser{UserID: client.UserID, Name: client.Name})
+		}
+	}
+	h.mu.Unlock()
+
+	sort.Slice(users, func(i, j int) bool { return users[i].UserID < users[j].UserID })
+	h.broadcast(roomID, Message{Type: MessagePresence, RoomID: roomID, Users: users})
+}
+
+func (h *Hub) broadcast(roomID uint, message Message) {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("failed to encode %s message: %v", message.Type, err)
+		return
+	}
+
+	h.mu.Lock()
+	subscribers := make([]*Client, 0, len(h.rooms[roomID]))
+	for client := range h.rooms[roomID] {
+		subscribers = append(subscribers, client)
+	}
+	h.mu.Unlock()
+
+	for _, client := range subscribers {
+		client.enqueue(payload)
+	}
+}
@@ -0,0 +1,338 @@
+package realtime
+
+import (
+	"api-go/internal/events"
+	"api-go/internal/utils"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// Intervalo dos comentários de keep-alive. Precisa ser menor que o
+	// timeout de ociosidade dos proxies no caminho.
+	keepAliveInterval = 15 * time.Second
+
+	// Tempo que o cliente espera antes de reconectar.
+	retryInterval = 3 * time.Second
+
+	// Eventos enfileirados por conexão antes de ela ser derrubada. O
+	// cliente reconecta e recupera o que perdeu pelo buffer de replay.
+	streamQueueSize = 64
+)
+
+// Nomes de evento SSE que não vêm do barramento.
+const (
+	StreamNotification = "notification"
+
+	// StreamReset avisa que o Last-Event-ID não está mais no buffer de replay
+	// e o cliente precisa recarregar o estado completo.
+	StreamReset = "reset"
+)
+
+// entry é um evento já serializado, destinado a um usuário ou a uma sala.
+type entry struct {
+	id     uint64
+	name   string
+	data   []byte
+	userID uint
+	roomID uint
+}
+
+// RoomLoader retorna as salas de que o usuário participa.
+type RoomLoader func(userID uint) ([]uint, error)
+
+// Stream distribui os eventos de cada usuário por Server-Sent Events e guarda
+// os últimos eventos para que clientes reconectados retomem de onde pararam.
+type Stream struct {
+	loadRooms RoomLoader
+
+	mu          sync.Mutex
+	lastID      uint64
+	buffer      []entry // buffer circular com os últimos eventos
+	next        int
+	subscribers map[*subscriber]struct{}
+}
+
+type subscriber struct {
+	userID uint
+	rooms  map[uint]bool // protegido por Stream.mu
+	queue  chan entry
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (s *subscriber) drop() {
+	s.once.Do(func() { close(s.done) })
+}
+
+func NewStream(replaySize int, loadRooms RoomLoader) *Stream {
+	return &Stream{
+		loadRooms:   loadRooms,
+		buffer:      make([]entry, 0, replaySize),
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// Register inscreve o stream no barramento de eventos.
+func (s *Stream) Register(bus *events.Bus) {
+	bus.SubscribeAll(s.onEvent)
+}
+
+// Close encerra todas as conexões abertas.
+func (s *Stream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subscribers {
+		sub.drop()
+	}
+}
+
+// NotifyUser envia um evento apenas para o usuário.
+func (s *Stream) NotifyUser(userID uint, name string, data any) {
+	s.publish(entry{name: name, userID: userID}, data)
+}
+
+// NotifyRoom envia um evento para todos os membros da sala.
+func (s *Stream) NotifyRoom(roomID uint, name string, data any) {
+	s.publish(entry{name: name, roomID: roomID}, data)
+}
+
+// onEvent repassa o evento às conexões abertas, como o Hub.onEvent.
+func (s *Stream) onEvent(e events.Event) error {
+	if events.EndsSession(e.Type) {
+		s.disconnectUser(e.UserID)
+		return nil
+	}
+	if !events.IsRoomEvent(e.Type) || e.RoomID == 0 {
+		return nil
+	}
+
+	// A entrada na sala passa a valer antes do evento, para que o novo
+	// membro o receba; a saída só depois, pelo mesmo motivo.
+	if e.Type == events.RoomMemberJoined {
+		s.setMembership(e.UserID, e.RoomID, true)
+	}
+	s.NotifyRoom(e.RoomID, e.Type, e)
+	if e.Type == events.RoomMemberLeft {
+		s.setMembership(e.UserID, e.RoomID, false)
+	}
+	return nil
+}
+
+// disconnectUser encerra todas as conexões do usuário. O EventSource
+// reconecta sozinho e passa de novo pela autenticação.
+func (s *Stream) disconnectUser(userID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subscribers {
+		if sub.userID == userID {
+			sub.drop()
+		}
+	}
+}
+
+func (s *Stream) setMembership(userID, roomID uint, member bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subscribers {
+		if sub.userID != userID {
+			continue
+		}
+		if member {
+			sub.rooms[roomID] = true
+		} else {
+			delete(sub.rooms, roomID)
+		}
+	}
+}
+
+func (s *Stream) publish(e entry, data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("failed to encode %s stream event: %v", e.name, err)
+		return
+	}
+	e.data = payload
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastID++
+	e.id = s.lastID
+	if len(s.buffer) < cap(s.buffer) {
+		s.buffer = append(s.buffer, e)
+	} else if cap(s.buffer) > 0 {
+		s.buffer[s.next] = e
+		s.next = (s.next + 1) % cap(s.buffer)
+	}
+
+	for sub := range s.subscribers {
+		if !sub.wants(e) {
+			continue
+		}
+		select {
+		case sub.queue <- e:
+		default:
+			log.Printf("event stream of user %d is too slow, disconnecting", sub.userID)
+			sub.drop()
+		}
+	}
+}
+
+func (sub *subscriber) wants(e entry) bool {
+	if e.userID != 0 {
+		return e.userID == sub.userID
+	}
+	return sub.rooms[e.roomID]
+}
+
+// subscribe registra a conexão e devolve os eventos perdidos desde lastEventID.
+// O registro e a cópia do buffer acontecem sob o mesmo lock, então nenhum
+// evento é perdido ou duplicado entre o replay e a entrega ao vivo.
+func (s *Stream) subscribe(userID uint, lastEventID uint64, resume bool) (*subscriber, []entry, bool, error) {
+	roomIDs, err := s.loadRooms(userID)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	sub := &subscriber{
+		userID: userID,
+		rooms:  make(map[uint]bool, len(roomIDs)),
+		queue:  make(chan entry, streamQueueSize),
+		done:   make(chan struct{}),
+	}
+	for _, roomID := range roomIDs {
+		sub.rooms[roomID] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers[sub] = struct{}{}
+
+	if !resume {
+		return sub, nil, false, nil
+	}
+
+	// Um ID maior que o último emitido vem de antes de um restart; um ID
+	// mais antigo que o buffer indica eventos que já foram descartados.
+	ordered := append(append([]entry{}, s.buffer[s.next:]...), s.buffer[:s.next]...)
+	if lastEventID > s.lastID || (len(ordered) > 0 && lastEventID+1 < ordered[0].id) {
+		return sub, nil, true, nil
+	}
+
+	var replay []entry
+	for _, e := range ordered {
+		if e.id > lastEventID && sub.wants(e) {
+			replay = append(replay, e)
+		}
+	}
+	return sub, replay, false, nil
+}
+
+func (s *Stream) unsubscribe(sub *subscriber) {
+	s.mu.Lock()
+	delete(s.subscribers, sub)
+	s.mu.Unlock()
+	sub.drop()
+}
+
+// Serve mantém a conexão SSE aberta até o cliente desconectar ou a sessão
+// deixar de valer. O Last-Event-ID é lido do header enviado pelo
+// EventSource ou do parâmetro "last_event_id".
+func (s *Stream) Serve(w http.ResponseWriter, r *http.Request, userID uint, check SessionCheck) {
+	lastEventID, resume, err := parseLastEventID(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Last-Event-ID")
+		return
+	}
+
+	sub, replay, reset, err := s.subscribe(userID, lastEventID, resume)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to open event stream")
+		return
+	}
+	defer s.unsubscribe(sub)
+
+	// O WriteTimeout do http.Server derrubaria a conexão; cada escrita ganha
+	// seu próprio prazo no lugar dele.
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("failed to clear write deadline of event stream: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // desativa o buffer do nginx
+	w.WriteHeader(http.StatusOK)
+
+	write := func(format string, args ...any) bool {
+		rc.SetWriteDeadline(time.Now().Add(writeWait))
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+	writeEntry := func(e entry) bool {
+		return write("id: %d\nevent: %s\ndata: %s\n\n", e.id, e.name, e.data)
+	}
+
+	if !write("retry: %d\n\n", retryInterval.Milliseconds()) {
+		return
+	}
+	if reset && !write("event: %s\ndata: {}\n\n", StreamReset) {
+		return
+	}
+	for _, e := range replay {
+		if !writeEntry(e) {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+	sessionTicker := time.NewTicker(sessionCheckInterval)
+	defer sessionTicker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sub.done:
+			return
+		case e := <-sub.queue:
+			if !writeEntry(e) {
+				return
+			}
+		case <-ticker.C:
+			if !write(": keep-alive\n\n") {
+				return
+			}
+		case <-sessionTicker.C:
+			if !check() {
+				return
+			}
+		}
+	}
+}
+
+func parseLastEventID(r *http.Request) (uint64, bool, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, false, nil
+	}
+
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return id, true, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"api-go/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AdminRepository struct {
+	DB *gorm.DB
+}
+
+func NewAdminRepository(db *gorm.DB) *AdminRepository {
+	return &AdminRepository{
+		DB: db,
+	}
+}
+
+// SystemStats resume o uso do sistema para a administração. As contagens
+// desconsideram as linhas excluídas logicamente.
+type SystemStats struct {
+	Users            int64
+	ActiveUsers      int64
+	SuspendedUsers   int64
+	Admins           int64
+	PendingDeletions int64
+
+	Rooms         int64
+	ArchivedRooms int64
+	Notes         int64
+	Attachments   int64
+	StorageBytes  int64
+
+	Reservations         int64
+	UpcomingReservations int64
+
+	PendingJobs int64
+	DeadJobs    int64
+}
+
+// GetStats calcula as estatísticas do sistema no instante now.
+func (r *AdminRepository) GetStats(now time.Time) (*SystemStats, error) {
+	var stats SystemStats
+	users := func() *gorm.DB {
+		return r.DB.Model(&models.User{}).Where("anonymized_at IS NULL")
+	}
+
+	counts := []struct {
+		query *gorm.DB
+		dest  *int64
+	}{
+		{users(), &stats.Users},
+		{users().Where("suspended_at IS NULL"), &stats.ActiveUsers},
+		{users().Where("suspended_at IS NOT NULL"), &stats.SuspendedUsers},
+		{users().Where("role = ?", models.UserRoleAdmin), &stats.Admins},
+		{users().Where("deletion_scheduled_at IS NOT NULL"), &stats.PendingDeletions},
+		{r.DB.Model(&models.Room{}), &stats.Rooms},
+		{r.DB.Model(&models.Room{}).Where("archived_at IS NOT NULL"), &stats.ArchivedRooms},
+		{r.DB.Model(&models.Note{}), &stats.Notes},
+		{r.DB.Model(&models.Attachment{}), &stats.Attachments},
+		{r.DB.Model(&models.Reservation{}), &stats.Reservations},
+		{r.DB.Model(&models.Reservation{}).Where("end_time > ? AND status IN ?", now, models.ReservationHolds), &stats.UpcomingReservations},
+		{r.DB.Model(&models.OutboxJob{}).Where("status IN ?", []string{models.JobPending, models.JobRunning}), &stats.PendingJobs},
+		{r.DB.Model(&models.OutboxJob{}).Where("status = ?", models.JobDead), &stats.DeadJobs},
+	}
+	for _, count := range counts {
+		if err := count.query.Count(count.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	err := r.DB.Model(&models.Attachment{}).
+		Select("COALESCE(SUM(size), 0)").
+		Scan(&stats.StorageBytes).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"api-go/internal/models"
+	"api-go/internal/tenant"
+	"context"
+	"hash/fnv"
+
+	"gorm.io/gorm"
+)
+
+type AttachmentsRepository struct {
+	DB *gorm.DB
+}
+
+func NewAttachmentsRepository(db *gorm.DB) *AttachmentsRepository {
+	return &AttachmentsRepository{
+		DB: db,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que usa o contexto da
+// requisição. Se ele trouxer uma organização, as consultas ficam restritas
+// a ela.
+func (r *AttachmentsRepository) WithContext(ctx context.Context) *AttachmentsRepository {
+	return &AttachmentsRepository{DB: r.DB.WithContext(ctx)}
+}
+
+// WithTx retorna uma cópia do repositório que opera dentro da transação.
+func (r *AttachmentsRepository) WithTx(tx *gorm.DB) *AttachmentsRepository {
+	return &AttachmentsRepository{DB: withTenantOf(r.DB, tx)}
+}
+
+func (r *AttachmentsRepository) Create(attachment *models.Attachment) error {
+	return r.DB.Create(attachment).Error
+}
+
+// CreateWithBlob grava o blob com put e depois o anexo que o referencia,
+// com a chave travada (ver LockStorageKey) para que a coleta não remova o
+// blob entre as duas gravações.
+func (r *AttachmentsRepository) CreateWithBlob(attachment *models.Attachment, put func() error) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := r.WithTx(tx).LockStorageKey(attachment.StorageKey); err != nil {
+			return err
+		}
+		if err := put(); err != nil {
+			return err
+		}
+		return tx.Create(attachment).Error
+	})
+}
+
+// LockStorageKey trava a chave do blob até o fim da transação. Como os
+// blobs são compartilhados por conteúdo, o upload e a coleta do mesmo blob
+// se excluem por essa trava.
+func (r *AttachmentsRepository) LockStorageKey(key string) error {
+	return r.DB.Exec("SELECT pg_advisory_xact_lock(?)", blobLockKey(key)).Error
+}
+
+// CollectOrphan remove o blob com remove se nenhum anexo o referencia mais,
+// com a chave travada, para que um upload do mesmo conteúdo não grave um
+// anexo entre a contagem e a remoção.
+func (r *AttachmentsRepository) CollectOrphan(key string, remove func() error) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		repo := r.WithTx(tx)
+		if err := repo.LockStorageKey(key); err != nil {
+			return err
+		}
+		count, err := repo.CountByStorageKey(key)
+		if err != nil || count > 0 {
+			return err
+		}
+		return remove()
+	})
+}
+
+func (r *AttachmentsRepository) GetByID(id uint) (*models.Attachment, error) {
+	var attachment models.Attachment
+	if err := r.DB.First(&attachment, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (r *AttachmentsRepository) GetByNoteID(noteID uint) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	if err := r.DB.Where("note_id = ?", noteID).Order("created_at").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// Delete remove definitivamente o registro do anexo, para que o blob
+// correspondente possa ser coletado em seguida. Os anexos só são excluídos
+// logicamente junto com a nota, para poderem ser restaurados com ela.
+func (r *AttachmentsRepository) Delete(id uint) error {
+	return r.DB.Unscoped().Delete(&models.Attachment{}, id).Error
+}
+
+// CountByStorageKey conta quantos anexos ainda referenciam o blob. Como o
+// storage é endereçado pelo conteúdo, o mesmo blob pode ser compartilhado
+// por vários anexos, inclusive de outras organizações.
+func (r *AttachmentsRepository) CountByStorageKey(key string) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(tenant.Without(r.DB.Statement.Context)).Unscoped().Model(&models.Attachment{}).Where("storage_key = ?", key).Count(&count).Error
+	return count, err
+}
+
+// blobLockKey deriva a chave do advisory lock da chave do blob.
+func blobLockKey(key string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("blob:" + key))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"api-go/internal/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// auditLockClass é a primeira chave dos advisory locks que serializam a
+// gravação de cada cadeia da trilha; a segunda é a sala da cadeia, ou zero
+// para a cadeia das entradas sem sala.
+const auditLockClass int32 = 0x61756474 // "audt"
+// AuditRepository guarda a trilha de auditoria. As entradas só são
+// inseridas, nunca alteradas.
+type AuditRepository struct {
+	DB *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{
+		DB: db,
+	}
+}
+
+// WithTx retorna uma cópia do repositório que opera dentro da transação.
+func (r *AuditRepository) WithTx(tx *gorm.DB) *AuditRepository {
+	return &AuditRepository{DB: tx}
+}
+
+// Append encadeia a entrada na última gravada da mesma cadeia e a insere.
+// Cada sala tem a sua cadeia, e as entradas sem sala formam outra; assim o
+// lock, que fica com a transação até o commit, só faz esperar as gravações
+// da mesma cadeia, e não a trilha inteira. Se a transação for desfeita, a
+// entrada some junto e a cadeia continua da anterior.
+func (r *AuditRepository) Append(entry *models.AuditEntry) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		chain := auditChain(entry)
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", auditLockClass, int32(chain)).Error; err != nil {
+			return err
+		}
+
+		last := tx.Model(&models.AuditEntry{})
+		if chain == 0 {
+			last = last.Where("room_id IS NULL")
+		} else {
+			last = last.Where("room_id = ?", chain)
+		}
+		var hashes []string
+		if err := last.Order("id DESC").Limit(1).Pluck("hash", &hashes).Error; err != nil {
+			return err
+		}
+		entry.PrevHash = ""
+		if len(hashes) > 0 {
+			entry.PrevHash = hashes[0]
+		}
+
+		// O Postgres guarda microssegundos; o hash precisa conferir com o
+		// horário lido de volta.
+		if entry.CreatedAt.IsZero() {
+			entry.CreatedAt = time.Now()
+		}
+		entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Microsecond)
+		entry.Hash = auditHash(entry)
+
+		return tx.Create(entry).Error
+	})
+}
+
+// auditChain retorna a cadeia da entrada: a sala dela, ou zero.
+func auditChain(entry *models.AuditEntry) uint {
+	if entry.RoomID == nil {
+		return 0
+	}
+	return *entry.RoomID
+}
+
+// auditHash calcula o hash da entrada a partir do hash da anterior e dos
+// campos gravados. Cada campo entra entre aspas, para que o conteúdo de um
+// não possa se passar pelo separador.
+func auditHash(entry *models.AuditEntry) string {
+	optional := func(id *uint) string {
+		if id == nil {
+			return ""
+		}
+		return strconv.FormatUint(uint64(*id), 10)
+	}
+
+	fields := []string{
+		entry.PrevHash,
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		optional(entry.ActorID),
+		entry.Action,
+		entry.TargetType,
+		strconv.FormatUint(uint64(entry.TargetID), 10),
+		optional(entry.RoomID),
+		entry.Before,
+		entry.After,
+		entry.IP,
+		entry.UserAgent,
+		entry.RequestID,
+	}
+
+	h := sha256.New()
+	for _, field := range fields {
+		h.Write([]byte(strconv.Quote(field)))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AuditFilter restringe a consulta da trilha. Campos zerados não filtram.
+type AuditFilter struct {
+	ActorID    *uint
+	Action     string
+	TargetType string
+	TargetID   uint
+	RoomID     *uint
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+func (r *AuditRepository) filtered(filter AuditFilter) *gorm.DB {
+	query := r.DB.Model(&models.AuditEntry{})
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != 0 {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.RoomID != nil {
+		query = query.Where("room_id = ?", *filter.RoomID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	return query.Session(&gorm.Session{})
+}
+
+// Search busca as entradas, mais recentes primeiro, com o total sem a
+// paginação.
+func (r *AuditRepository) Search(filter AuditFilter) ([]models.AuditEntry, int64, error) {
+	query := r.filtered(filter)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var entries []models.AuditEntry
+	err := query.Order("id DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&entries).Error
+	return entries, total, err
+}
+
+// Each percorre em lotes, em ordem cronológica, todas as entradas do
+// filtro, ignorando a paginação.
+func (r *AuditRepository) Each(filter AuditFilter, fn func(entries []models.AuditEntry) error) error {
+	var batch []models.AuditEntry
+	return r.filtered(filter).FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
+
+// AuditVerification é o resultado da conferência das cadeias.
+type AuditVerification struct {
+	Checked int64
+	// BrokenAt é a primeira entrada cujo hash não confere, ou nil se as
+	// cadeias estiverem íntegras.
+	BrokenAt *uint
+	// LastHash resume as pontas de todas as cadeias. Guardado fora do
+	// banco, permite notar também a remoção das entradas mais recentes.
+	LastHash string
+}
+
+var errAuditChainBroken = errors.New("audit chain broken")
+
+// Verify recalcula todas as cadeias, da primeira entrada à última.
+func (r *AuditRepository) Verify() (*AuditVerification, error) {
+	var result AuditVerification
+	heads := make(map[uint]string)
+	var batch []models.AuditEntry
+	err := r.DB.FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			entry := &batch[i]
+			chain := auditChain(entry)
+			if entry.PrevHash != heads[chain] || entry.Hash != auditHash(entry) {
+				id := entry.ID
+				result.BrokenAt = &id
+				return errAuditChainBroken
+			}
+			heads[chain] = entry.Hash
+			result.Checked++
+		}
+		return nil
+	}).Error
+	if err != nil && !errors.Is(err, errAuditChainBroken) {
+		return nil, err
+	}
+	if len(heads) > 0 {
+		result.LastHash = auditHeadsHash(heads)
+	}
+	return &result, nil
+}
+
+// auditHeadsHash calcula o resumo das pontas das cadeias, em ordem de sala.
+func auditHeadsHash(heads map[uint]string) string {
+	chains := make([]uint, 0, len(heads))
+	for chain := range heads {
+		chains = append(chains, chain)
+	}
+	slices.Sort(chains)
+
+	h := sha256.New()
+	for _, chain := range chains {
+		fmt.Fprintf(h, "%d:%s\n", chain, heads[chain])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"api-go/internal/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExportsRepository guarda os pedidos de exportação de dados dos usuários.
+type ExportsRepository struct {
+	DB *gorm.DB
+}
+
+func NewExportsRepository(db *gorm.DB) *ExportsRepository {
+	return &ExportsRepository{
+		DB: db,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que usa o contexto dado.
+func (r *ExportsRepository) WithContext(ctx context.Context) *ExportsRepository {
+	return &ExportsRepository{DB: r.DB.WithContext(ctx)}
+}
+
+func (r *ExportsRepository) WithTx(tx *gorm.DB) *ExportsRepository {
+	return &ExportsRepository{DB: tx}
+}
+
+func (r *ExportsRepository) Create(export *models.DataExport) error {
+	return r.DB.Create(export).Error
+}
+
+// GetByID retorna a exportação, ou nil se ela não existir.
+func (r *ExportsRepository) GetByID(id uint) (*models.DataExport, error) {
+	var export models.DataExport
+	if err := r.DB.First(&export, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+// GetByTokenHash retorna a exportação do link, ou nil se não houver.
+func (r *ExportsRepository) GetByTokenHash(tokenHash string) (*models.DataExport, error) {
+	var export models.DataExport
+	if err := r.DB.Where("token_hash = ?", tokenHash).First(&export).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+// GetByUserID lista as exportações do usuário, mais recentes primeiro.
+func (r *ExportsRepository) GetByUserID(userID uint) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	if err := r.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&exports).Error; err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+// HasPending informa se o usuário tem uma exportação ainda sendo gerada.
+func (r *ExportsRepository) HasPending(userID uint) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.DataExport{}).
+		Where("user_id = ? AND status = ?", userID, models.ExportPending).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// MarkReady registra o arquivo gerado, se a exportação ainda estiver pendente.
+func (r *ExportsRepository) MarkReady(id uint, storageKey string, size int64, now, expiresAt time.Time) (bool, error) {
+	result := r.DB.Model(&models.DataExport{}).
+		Where("id = ? AND status = ?", id, models.ExportPending).
+		Updates(map[string]any{
+			"status":      models.ExportReady,
+			"storage_key": storageKey,
+			"size":        size,
+			"ready_at":    now,
+			"expires_at":  expiresAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkFailed encerra a exportação pendente com o erro que impediu a geração.
+func (r *ExportsRepository) MarkFailed(id uint, reason string) error {
+	return r.DB.Model(&models.DataExport{}).
+		Where("id = ? AND status = ?", id, models.ExportPending).
+		Updates(map[string]any{
+			"status": models.ExportFailed,
+			"error":  reason,
+		}).Error
+}
+
+// Consume gasta o link de uso único. Retorna false se a exportação não está
+// pronta, já foi baixada ou expirou.
+func (r *ExportsRepository) Consume(id uint, now time.Time) (bool, error) {
+	result := r.DB.Model(&models.DataExport{}).
+		Where("id = ? AND status = ? AND expires_at > ?", id, models.ExportReady, now).
+		Updates(map[string]any{
+			"status":        models.ExportDownloaded,
+			"downloaded_at": now,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetExpired retorna as exportações prontas cujo link expirou sem download.
+func (r *ExportsRepository) GetExpired(now time.Time) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := r.DB.Where("status = ? AND expires_at <= ?", models.ExportReady, now).Find(&exports).Error
+	return exports, err
+}
+
+// Expire encerra a exportação pronta que não foi baixada.
+func (r *ExportsRepository) Expire(id uint) (bool, error) {
+	result := r.DB.Model(&models.DataExport{}).
+		Where("id = ? AND status = ?", id, models.ExportReady).
+		Update("status", models.ExportExpired)
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteByUserID exclui logicamente as exportações do usuário e retorna as
+// chaves dos arquivos que ainda estavam no storage.
+func (r *ExportsRepository) DeleteByUserID(userID uint) ([]string, error) {
+	var keys []string
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.DataExport{}).
+			Where("user_id = ? AND status = ? AND storage_key <> ''", userID, models.ExportReady).
+			Pluck("storage_key", &keys).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.DataExport{}).Error
+	})
+	return keys, err
+}
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"api-go/internal/models"
+	"api-go/internal/tenant"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// effectiveMembers retorna as participações efetivas nas salas (user_id,
+// room_id, role): as diretas e as herdadas dos grupos com acesso à sala.
+// Quem participa das duas formas aparece mais de uma vez. Por ser SQL
+// escrito à mão, a consulta não passa pelos callbacks do tenant; se o
+// contexto de db trouxer uma organização, ela é restrita às salas dela
+// aqui mesmo.
+func effectiveMembers(db *gorm.DB) *gorm.DB {
+	members := db.Raw(`SELECT user_id, room_id, role FROM room_members WHERE deleted_at IS NULL
+UNION ALL
+SELECT user_group_members.user_id, room_group_grants.room_id, room_group_grants.role
+FROM room_group_grants
+JOIN user_group_members ON user_group_members.group_id = room_group_grants.group_id AND user_group_members.deleted_at IS NULL
+JOIN user_groups ON user_groups.id = room_group_grants.group_id AND user_groups.deleted_at IS NULL
+WHERE room_group_grants.deleted_at IS NULL`)
+
+	orgID, ok := tenant.FromContext(db.Statement.Context)
+	if !ok {
+		return members
+	}
+	return db.Raw(`SELECT user_id, room_id, role FROM (?) AS members
+WHERE room_id IN (SELECT id FROM rooms WHERE organization_id = ?)`, members, orgID)
+}
+
+// GroupsRepository guarda os grupos de usuários, os membros deles e os
+// acessos dos grupos às salas.
+type GroupsRepository struct {
+	DB *gorm.DB
+}
+
+func NewGroupsRepository(db *gorm.DB) *GroupsRepository {
+	return &GroupsRepository{
+		DB: db,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que usa o contexto da
+// requisição. Se ele trouxer uma organização, as consultas ficam restritas
+// a ela.
+func (r *GroupsRepository) WithContext(ctx context.Context) *GroupsRepository {
+	return &GroupsRepository{DB: r.DB.WithContext(ctx)}
+}
+
+// WithTx retorna uma cópia do repositório que opera dentro da transação.
+func (r *GroupsRepository) WithTx(tx *gorm.DB) *GroupsRepository {
+	return &GroupsRepository{DB: withTenantOf(r.DB, tx)}
+}
+
+func (r *GroupsRepository) Create(group *models.UserGroup) error {
+	return r.DB.Create(group).Error
+}
+
+// FindByID retorna o grupo com os membros, por nome.
+func (r *GroupsRepository) FindByID(id uint) (*models.UserGroup, error) {
+	var group models.UserGroup
+	err := r.DB.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Joins("User").Order(`"User".name, "User".id`)
+	}).First(&group, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+// FindByName retorna o grupo da organização com esse nome.
+func (r *GroupsRepository) FindByName(orgID uint, name string) (*models.UserGroup, error) {
+	var group models.UserGroup
+	if err := r.DB.Where("organization_id = ? AND name = ?", orgID, name).First(&group).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+// List retorna os grupos da organização, por nome.
+func (r *GroupsRepository) List(orgID uint) ([]models.UserGroup, error) {
+	var groups []models.UserGroup
+	err := r.DB.Where("organization_id = ?", orgID).Order("name, id").Find(&groups).Error
+	return groups, err
+}
+
+// CountMembers conta os membros de cada grupo.
+func (r *GroupsRepository) CountMembers(groupIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(groupIDs))
+	if len(groupIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		GroupID uint
+		Count   int64
+	}
+	err := r.DB.Model(&models.UserGroupMember{}).
+		Select("group_id, COUNT(*) AS count").
+		Where("group_id IN ?", groupIDs).
+		Group("group_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.GroupID] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *GroupsRepository) Update(id uint, name, description string) error {
+	return r.DB.Model(&models.UserGroup{}).Where("id = ?", id).
+		Updates(map[string]any{"name": name, "description": description}).Error
+}
+
+// Delete exclui o grupo junto com os membros e os acessos dele às salas;
+// quem só participava delas pelo grupo deixa de participar.
+func (r *GroupsRepository) Delete(id uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		tx = withTenantOf(r.DB, tx)
+		if err := tx.Where("group_id = ?", id).Delete(&models.RoomGroupGrant{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ?", id).Delete(&models.UserGroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.UserGroup{}, id).Error
+	})
+}
+
+// IsMember informa se o usuário está no grupo.
+func (r *GroupsRepository) IsMember(groupID, userID uint) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.UserGroupMember{}).
+		Where("group_id = ? AND user_id = ?", groupID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetMemberIDs retorna os IDs dos membros do grupo.
+func (r *GroupsRepository) GetMemberIDs(groupID uint) ([]uint, error) {
+	var ids []uint
+	err := r.DB.Model(&models.UserGroupMember{}).Where("group_id = ?", groupID).Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+func (r *GroupsRepository) AddMember(groupID, userID uint) (*models.UserGroupMember, error) {
+	member := models.UserGroupMember{
+		GroupID: groupID,
+		UserID:  userID,
+	}
+	if err := r.DB.Create(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *GroupsRepository) RemoveMember(groupID, userID uint) error {
+	return r.DB.Where("group_id = ? AND user_id = ?", groupID, userID).
+		Delete(&models.UserGroupMember{}).Error
+}
+
+// GetRoomGrants retorna os grupos com acesso à sala, por nome do grupo.
+func (r *GroupsRepository) GetRoomGrants(roomID uint) ([]models.RoomGroupGrant, error) {
+	var grants []models.RoomGroupGrant
+	err := r.DB.Joins("Group").
+		Where("room_group_grants.room_id = ?", roomID).
+		Order(`"Group".name, "Group".id`).
+		Find(&grants).Error
+	return grants, err
+}
+
+// GetGroupGrants retorna os acessos do grupo às salas, com as salas.
+func (r *GroupsRepository) GetGroupGrants(groupID uint) ([]models.RoomGroupGrant, error) {
+	var grants []models.RoomGroupGrant
+	err := r.DB.Preload("Room").Where("group_id = ?", groupID).Order("room_id").Find(&grants).Error
+	return grants, err
+}
+
+// GetGrant retorna o acesso do grupo à sala, ou nil se ele não tiver.
+func (r *GroupsRepository) GetGrant(roomID, groupID uint) (*models.RoomGroupGrant, error) {
+	var grant models.RoomGroupGrant
+	err := r.DB.Joins("Group").
+		Where("room_group_grants.room_id = ? AND room_group_grants.group_id = ?", roomID, groupID).
+		First(&grant).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &grant, nil
+}
+
+func (r *GroupsRepository) Grant(roomID, groupID uint, role string, grantedBy uint) (*models.RoomGroupGrant, error) {
+	grant := models.RoomGroupGrant{
+		RoomID:    roomID,
+		GroupID:   groupID,
+		Role:      role,
+		GrantedBy: grantedBy,
+	}
+	if err := r.DB.Create(&grant).Error; err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+func (r *GroupsRepository) UpdateGrantRole(roomID, groupID uint, role string) error {
+	return r.DB.Model(&models.RoomGroupGrant{}).
+		Where("room_id = ? AND group_id = ?", roomID, groupID).
+		Update("role", role).Error
+}
+
+func (r *GroupsRepository) Revoke(roomID, groupID uint) error {
+	return r.DB.Where("room_id = ? AND group_id = ?", roomID, groupID).
+		Delete(&models.RoomGroupGrant{}).Error
+}
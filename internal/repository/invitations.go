@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"api-go/internal/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// InvitationsRepository guarda os convites e os pedidos de entrada nas salas.
+type InvitationsRepository struct {
+	DB *gorm.DB
+}
+
+func NewInvitationsRepository(db *gorm.DB) *InvitationsRepository {
+	return &InvitationsRepository{
+		DB: db,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que usa o contexto da
+// requisição. Se ele trouxer uma organização, as consultas ficam restritas
+// a ela.
+func (r *InvitationsRepository) WithContext(ctx context.Context) *InvitationsRepository {
+	return &InvitationsRepository{DB: r.DB.WithContext(ctx)}
+}
+
+// WithTx retorna uma cópia do repositório que opera dentro da transação.
+func (r *InvitationsRepository) WithTx(tx *gorm.DB) *InvitationsRepository {
+	return &InvitationsRepository{DB: withTenantOf(r.DB, tx)}
+}
+
+func (r *InvitationsRepository) CreateInvite(invite *models.RoomInvite) error {
+	return r.DB.Create(invite).Error
+}
+
+func (r *InvitationsRepository) GetInvites(roomID uint) ([]models.RoomInvite, error) {
+	var invites []models.RoomInvite
+	err := r.DB.Where("room_id = ?", roomID).Order("created_at DESC").Find(&invites).Error
+	return invites, err
+}
+
+func (r *InvitationsRepository) GetInvite(id uint) (*models.RoomInvite, error) {
+	var invite models.RoomInvite
+	if err := r.DB.First(&invite, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invite, nil
+}
+
+func (r *InvitationsRepository) GetInviteByCode(code string) (*models.RoomInvite, error) {
+	var invite models.RoomInvite
+	if err := r.DB.Where("code = ?", code).First(&invite).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// RedeemInvite conta um uso do convite, se ele ainda estiver válido. Retorna
+// false se o convite expirou ou esgotou os usos.
+func (r *InvitationsRepository) RedeemInvite(id uint, now time.Time) (bool, error) {
+	result := r.DB.Model(&models.RoomInvite{}).
+		Where("id = ? AND (max_uses = 0 OR uses < max_uses) AND (expires_at IS NULL OR expires_at > ?)", id, now).
+		Update("uses", gorm.Expr("uses + 1"))
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteInvite revoga o convite.
+func (r *InvitationsRepository) DeleteInvite(id uint) error {
+	return r.DB.Delete(&models.RoomInvite{}, id).Error
+}
+
+func (r *InvitationsRepository) CreateJoinRequest(request *models.RoomJoinRequest) error {
+	return r.DB.Create(request).Error
+}
+
+func (r *InvitationsRepository) GetJoinRequest(id uint) (*models.RoomJoinRequest, error) {
+	var request models.RoomJoinRequest
+	if err := r.DB.Preload("User").First(&request, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+// GetJoinRequests lista os pedidos da sala, mais antigos primeiro,
+// opcionalmente filtrados pelo estado.
+func (r *InvitationsRepository) GetJoinRequests(roomID uint, status string) ([]models.RoomJoinRequest, error) {
+	var requests []models.RoomJoinRequest
+	query := r.DB.Preload("User").Where("room_id = ?", roomID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at").Find(&requests).Error
+	return requests, err
+}
+
+// HasPendingJoinRequest informa se o usuário já tem um pedido aguardando
+// decisão na sala.
+func (r *InvitationsRepository) HasPendingJoinRequest(userID, roomID uint) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.RoomJoinRequest{}).
+		Where("user_id = ? AND room_id = ? AND status = ?", userID, roomID, models.JoinRequestPending).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// DecideJoinRequest registra a decisão, se o pedido ainda estiver pendente.
+func (r *InvitationsRepository) DecideJoinRequest(id uint, status string, decidedBy uint) (bool, error) {
+	result := r.DB.Model(&models.RoomJoinRequest{}).
+		Where("id = ? AND status = ?", id, models.JoinRequestPending).
+		Updates(map[string]any{
+			"status":     status,
+			"decided_by": decidedBy,
+			"decided_at": time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
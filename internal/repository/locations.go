@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"api-go/internal/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// LocationsRepository guarda a hierarquia local → prédio → andar em que as
+// salas ficam.
+type LocationsRepository struct {
+	DB *gorm.DB
+}
+
+func NewLocationsRepository(db *gorm.DB) *LocationsRepository {
+	return &LocationsRepository{
+		DB: db,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que usa o contexto dado.
+func (r *LocationsRepository) WithContext(ctx context.Context) *LocationsRepository {
+	return &LocationsRepository{DB: r.DB.WithContext(ctx)}
+}
+
+func (r *LocationsRepository) CreateSite(site *models.Site) error {
+	return r.DB.Create(site).Error
+}
+
+func (r *LocationsRepository) GetSites() ([]models.Site, error) {
+	var sites []models.Site
+	err := r.DB.Order("name").Find(&sites).Error
+	return sites, err
+}
+
+// GetSite busca o local com os seus prédios.
+func (r *LocationsRepository) GetSite(id uint) (*models.Site, error) {
+	var site models.Site
+	err := r.DB.Preload("Buildings", func(db *gorm.DB) *gorm.DB { return db.Order("name") }).
+		First(&site, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &site, nil
+}
+
+func (r *LocationsRepository) UpdateSite(site *models.Site) error {
+	return r.DB.Model(site).Select("name", "address").Updates(site).Error
+}
+
+func (r *LocationsRepository) DeleteSite(id uint) error {
+	return r.DB.Delete(&models.Site{}, id).Error
+}
+
+func (r *LocationsRepository) CreateBuilding(building *models.Building) error {
+	return r.DB.Create(building).Error
+}
+
+// GetBuildings lista os prédios do local, ou de todos se siteID for 0.
+func (r *LocationsRepository) GetBuildings(siteID uint) ([]models.Building, error) {
+	var buildings []models.Building
+	query := r.DB.Order("name")
+	if siteID != 0 {
+		query = query.Where("site_id = ?", siteID)
+	}
+	err := query.Find(&buildings).Error
+	return buildings, err
+}
+
+// GetBuilding busca o prédio com os seus andares.
+func (r *LocationsRepository) GetBuilding(id uint) (*models.Building, error) {
+	var building models.Building
+	err := r.DB.Preload("Floors", func(db *gorm.DB) *gorm.DB { return db.Order("level") }).
+		First(&building, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &building, nil
+}
+
+func (r *LocationsRepository) UpdateBuilding(building *models.Building) error {
+	return r.DB.Model(building).Select("site_id", "name").Updates(building).Error
+}
+
+func (r *LocationsRepository) DeleteBuilding(id uint) error {
+	return r.DB.Delete(&models.Building{}, id).Error
+}
+
+func (r *LocationsRepository) CreateFloor(floor *models.Floor) error {
+	return r.DB.Create(floor).Error
+}
+
+// GetFloors lista os andares do prédio, ou de todos se buildingID for 0.
+func (r *LocationsRepository) GetFloors(buildingID uint) ([]models.Floor, error) {
+	var floors []models.Floor
+	query := r.DB.Order("building_id, level")
+	if buildingID != 0 {
+		query = query.Where("building_id = ?", buildingID)
+	}
+	err := query.Find(&floors).Error
+	return floors, err
+}
+
+func (r *LocationsRepository) GetFloor(id uint) (*models.Floor, error) {
+	var floor models.Floor
+	if err := r.DB.First(&floor, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &floor, nil
+}
+
+func (r *LocationsRepository) UpdateFloor(floor *models.Floor) error {
+	return r.DB.Model(floor).Select("building_id", "name", "level", "plan_url").Updates(floor).Error
+}
+
+func (r *LocationsRepository) DeleteFloor(id uint) error {
+	return r.DB.Delete(&models.Floor{}, id).Error
+}
+
+// SiteHasBuildings informa se o local ainda tem prédios, o que impede a
+// sua exclusão. O mesmo vale para andares e salas nas funções seguintes.
+func (r *LocationsRepository) SiteHasBuildings(siteID uint) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.Building{}).Where("site_id = ?", siteID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *LocationsRepository) BuildingHasFloors(buildingID uint) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.Floor{}).Where("building_id = ?", buildingID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *LocationsRepository) FloorHasRooms(floorID uint) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.Room{}).Where("floor_id = ?", floorID).Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"api-go/internal/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// softDeleted lista os modelos com exclusão lógica (gorm.Model), na ordem em
+// que podem ser removidos definitivamente: os dependentes antes das tabelas
+// que eles referenciam.
+var softDeleted = []any{
+	&models.Mention{},
+	&models.Attachment{},
+	&models.Note{},
+	&models.Notification{},
+	&models.NotificationPreference{},
+	&models.WaitlistEntry{},
+	&models.Reservation{},
+	&models.RoomMember{},
+	&models.RoomGroupGrant{},
+	&models.UserGroupMember{},
+	&models.UserGroup{},
+	&models.RoomBlackout{},
+	&models.RoomInvite{},
+	&models.RoomJoinRequest{},
+	&models.RoomOwnershipTransfer{},
+	&models.DataExport{},
+	&models.WebhookDelivery{},
+	&models.Webhook{},
+	&models.Room{},
+	&models.Floor{},
+	&models.Building{},
+	&models.Site{},
+	&models.User{},
+}
+
+// MaintenanceRepository reúne as limpezas de dados antigos feitas pelas
+// tarefas agendadas.
+type MaintenanceRepository struct {
+	DB *gorm.DB
+}
+
+func NewMaintenanceRepository(db *gorm.DB) *MaintenanceRepository {
+	return &MaintenanceRepository{
+		DB: db,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que usa o contexto dado.
+func (r *MaintenanceRepository) WithContext(ctx context.Context) *MaintenanceRepository {
+	return &MaintenanceRepository{DB: r.DB.WithContext(ctx)}
+}
+
+// PurgeSoftDeleted remove definitivamente as linhas excluídas logicamente
+// antes do corte. Retorna quantas linhas foram removidas por tabela.
+func (r *MaintenanceRepository) PurgeSoftDeleted(cutoff time.Time) (map[string]int64, error) {
+	purged := make(map[string]int64)
+	for _, model := range softDeleted {
+		stmt := &gorm.Statement{DB: r.DB}
+		if err := stmt.Parse(model); err != nil {
+			return purged, err
+		}
+
+		result := r.DB.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Delete(model)
+		if result.Error != nil {
+			return purged, result.Error
+		}
+		if result.RowsAffected > 0 {
+			purged[stmt.Schema.Table] = result.RowsAffected
+		}
+	}
+	return purged, nil
+}
+
+// DeletedAttachmentKeys retorna as chaves de storage dos anexos excluídos
+// logicamente antes do corte, que serão removidos por PurgeSoftDeleted.
+func (r *MaintenanceRepository) DeletedAttachmentKeys(cutoff time.Time) ([]string, error) {
+	var keys []string
+	err := r.DB.Unscoped().Model(&models.Attachment{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Distinct().
+		Pluck("storage_key", &keys).Error
+	return keys, err
+}
+
+// PurgeFinishedJobs remove os jobs do outbox concluídos ou esgotados antes
+// do corte.
+func (r *MaintenanceRepository) PurgeFinishedJobs(cutoff time.Time) (int64, error) {
+	result := r.DB.Where("status IN ? AND updated_at < ?", []string{models.JobDone, models.JobDead}, cutoff).
+		Delete(&models.OutboxJob{})
+	return result.RowsAffected, result.Error
+}
+
+// PurgeFinishedDeliveries remove do log as entregas de webhook encerradas
+// antes do corte.
+func (r *MaintenanceRepository) PurgeFinishedDeliveries(cutoff time.Time) (int64, error) {
+	result := r.DB.Unscoped().
+		Where("status IN ? AND updated_at < ?", []string{models.WebhookDeliverySucceeded, models.WebhookDeliveryDead}, cutoff).
+		Delete(&models.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
+
+// ClearExpiredPasswordResets apaga o hash dos tokens de redefinição de senha
+// que expiraram. A redefinição continua obrigatória: só um token novo,
+// gerado por um administrador, libera o login.
+func (r *MaintenanceRepository) ClearExpiredPasswordResets(now time.Time) (int64, error) {
+	result := r.DB.Model(&models.User{}).
+		Where("password_reset_expires_at IS NOT NULL AND password_reset_expires_at < ?", now).
+		Updates(map[string]any{
+			"password_reset_token_hash": "",
+			"password_reset_expires_at": nil,
+		})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"api-go/internal/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type MentionsRepository struct {
+	DB *gorm.DB
+}
+
+func NewMentionsRepository(db *gorm.DB) *MentionsRepository {
+	return &MentionsRepository{
+		DB: db,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que usa o contexto dado.
+func (r *MentionsRepository) WithContext(ctx context.Context) *MentionsRepository {
+	return &MentionsRepository{DB: r.DB.WithContext(ctx)}
+}
+
+// WithTx retorna uma cópia do repositório que opera dentro da transação.
+func (r *MentionsRepository) WithTx(tx *gorm.DB) *MentionsRepository {
+	return &MentionsRepository{DB: withTenantOf(r.DB, tx)}
+}
+
+// Sync substitui as menções da nota pelos usuários informados e retorna
+// apenas os usuários que não estavam mencionados antes.
+func (r *MentionsRepository) Sync(noteID, mentionedByID uint, userIDs []uint) ([]uint, error) {
+	var added []uint
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var existing []uint
+		if err := tx.Model(&models.Mention{}).Where("note_id = ?", noteID).Pluck("user_id", &existing).Error; err != nil {
+			return err
+		}
+
+		current := make(map[uint]bool, len(existing))
+		for _, id := range existing {
+			current[id] = true
+		}
+
+		wanted := make(map[uint]bool, len(userIDs))
+		for _, id := range userIDs {
+			wanted[id] = true
+			if current[id] {
+				continue
+			}
+			mention := models.Mention{NoteID: noteID, UserID: id, MentionedByID: mentionedByID}
+			if err := tx.Create(&mention).Error; err != nil {
+				return err
+			}
+			added = append(added, id)
+		}
+
+		var removed []uint
+		for _, id := range existing {
+			if !wanted[id] {
+				removed = append(removed, id)
+			}
+		}
+		if len(removed) > 0 {
+			return tx.Unscoped().Where("note_id = ? AND user_id IN ?", noteID, removed).Delete(&models.Mention{}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// GetNotesMentioningUser retorna as notas que mencionam o usuário, mais
+// recentes primeiro.
+func (r *MentionsRepository) GetNotesMentioningUser(userID uint) ([]models.Note, error) {
+	var notes []models.Note
+	err := r.DB.Preload("User").Preload("Room").
+		Where("id IN (?)", r.DB.Model(&models.Mention{}).Select("note_id").Where("user_id = ?", userID)).
+		Order("created_at DESC").
+		Find(&notes).Error
+	if err != nil {
+		return nil, err
+	}
+	ensureNotesRendered(r.DB, notes)
+	return notes, nil
+}
@@ -1,11 +1,20 @@
 package repository
 
 import (
+	"api-go/internal/markdown"
 	"api-go/internal/models"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// renderedColumns são as colunas que guardam o cache do conteúdo renderizado.
+var renderedColumns = []string{"content_html", "excerpt", "table_of_contents", "rendered_checksum"}
+
 type NotesRepository struct {
 	DB *gorm.DB
 }
@@ -16,12 +25,27 @@ func NewNotesRepository(db *gorm.DB) *NotesRepository {
 	}
 }
 
-func (r *NotesRepository) Create(userID, roomID uint, title, content string) (*models.Note, error) {
+// WithContext retorna uma cópia do repositório que usa o contexto dado.
+func (r *NotesRepository) WithContext(ctx context.Context) *NotesRepository {
+	return &NotesRepository{DB: r.DB.WithContext(ctx)}
+}
+
+// WithTx retorna uma cópia do repositório que opera dentro da transação.
+func (r *NotesRepository) WithTx(tx *gorm.DB) *NotesRepository {
+	return &NotesRepository{DB: withTenantOf(r.DB, tx)}
+}
+
+func (r *NotesRepository) Create(userID, roomID uint, title, content, format string) (*models.Note, error) {
 	note := models.Note{
 		UserID:  userID,
 		RoomID:  roomID,
 		Title:   title,
 		Content: content,
+		Format:  format,
+	}
+
+	if err := renderNote(&note); err != nil {
+		return nil, err
 	}
 
 	if err := r.DB.Create(&note).Error; err != nil {
@@ -32,13 +56,15 @@ func (r *NotesRepository) Create(userID, roomID uint, title, content string) (*m
 
 func (r *NotesRepository) GetByID(id uint) (*models.Note, error) {
 	var note models.Note
-	if err := r.DB.Preload("User").Preload("Room").First(&note, id).Error; err != nil {
+	if err := r.DB.Preload("User").Preload("Room").Preload("Attachments").First(&note, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
 		return nil, err
 	}
-	return &note, nil
+	notes := []models.Note{note}
+	ensureNotesRendered(r.DB, notes)
+	return &notes[0], nil
 }
 
 func (r *NotesRepository) GetByRoomID(roomID uint) ([]models.Note, error) {
@@ -46,6 +72,7 @@ func (r *NotesRepository) GetByRoomID(roomID uint) ([]models.Note, error) {
 	if err := r.DB.Preload("User").Where("room_id = ?", roomID).Find(&notes).Error; err != nil {
 		return nil, err
 	}
+	ensureNotesRendered(r.DB, notes)
 	return notes, nil
 }
 
@@ -54,29 +81,51 @@ func (r *NotesRepository) GetByUserID(userID uint) ([]models.Note, error) {
 	if err := r.DB.Preload("Room").Where("user_id = ?", userID).Find(&notes).Error; err != nil {
 		return nil, err
 	}
+	ensureNotesRendered(r.DB, notes)
 	return notes, nil
 }
 
-func (r *NotesRepository) Update(id uint, title, content string) error {
-	updates := map[string]interface{}{
-		"title":   title,
-		"content": content,
+func (r *NotesRepository) Update(id uint, title, content, format string) error {
+	note := models.Note{
+		Title:   title,
+		Content: content,
+		Format:  format,
+	}
+
+	if err := renderNote(&note); err != nil {
+		return err
 	}
 
-	if err := r.DB.Model(&models.Note{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+	columns := append([]string{"title", "content", "format"}, renderedColumns...)
+	if err := r.DB.Model(&models.Note{}).Where("id = ?", id).Select(columns).Updates(&note).Error; err != nil {
 		return err
 	}
 	return nil
 }
 
+// Delete exclui logicamente a nota e os anexos dela com o mesmo DeletedAt,
+// para que possam ser restaurados juntos.
 func (r *NotesRepository) Delete(id uint) error {
-	if err := r.DB.Delete(&models.Note{}, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil
+	now := time.Now().Truncate(time.Microsecond)
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Attachment{}).Where("note_id = ?", id).Update("deleted_at", now).Error; err != nil {
+			return err
 		}
-		return err
-	}
-	return nil
+		return tx.Model(&models.Note{}).Where("id = ?", id).Update("deleted_at", now).Error
+	})
+}
+
+// DeleteByUserID exclui logicamente as notas do usuário e os anexos delas,
+// como Delete faz com uma nota.
+func (r *NotesRepository) DeleteByUserID(userID uint) error {
+	now := time.Now().Truncate(time.Microsecond)
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		notes := tx.Model(&models.Note{}).Select("id").Where("user_id = ?", userID)
+		if err := tx.Model(&models.Attachment{}).Where("note_id IN (?)", notes).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Note{}).Where("user_id = ?", userID).Update("deleted_at", now).Error
+	})
 }
 
 func (r *NotesRepository) GetByUserAndRoom(userID, roomID uint) ([]models.Note, error) {
@@ -92,5 +141,60 @@ func (r *NotesRepository) GetAll() ([]models.Note, error) {
 	if err := r.DB.Preload("User").Preload("Room").Find(&notes).Error; err != nil {
 		return nil, err
 	}
+	ensureNotesRendered(r.DB, notes)
 	return notes, nil
 }
+
+// renderNote preenche o cache de renderização da nota a partir do conteúdo e do formato.
+func renderNote(note *models.Note) error {
+	if note.Format == "" {
+		note.Format = models.NoteFormatPlain
+	}
+
+	var rendered *markdown.Rendered
+	if note.Format == models.NoteFormatMarkdown {
+		var err error
+		if rendered, err = markdown.Markdown(note.Content); err != nil {
+			return err
+		}
+	} else {
+		rendered = markdown.Plain(note.Content)
+	}
+
+	note.ContentHTML = rendered.HTML
+	note.Excerpt = rendered.Excerpt
+	note.TableOfContents = nil
+	for _, heading := range rendered.TOC {
+		note.TableOfContents = append(note.TableOfContents, models.NoteHeading{
+			Level: heading.Level,
+			Text:  heading.Text,
+			ID:    heading.ID,
+		})
+	}
+	note.RenderedChecksum = renderChecksum(note)
+	return nil
+}
+
+func renderChecksum(note *models.Note) string {
+	sum := sha256.Sum256([]byte(markdown.Version + "\x00" + note.Format + "\x00" + note.Content))
+	return hex.EncodeToString(sum[:])
+}
+
+// ensureNotesRendered renderiza novamente as notas cujo cache está ausente ou
+// desatualizado (notas antigas ou versão nova do renderizador) e persiste o
+// resultado sem alterar updated_at.
+func ensureNotesRendered(db *gorm.DB, notes []models.Note) {
+	for i := range notes {
+		note := &notes[i]
+		if note.RenderedChecksum != "" && note.RenderedChecksum == renderChecksum(note) {
+			continue
+		}
+		if err := renderNote(note); err != nil {
+			log.Printf("failed to render note %d: %v", note.ID, err)
+			continue
+		}
+		if err := db.Model(&models.Note{}).Where("id = ?", note.ID).Select(renderedColumns).UpdateColumns(note).Error; err != nil {
+			log.Printf("failed to cache rendered note %d: %v", note.ID, err)
+		}
+	}
+}
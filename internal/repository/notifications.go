@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"api-go/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationsRepository struct {
+	DB *gorm.DB
+}
+
+func NewNotificationsRepository(db *gorm.DB) *NotificationsRepository {
+	return &NotificationsRepository{
+		DB: db,
+	}
+}
+
+// Create grava a notificação. Retorna false, sem erro, se o usuário já tem
+// a notificação desse tipo para o mesmo evento.
+func (r *NotificationsRepository) Create(notification *models.Notification) (bool, error) {
+	result := r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}, {Name: "event_id"}},
+		DoNothing: true,
+	}).Create(notification)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *NotificationsRepository) GetByUserID(userID uint, unreadOnly bool, limit int) ([]models.Notification, error) {
+	var notifications []models.Notification
+	query := r.DB.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if err := query.Order("created_at DESC").Limit(limit).Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (r *NotificationsRepository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	err := r.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// MarkRead marca a notificação do usuário como lida. Retorna false se ela
+// não existir ou pertencer a outro usuário.
+func (r *NotificationsRepository) MarkRead(id, userID uint) (bool, error) {
+	var notification models.Notification
+	if err := r.DB.Where("id = ? AND user_id = ?", id, userID).First(&notification).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	if notification.ReadAt != nil {
+		return true, nil
+	}
+	return true, r.DB.Model(&notification).Update("read_at", time.Now()).Error
+}
+
+func (r *NotificationsRepository) MarkAllRead(userID uint) (int64, error) {
+	result := r.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+func (r *NotificationsRepository) GetPreferences(userID uint) ([]models.NotificationPreference, error) {
+	var preferences []models.NotificationPreference
+	if err := r.DB.Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
+
+func (r *NotificationsRepository) SetPreference(userID uint, notificationType string, enabled bool) error {
+	preference := models.NotificationPreference{
+		UserID:  userID,
+		Type:    notificationType,
+		Enabled: enabled,
+	}
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(&preference).Error
+}
+
+// FilterEnabled remove da lista os usuários que desativaram o tipo de notificação.
+func (r *NotificationsRepository) FilterEnabled(notificationType string, userIDs []uint) ([]uint, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	var disabled []uint
+	err := r.DB.Model(&models.NotificationPreference{}).
+		Where("type = ? AND enabled = ? AND user_id IN ?", notificationType, false, userIDs).
+		Pluck("user_id", &disabled).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var enabled []uint
+	for _, id := range userIDs {
+		if !containsID(disabled, id) {
+			enabled = append(enabled, id)
+		}
+	}
+	return enabled, nil
+}
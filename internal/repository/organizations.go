@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"api-go/internal/models"
+	"api-go/internal/tenant"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// withTenantOf passa para tx o contexto de db quando ele traz uma
+// organização, para que as consultas na transação continuem restritas a
+// ela.
+func withTenantOf(db, tx *gorm.DB) *gorm.DB {
+	if _, ok := tenant.FromContext(db.Statement.Context); ok {
+		return tx.WithContext(db.Statement.Context)
+	}
+	return tx
+}
+
+// OrganizationsRepository guarda as organizações e os seus membros.
+type OrganizationsRepository struct {
+	DB *gorm.DB
+}
+
+func NewOrganizationsRepository(db *gorm.DB) *OrganizationsRepository {
+	return &OrganizationsRepository{
+		DB: db,
+	}
+}
+
+// WithTx retorna uma cópia do repositório que opera dentro da transação.
+func (r *OrganizationsRepository) WithTx(tx *gorm.DB) *OrganizationsRepository {
+	return &OrganizationsRepository{DB: tx}
+}
+
+// Create cria a organização com ownerID como dono.
+func (r *OrganizationsRepository) Create(org *models.Organization, ownerID uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.OrganizationMember{
+			OrganizationID: org.ID,
+			UserID:         ownerID,
+			Role:           models.OrgRoleOwner,
+		}).Error
+	})
+}
+
+func (r *OrganizationsRepository) FindByID(id uint) (*models.Organization, error) {
+	var org models.Organization
+	if err := r.DB.First(&org, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *OrganizationsRepository) FindBySlug(slug string) (*models.Organization, error) {
+	var org models.Organization
+	if err := r.DB.Where("slug = ?", slug).First(&org).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &org, nil
+}
+
+// Lock trava a organização até o fim da transação, para serializar as
+// mudanças nos donos.
+func (r *OrganizationsRepository) Lock(id uint) error {
+	var org models.Organization
+	return r.DB.Clauses(clause.Locking{Strength: "UPDATE"}).First(&org, id).Error
+}
+
+func (r *OrganizationsRepository) Rename(id uint, name string) error {
+	return r.DB.Model(&models.Organization{}).Where("id = ?", id).Update("name", name).Error
+}
+
+// GetMembership retorna a participação do usuário na organização, ou nil se
+// ele não participar dela.
+func (r *OrganizationsRepository) GetMembership(orgID, userID uint) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	err := r.DB.Preload("Organization").
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		First(&member).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetDefaultMembership retorna a participação mais antiga do usuário, usada
+// quando a requisição não indica a organização, ou nil se ele não
+// participar de nenhuma.
+func (r *OrganizationsRepository) GetDefaultMembership(userID uint) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	err := r.DB.Preload("Organization").
+		Where("user_id = ?", userID).
+		Order("created_at, id").
+		First(&member).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetUserMemberships retorna as organizações do usuário, por nome.
+func (r *OrganizationsRepository) GetUserMemberships(userID uint) ([]models.OrganizationMember, error) {
+	var members []models.OrganizationMember
+	err := r.DB.Joins("Organization").
+		Where("organization_members.user_id = ?", userID).
+		Order(`"Organization".name, "Organization".id`).
+		Find(&members).Error
+	return members, err
+}
+
+// GetMembers retorna os membros da organização, por nome.
+func (r *OrganizationsRepository) GetMembers(orgID uint) ([]models.OrganizationMember, error) {
+	var members []models.OrganizationMember
+	err := r.DB.Joins("User").
+		Where("organization_members.organization_id = ?", orgID).
+		Order(`"User".name, "User".id`).
+		Find(&members).Error
+	return members, err
+}
+
+func (r *OrganizationsRepository) AddMember(orgID, userID uint, role string) (*models.OrganizationMember, error) {
+	member := models.OrganizationMember{
+		OrganizationID: orgID,
+		UserID:         userID,
+		Role:           role,
+	}
+	if err := r.DB.Create(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *OrganizationsRepository) UpdateMemberRole(orgID, userID uint, role string) error {
+	return r.DB.Model(&models.OrganizationMember{}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Update("role", role).Error
+}
+
+// CountOwners conta os donos da organização.
+func (r *OrganizationsRepository) CountOwners(orgID uint) (int64, error) {
+	var count int64
+	err := r.DB.Model(&models.OrganizationMember{}).
+		Where("organization_id = ? AND role = ?", orgID, models.OrgRoleOwner).
+		Count(&count).Error
+	return count, err
+}
+
+// CountOwnedRooms conta as salas da organização de que o usuário é dono.
+func (r *OrganizationsRepository) CountOwnedRooms(orgID, userID uint) (int64, error) {
+	var count int64
+	err := r.DB.Model(&models.Room{}).
+		Where("organization_id = ? AND created_by = ?", orgID, userID).
+		Count(&count).Error
+	return count, err
+}
+
+// RemoveMember tira o usuário da organização e das salas e grupos dela.
+func (r *OrganizationsRepository) RemoveMember(orgID, userID uint) error {
+	rooms := r.DB.Unscoped().Model(&models.Room{}).Select("id").Where("organization_id = ?", orgID)
+	err := r.DB.Where("user_id = ? AND room_id IN (?)", userID, rooms).
+		Delete(&models.RoomMember{}).Error
+	if err != nil {
+		return err
+	}
+	groups := r.DB.Unscoped().Model(&models.UserGroup{}).Select("id").Where("organization_id = ?", orgID)
+	err = r.DB.Where("user_id = ? AND group_id IN (?)", userID, groups).
+		Delete(&models.UserGroupMember{}).Error
+	if err != nil {
+		return err
+	}
+	return r.DB.Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Delete(&models.OrganizationMember{}).Error
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"api-go/internal/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OwnershipRepository guarda as transferências de propriedade das salas.
+type OwnershipRepository struct {
+	DB *gorm.DB
+}
+
+func NewOwnershipRepository(db *gorm.DB) *OwnershipRepository {
+	return &OwnershipRepository{
+		DB: db,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que usa o contexto da
+// requisição. Se ele trouxer uma organização, as consultas ficam restritas
+// a ela.
+func (r *OwnershipRepository) WithContext(ctx context.Context) *OwnershipRepository {
+	return &OwnershipRepository{DB: r.DB.WithContext(ctx)}
+}
+
+// WithTx retorna uma cópia do repositório que opera dentro da transação.
+func (r *OwnershipRepository) WithTx(tx *gorm.DB) *OwnershipRepository {
+	return &OwnershipRepository{DB: withTenantOf(r.DB, tx)}
+}
+
+func (r *OwnershipRepository) Create(transfer *models.RoomOwnershipTransfer) error {
+	return r.DB.Create(transfer).Error
+}
+
+// GetPending retorna a transferência pendente da sala, ou nil se não houver.
+func (r *OwnershipRepository) GetPending(roomID uint) (*models.RoomOwnershipTransfer, error) {
+	var transfer models.RoomOwnershipTransfer
+	err := r.DB.Preload("FromUser").Preload("ToUser").
+		Where("room_id = ? AND status = ?", roomID, models.TransferPending).
+		Order("created_at DESC").
+		First(&transfer).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// Decide encerra a transferência com o estado dado, se ela ainda estiver
+// pendente.
+func (r *OwnershipRepository) Decide(id uint, status string, now time.Time) (bool, error) {
+	result := r.DB.Model(&models.RoomOwnershipTransfer{}).
+		Where("id = ? AND status = ?", id, models.TransferPending).
+		Updates(map[string]any{
+			"status":     status,
+			"decided_at": now,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// CancelForUser cancela as transferências pendentes oferecidas pelo usuário
+// ou para ele.
+func (r *OwnershipRepository) CancelForUser(userID uint, now time.Time) error {
+	return r.DB.Model(&models.RoomOwnershipTransfer{}).
+		Where("status = ? AND (from_user_id = ? OR to_user_id = ?)", models.TransferPending, userID, userID).
+		Updates(map[string]any{
+			"status":     models.TransferCancelled,
+			"decided_at": now,
+		}).Error
+}
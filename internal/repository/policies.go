@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"api-go/internal/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PoliciesRepository struct {
+	DB *gorm.DB
+}
+
+func NewPoliciesRepository(db *gorm.DB) *PoliciesRepository {
+	return &PoliciesRepository{
+		DB: db,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que usa o contexto dado.
+func (r *PoliciesRepository) WithContext(ctx context.Context) *PoliciesRepository {
+	return &PoliciesRepository{DB: r.DB.WithContext(ctx)}
+}
+
+// WithTx retorna uma cópia do repositório que opera dentro da transação.
+func (r *PoliciesRepository) WithTx(tx *gorm.DB) *PoliciesRepository {
+	return &PoliciesRepository{DB: withTenantOf(r.DB, tx)}
+}
+
+// UpdatePolicy substitui a política de reserva da sala.
+func (r *PoliciesRepository) UpdatePolicy(roomID uint, policy models.BookingPolicy) error {
+	room := models.Room{Policy: policy}
+	room.ID = roomID
+	return r.DB.Model(&room).Select(
+		"policy_opening_hours",
+		"policy_min_duration_minutes",
+		"policy_max_duration_minutes",
+		"policy_min_notice_minutes",
+		"policy_max_advance_days",
+		"policy_buffer_minutes",
+		"policy_max_hours_per_week",
+		"policy_max_active_reservations",
+	).Updates(&room).Error
+}
+
+func (r *PoliciesRepository) CreateBlackout(blackout *models.RoomBlackout) error {
+	return r.DB.Create(blackout).Error
+}
+
+func (r *PoliciesRepository) GetBlackout(id uint) (*models.RoomBlackout, error) {
+	var blackout models.RoomBlackout
+	if err := r.DB.First(&blackout, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &blackout, nil
+}
+
+// GetBlackouts lista os bloqueios da sala que terminam depois de from.
+func (r *PoliciesRepository) GetBlackouts(roomID uint, from time.Time) ([]models.RoomBlackout, error) {
+	var blackouts []models.RoomBlackout
+	err := r.DB.Where("room_id = ? AND end_time > ?", roomID, from).
+		Order("start_time").
+		Find(&blackouts).Error
+	return blackouts, err
+}
+
+// GetOverlappingBlackouts lista os bloqueios da sala que se sobrepõem ao período.
+func (r *PoliciesRepository) GetOverlappingBlackouts(roomID uint, startTime, endTime time.Time) ([]models.RoomBlackout, error) {
+	var blackouts []models.RoomBlackout
+	err := r.DB.Where("room_id = ? AND start_time < ? AND end_time > ?", roomID, endTime, startTime).
+		Order("start_time").
+		Find(&blackouts).Error
+	return blackouts, err
+}
+
+func (r *PoliciesRepository) DeleteBlackout(id uint) error {
+	return r.DB.Delete(&models.RoomBlackout{}, id).Error
+}
@@ -2,9 +2,23 @@ package repository
 
 import (
 	"api-go/internal/models"
+	"api-go/internal/policy"
+	"context"
+	"errors"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrReservationConflict indica que a sala já está com todos os lugares
+	// reservados em algum momento do período.
+	ErrReservationConflict = errors.New("room is fully booked for this period")
+
+	// ErrReservationOverlap indica que o usuário já tem uma reserva na sala
+	// que se sobrepõe ao período.
+	ErrReservationOverlap = errors.New("user already has a reservation in this period")
 )
 
 type ReservationsRepository struct {
@@ -17,38 +31,309 @@ func NewReservationsRepository(db *gorm.DB) *ReservationsRepository {
 	}
 }
 
-func (r *ReservationsRepository) Create(userID uint, roomID uint, startTime time.Time, endTime time.Time) error {
+// WithContext retorna uma cópia do repositório que usa o contexto dado.
+func (r *ReservationsRepository) WithContext(ctx context.Context) *ReservationsRepository {
+	return &ReservationsRepository{DB: r.DB.WithContext(ctx)}
+}
+
+// WithTx retorna uma cópia do repositório que opera dentro da transação.
+func (r *ReservationsRepository) WithTx(tx *gorm.DB) *ReservationsRepository {
+	return &ReservationsRepository{DB: withTenantOf(r.DB, tx)}
+}
+
+// Create grava a reserva se ainda houver lugar na sala durante o período.
+// Cada reserva aprovada ou pendente ocupa um lugar; a capacidade da sala
+// limita quantas delas podem se sobrepor.
+func (r *ReservationsRepository) Create(userID uint, roomID uint, startTime time.Time, endTime time.Time, status string) (*models.Reservation, error) {
 	reservation := models.Reservation{
 		UserID:    userID,
 		RoomID:    roomID,
 		StartTime: startTime,
 		EndTime:   endTime,
+		Status:    status,
 	}
 
-	if err := r.DB.Create(&reservation).Error; err != nil {
-		return err
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkAvailability(tx, 0, userID, roomID, startTime, endTime); err != nil {
+			return err
+		}
+		return tx.Create(&reservation).Error
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return &reservation, nil
 }
 
 func (r *ReservationsRepository) GetByID(id uint) (*models.Reservation, error) {
-	//
-	return nil, nil
+	var reservation models.Reservation
+	if err := r.DB.First(&reservation, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &reservation, nil
 }
 
-func (r *ReservationsRepository) Update(id uint, userID uint, roomID uint, startTime time.Time, endTime time.Time) error {
-	//
-	return nil
+// Update muda o período da reserva, que passa ao estado informado.
+func (r *ReservationsRepository) Update(id uint, startTime time.Time, endTime time.Time, status string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var reservation models.Reservation
+		if err := tx.First(&reservation, id).Error; err != nil {
+			return err
+		}
+		if err := checkAvailability(tx, id, reservation.UserID, reservation.RoomID, startTime, endTime); err != nil {
+			return err
+		}
+
+		// Com o novo horário, o lembrete e o check-in valem de novo.
+		updates := map[string]interface{}{
+			"start_time":       startTime,
+			"end_time":         endTime,
+			"reminder_sent_at": nil,
+			"checked_in_at":    nil,
+			"status":           status,
+		}
+		return tx.Model(&models.Reservation{}).Where("id = ?", id).Updates(updates).Error
+	})
 }
+
 func (r *ReservationsRepository) Delete(id uint) error {
+	if err := r.DB.Delete(&models.Reservation{}, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
 	return nil
 }
 
 func (r *ReservationsRepository) GetByUserID(userID uint) ([]models.Reservation, error) {
-	return nil, nil
+	var reservations []models.Reservation
+	if err := r.DB.Where("user_id = ?", userID).Order("start_time").Find(&reservations).Error; err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// GetUpcomingByUserID retorna as reservas do usuário que ainda não
+// terminaram e ocupam lugar na sala.
+func (r *ReservationsRepository) GetUpcomingByUserID(userID uint, now time.Time) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.DB.Where("user_id = ? AND end_time > ? AND status IN ?", userID, now, models.ReservationHolds).
+		Order("start_time").
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// GetByRoomID lista as reservas da sala, opcionalmente apenas as do estado
+// informado.
+func (r *ReservationsRepository) GetByRoomID(roomID uint, status string) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	query := r.DB.Where("room_id = ?", roomID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("start_time").Find(&reservations).Error; err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// GetNearby retorna as reservas da sala que se sobrepõem ao período
+// estendido pela margem, em ambos os lados.
+func (r *ReservationsRepository) GetNearby(roomID uint, startTime, endTime time.Time, margin time.Duration) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.DB.Where("room_id = ? AND start_time < ? AND end_time > ?", roomID, endTime.Add(margin), startTime.Add(-margin)).
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// GetDueReminders retorna as reservas que começam no período (from, to] e
+// ainda não receberam lembrete.
+func (r *ReservationsRepository) GetDueReminders(from, to time.Time) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.DB.Where("start_time > ? AND start_time <= ? AND reminder_sent_at IS NULL", from, to).
+		Where("status = ?", models.ReservationApproved).
+		Order("start_time").
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// MarkReminderSent registra o envio do lembrete. Retorna false se outro
+// processo já o tiver registrado.
+func (r *ReservationsRepository) MarkReminderSent(id uint) (bool, error) {
+	result := r.DB.Model(&models.Reservation{}).
+		Where("id = ? AND reminder_sent_at IS NULL", id).
+		Update("reminder_sent_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetNotCheckedIn retorna as reservas em andamento, em salas que exigem
+// check-in, que começaram até o prazo e não tiveram check-in.
+func (r *ReservationsRepository) GetNotCheckedIn(deadline time.Time) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.DB.Where("start_time <= ? AND end_time > ? AND checked_in_at IS NULL", deadline, time.Now()).
+		Where("status = ?", models.ReservationApproved).
+		Where("room_id IN (?)", r.DB.Model(&models.Room{}).Select("id").Where("check_in_required")).
+		Order("start_time").
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// CheckIn registra o check-in. Retorna false se ele já tinha sido feito.
+func (r *ReservationsRepository) CheckIn(id uint) (bool, error) {
+	result := r.DB.Model(&models.Reservation{}).
+		Where("id = ? AND checked_in_at IS NULL", id).
+		Update("checked_in_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// Release cancela a reserva se ela continuar sem check-in e conta a falta
+// para o usuário. Retorna false se o check-in foi feito ou a reserva já foi
+// cancelada nesse meio tempo.
+func (r *ReservationsRepository) Release(reservation *models.Reservation) (bool, error) {
+	released := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND checked_in_at IS NULL", reservation.ID).Delete(&models.Reservation{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		released = true
+		return tx.Model(&models.User{}).Where("id = ?", reservation.UserID).
+			Update("no_shows", gorm.Expr("no_shows + 1")).Error
+	})
+	return released, err
+}
+
+// GetNoShows retorna quantas reservas do usuário foram liberadas por falta
+// de check-in.
+func (r *ReservationsRepository) GetNoShows(userID uint) (int, error) {
+	var noShows []int
+	err := r.DB.Model(&models.User{}).Where("id = ?", userID).Pluck("no_shows", &noShows).Error
+	if err != nil || len(noShows) == 0 {
+		return 0, err
+	}
+	return noShows[0], nil
+}
+
+// Decide registra a decisão sobre uma reserva pendente. Retorna false se ela
+// já não estava pendente. decidedBy é nil quando a decisão é automática.
+func (r *ReservationsRepository) Decide(id uint, status string, decidedBy *uint, reason string) (bool, error) {
+	result := r.DB.Model(&models.Reservation{}).
+		Where("id = ? AND status = ?", id, models.ReservationPending).
+		Updates(map[string]any{
+			"status":          status,
+			"decided_by":      decidedBy,
+			"decided_at":      time.Now(),
+			"decision_reason": reason,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// SetStatus muda o estado da reserva se ele ainda for o esperado. Retorna
+// false se outro processo o alterou antes.
+func (r *ReservationsRepository) SetStatus(id uint, from, to string) (bool, error) {
+	result := r.DB.Model(&models.Reservation{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	return result.RowsAffected > 0, result.Error
+}
+
+// CheckAvailability verifica, sem reservar, se o usuário conseguiria
+// reservar a sala no período.
+func (r *ReservationsRepository) CheckAvailability(userID, roomID uint, startTime, endTime time.Time) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		return checkAvailability(tx, 0, userID, roomID, startTime, endTime)
+	})
+}
+
+// GetStalePending retorna as reservas pendentes sem alteração desde o corte
+// ou cujo horário já começou.
+func (r *ReservationsRepository) GetStalePending(cutoff time.Time) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.DB.Where("status = ?", models.ReservationPending).
+		Where("updated_at < ? OR start_time <= ?", cutoff, time.Now()).
+		Order("start_time").
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// GetUsage calcula o uso da sala pelo usuário para as cotas da política: as
+// horas reservadas no período [from, to) e as reservas que ainda não
+// terminaram. A reserva excludeID (a que está sendo remarcada) não conta.
+func (r *ReservationsRepository) GetUsage(userID, roomID uint, from, to time.Time, excludeID uint) (time.Duration, int, error) {
+	holds := r.DB.Where("user_id = ? AND room_id = ? AND status IN ?", userID, roomID, models.ReservationHolds)
+	if excludeID != 0 {
+		holds = holds.Where("id <> ?", excludeID)
+	}
+
+	var inPeriod []models.Reservation
+	err := holds.Session(&gorm.Session{}).
+		Where("start_time >= ? AND start_time < ?", from, to).
+		Find(&inPeriod).Error
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var booked time.Duration
+	for _, reservation := range inPeriod {
+		booked += reservation.EndTime.Sub(reservation.StartTime)
+	}
+
+	var active int64
+	err = holds.Session(&gorm.Session{}).Model(&models.Reservation{}).
+		Where("end_time > ?", time.Now()).
+		Count(&active).Error
+	return booked, int(active), err
+}
 
+// LockRoom trava a sala até o fim da transação, com o mesmo lock do
+// checkAvailability. Serve para conferir as cotas da política antes de
+// gravar a reserva sem que outra reserva concorrente passe no meio.
+func (r *ReservationsRepository) LockRoom(roomID uint) error {
+	var room models.Room
+	return r.DB.Clauses(clause.Locking{Strength: "UPDATE"}).First(&room, roomID).Error
 }
 
-func (r *ReservationsRepository) GetByRoomID(roomID uint) ([]models.Reservation, error) {
-	return nil, nil
+// checkAvailability trava a linha da sala (serializando reservas concorrentes)
+// e verifica se o período ainda comporta mais uma reserva.
+func checkAvailability(tx *gorm.DB, excludeID, userID, roomID uint, startTime, endTime time.Time) error {
+	var room models.Room
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&room, roomID).Error; err != nil {
+		return err
+	}
+
+	holds := tx.Model(&models.Reservation{}).
+		Where("room_id = ? AND status IN ?", roomID, models.ReservationHolds)
+	if excludeID != 0 {
+		holds = holds.Where("id <> ?", excludeID)
+	}
+
+	var own int64
+	err := holds.Session(&gorm.Session{}).
+		Where("user_id = ? AND start_time < ? AND end_time > ?", userID, endTime, startTime).
+		Count(&own).Error
+	if err != nil {
+		return err
+	}
+	if own > 0 {
+		return ErrReservationOverlap
+	}
+
+	// O intervalo entre reservas exigido pela sala conta como ocupado.
+	from, until := policy.Occupied(room.Policy, startTime, endTime)
+
+	var count int64
+	err = holds.Session(&gorm.Session{}).
+		Where("start_time < ? AND end_time > ?", until, from).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count >= int64(room.Capacity) {
+		return ErrReservationConflict
+	}
+	return nil
 }
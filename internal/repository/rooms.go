@@ -2,8 +2,11 @@ package repository
 
 import (
 	"api-go/internal/models"
+	"context"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RoomsRepository struct {
@@ -16,13 +19,28 @@ func NewRoomsRepository(db *gorm.DB) *RoomsRepository {
 	}
 }
 
-func (r *RoomsRepository) Create(name string, description string, subject string, capacity int, createdBy uint) (*models.Room, error) {
+// WithContext retorna uma cópia do repositório que usa o contexto da
+// requisição. Se ele trouxer uma organização, as consultas ficam restritas
+// a ela.
+func (r *RoomsRepository) WithContext(ctx context.Context) *RoomsRepository {
+	return &RoomsRepository{DB: r.DB.WithContext(ctx)}
+}
+
+// WithTx retorna uma cópia do repositório que opera dentro da transação.
+func (r *RoomsRepository) WithTx(tx *gorm.DB) *RoomsRepository {
+	return &RoomsRepository{DB: withTenantOf(r.DB, tx)}
+}
+
+func (r *RoomsRepository) Create(organizationID uint, name string, description string, subject string, capacity int, createdBy uint, timeZone, visibility string) (*models.Room, error) {
 	room := models.Room{
-		Name:        name,
-		Description: description,
-		Subject:     subject,
-		Capacity:    capacity,
-		CreatedBy:   createdBy,
+		OrganizationID: organizationID,
+		Name:           name,
+		Description:    description,
+		Subject:        subject,
+		Capacity:       capacity,
+		CreatedBy:      createdBy,
+		TimeZone:       timeZone,
+		Visibility:     visibility,
 	}
 
 	if err := r.DB.Create(&room).Error; err != nil {
@@ -33,7 +51,20 @@ func (r *RoomsRepository) Create(name string, description string, subject string
 
 func (r *RoomsRepository) GetByID(id uint) (*models.Room, error) {
 	var room models.Room
-	if err := r.DB.Preload("Members.User").Preload("Notes.User").First(&room, id).Error; err != nil {
+	if err := r.DB.Preload("Members.User").Preload("Notes.User").Preload("Amenities").First(&room, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	ensureNotesRendered(r.DB, room.Notes)
+	return &room, nil
+}
+
+// FindByID busca a sala sem carregar membros e notas.
+func (r *RoomsRepository) FindByID(id uint) (*models.Room, error) {
+	var room models.Room
+	if err := r.DB.Preload("Amenities").First(&room, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
@@ -42,9 +73,52 @@ func (r *RoomsRepository) GetByID(id uint) (*models.Room, error) {
 	return &room, nil
 }
 
-func (r *RoomsRepository) GetAll() ([]models.Room, error) {
+// RoomFilter restringe a busca de salas. Campos zerados não filtram; as
+// salas precisam ter todos os recursos de Amenities.
+type RoomFilter struct {
+	SiteID     uint
+	BuildingID uint
+	FloorID    uint
+	Amenities  []string
+
+	// VisibleTo esconde as salas não públicas, exceto as que têm esse
+	// usuário como membro, direto ou por um grupo.
+	VisibleTo uint
+}
+
+func (r *RoomsRepository) GetAll(filter RoomFilter) ([]models.Room, error) {
+	query := r.DB.Preload("Amenities").Where("archived_at IS NULL")
+
+	if filter.VisibleTo != 0 {
+		query = query.Where("visibility = ? OR id IN (?)", models.RoomPublic, r.effectiveMembers().
+			Select("room_id").
+			Where("user_id = ?", filter.VisibleTo))
+	}
+
+	switch {
+	case filter.FloorID != 0:
+		query = query.Where("floor_id = ?", filter.FloorID)
+	case filter.BuildingID != 0:
+		query = query.Where("floor_id IN (?)", r.DB.Model(&models.Floor{}).
+			Select("id").
+			Where("building_id = ?", filter.BuildingID))
+	case filter.SiteID != 0:
+		query = query.Where("floor_id IN (?)", r.DB.Model(&models.Floor{}).
+			Select("floors.id").
+			Joins("JOIN buildings ON buildings.id = floors.building_id AND buildings.deleted_at IS NULL").
+			Where("buildings.site_id = ?", filter.SiteID))
+	}
+
+	if len(filter.Amenities) > 0 {
+		query = query.Where("id IN (?)", r.DB.Model(&models.RoomAmenity{}).
+			Select("room_id").
+			Where("amenity IN ?", filter.Amenities).
+			Group("room_id").
+			Having("COUNT(*) = ?", len(filter.Amenities)))
+	}
+
 	var rooms []models.Room
-	if err := r.DB.Find(&rooms).Error; err != nil {
+	if err := query.Find(&rooms).Error; err != nil {
 		return nil, err // Retorna o erro
 	}
 	return rooms, nil // Retorna a lista de salas
@@ -64,14 +138,81 @@ func (r *RoomsRepository) Update(id uint, name string, description string, subje
 	return nil
 }
 
+// UpdateSettings grava as configurações de reserva informadas, indexadas
+// pelo nome da coluna.
+func (r *RoomsRepository) UpdateSettings(id uint, settings map[string]any) error {
+	if len(settings) == 0 {
+		return nil
+	}
+	return r.DB.Model(&models.Room{}).Where("id = ?", id).Updates(settings).Error
+}
+
+// UpdateAttributes substitui a posição da sala na hierarquia e os seus
+// recursos.
+func (r *RoomsRepository) UpdateAttributes(id uint, floorID *uint, planX, planY *float64, amenities []string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Room{}).Where("id = ?", id).Updates(map[string]any{
+			"floor_id": floorID,
+			"plan_x":   planX,
+			"plan_y":   planY,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("room_id = ?", id).Delete(&models.RoomAmenity{}).Error; err != nil {
+			return err
+		}
+		if len(amenities) == 0 {
+			return nil
+		}
+
+		rows := make([]models.RoomAmenity, len(amenities))
+		for i, amenity := range amenities {
+			rows[i] = models.RoomAmenity{RoomID: id, Amenity: amenity}
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+// Delete exclui logicamente a sala junto com as notas, os anexos delas, as
+// participações e os acessos dos grupos, todos com o mesmo DeletedAt, para que possam ser
+// restaurados juntos.
 func (r *RoomsRepository) Delete(id uint) error {
-	if err := r.DB.Delete(&models.Room{}, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil // Retorna nil se não encontrar o registro para excluir
+	now := time.Now().Truncate(time.Microsecond)
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		noteIDs := tx.Model(&models.Note{}).Select("id").Where("room_id = ?", id)
+		if err := tx.Model(&models.Attachment{}).Where("note_id IN (?)", noteIDs).Update("deleted_at", now).Error; err != nil {
+			return err
 		}
-		return err
+		if err := tx.Model(&models.Note{}).Where("room_id = ?", id).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RoomMember{}).Where("room_id = ?", id).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RoomGroupGrant{}).Where("room_id = ?", id).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Room{}).Where("id = ?", id).Update("deleted_at", now).Error
+	})
+}
+
+// SetArchived arquiva ou desarquiva a sala.
+func (r *RoomsRepository) SetArchived(id uint, archived bool) error {
+	var archivedAt *time.Time
+	if archived {
+		now := time.Now()
+		archivedAt = &now
 	}
-	return nil
+	return r.DB.Model(&models.Room{}).Where("id = ?", id).Update("archived_at", archivedAt).Error
+}
+
+// IsArchived informa se a sala está arquivada.
+func (r *RoomsRepository) IsArchived(roomID uint) bool {
+	var count int64
+	r.DB.Model(&models.Room{}).Where("id = ? AND archived_at IS NOT NULL", roomID).Count(&count)
+	return count > 0
 }
 
 func (r *RoomsRepository) GetByName(name string) (*models.Room, error) {
@@ -85,11 +226,11 @@ func (r *RoomsRepository) GetByName(name string) (*models.Room, error) {
 	return &room, nil
 }
 
-func (r *RoomsRepository) JoinRoom(userID, roomID uint) error {
+func (r *RoomsRepository) JoinRoom(userID, roomID uint, role string) error {
 	member := models.RoomMember{
 		UserID: userID,
 		RoomID: roomID,
-		Role:   "member",
+		Role:   role,
 	}
 	return r.DB.Create(&member).Error
 }
@@ -98,23 +239,328 @@ func (r *RoomsRepository) LeaveRoom(userID, roomID uint) error {
 	return r.DB.Where("user_id = ? AND room_id = ?", userID, roomID).Delete(&models.RoomMember{}).Error
 }
 
+// IsUserInRoom informa se o usuário participa da sala, diretamente ou por
+// um grupo.
 func (r *RoomsRepository) IsUserInRoom(userID, roomID uint) bool {
 	var count int64
-	r.DB.Model(&models.RoomMember{}).Where("user_id = ? AND room_id = ?", userID, roomID).Count(&count)
+	r.effectiveMembers().Where("user_id = ? AND room_id = ?", userID, roomID).Count(&count)
 	return count > 0
 }
 
+// GetUserRooms retorna as salas de que o usuário participa, diretamente ou
+// por um grupo.
 func (r *RoomsRepository) GetUserRooms(userID uint) ([]models.Room, error) {
 	var rooms []models.Room
-	err := r.DB.Joins("JOIN room_members ON rooms.id = room_members.room_id").
-		Where("room_members.user_id = ?", userID).
-		Distinct().
+	err := r.DB.Preload("Amenities").
+		Where("id IN (?)", r.effectiveMembers().Select("room_id").Where("user_id = ?", userID)).
 		Find(&rooms).Error
 	return rooms, err
 }
 
+// GetUserRoomIDs retorna os IDs das salas em que o usuário é membro,
+// diretamente ou por um grupo.
+func (r *RoomsRepository) GetUserRoomIDs(userID uint) ([]uint, error) {
+	var roomIDs []uint
+	err := r.effectiveMembers().
+		Where("user_id = ?", userID).
+		Distinct().
+		Pluck("room_id", &roomIDs).Error
+	return roomIDs, err
+}
+
+// GetMembers retorna os membros efetivos da sala: os diretos, na ordem de
+// entrada, e depois os que só participam por grupos. Role é o papel
+// efetivo, o maior entre o direto e os dos grupos.
+func (r *RoomsRepository) GetMembers(roomID uint) ([]models.RoomMember, error) {
+	var members []models.RoomMember
+	if err := r.DB.Preload("User").Where("room_id = ?", roomID).Order("created_at, id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+
+	var grants []struct {
+		UserID  uint
+		GroupID uint
+		Role    string
+	}
+	err := r.DB.Model(&models.RoomGroupGrant{}).
+		Select("user_group_members.user_id, room_group_grants.group_id, room_group_grants.role").
+		Joins("JOIN user_group_members ON user_group_members.group_id = room_group_grants.group_id AND user_group_members.deleted_at IS NULL").
+		Joins("JOIN user_groups ON user_groups.id = room_group_grants.group_id AND user_groups.deleted_at IS NULL").
+		Where("room_group_grants.room_id = ?", roomID).
+		Order("user_group_members.user_id, room_group_grants.group_id").
+		Scan(&grants).Error
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[uint]int, len(members))
+	for i, member := range members {
+		index[member.UserID] = i
+	}
+	var inherited []uint
+	for _, grant := range grants {
+		i, ok := index[grant.UserID]
+		if !ok {
+			i = len(members)
+			index[grant.UserID] = i
+			members = append(members, models.RoomMember{UserID: grant.UserID, RoomID: roomID, Role: grant.Role})
+			inherited = append(inherited, grant.UserID)
+		}
+		members[i].GroupIDs = append(members[i].GroupIDs, grant.GroupID)
+		members[i].Role = strongerRole(members[i].Role, grant.Role)
+	}
+
+	if len(inherited) > 0 {
+		var users []models.User
+		if err := r.DB.Where("id IN ?", inherited).Find(&users).Error; err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			members[index[user.ID]].User = user
+		}
+	}
+	return members, nil
+}
+
+// GetMemberships retorna as participações do usuário, com as salas.
+func (r *RoomsRepository) GetMemberships(userID uint) ([]models.RoomMember, error) {
+	var members []models.RoomMember
+	err := r.DB.Preload("Room").Where("user_id = ?", userID).Order("created_at").Find(&members).Error
+	return members, err
+}
+
+// GetMemberRole retorna o papel efetivo do usuário na sala, o maior entre o
+// direto e os dos grupos, ou "" se ele não for membro.
+func (r *RoomsRepository) GetMemberRole(userID, roomID uint) (string, error) {
+	var roles []string
+	err := r.effectiveMembers().
+		Where("user_id = ? AND room_id = ?", userID, roomID).
+		Pluck("role", &roles).Error
+	if err != nil {
+		return "", err
+	}
+	role := ""
+	for _, other := range roles {
+		role = strongerRole(role, other)
+	}
+	return role, nil
+}
+
+// EffectiveMembership é a participação efetiva de um usuário numa sala,
+// com o papel efetivo e os nomes para os eventos.
+type EffectiveMembership struct {
+	UserID   uint
+	UserName string
+	RoomID   uint
+	RoomName string
+	Role     string
+}
+
+// GetEffectiveMemberships retorna as participações efetivas dos usuários
+// userIDs nas salas roomIDs, uma por usuário e sala, ordenadas por sala e
+// usuário. nil em userIDs ou em roomIDs não filtra.
+func (r *RoomsRepository) GetEffectiveMemberships(userIDs, roomIDs []uint) ([]EffectiveMembership, error) {
+	query := r.effectiveMembers().
+		Select("room_members.user_id, users.name AS user_name, room_members.room_id, rooms.name AS room_name, room_members.role").
+		Joins("JOIN users ON users.id = room_members.user_id").
+		Joins("JOIN rooms ON rooms.id = room_members.room_id AND rooms.deleted_at IS NULL").
+		Order("room_members.room_id, room_members.user_id")
+	if userIDs != nil {
+		query = query.Where("room_members.user_id IN ?", userIDs)
+	}
+	if roomIDs != nil {
+		query = query.Where("room_members.room_id IN ?", roomIDs)
+	}
+
+	var rows []EffectiveMembership
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var memberships []EffectiveMembership
+	for _, row := range rows {
+		if n := len(memberships); n > 0 && memberships[n-1].UserID == row.UserID && memberships[n-1].RoomID == row.RoomID {
+			memberships[n-1].Role = strongerRole(memberships[n-1].Role, row.Role)
+			continue
+		}
+		memberships = append(memberships, row)
+	}
+	return memberships, nil
+}
+
+// GetDirectRole retorna o papel da participação direta do usuário na sala,
+// ou "" se ele não tiver uma (mesmo que participe por um grupo).
+func (r *RoomsRepository) GetDirectRole(userID, roomID uint) (string, error) {
+	var roles []string
+	err := r.DB.Model(&models.RoomMember{}).
+		Where("user_id = ? AND room_id = ?", userID, roomID).
+		Limit(1).
+		Pluck("role", &roles).Error
+	if err != nil || len(roles) == 0 {
+		return "", err
+	}
+	return roles[0], nil
+}
+
+// IsRoomAdmin informa se o usuário pode administrar a sala: o criador ou
+// um membro com papel de admin.
+func (r *RoomsRepository) IsRoomAdmin(userID uint, room *models.Room) bool {
+	if room.CreatedBy == userID {
+		return true
+	}
+	role, err := r.GetMemberRole(userID, room.ID)
+	return err == nil && role == models.RoomRoleAdmin
+}
+
+// GetAdminIDs retorna o criador e os admins da sala, diretos ou por grupo.
+func (r *RoomsRepository) GetAdminIDs(roomID uint) ([]uint, error) {
+	var ids []uint
+	err := r.effectiveMembers().
+		Where("room_id = ? AND role = ?", roomID, models.RoomRoleAdmin).
+		Distinct().
+		Pluck("user_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var createdBy []uint
+	if err := r.DB.Model(&models.Room{}).Where("id = ?", roomID).Pluck("created_by", &createdBy).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range createdBy {
+		if !containsID(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *RoomsRepository) UpdateMemberRole(userID, roomID uint, role string) error {
+	return r.DB.Model(&models.RoomMember{}).
+		Where("user_id = ? AND room_id = ?", userID, roomID).
+		Update("role", role).Error
+}
+
+// TransferOwnership passa a sala para outro usuário, que vira admin direto
+// (o dono precisa ser membro direto), se ela ainda pertencer a fromID.
+func (r *RoomsRepository) TransferOwnership(roomID, fromID, toID uint) (bool, error) {
+	result := r.DB.Model(&models.Room{}).
+		Where("id = ? AND created_by = ?", roomID, fromID).
+		Update("created_by", toID)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	result = r.DB.Model(&models.RoomMember{}).
+		Where("user_id = ? AND room_id = ?", toID, roomID).
+		Update("role", models.RoomRoleAdmin)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return true, r.JoinRoom(toID, roomID, models.RoomRoleAdmin)
+	}
+	return true, nil
+}
+
+// GetOwnedRooms retorna as salas de que o usuário é dono.
+func (r *RoomsRepository) GetOwnedRooms(userID uint) ([]models.Room, error) {
+	var rooms []models.Room
+	err := r.DB.Where("created_by = ?", userID).Find(&rooms).Error
+	return rooms, err
+}
+
+// GetSuccessor retorna quem herda a sala se o dono sair: o admin efetivo
+// (direto ou por grupo) mais antigo ou, sem admins, o membro mais antigo.
+// Quem participa só por grupos vem depois dos membros diretos de mesmo
+// papel. Retorna nil se não houver mais ninguém.
+func (r *RoomsRepository) GetSuccessor(roomID, ownerID uint) (*models.RoomMember, error) {
+	var candidates []models.RoomMember
+	err := r.effectiveMembers().
+		Select("MIN(direct.id) AS id, room_members.user_id, room_members.room_id, CASE WHEN BOOL_OR(room_members.role = ?) THEN ? ELSE ? END AS role",
+			models.RoomRoleAdmin, models.RoomRoleAdmin, models.RoomRoleMember).
+		Joins("LEFT JOIN room_members AS direct ON direct.user_id = room_members.user_id AND direct.room_id = room_members.room_id AND direct.deleted_at IS NULL").
+		Where("room_members.room_id = ? AND room_members.user_id <> ?", roomID, ownerID).
+		Group("room_members.user_id, room_members.room_id").
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "BOOL_OR(room_members.role = ?) DESC, MIN(direct.created_at) NULLS LAST, room_members.user_id",
+			Vars:               []any{models.RoomRoleAdmin},
+			WithoutParentheses: true,
+		}}).
+		Limit(1).
+		Scan(&candidates).Error
+	if err != nil || len(candidates) == 0 {
+		return nil, err
+	}
+
+	successor := candidates[0]
+	if err := r.DB.First(&successor.User, successor.UserID).Error; err != nil {
+		return nil, err
+	}
+	return &successor, nil
+}
+
+// GetRoomMemberCount conta os membros efetivos da sala, para a capacidade.
+// Quem participa diretamente e por grupos conta uma vez só.
 func (r *RoomsRepository) GetRoomMemberCount(roomID uint) (int64, error) {
 	var count int64
-	err := r.DB.Model(&models.RoomMember{}).Where("room_id = ?", roomID).Count(&count).Error
+	err := r.effectiveMembers().Where("room_id = ?", roomID).Distinct("user_id").Count(&count).Error
+	return count, err
+}
+
+// CountMembersWithGroup conta os membros efetivos que a sala teria se o
+// grupo recebesse acesso a ela. Um grupo de outra organização não conta.
+func (r *RoomsRepository) CountMembersWithGroup(roomID, groupID uint) (int64, error) {
+	var count int64
+	err := r.DB.Table("(?) AS room_members", r.DB.Raw(
+		`SELECT user_id FROM (?) AS effective WHERE room_id = ?
+UNION
+SELECT user_id FROM user_group_members WHERE group_id = ? AND deleted_at IS NULL
+AND group_id IN (SELECT user_groups.id FROM user_groups JOIN rooms ON rooms.organization_id = user_groups.organization_id WHERE rooms.id = ?)`,
+		effectiveMembers(r.DB), roomID, groupID, roomID,
+	)).Count(&count).Error
 	return count, err
 }
+
+// effectiveMembers consulta as participações efetivas nas salas, com as
+// colunas user_id, room_id e role, sob o nome room_members.
+func (r *RoomsRepository) effectiveMembers() *gorm.DB {
+	return r.DB.Table("(?) AS room_members", effectiveMembers(r.DB))
+}
+
+// strongerRole retorna o maior dos dois papéis na sala.
+func strongerRole(a, b string) string {
+	if a == models.RoomRoleAdmin || b == models.RoomRoleAdmin {
+		return models.RoomRoleAdmin
+	}
+	if a == "" {
+		return b
+	}
+	return a
+}
+
+func containsID(ids []uint, id uint) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTimeZones retorna o fuso de cada sala, incluindo as excluídas.
+func (r *RoomsRepository) GetTimeZones(ids []uint) (map[uint]string, error) {
+	zones := make(map[uint]string, len(ids))
+	if len(ids) == 0 {
+		return zones, nil
+	}
+
+	var rooms []models.Room
+	if err := r.DB.Unscoped().Select("id", "time_zone").Where("id IN ?", ids).Find(&rooms).Error; err != nil {
+		return zones, err
+	}
+	for _, room := range rooms {
+		zones[room.ID] = room.TimeZone
+	}
+	return zones, nil
+}
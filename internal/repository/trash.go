@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"api-go/internal/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TrashRepository consulta, restaura e remove de vez as salas e notas
+// excluídas logicamente.
+type TrashRepository struct {
+	DB *gorm.DB
+}
+
+func NewTrashRepository(db *gorm.DB) *TrashRepository {
+	return &TrashRepository{
+		DB: db,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que usa o contexto dado.
+func (r *TrashRepository) WithContext(ctx context.Context) *TrashRepository {
+	return &TrashRepository{DB: r.DB.WithContext(ctx)}
+}
+
+// WithTx retorna uma cópia do repositório que opera dentro da transação.
+func (r *TrashRepository) WithTx(tx *gorm.DB) *TrashRepository {
+	return &TrashRepository{DB: withTenantOf(r.DB, tx)}
+}
+
+// GetDeletedRooms retorna as salas do usuário excluídas desde since, as mais
+// recentes primeiro.
+func (r *TrashRepository) GetDeletedRooms(ownerID uint, since time.Time) ([]models.Room, error) {
+	var rooms []models.Room
+	err := r.DB.Unscoped().
+		Where("created_by = ? AND deleted_at IS NOT NULL AND deleted_at >= ?", ownerID, since).
+		Order("deleted_at DESC").
+		Find(&rooms).Error
+	return rooms, err
+}
+
+// GetDeletedNotes retorna as notas do usuário excluídas desde since, as mais
+// recentes primeiro, com a sala de cada uma mesmo que ela também tenha sido
+// excluída.
+func (r *TrashRepository) GetDeletedNotes(userID uint, since time.Time) ([]models.Note, error) {
+	var notes []models.Note
+	err := r.DB.Unscoped().
+		Preload("Room", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("user_id = ? AND deleted_at IS NOT NULL AND deleted_at >= ?", userID, since).
+		Order("deleted_at DESC").
+		Find(&notes).Error
+	return notes, err
+}
+
+// GetDeletedRoom retorna a sala se ela estiver excluída, ou nil.
+func (r *TrashRepository) GetDeletedRoom(id uint) (*models.Room, error) {
+	var room models.Room
+	if err := r.DB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&room).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &room, nil
+}
+
+// GetDeletedNote retorna a nota se ela estiver excluída, ou nil. A sala vem
+// junto mesmo que também tenha sido excluída.
+func (r *TrashRepository) GetDeletedNote(id uint) (*models.Note, error) {
+	var note models.Note
+	err := r.DB.Unscoped().
+		Preload("Room", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		First(&note).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &note, nil
+}
+
+// RestoreRoom restaura a sala e as notas, anexos e participações excluídos
+// junto com ela. O que já tinha sido excluído antes continua na lixeira.
+func (r *TrashRepository) RestoreRoom(room *models.Room) error {
+	deletedAt := room.DeletedAt.Time
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		noteIDs := tx.Unscoped().Model(&models.Note{}).Select("id").Where("room_id = ?", room.ID)
+		if err := tx.Unscoped().Model(&models.Attachment{}).
+			Where("note_id IN (?) AND deleted_at = ?", noteIDs, deletedAt).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Note{}).
+			Where("room_id = ? AND deleted_at = ?", room.ID, deletedAt).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.RoomMember{}).
+			Where("room_id = ? AND deleted_at = ?", room.ID, deletedAt).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.RoomGroupGrant{}).
+			Where("room_id = ? AND deleted_at = ?", room.ID, deletedAt).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&models.Room{}).Where("id = ?", room.ID).Update("deleted_at", nil).Error
+	})
+}
+
+// RestoreNote restaura a nota e os anexos excluídos junto com ela.
+func (r *TrashRepository) RestoreNote(note *models.Note) error {
+	deletedAt := note.DeletedAt.Time
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Attachment{}).
+			Where("note_id = ? AND deleted_at = ?", note.ID, deletedAt).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&models.Note{}).Where("id = ?", note.ID).Update("deleted_at", nil).Error
+	})
+}
+
+// PurgeRoom remove definitivamente a sala e tudo o que pertence a ela.
+// Retorna as chaves de storage dos anexos removidos, para a coleta dos blobs.
+func (r *TrashRepository) PurgeRoom(id uint) ([]string, error) {
+	var keys []string
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		tx = tx.Unscoped().Session(&gorm.Session{})
+		noteIDs := tx.Model(&models.Note{}).Select("id").Where("room_id = ?", id)
+		if err := tx.Model(&models.Attachment{}).Where("note_id IN (?)", noteIDs).
+			Distinct().
+			Pluck("storage_key", &keys).Error; err != nil {
+			return err
+		}
+
+		steps := []struct {
+			model any
+			query string
+			arg   any
+		}{
+			{&models.Mention{}, "note_id IN (?)", noteIDs},
+			{&models.Attachment{}, "note_id IN (?)", noteIDs},
+			{&models.Note{}, "room_id = ?", id},
+			{&models.WaitlistEntry{}, "room_id = ?", id},
+			{&models.Reservation{}, "room_id = ?", id},
+			{&models.RoomMember{}, "room_id = ?", id},
+			{&models.RoomGroupGrant{}, "room_id = ?", id},
+			{&models.RoomBlackout{}, "room_id = ?", id},
+			{&models.RoomAmenity{}, "room_id = ?", id},
+			{&models.RoomInvite{}, "room_id = ?", id},
+			{&models.RoomJoinRequest{}, "room_id = ?", id},
+			{&models.RoomOwnershipTransfer{}, "room_id = ?", id},
+			{&models.WebhookDelivery{}, "webhook_id IN (?)", tx.Model(&models.Webhook{}).Select("id").Where("room_id = ?", id)},
+			{&models.Webhook{}, "room_id = ?", id},
+			{&models.Room{}, "id = ?", id},
+		}
+		for _, step := range steps {
+			if err := tx.Where(step.query, step.arg).Delete(step.model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// PurgeNote remove definitivamente a nota, os anexos e as menções dela.
+// Retorna as chaves de storage dos anexos removidos.
+func (r *TrashRepository) PurgeNote(id uint) ([]string, error) {
+	var keys []string
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		tx = tx.Unscoped().Session(&gorm.Session{})
+		if err := tx.Model(&models.Attachment{}).Where("note_id = ?", id).
+			Distinct().
+			Pluck("storage_key", &keys).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("note_id = ?", id).Delete(&models.Mention{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("note_id = ?", id).Delete(&models.Attachment{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.Note{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
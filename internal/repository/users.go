@@ -2,7 +2,11 @@ package repository
 
 import (
 	"api-go/internal/models"
+	"api-go/internal/tenant"
+	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -17,6 +21,15 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	}
 }
 
+// WithContext retorna uma cópia do repositório que usa o contexto dado.
+func (r *UserRepository) WithContext(ctx context.Context) *UserRepository {
+	return &UserRepository{DB: r.DB.WithContext(ctx)}
+}
+
+func (r *UserRepository) WithTx(tx *gorm.DB) *UserRepository {
+	return &UserRepository{DB: withTenantOf(r.DB, tx)}
+}
+
 func (r *UserRepository) Create(email string, name string, password string) (*models.User, error) {
 	var userCount int64
 	// Verifica se um usuário com o email já existe
@@ -32,6 +45,7 @@ func (r *UserRepository) Create(email string, name string, password string) (*mo
 		Name:     name,
 		Email:    email,
 		Password: password,
+		Role:     models.UserRoleUser,
 	}
 
 	if err := r.DB.Create(&user).Error; err != nil {
@@ -51,12 +65,16 @@ func (r *UserRepository) GetByID(id uint) (*models.User, error) {
 	return &user, nil
 }
 
-func (r *UserRepository) GetAll() ([]models.User, error) {
-	var users []models.User
-	if err := r.DB.Find(&users).Error; err != nil {
-		return nil, fmt.Errorf("falha ao buscar usuários: %w", err)
+// FindByID busca o usuário, retornando nil se ele não existir.
+func (r *UserRepository) FindByID(id uint) (*models.User, error) {
+	var user models.User
+	if err := r.DB.First(&user, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
 	}
-	return users, nil
+	return &user, nil
 }
 
 func (r *UserRepository) Update(user *models.User) error {
@@ -66,14 +84,217 @@ func (r *UserRepository) Update(user *models.User) error {
 	return nil
 }
 
-func (r *UserRepository) Delete(id uint) error {
-	if err := r.DB.Delete(&models.User{}, id).Error; err != nil {
+// ScheduleDeletion agenda a exclusão da conta para at, com a política de
+// conteúdo informada. Retorna false se a exclusão já estava agendada.
+func (r *UserRepository) ScheduleDeletion(id uint, at time.Time, content string) (bool, error) {
+	result := r.DB.Model(&models.User{}).
+		Where("id = ? AND deletion_scheduled_at IS NULL AND anonymized_at IS NULL", id).
+		Updates(map[string]any{
+			"deletion_scheduled_at": at,
+			"deletion_content":      content,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// CancelDeletion desfaz a exclusão agendada. Retorna false se não havia
+// exclusão agendada.
+func (r *UserRepository) CancelDeletion(id uint) (bool, error) {
+	result := r.DB.Model(&models.User{}).
+		Where("id = ? AND deletion_scheduled_at IS NOT NULL AND anonymized_at IS NULL", id).
+		Updates(map[string]any{
+			"deletion_scheduled_at": nil,
+			"deletion_content":      "",
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetDueDeletions retorna as contas cujo período de carência terminou.
+func (r *UserRepository) GetDueDeletions(now time.Time) ([]models.User, error) {
+	var users []models.User
+	err := r.DB.Where("deletion_scheduled_at <= ? AND anonymized_at IS NULL", now).
+		Order("deletion_scheduled_at").
+		Find(&users).Error
+	return users, err
+}
+
+// Anonymize conclui a exclusão da conta: remove os dados pessoais, libera o
+// email para um novo cadastro e exclui logicamente as participações nas
+// salas, nos grupos e nas organizações e as notificações do usuário. A linha continua no banco para que as
+// notas mantidas sigam com um autor, agora anônimo. Retorna false se a
+// exclusão foi desfeita ou já concluída por outro processo.
+func (r *UserRepository) Anonymize(id uint, now time.Time) (bool, error) {
+	var anonymized bool
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND deletion_scheduled_at IS NOT NULL AND anonymized_at IS NULL", id).
+			Updates(map[string]any{
+				"name":                  "Usuário removido",
+				"email":                 fmt.Sprintf("deleted-%d@users.invalid", id),
+				"password":              "",
+				"time_zone":             "",
+				"role":                  models.UserRoleUser,
+				"deletion_scheduled_at": nil,
+				"anonymized_at":         now,
+
+				"password_reset_required":   false,
+				"password_reset_token_hash": "",
+				"password_reset_expires_at": nil,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		anonymized = true
+
+		if err := tx.Where("user_id = ?", id).Delete(&models.RoomMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.UserGroupMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.OrganizationMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.Notification{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", id).Delete(&models.NotificationPreference{}).Error
+	})
+	if err != nil {
+		return false, fmt.Errorf("falha ao anonimizar usuário: %w", err)
+	}
+	return anonymized, nil
+}
+
+// UserFilter restringe a busca de usuários da administração. Campos
+// zerados não filtram.
+type UserFilter struct {
+	// Query procura no nome e no email.
+	Query string
+	// Status é "active" ou "suspended".
+	Status string
+	Role   string
+	Limit  int
+	Offset int
+}
+
+// Search busca as contas não excluídas, mais recentes primeiro.
+func (r *UserRepository) Search(filter UserFilter) ([]models.User, int64, error) {
+	query := r.DB.Model(&models.User{}).Where("anonymized_at IS NULL")
+	if filter.Query != "" {
+		pattern := "%" + escapeLike(filter.Query) + "%"
+		query = query.Where("name ILIKE ? OR email ILIKE ?", pattern, pattern)
+	}
+	switch filter.Status {
+	case "active":
+		query = query.Where("suspended_at IS NULL")
+	case "suspended":
+		query = query.Where("suspended_at IS NOT NULL")
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+
+	query = query.Session(&gorm.Session{})
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []models.User
+	err := query.Order("created_at DESC, id DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&users).Error
+	return users, total, err
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike escapa os curingas do LIKE no texto buscado.
+func escapeLike(text string) string {
+	return likeEscaper.Replace(text)
+}
+
+// Suspend suspende a conta. Retorna false se ela já estava suspensa.
+func (r *UserRepository) Suspend(id uint, reason string, now time.Time) (bool, error) {
+	result := r.DB.Model(&models.User{}).
+		Where("id = ? AND suspended_at IS NULL AND anonymized_at IS NULL", id).
+		Updates(map[string]any{
+			"suspended_at":      now,
+			"suspension_reason": reason,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Reactivate encerra a suspensão da conta. Retorna false se ela não estava
+// suspensa.
+func (r *UserRepository) Reactivate(id uint) (bool, error) {
+	result := r.DB.Model(&models.User{}).
+		Where("id = ? AND suspended_at IS NOT NULL AND anonymized_at IS NULL", id).
+		Updates(map[string]any{
+			"suspended_at":      nil,
+			"suspension_reason": "",
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// SetRole altera o papel global do usuário.
+func (r *UserRepository) SetRole(id uint, role string) error {
+	return r.DB.Model(&models.User{}).
+		Where("id = ? AND anonymized_at IS NULL", id).
+		Update("role", role).Error
+}
+
+// GrantAdmin promove a administradores as contas com os emails informados.
+func (r *UserRepository) GrantAdmin(emails []string) (int64, error) {
+	result := r.DB.Model(&models.User{}).
+		Where("email IN ? AND role <> ? AND anonymized_at IS NULL", emails, models.UserRoleAdmin).
+		Update("role", models.UserRoleAdmin)
+	return result.RowsAffected, result.Error
+}
+
+// RequirePasswordReset bloqueia o login até a senha ser redefinida com o
+// token cujo hash é informado e encerra as sessões abertas.
+func (r *UserRepository) RequirePasswordReset(id uint, tokenHash string, expiresAt, now time.Time) (bool, error) {
+	result := r.DB.Model(&models.User{}).
+		Where("id = ? AND anonymized_at IS NULL", id).
+		Updates(map[string]any{
+			"password_reset_required":   true,
+			"password_reset_token_hash": tokenHash,
+			"password_reset_expires_at": expiresAt,
+			"tokens_valid_after":        now,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetByPasswordResetToken busca o usuário pelo hash do token de
+// redefinição de senha, retornando nil se nenhum tiver esse token.
+func (r *UserRepository) GetByPasswordResetToken(tokenHash string) (*models.User, error) {
+	var user models.User
+	err := r.DB.Where("password_reset_required AND password_reset_token_hash = ?", tokenHash).
+		First(&user).Error
+	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return fmt.Errorf("usuário com ID %d não encontrado para exclusão", id)
+			return nil, nil
 		}
-		return fmt.Errorf("falha ao excluir usuário: %w", err)
+		return nil, err
 	}
-	return nil
+	return &user, nil
+}
+
+// ResetPassword troca a senha usando o token de redefinição, que deixa de
+// valer, e encerra as sessões abertas. Retorna false se o token foi usado
+// ou expirou nesse meio-tempo.
+func (r *UserRepository) ResetPassword(id uint, tokenHash, passwordHash string, now time.Time) (bool, error) {
+	result := r.DB.Model(&models.User{}).
+		Where("id = ? AND password_reset_required AND password_reset_token_hash = ? AND password_reset_expires_at > ?", id, tokenHash, now).
+		Updates(map[string]any{
+			"password":                  passwordHash,
+			"password_reset_required":   false,
+			"password_reset_token_hash": "",
+			"password_reset_expires_at": nil,
+			"tokens_valid_after":        now,
+		})
+	return result.RowsAffected > 0, result.Error
 }
 
 func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
@@ -86,3 +307,72 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 	}
 	return &user, nil
 }
+
+// FindByEmail busca o usuário pelo email, retornando nil se ele não existir.
+func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
+	var user models.User
+	if err := r.DB.Where("email = ?", email).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// sharedMembers seleciona os usuários que participam de alguma sala em
+// comum com userID, incluindo ele próprio, diretamente ou por grupos. Com
+// uma organização no contexto, só contam as salas dela.
+func (r *UserRepository) sharedMembers(userID uint) *gorm.DB {
+	query := r.DB.Table("(?) AS mine", effectiveMembers(r.DB)).
+		Select("theirs.user_id").
+		Joins("JOIN (?) AS theirs ON theirs.room_id = mine.room_id", effectiveMembers(r.DB)).
+		Where("mine.user_id = ?", userID)
+	if _, ok := tenant.FromContext(r.DB.Statement.Context); ok {
+		query = query.Where("mine.room_id IN (?)", r.DB.Model(&models.Room{}).Select("id"))
+	}
+	return query
+}
+
+// SharesRoom informa se os dois usuários participam de alguma sala em comum.
+func (r *UserRepository) SharesRoom(userID, otherID uint) (bool, error) {
+	var count int64
+	err := r.sharedMembers(userID).Where("theirs.user_id = ?", otherID).Count(&count).Error
+	return count > 0, err
+}
+
+// GetDirectory busca os usuários que participam de alguma sala em comum com
+// userID, em ordem alfabética. O texto é procurado em parte do nome ou
+// comparado com o email inteiro, sem diferenciar maiúsculas: uma busca por
+// parte do email revelaria, letra a letra, os emails mascarados.
+func (r *UserRepository) GetDirectory(userID uint, text string, limit int) ([]models.User, error) {
+	query := r.DB.Where("id IN (?) AND anonymized_at IS NULL", r.sharedMembers(userID))
+	if text != "" {
+		pattern := "%" + escapeLike(text) + "%"
+		query = query.Where("name ILIKE ? OR LOWER(email) = LOWER(?)", pattern, text)
+	}
+
+	var users []models.User
+	err := query.Order("name, id").Limit(limit).Find(&users).Error
+	return users, err
+}
+
+// GetManagedIDs retorna, dentre userIDs, os usuários que participam de
+// alguma sala administrada por adminID (como criador ou admin da sala),
+// diretamente ou por grupos.
+func (r *UserRepository) GetManagedIDs(adminID uint, userIDs []uint) ([]uint, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	managedRooms := r.DB.Model(&models.Room{}).Select("id").
+		Where("created_by = ?", adminID).
+		Or("id IN (?)", r.DB.Table("(?) AS room_members", effectiveMembers(r.DB)).Select("room_id").
+			Where("user_id = ? AND role = ?", adminID, models.RoomRoleAdmin))
+
+	var ids []uint
+	err := r.DB.Table("(?) AS room_members", effectiveMembers(r.DB)).
+		Where("user_id IN ? AND room_id IN (?)", userIDs, managedRooms).
+		Distinct().
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"api-go/internal/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WaitlistRepository struct {
+	DB *gorm.DB
+}
+
+func NewWaitlistRepository(db *gorm.DB) *WaitlistRepository {
+	return &WaitlistRepository{
+		DB: db,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que usa o contexto da
+// requisição. Se ele trouxer uma organização, as consultas ficam restritas
+// a ela.
+func (r *WaitlistRepository) WithContext(ctx context.Context) *WaitlistRepository {
+	return &WaitlistRepository{DB: r.DB.WithContext(ctx)}
+}
+
+// WithTx retorna uma cópia do repositório que opera dentro da transação.
+func (r *WaitlistRepository) WithTx(tx *gorm.DB) *WaitlistRepository {
+	return &WaitlistRepository{DB: withTenantOf(r.DB, tx)}
+}
+
+func (r *WaitlistRepository) Create(entry *models.WaitlistEntry) error {
+	return r.DB.Create(entry).Error
+}
+
+func (r *WaitlistRepository) GetByID(id uint) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+	if err := r.DB.First(&entry, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *WaitlistRepository) GetByUserID(userID uint) ([]models.WaitlistEntry, error) {
+	var entries []models.WaitlistEntry
+	if err := r.DB.Where("user_id = ?", userID).Order("start_time").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetByReservationID retorna a entrada que originou a reserva provisória.
+func (r *WaitlistRepository) GetByReservationID(reservationID uint) (*models.WaitlistEntry, error) {
+	var entries []models.WaitlistEntry
+	if err := r.DB.Where("reservation_id = ?", reservationID).Limit(1).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return &entries[0], nil
+}
+
+// HasActive informa se o usuário já espera, ou tem uma oferta, na sala em um
+// período que se sobrepõe ao informado.
+func (r *WaitlistRepository) HasActive(userID, roomID uint, startTime, endTime time.Time) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.WaitlistEntry{}).
+		Where("user_id = ? AND room_id = ? AND status IN ?", userID, roomID, []string{models.WaitlistWaiting, models.WaitlistOffered}).
+		Where("start_time < ? AND end_time > ?", endTime, startTime).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetWaiting retorna as entradas da sala ainda aguardando, por ordem de chegada.
+func (r *WaitlistRepository) GetWaiting(roomID uint) ([]models.WaitlistEntry, error) {
+	var entries []models.WaitlistEntry
+	err := r.DB.Where("room_id = ? AND status = ? AND start_time > ?", roomID, models.WaitlistWaiting, time.Now()).
+		Order("created_at, id").
+		Find(&entries).Error
+	return entries, err
+}
+
+// GetExpiredOffers retorna as ofertas cujo prazo de confirmação passou.
+func (r *WaitlistRepository) GetExpiredOffers(now time.Time) ([]models.WaitlistEntry, error) {
+	var entries []models.WaitlistEntry
+	err := r.DB.Where("status = ? AND offer_expires_at <= ?", models.WaitlistOffered, now).
+		Find(&entries).Error
+	return entries, err
+}
+
+// Offer registra a reserva provisória oferecida à entrada. Retorna false se
+// a entrada já não estava aguardando.
+func (r *WaitlistRepository) Offer(id, reservationID uint, expiresAt time.Time) (bool, error) {
+	now := time.Now()
+	result := r.DB.Model(&models.WaitlistEntry{}).
+		Where("id = ? AND status = ?", id, models.WaitlistWaiting).
+		Updates(map[string]any{
+			"status":           models.WaitlistOffered,
+			"reservation_id":   reservationID,
+			"offered_at":       now,
+			"offer_expires_at": expiresAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// SetStatus muda o estado da entrada se ele ainda for um dos esperados.
+// Retorna false se outro processo o alterou antes.
+func (r *WaitlistRepository) SetStatus(id uint, to string, from ...string) (bool, error) {
+	result := r.DB.Model(&models.WaitlistEntry{}).
+		Where("id = ? AND status IN ?", id, from).
+		Update("status", to)
+	return result.RowsAffected > 0, result.Error
+}
+
+// CancelForUser tira o usuário de todas as listas de espera em que ele
+// aguarda ou tem uma oferta em aberto.
+func (r *WaitlistRepository) CancelForUser(userID uint) error {
+	return r.DB.Model(&models.WaitlistEntry{}).
+		Where("user_id = ? AND status IN ?", userID, []string{models.WaitlistWaiting, models.WaitlistOffered}).
+		Update("status", models.WaitlistCancelled).Error
+}
+
+// ExpireStarted encerra as entradas que ainda aguardavam quando o período
+// começou.
+func (r *WaitlistRepository) ExpireStarted(now time.Time) (int64, error) {
+	result := r.DB.Model(&models.WaitlistEntry{}).
+		Where("status = ? AND start_time <= ?", models.WaitlistWaiting, now).
+		Update("status", models.WaitlistExpired)
+	return result.RowsAffected, result.Error
+}
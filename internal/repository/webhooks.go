@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"api-go/internal/models"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhooksRepository struct {
+	DB *gorm.DB
+}
+
+func NewWebhooksRepository(db *gorm.DB) *WebhooksRepository {
+	return &WebhooksRepository{
+		DB: db,
+	}
+}
+
+// WithContext retorna uma cópia do repositório que usa o contexto da
+// requisição. Se ele trouxer uma organização, as consultas ficam restritas
+// a ela.
+func (r *WebhooksRepository) WithContext(ctx context.Context) *WebhooksRepository {
+	return &WebhooksRepository{DB: r.DB.WithContext(ctx)}
+}
+
+// WithTx retorna uma cópia do repositório que opera dentro da transação.
+func (r *WebhooksRepository) WithTx(tx *gorm.DB) *WebhooksRepository {
+	return &WebhooksRepository{DB: withTenantOf(r.DB, tx)}
+}
+
+func (r *WebhooksRepository) Create(webhook *models.Webhook) error {
+	return r.DB.Create(webhook).Error
+}
+
+func (r *WebhooksRepository) GetByID(id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := r.DB.First(&webhook, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *WebhooksRepository) GetByUserID(userID uint) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if err := r.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *WebhooksRepository) Update(webhook *models.Webhook) error {
+	return r.DB.Model(webhook).Select("url", "events", "active").Updates(webhook).Error
+}
+
+func (r *WebhooksRepository) Delete(id uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Webhook{}, id).Error
+	})
+}
+
+// DeleteByUserID exclui os webhooks do usuário e o log de entregas deles.
+func (r *WebhooksRepository) DeleteByUserID(userID uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		webhooks := tx.Model(&models.Webhook{}).Select("id").Where("user_id = ?", userID)
+		if err := tx.Where("webhook_id IN (?)", webhooks).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.Webhook{}).Error
+	})
+}
+
+// GetForRoomEvent retorna os webhooks ativos que devem receber um evento da
+// sala: os da própria sala e os de usuários que são membros dela, diretos
+// ou por grupos. Como quem sai da sala já não é membro quando o evento é
+// tratado, memberIDs permite incluir usuários afetados pelo evento.
+func (r *WebhooksRepository) GetForRoomEvent(roomID uint, memberIDs ...uint) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	members := r.DB.Table("(?) AS room_members", effectiveMembers(r.DB)).Select("user_id").Where("room_id = ?", roomID)
+	query := r.DB.Where("active = ?", true).Where(
+		r.DB.Where("room_id = ? AND user_id IN (?)", roomID, members).
+			Or("room_id IS NULL AND user_id IN (?)", members),
+	)
+	if len(memberIDs) > 0 {
+		query = query.Or("active = ? AND room_id IS NULL AND user_id IN ?", true, memberIDs)
+	}
+	if err := query.Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// CreateDelivery grava a entrega. Retorna false, sem erro, se ela é a
+// primeira entrega de um evento que o webhook já recebeu.
+func (r *WebhooksRepository) CreateDelivery(delivery *models.WebhookDelivery) (bool, error) {
+	result := r.DB.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "webhook_id"}, {Name: "event_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "redelivery = false"}}},
+		DoNothing:   true,
+	}).Create(delivery)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *WebhooksRepository) GetDelivery(id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := r.DB.First(&delivery, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *WebhooksRepository) GetDeliveries(webhookID uint, status string, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	query := r.DB.Where("webhook_id = ?", webhookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("created_at DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// SaveAttempt grava o resultado de uma tentativa de entrega.
+func (r *WebhooksRepository) SaveAttempt(delivery *models.WebhookDelivery) error {
+	return r.DB.Model(delivery).
+		Select("status", "attempts", "next_attempt_at", "last_attempt_at", "response_status", "last_error").
+		Updates(delivery).Error
+}
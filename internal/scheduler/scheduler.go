@@ -0,0 +1,229 @@
+// Package scheduler executa tarefas periódicas descritas por expressões cron.
+// Com várias réplicas da API, só a líder executa as tarefas. A liderança é
+// um advisory lock de sessão do Postgres, preso a uma conexão dedicada: ele
+// dura enquanto a conexão estiver viva, e quando a líder cai o Postgres o
+// solta e outra réplica assume na próxima tentativa.
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+// Task é uma tarefa periódica. Ela precisa tolerar ser executada de novo
+// depois de uma falha ou de uma execução interrompida.
+type Task func(ctx context.Context) error
+
+// leaderCheck é o intervalo entre as tentativas de assumir a liderança e as
+// verificações de que a conexão da líder continua viva.
+const leaderCheck = 15 * time.Second
+
+type Scheduler struct {
+	DB *gorm.DB
+
+	cron *cron.Cron
+
+	// ctx é cancelado no shutdown, interrompendo as tarefas em andamento.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu    sync.Mutex
+	names map[string]bool
+
+	// conn segura o lock da liderança, e term é cancelado quando ela é
+	// perdida, interrompendo as tarefas da réplica. Ambos são nil enquanto
+	// a réplica não é a líder.
+	leaderMu  sync.Mutex
+	conn      *sql.Conn
+	term      context.Context
+	endTerm   context.CancelFunc
+	campaigns sync.WaitGroup
+}
+
+func New(db *gorm.DB) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		DB: db,
+		// Uma tarefa que passa do intervalo não se sobrepõe à próxima execução.
+		cron:   cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DefaultLogger))),
+		ctx:    ctx,
+		cancel: cancel,
+		names:  make(map[string]bool),
+	}
+}
+
+// Add agenda a tarefa. spec segue o formato padrão de cinco campos
+// ("*/5 * * * *") ou os atalhos do cron ("@hourly", "@every 1m"). O nome
+// identifica a tarefa entre as réplicas e precisa ser único.
+func (s *Scheduler) Add(name, spec string, timeout time.Duration, task Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.names[name] {
+		return fmt.Errorf("task %q already scheduled", name)
+	}
+
+	_, err := s.cron.AddFunc(spec, func() { s.run(name, timeout, task) })
+	if err != nil {
+		return fmt.Errorf("invalid schedule for task %q: %w", name, err)
+	}
+	s.names[name] = true
+	return nil
+}
+
+// Start inicia o agendador e a disputa pela liderança.
+func (s *Scheduler) Start() {
+	s.campaigns.Add(1)
+	go s.campaign()
+	s.cron.Start()
+}
+
+// Shutdown para de agendar e aguarda as tarefas em andamento. Se o contexto
+// expirar antes, elas são canceladas.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	stopped := s.cron.Stop()
+	defer func() {
+		s.campaigns.Wait()
+		s.resign()
+	}()
+	select {
+	case <-stopped.Done():
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-stopped.Done()
+		return ctx.Err()
+	}
+}
+
+// campaign tenta assumir a liderança até o shutdown e, enquanto é a líder,
+// confere se a conexão que segura o lock continua viva.
+func (s *Scheduler) campaign() {
+	defer s.campaigns.Done()
+	ticker := time.NewTicker(leaderCheck)
+	defer ticker.Stop()
+	for {
+		if err := s.checkLeadership(); err != nil {
+			log.Printf("scheduler leadership check failed: %v", err)
+		}
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) checkLeadership() error {
+	s.leaderMu.Lock()
+	defer s.leaderMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(s.ctx, leaderCheck)
+	defer cancel()
+
+	if s.conn != nil {
+		if err := s.conn.PingContext(ctx); err != nil {
+			// Com a conexão perdida, o Postgres já soltou o lock e outra
+			// réplica pode assumir: as tarefas daqui param na hora.
+			log.Println("scheduler lost leadership")
+			s.endTermLocked()
+			return err
+		}
+		return nil
+	}
+
+	db, err := s.DB.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	acquired := false
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockKey("leader")).Scan(&acquired); err != nil || !acquired {
+		conn.Close()
+		return err
+	}
+
+	log.Println("scheduler acquired leadership")
+	s.conn = conn
+	s.term, s.endTerm = context.WithCancel(s.ctx)
+	return nil
+}
+
+// resign solta a liderança no shutdown, para que outra réplica assuma sem
+// esperar a conexão expirar.
+func (s *Scheduler) resign() {
+	s.leaderMu.Lock()
+	defer s.leaderMu.Unlock()
+	if s.conn == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := s.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockKey("leader")); err != nil {
+		log.Printf("failed to release scheduler leadership: %v", err)
+	}
+	s.endTermLocked()
+}
+
+// endTermLocked encerra a liderança. Fechar a conexão devolve ao pool uma
+// conexão que pode ainda segurar o lock, então ela é descartada.
+func (s *Scheduler) endTermLocked() {
+	s.endTerm()
+	s.conn.Raw(func(any) error { return driver.ErrBadConn })
+	s.conn.Close()
+	s.conn, s.term, s.endTerm = nil, nil, nil
+}
+
+// leaderTerm retorna o contexto da liderança atual, ou nil se a réplica não
+// é a líder.
+func (s *Scheduler) leaderTerm() context.Context {
+	s.leaderMu.Lock()
+	defer s.leaderMu.Unlock()
+	return s.term
+}
+
+// run executa a tarefa se esta réplica for a líder. A tarefa é cancelada se
+// a liderança for perdida no meio dela.
+func (s *Scheduler) run(name string, timeout time.Duration, task Task) {
+	term := s.leaderTerm()
+	if term == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(term, timeout)
+	defer cancel()
+
+	started := time.Now()
+	if err := safeRun(ctx, task); err != nil {
+		log.Printf("scheduled task %s failed after %s: %v", name, time.Since(started).Round(time.Millisecond), err)
+		return
+	}
+	log.Printf("scheduled task %s finished in %s", name, time.Since(started).Round(time.Millisecond))
+}
+
+// lockKey deriva a chave de um advisory lock do nome.
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("scheduler:" + name))
+	return int64(h.Sum64())
+}
+
+func safeRun(ctx context.Context, task Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panicked: %v", r)
+		}
+	}()
+	return task(ctx)
+}
@@ -0,0 +1,237 @@
+package scheduler
+
+import (
+	"api-go/internal/accounts"
+	"api-go/internal/events"
+	"api-go/internal/jobs"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/storage"
+	"api-go/internal/waitlist"
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const taskTimeout = 5 * time.Minute
+
+// Tasks são as tarefas agendadas da API.
+type Tasks struct {
+	ReservationsRepository *repository.ReservationsRepository
+	RoomsRepository        *repository.RoomsRepository
+	MaintenanceRepository  *repository.MaintenanceRepository
+	AttachmentsRepository  *repository.AttachmentsRepository
+	BlobStore              storage.BlobStore
+	Outbox                 *jobs.Outbox
+	Waitlist               *waitlist.Service
+	Accounts               *accounts.Service
+
+	// ReminderBefore é a antecedência do lembrete de reserva.
+	ReminderBefore time.Duration
+
+	// Retention é por quanto tempo as linhas excluídas logicamente são
+	// mantidas antes de serem removidas de vez.
+	Retention time.Duration
+
+	// JobRetention é por quanto tempo os jobs concluídos do outbox e as
+	// entregas de webhook encerradas ficam no banco.
+	JobRetention time.Duration
+
+	// CheckInGrace é o prazo, contado do início da reserva, para o
+	// check-in. Nas salas que exigem check-in, reservas sem ele depois do
+	// prazo são canceladas e liberam o lugar.
+	CheckInGrace time.Duration
+
+	// ApprovalTimeout é por quanto tempo uma reserva pode ficar pendente.
+	// Depois dele, ou quando o horário chega, ela expira e libera o lugar.
+	ApprovalTimeout time.Duration
+}
+
+// Register agenda as tarefas.
+func (t *Tasks) Register(s *Scheduler) error {
+	if err := s.Add("reservation-reminders", "@every 1m", taskTimeout, t.SendReminders); err != nil {
+		return err
+	}
+	if err := s.Add("reservation-auto-release", "@every 1m", taskTimeout, t.ReleaseNotCheckedIn); err != nil {
+		return err
+	}
+	if err := s.Add("reservation-approval-expiry", "@every 1m", taskTimeout, t.ExpirePending); err != nil {
+		return err
+	}
+	if err := s.Add("waitlist-offer-expiry", "@every 1m", taskTimeout, t.Waitlist.ExpireOffers); err != nil {
+		return err
+	}
+	if err := s.Add("purge-finished-jobs", "@hourly", taskTimeout, t.PurgeFinishedJobs); err != nil {
+		return err
+	}
+	if err := s.Add("purge-expired-exports", "@hourly", taskTimeout, t.Accounts.PurgeExpiredExports); err != nil {
+		return err
+	}
+	if err := s.Add("account-deletions", "@every 15m", taskTimeout, t.Accounts.FinalizeDeletions); err != nil {
+		return err
+	}
+	if err := s.Add("password-reset-expiry", "@hourly", taskTimeout, t.ClearExpiredPasswordResets); err != nil {
+		return err
+	}
+	return s.Add("purge-soft-deleted", "30 3 * * *", taskTimeout, t.PurgeSoftDeleted)
+}
+
+// SendReminders avisa os usuários das reservas que começam dentro da
+// antecedência configurada. Cada reserva recebe um único lembrete.
+func (t *Tasks) SendReminders(ctx context.Context) error {
+	now := time.Now()
+	reservations, err := t.ReservationsRepository.WithContext(ctx).GetDueReminders(now, now.Add(t.ReminderBefore))
+	if err != nil {
+		return err
+	}
+
+	for _, reservation := range reservations {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		room, err := t.RoomsRepository.WithContext(ctx).FindByID(reservation.RoomID)
+		if err != nil {
+			return err
+		}
+		if room == nil {
+			continue
+		}
+
+		err = t.Outbox.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			marked, err := t.ReservationsRepository.WithTx(tx).MarkReminderSent(reservation.ID)
+			if err != nil || !marked {
+				return err
+			}
+			return t.Outbox.Publish(tx, events.ForReservation(events.ReservationReminder, room, &reservation, nil))
+		})
+		if err != nil {
+			log.Printf("failed to send reminder of reservation %d: %v", reservation.ID, err)
+		}
+	}
+	return nil
+}
+
+// ReleaseNotCheckedIn cancela as reservas que passaram do prazo de check-in,
+// liberando o lugar para outras pessoas, e registra a falta do usuário.
+func (t *Tasks) ReleaseNotCheckedIn(ctx context.Context) error {
+	reservations, err := t.ReservationsRepository.WithContext(ctx).GetNotCheckedIn(time.Now().Add(-t.CheckInGrace))
+	if err != nil {
+		return err
+	}
+
+	for _, reservation := range reservations {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		room, err := t.RoomsRepository.WithContext(ctx).FindByID(reservation.RoomID)
+		if err != nil {
+			return err
+		}
+
+		err = t.Outbox.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			released, err := t.ReservationsRepository.WithTx(tx).Release(&reservation)
+			if err != nil || !released || room == nil {
+				return err
+			}
+			return t.Outbox.Publish(tx, events.ForReservation(events.ReservationCancelled, room, &reservation, map[string]any{
+				"reason": "not_checked_in",
+			}))
+		})
+		if err != nil {
+			log.Printf("failed to release reservation %d: %v", reservation.ID, err)
+		}
+	}
+	return nil
+}
+
+// ExpirePending encerra os pedidos de reserva que ficaram sem decisão.
+func (t *Tasks) ExpirePending(ctx context.Context) error {
+	reservations, err := t.ReservationsRepository.WithContext(ctx).GetStalePending(time.Now().Add(-t.ApprovalTimeout))
+	if err != nil {
+		return err
+	}
+
+	for _, reservation := range reservations {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		room, err := t.RoomsRepository.WithContext(ctx).FindByID(reservation.RoomID)
+		if err != nil {
+			return err
+		}
+
+		err = t.Outbox.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			expired, err := t.ReservationsRepository.WithTx(tx).Decide(reservation.ID, models.ReservationExpired, nil, "")
+			if err != nil || !expired || room == nil {
+				return err
+			}
+			reservation.Status = models.ReservationExpired
+			return t.Outbox.Publish(tx, events.ForReservation(events.ReservationExpired, room, &reservation, nil))
+		})
+		if err != nil {
+			log.Printf("failed to expire reservation %d: %v", reservation.ID, err)
+		}
+	}
+	return nil
+}
+
+// PurgeFinishedJobs remove os jobs do outbox e as entregas de webhook que
+// terminaram há mais tempo que a retenção.
+func (t *Tasks) PurgeFinishedJobs(ctx context.Context) error {
+	cutoff := time.Now().Add(-t.JobRetention)
+
+	jobsPurged, err := t.MaintenanceRepository.WithContext(ctx).PurgeFinishedJobs(cutoff)
+	if err != nil {
+		return err
+	}
+	deliveriesPurged, err := t.MaintenanceRepository.WithContext(ctx).PurgeFinishedDeliveries(cutoff)
+	if err != nil {
+		return err
+	}
+
+	if jobsPurged > 0 || deliveriesPurged > 0 {
+		log.Printf("purged %d outbox jobs and %d webhook deliveries", jobsPurged, deliveriesPurged)
+	}
+	return nil
+}
+
+// ClearExpiredPasswordResets descarta os tokens de redefinição de senha
+// expirados, para que o hash não fique guardado sem servir para nada.
+func (t *Tasks) ClearExpiredPasswordResets(ctx context.Context) error {
+	cleared, err := t.MaintenanceRepository.WithContext(ctx).ClearExpiredPasswordResets(time.Now())
+	if err != nil {
+		return err
+	}
+	if cleared > 0 {
+		log.Printf("cleared %d expired password reset tokens", cleared)
+	}
+	return nil
+}
+
+// PurgeSoftDeleted remove definitivamente o que foi excluído há mais tempo
+// que a retenção.
+func (t *Tasks) PurgeSoftDeleted(ctx context.Context) error {
+	cutoff := time.Now().Add(-t.Retention)
+	keys, err := t.MaintenanceRepository.WithContext(ctx).DeletedAttachmentKeys(cutoff)
+	if err != nil {
+		return err
+	}
+
+	purged, err := t.MaintenanceRepository.WithContext(ctx).PurgeSoftDeleted(cutoff)
+	for table, count := range purged {
+		log.Printf("purged %d soft-deleted rows from %s", count, table)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Os blobs dos anexos removidos só saem do storage se nenhum outro anexo
+	// ainda os referencia.
+	storage.CollectOrphans(ctx, t.BlobStore, keys, t.AttachmentsRepository.WithContext(ctx).CollectOrphan)
+	return nil
+}
@@ -0,0 +1,74 @@
+package dtos
+
+type AdminUserResponse struct {
+	ID                    uint    `json:"id"`
+	Name                  string  `json:"name"`
+	Email                 string  `json:"email"`
+	Role                  string  `json:"role" enums:"user,admin"`
+	TimeZone              string  `json:"time_zone"`
+	NoShows               int     `json:"no_shows"`
+	SuspendedAt           *string `json:"suspended_at"`
+	SuspensionReason      string  `json:"suspension_reason,omitempty"`
+	PasswordResetRequired bool    `json:"password_reset_required"`
+	DeletionScheduledAt   *string `json:"deletion_scheduled_at"`
+	CreatedAt             string  `json:"created_at"`
+}
+
+type AdminUserListResponse struct {
+	Users []AdminUserResponse `json:"users"`
+	// Total é o número de usuários que atendem aos filtros, sem a paginação.
+	Total int64 `json:"total"`
+}
+
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" enums:"user,admin"`
+}
+
+type SuspendUserRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// PasswordResetResponse traz o token de redefinição, mostrado só uma vez,
+// para ser repassado ao usuário.
+type PasswordResetResponse struct {
+	ResetToken string `json:"reset_token"`
+	ExpiresAt  string `json:"expires_at"`
+}
+
+type SystemStatsResponse struct {
+	Users        UserStatsResponse        `json:"users"`
+	Rooms        RoomStatsResponse        `json:"rooms"`
+	Notes        NoteStatsResponse        `json:"notes"`
+	Reservations ReservationStatsResponse `json:"reservations"`
+	Jobs         JobStatsResponse         `json:"jobs"`
+	GeneratedAt  string                   `json:"generated_at"`
+}
+
+type UserStatsResponse struct {
+	Total            int64 `json:"total"`
+	Active           int64 `json:"active"`
+	Suspended        int64 `json:"suspended"`
+	Admins           int64 `json:"admins"`
+	PendingDeletions int64 `json:"pending_deletions"`
+}
+
+type RoomStatsResponse struct {
+	Total    int64 `json:"total"`
+	Archived int64 `json:"archived"`
+}
+
+type NoteStatsResponse struct {
+	Total        int64 `json:"total"`
+	Attachments  int64 `json:"attachments"`
+	StorageBytes int64 `json:"storage_bytes"`
+}
+
+type ReservationStatsResponse struct {
+	Total    int64 `json:"total"`
+	Upcoming int64 `json:"upcoming"`
+}
+
+type JobStatsResponse struct {
+	Pending int64 `json:"pending"`
+	Dead    int64 `json:"dead"`
+}
@@ -0,0 +1,12 @@
+package dtos
+
+type AttachmentResponse struct {
+	ID          uint   `json:"id"`
+	NoteID      uint   `json:"note_id"`
+	UserID      uint   `json:"user_id"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Checksum    string `json:"checksum"`
+	CreatedAt   string `json:"created_at"`
+}
@@ -0,0 +1,36 @@
+package dtos
+
+import "encoding/json"
+
+type AuditEntryResponse struct {
+	ID         uint   `json:"id"`
+	ActorID    *uint  `json:"actor_id"`
+	Action     string `json:"action"`
+	TargetType string `json:"target_type" enums:"user,room,note,organization"`
+	TargetID   uint   `json:"target_id"`
+	RoomID     *uint  `json:"room_id"`
+	// Before e After são fotografias do alvo antes e depois da ação.
+	Before json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After  json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	// IP e UserAgent só aparecem para os administradores.
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	RequestID string `json:"request_id"`
+	PrevHash  string `json:"prev_hash"`
+	Hash      string `json:"hash"`
+	CreatedAt string `json:"created_at"`
+}
+
+type AuditEntryListResponse struct {
+	Entries []AuditEntryResponse `json:"entries"`
+	// Total é o número de entradas que atendem aos filtros, sem a paginação.
+	Total int64 `json:"total"`
+}
+
+type AuditVerificationResponse struct {
+	Valid   bool  `json:"valid"`
+	Checked int64 `json:"checked"`
+	// BrokenAt é a primeira entrada cujo hash não confere.
+	BrokenAt *uint  `json:"broken_at"`
+	LastHash string `json:"last_hash"`
+}
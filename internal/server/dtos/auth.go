@@ -20,6 +20,17 @@ type AuthProfileResponse struct {
 	UserID    uint   `json:"user_id"`
 	Name      string `json:"name"`
 	Email     string `json:"email"`
+	Role      string `json:"role" enums:"user,admin"`
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
+	// DeletionScheduledAt indica a exclusão agendada da conta, que ainda
+	// pode ser desfeita.
+	DeletionScheduledAt *string `json:"deletion_scheduled_at"`
+}
+
+// ResetPasswordRequest define a nova senha com o token de redefinição
+// gerado por um administrador.
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
 }
@@ -0,0 +1,20 @@
+package dtos
+
+type CreateDataExportRequest struct {
+	Format string `json:"format,omitempty" enums:"zip,json"` // padrão zip
+}
+
+type DataExportResponse struct {
+	ID     uint   `json:"id"`
+	Format string `json:"format" enums:"zip,json"`
+	Status string `json:"status" enums:"pending,ready,downloaded,expired,failed"`
+	Size   int64  `json:"size"`
+	Error  string `json:"error,omitempty"`
+	// DownloadURL é o link de uso único, mostrado só na criação. Ele passa a
+	// funcionar quando a exportação fica pronta.
+	DownloadURL  string  `json:"download_url,omitempty"`
+	CreatedAt    string  `json:"created_at"`
+	ReadyAt      *string `json:"ready_at"`
+	ExpiresAt    *string `json:"expires_at"`
+	DownloadedAt *string `json:"downloaded_at"`
+}
@@ -0,0 +1,53 @@
+package dtos
+
+type CreateGroupRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type UpdateGroupRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type GroupResponse struct {
+	ID             uint   `json:"id"`
+	OrganizationID uint   `json:"organization_id"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	MemberCount    int64  `json:"member_count"`
+	CreatedBy      uint   `json:"created_by"`
+	CreatedAt      string `json:"created_at"`
+	// Members só vem na consulta de um grupo.
+	Members []GroupMemberResponse `json:"members,omitempty"`
+}
+
+type AddGroupMemberRequest struct {
+	UserID uint `json:"user_id"`
+}
+
+type GroupMemberResponse struct {
+	UserID   uint   `json:"user_id"`
+	UserName string `json:"user_name"`
+	Email    string `json:"email"`
+	AddedAt  string `json:"added_at"`
+}
+
+type CreateRoomGroupGrantRequest struct {
+	GroupID uint   `json:"group_id"`
+	Role    string `json:"role" enums:"member,admin"` // member se vazio
+}
+
+type UpdateRoomGroupGrantRequest struct {
+	Role string `json:"role" enums:"member,admin"`
+}
+
+type RoomGroupGrantResponse struct {
+	RoomID      uint   `json:"room_id"`
+	GroupID     uint   `json:"group_id"`
+	GroupName   string `json:"group_name"`
+	Role        string `json:"role" enums:"member,admin"`
+	MemberCount int64  `json:"member_count"`
+	GrantedBy   uint   `json:"granted_by"`
+	GrantedAt   string `json:"granted_at"`
+}
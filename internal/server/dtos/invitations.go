@@ -0,0 +1,36 @@
+package dtos
+
+type CreateInviteRequest struct {
+	Role    string `json:"role" enums:"member,admin"` // padrão member
+	MaxUses int    `json:"max_uses"`                  // 0 não limita
+	// ExpiresInHours define a validade do convite; 0 não expira.
+	ExpiresInHours int `json:"expires_in_hours"`
+}
+
+type InviteResponse struct {
+	ID        uint    `json:"id"`
+	RoomID    uint    `json:"room_id"`
+	Code      string  `json:"code"`
+	Role      string  `json:"role"`
+	MaxUses   int     `json:"max_uses"`
+	Uses      int     `json:"uses"`
+	ExpiresAt *string `json:"expires_at"`
+	CreatedBy uint    `json:"created_by"`
+	CreatedAt string  `json:"created_at"`
+}
+
+type CreateJoinRequestRequest struct {
+	Message string `json:"message"`
+}
+
+type JoinRequestResponse struct {
+	ID        uint    `json:"id"`
+	RoomID    uint    `json:"room_id"`
+	UserID    uint    `json:"user_id"`
+	UserName  string  `json:"user_name"`
+	Message   string  `json:"message"`
+	Status    string  `json:"status" enums:"pending,approved,denied"`
+	DecidedBy *uint   `json:"decided_by"`
+	DecidedAt *string `json:"decided_at"`
+	CreatedAt string  `json:"created_at"`
+}
@@ -0,0 +1,55 @@
+package dtos
+
+type SiteRequest struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+type SiteResponse struct {
+	ID        uint               `json:"id"`
+	Name      string             `json:"name"`
+	Address   string             `json:"address"`
+	CreatedBy uint               `json:"created_by"`
+	Buildings []BuildingResponse `json:"buildings,omitempty"`
+	CreatedAt string             `json:"created_at"`
+}
+
+type BuildingRequest struct {
+	SiteID uint   `json:"site_id"`
+	Name   string `json:"name"`
+}
+
+type BuildingResponse struct {
+	ID        uint            `json:"id"`
+	SiteID    uint            `json:"site_id"`
+	Name      string          `json:"name"`
+	CreatedBy uint            `json:"created_by"`
+	Floors    []FloorResponse `json:"floors,omitempty"`
+	CreatedAt string          `json:"created_at"`
+}
+
+type FloorRequest struct {
+	BuildingID uint   `json:"building_id"`
+	Name       string `json:"name"`
+	Level      int    `json:"level"`
+	PlanURL    string `json:"plan_url"`
+}
+
+type FloorResponse struct {
+	ID         uint   `json:"id"`
+	BuildingID uint   `json:"building_id"`
+	Name       string `json:"name"`
+	Level      int    `json:"level"`
+	PlanURL    string `json:"plan_url"`
+	CreatedBy  uint   `json:"created_by"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// UpdateRoomAttributesRequest substitui a localização e os recursos da sala.
+// floor_id nulo tira a sala da hierarquia.
+type UpdateRoomAttributesRequest struct {
+	FloorID   *uint    `json:"floor_id"`
+	PlanX     *float64 `json:"plan_x" minimum:"0" maximum:"1"`
+	PlanY     *float64 `json:"plan_y" minimum:"0" maximum:"1"`
+	Amenities []string `json:"amenities" enums:"projector,whiteboard,display,video_conference,speakerphone,wheelchair_access,hearing_loop,adjustable_desk"`
+}
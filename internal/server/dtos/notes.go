@@ -4,22 +4,35 @@ type CreateNoteRequest struct {
 	RoomID  uint   `json:"room_id"`
 	Title   string `json:"title"`
 	Content string `json:"content"`
+	Format  string `json:"format,omitempty" enums:"plain,markdown"`
 }
 
 type UpdateNoteRequest struct {
 	Title   string `json:"title"`
 	Content string `json:"content"`
+	Format  string `json:"format,omitempty" enums:"plain,markdown"`
 }
 
 type NoteResponse struct {
-	ID        uint   `json:"id"`
-	UserID    uint   `json:"user_id"`
-	RoomID    uint   `json:"room_id"`
-	Title     string `json:"title"`
-	Content   string `json:"content"`
-	UserName  string `json:"user_name,omitempty"`
-	UserEmail string `json:"user_email,omitempty"`
-	RoomName  string `json:"room_name,omitempty"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID          uint                  `json:"id"`
+	UserID      uint                  `json:"user_id"`
+	RoomID      uint                  `json:"room_id"`
+	Title       string                `json:"title"`
+	Content     string                `json:"content"`
+	Format      string                `json:"format"`
+	ContentHTML string                `json:"content_html"`
+	Excerpt     string                `json:"excerpt"`
+	TOC         []NoteHeadingResponse `json:"table_of_contents,omitempty"`
+	UserName    string                `json:"user_name,omitempty"`
+	UserEmail   string                `json:"user_email,omitempty"`
+	RoomName    string                `json:"room_name,omitempty"`
+	Attachments []AttachmentResponse  `json:"attachments,omitempty"`
+	CreatedAt   string                `json:"created_at"`
+	UpdatedAt   string                `json:"updated_at"`
+}
+
+type NoteHeadingResponse struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id"`
 }
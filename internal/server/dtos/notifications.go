@@ -0,0 +1,33 @@
+package dtos
+
+type NotificationResponse struct {
+	ID            uint    `json:"id"`
+	Type          string  `json:"type"`
+	Title         string  `json:"title"`
+	Message       string  `json:"message"`
+	ActorID       *uint   `json:"actor_id,omitempty"`
+	RoomID        *uint   `json:"room_id,omitempty"`
+	NoteID        *uint   `json:"note_id,omitempty"`
+	ReservationID *uint   `json:"reservation_id,omitempty"`
+	Read          bool    `json:"read"`
+	ReadAt        *string `json:"read_at,omitempty"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+type UnreadCountResponse struct {
+	Unread int64 `json:"unread"`
+}
+
+type NotificationPreferenceRequest struct {
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreferenceRequest `json:"preferences"`
+}
+
+type NotificationPreferenceResponse struct {
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+}
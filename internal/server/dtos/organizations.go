@@ -0,0 +1,45 @@
+package dtos
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name"`
+	// Slug identifica a organização no header X-Organization e no
+	// subdomínio: letras minúsculas, números e hífens.
+	Slug string `json:"slug"`
+}
+
+type UpdateOrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+type OrganizationResponse struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	// Role é o papel de quem consulta na organização; vazio para os
+	// administradores globais que não participam dela.
+	Role      string `json:"role" enums:"owner,admin,member"`
+	CreatedAt string `json:"created_at"`
+}
+
+type AddOrganizationMemberRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role" enums:"owner,admin,member"` // member se vazio
+}
+
+type UpdateOrganizationMemberRequest struct {
+	Role string `json:"role" enums:"owner,admin,member"`
+}
+
+type OrganizationMemberResponse struct {
+	UserID   uint   `json:"user_id"`
+	UserName string `json:"user_name"`
+	Email    string `json:"email"`
+	Role     string `json:"role" enums:"owner,admin,member"`
+	JoinedAt string `json:"joined_at"`
+}
+
+type SwitchOrganizationResponse struct {
+	// Token é um novo JWT com a organização como ativa.
+	Token        string               `json:"token"`
+	Organization OrganizationResponse `json:"organization"`
+}
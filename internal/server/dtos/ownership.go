@@ -0,0 +1,17 @@
+package dtos
+
+type CreateOwnershipTransferRequest struct {
+	UserID uint `json:"user_id"` // membro que vai receber a sala
+}
+
+type OwnershipTransferResponse struct {
+	ID           uint    `json:"id"`
+	RoomID       uint    `json:"room_id"`
+	FromUserID   uint    `json:"from_user_id"`
+	FromUserName string  `json:"from_user_name"`
+	ToUserID     uint    `json:"to_user_id"`
+	ToUserName   string  `json:"to_user_name"`
+	Status       string  `json:"status" enums:"pending,accepted,declined,cancelled"`
+	DecidedAt    *string `json:"decided_at"`
+	CreatedAt    string  `json:"created_at"`
+}
@@ -0,0 +1,50 @@
+package dtos
+
+import "time"
+
+type OpeningHours struct {
+	Weekday int    `json:"weekday" minimum:"0" maximum:"6"`
+	Open    string `json:"open" example:"08:00"`
+	Close   string `json:"close" example:"18:00"`
+}
+
+// BookingPolicy são as regras de reserva da sala. Valores zero significam
+// "sem limite" e, sem horários de funcionamento, a sala pode ser reservada a
+// qualquer hora.
+type BookingPolicy struct {
+	OpeningHours          []OpeningHours `json:"opening_hours"`
+	MinDurationMinutes    int            `json:"min_duration_minutes"`
+	MaxDurationMinutes    int            `json:"max_duration_minutes"`
+	MinNoticeMinutes      int            `json:"min_notice_minutes"`
+	MaxAdvanceDays        int            `json:"max_advance_days"`
+	BufferMinutes         int            `json:"buffer_minutes"`
+	MaxHoursPerWeek       int            `json:"max_hours_per_week"`
+	MaxActiveReservations int            `json:"max_active_reservations"`
+}
+
+type CreateBlackoutRequest struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Reason    string    `json:"reason"`
+}
+
+type BlackoutResponse struct {
+	ID        uint   `json:"id"`
+	RoomID    uint   `json:"room_id"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Reason    string `json:"reason"`
+	CreatedBy uint   `json:"created_by"`
+}
+
+type PolicyViolation struct {
+	Rule    string `json:"rule" enums:"opening_hours,blackout,min_duration,max_duration,min_notice,max_advance,max_hours_per_week,max_active_reservations"`
+	Message string `json:"message"`
+}
+
+// PolicyErrorResponse é devolvida quando a reserva viola as regras da sala.
+type PolicyErrorResponse struct {
+	Message    string            `json:"message"`
+	Status     int               `json:"status"`
+	Violations []PolicyViolation `json:"violations"`
+}
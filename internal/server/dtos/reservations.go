@@ -0,0 +1,44 @@
+package dtos
+
+// Os horários aceitam RFC 3339 ou horário de parede sem fuso, interpretado
+// em time_zone, no fuso preferido do usuário ou no fuso da sala, nesta ordem.
+type CreateReservationRequest struct {
+	RoomID    uint      `json:"room_id"`
+	StartTime LocalTime `json:"start_time" swaggertype:"string" example:"2026-03-09T09:00:00"`
+	EndTime   LocalTime `json:"end_time" swaggertype:"string" example:"2026-03-09T10:30:00"`
+	TimeZone  string    `json:"time_zone,omitempty" example:"America/Sao_Paulo"`
+}
+
+type UpdateReservationRequest struct {
+	StartTime LocalTime `json:"start_time" swaggertype:"string" example:"2026-03-09T09:00:00"`
+	EndTime   LocalTime `json:"end_time" swaggertype:"string" example:"2026-03-09T10:30:00"`
+	TimeZone  string    `json:"time_zone,omitempty" example:"America/Sao_Paulo"`
+}
+
+type ReservationResponse struct {
+	ID          uint    `json:"id"`
+	UserID      uint    `json:"user_id"`
+	RoomID      uint    `json:"room_id"`
+	StartTime   string  `json:"start_time"` // UTC
+	EndTime     string  `json:"end_time"`   // UTC
+	TimeZone    string  `json:"time_zone"`  // fuso de local_start_time e local_end_time
+	LocalStart  string  `json:"local_start_time"`
+	LocalEnd    string  `json:"local_end_time"`
+	Status      string  `json:"status" enums:"pending,approved,rejected,expired,held"`
+	DecidedBy   *uint   `json:"decided_by"`
+	DecidedAt   *string `json:"decided_at"`
+	Reason      string  `json:"decision_reason,omitempty"`
+	CheckedInAt *string `json:"checked_in_at"`
+	CreatedAt   string  `json:"created_at"`
+	UpdatedAt   string  `json:"updated_at"`
+}
+
+// ReservationDecisionRequest acompanha a aprovação ou recusa de uma reserva.
+type ReservationDecisionRequest struct {
+	Reason string `json:"reason"`
+}
+
+type NoShowsResponse struct {
+	UserID  uint `json:"user_id"`
+	NoShows int  `json:"no_shows"`
+}
@@ -5,19 +5,31 @@ type CreateRoomRequest struct {
 	Description string `json:"description"`
 	Subject     string `json:"subject"`
 	Capacity    int    `json:"capacity"`
+	TimeZone    string `json:"time_zone,omitempty" example:"America/Sao_Paulo"`      // padrão UTC
+	Visibility  string `json:"visibility,omitempty" enums:"public,unlisted,private"` // padrão public
 }
 
 type RoomResponse struct {
-	ID          uint              `json:"id"`
-	Name        string            `json:"name"`
-	Description string            `json:"description"`
-	Subject     string            `json:"subject"`
-	Capacity    int               `json:"capacity"`
-	CreatedBy   uint              `json:"created_by"`
-	Members     []RoomMemberResponse `json:"members,omitempty"`
-	Notes       []NoteResponse    `json:"notes,omitempty"`
-	CreatedAt   string            `json:"created_at"`
-	UpdatedAt   string            `json:"updated_at"`
+	ID               uint                 `json:"id"`
+	Name             string               `json:"name"`
+	Description      string               `json:"description"`
+	Subject          string               `json:"subject"`
+	Capacity         int                  `json:"capacity"`
+	CreatedBy        uint                 `json:"created_by"`
+	OrganizationID   uint                 `json:"organization_id"`
+	TimeZone         string               `json:"time_zone"`
+	Visibility       string               `json:"visibility" enums:"public,unlisted,private"`
+	CheckInRequired  bool                 `json:"check_in_required"`
+	RequiresApproval bool                 `json:"requires_approval"`
+	FloorID          *uint                `json:"floor_id"`
+	PlanX            *float64             `json:"plan_x"`
+	PlanY            *float64             `json:"plan_y"`
+	Amenities        []string             `json:"amenities"`
+	ArchivedAt       *string              `json:"archived_at"`
+	Members          []RoomMemberResponse `json:"members,omitempty"`
+	Notes            []NoteResponse       `json:"notes,omitempty"`
+	CreatedAt        string               `json:"created_at"`
+	UpdatedAt        string               `json:"updated_at"`
 }
 
 type UpdateRoomRequest struct {
@@ -27,14 +39,32 @@ type UpdateRoomRequest struct {
 	Capacity    int    `json:"capacity"`
 }
 
+// UpdateRoomSettingsRequest altera as configurações de reserva da sala.
+// Campos omitidos mantêm o valor atual.
+type UpdateRoomSettingsRequest struct {
+	CheckInRequired  *bool   `json:"check_in_required,omitempty"`
+	RequiresApproval *bool   `json:"requires_approval,omitempty"`
+	TimeZone         *string `json:"time_zone,omitempty" example:"America/Sao_Paulo"`
+	Visibility       *string `json:"visibility,omitempty" enums:"public,unlisted,private"`
+}
+
 type JoinRoomRequest struct {
 	RoomID uint `json:"room_id"`
 }
 
+type UpdateMemberRoleRequest struct {
+	Role string `json:"role" enums:"member,admin"`
+}
+
 type RoomMemberResponse struct {
 	UserID    uint   `json:"user_id"`
 	UserName  string `json:"user_name"`
 	UserEmail string `json:"user_email"`
-	Role      string `json:"role"`
-	JoinedAt  string `json:"joined_at"`
+	// Role é o papel efetivo, o maior entre o direto e os dos grupos.
+	Role string `json:"role"`
+	// Direct informa se o usuário entrou diretamente na sala; quem só
+	// participa por grupos não tem JoinedAt.
+	Direct   bool   `json:"direct"`
+	GroupIDs []uint `json:"group_ids,omitempty"`
+	JoinedAt string `json:"joined_at,omitempty"`
 }
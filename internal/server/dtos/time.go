@@ -0,0 +1,79 @@
+package dtos
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Formatos aceitos para horários sem fuso.
+var wallTimeLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+// LocalTime é um horário de entrada com fuso (RFC 3339) ou sem ele
+// ("2006-01-02T15:04:05"). Sem fuso, é um horário de parede, resolvido com
+// Resolve no fuso da reserva.
+type LocalTime struct {
+	value    time.Time
+	floating bool
+}
+
+func (t *LocalTime) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if value == "" {
+		*t = LocalTime{}
+		return nil
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		*t = LocalTime{value: parsed}
+		return nil
+	}
+	for _, layout := range wallTimeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			*t = LocalTime{value: parsed, floating: true}
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid time %q, expected RFC 3339 or 2006-01-02T15:04:05", value)
+}
+
+func (t LocalTime) IsZero() bool {
+	return t.value.IsZero()
+}
+
+// Resolve retorna o instante do horário. Horários de parede são
+// interpretados em loc; ok é false se o horário não existe nele, como no
+// salto do horário de verão. Nos horários repetidos, quando o relógio volta,
+// vale o primeiro.
+func (t LocalTime) Resolve(loc *time.Location) (resolved time.Time, ok bool) {
+	if !t.floating || t.value.IsZero() {
+		return t.value, true
+	}
+
+	v := t.value
+	resolved = time.Date(v.Year(), v.Month(), v.Day(), v.Hour(), v.Minute(), v.Second(), v.Nanosecond(), loc)
+	if resolved.Hour() != v.Hour() || resolved.Minute() != v.Minute() {
+		return resolved, false
+	}
+
+	// O time.Date não garante qual das leituras de um horário repetido ele
+	// escolhe. Os deslocamentos em vigor um dia antes e um dia depois dão as
+	// duas leituras possíveis; fica a mais cedo que mostre o mesmo horário.
+	_, offset := resolved.Zone()
+	for _, probe := range []time.Time{resolved.AddDate(0, 0, -1), resolved.AddDate(0, 0, 1)} {
+		_, other := probe.Zone()
+		candidate := resolved.Add(time.Duration(offset-other) * time.Second)
+		if candidate.Before(resolved) && sameWallTime(candidate.In(loc), v) {
+			resolved = candidate
+		}
+	}
+	return resolved, true
+}
+
+func sameWallTime(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay() &&
+		a.Hour() == b.Hour() && a.Minute() == b.Minute() && a.Second() == b.Second()
+}
@@ -0,0 +1,95 @@
+package dtos
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestLocalTimeResolve(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		input  string
+		loc    *time.Location
+		want   time.Time
+		wantOK bool
+	}{
+		{
+			name:   "wall time",
+			input:  "2023-06-01T09:30:00",
+			loc:    berlin,
+			want:   time.Date(2023, time.June, 1, 7, 30, 0, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			name:   "explicit offset ignores the location",
+			input:  "2023-06-01T09:30:00Z",
+			loc:    berlin,
+			want:   time.Date(2023, time.June, 1, 9, 30, 0, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			// Em 26/03/2023 os relógios de Berlim pularam de 02:00 para 03:00.
+			name:  "berlin spring forward gap",
+			input: "2023-03-26T02:30",
+			loc:   berlin,
+		},
+		{
+			// Em 29/10/2023 as 02:30 de Berlim aconteceram duas vezes; a
+			// primeira ainda no horário de verão (+02:00).
+			name:   "berlin fall back",
+			input:  "2023-10-29T02:30",
+			loc:    berlin,
+			want:   time.Date(2023, time.October, 29, 0, 30, 0, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			name:   "berlin after fall back",
+			input:  "2023-10-29T03:30",
+			loc:    berlin,
+			want:   time.Date(2023, time.October, 29, 2, 30, 0, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			// Em 04/11/2018 a meia-noite de São Paulo virou 01:00.
+			name:  "sao paulo spring forward gap",
+			input: "2018-11-04T00:30:00",
+			loc:   saoPaulo,
+		},
+		{
+			// Em 17/02/2019 a meia-noite de São Paulo voltou para as 23:00
+			// do dia 16; a primeira 23:30 ainda foi no horário de verão (-02:00).
+			name:   "sao paulo fall back",
+			input:  "2019-02-16T23:30:00",
+			loc:    saoPaulo,
+			want:   time.Date(2019, time.February, 17, 1, 30, 0, 0, time.UTC),
+			wantOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value LocalTime
+			if err := json.Unmarshal([]byte(`"`+tt.input+`"`), &value); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			got, ok := value.Resolve(tt.loc)
+			if ok != tt.wantOK {
+				t.Fatalf("Resolve() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !got.Equal(tt.want) {
+				t.Errorf("Resolve() = %s, want %s", got.UTC(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package dtos
+
+type TrashResponse struct {
+	Rooms []TrashedRoomResponse `json:"rooms"`
+	Notes []TrashedNoteResponse `json:"notes"`
+}
+
+type TrashedRoomResponse struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Subject   string `json:"subject"`
+	DeletedAt string `json:"deleted_at"`
+	PurgeAt   string `json:"purge_at"` // quando será removida de vez
+}
+
+type TrashedNoteResponse struct {
+	ID          uint   `json:"id"`
+	RoomID      uint   `json:"room_id"`
+	RoomName    string `json:"room_name"`
+	RoomDeleted bool   `json:"room_deleted"` // a sala precisa ser restaurada antes da nota
+	Title       string `json:"title"`
+	DeletedAt   string `json:"deleted_at"`
+	PurgeAt     string `json:"purge_at"`
+}
@@ -7,9 +7,10 @@ type CreateUserRequest struct {
 }
 
 type UserResponse struct {
-	ID    uint   `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	TimeZone string `json:"time_zone"`
 }
 
 type UserListResponse struct {
@@ -20,4 +21,17 @@ type UpdateUserRequest struct {
 	Name     string `json:"name,omitempty"`
 	Email    string `json:"email,omitempty"`
 	Password string `json:"password,omitempty"`
+	// TimeZone é o fuso IANA preferido; "" volta a seguir o fuso da sala.
+	TimeZone *string `json:"time_zone,omitempty" example:"America/Sao_Paulo"`
+}
+
+// DeleteUserRequest escolhe o que acontece com as notas do usuário quando a
+// exclusão for concluída. Omitido usa o padrão do servidor.
+type DeleteUserRequest struct {
+	Content string `json:"content,omitempty" enums:"anonymize,delete"`
+}
+
+type AccountDeletionResponse struct {
+	DeletionScheduledAt string `json:"deletion_scheduled_at"`
+	Content             string `json:"content" enums:"anonymize,delete"`
 }
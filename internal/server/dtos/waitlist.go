@@ -0,0 +1,24 @@
+package dtos
+
+// Os horários seguem as mesmas regras de fuso de CreateReservationRequest.
+type JoinWaitlistRequest struct {
+	RoomID    uint      `json:"room_id"`
+	StartTime LocalTime `json:"start_time" swaggertype:"string" example:"2026-03-09T09:00:00"`
+	EndTime   LocalTime `json:"end_time" swaggertype:"string" example:"2026-03-09T10:30:00"`
+	TimeZone  string    `json:"time_zone,omitempty" example:"America/Sao_Paulo"`
+}
+
+type WaitlistEntryResponse struct {
+	ID             uint    `json:"id"`
+	UserID         uint    `json:"user_id"`
+	RoomID         uint    `json:"room_id"`
+	StartTime      string  `json:"start_time"` // UTC
+	EndTime        string  `json:"end_time"`   // UTC
+	TimeZone       string  `json:"time_zone"`
+	LocalStart     string  `json:"local_start_time"`
+	LocalEnd       string  `json:"local_end_time"`
+	Status         string  `json:"status" enums:"waiting,offered,accepted,expired,cancelled"`
+	ReservationID  *uint   `json:"reservation_id"`
+	OfferExpiresAt *string `json:"offer_expires_at"`
+	CreatedAt      string  `json:"created_at"`
+}
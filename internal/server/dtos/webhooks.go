@@ -0,0 +1,41 @@
+package dtos
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" example:"https://example.com/hooks/rooms"`
+	RoomID *uint    `json:"room_id,omitempty"` // sem sala, recebe os eventos de todas as salas do usuário
+	Events []string `json:"events,omitempty"`  // vazio recebe todos os eventos
+	Secret string   `json:"secret,omitempty"`  // gerado se não for informado
+}
+
+type UpdateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+type WebhookResponse struct {
+	ID        uint     `json:"id"`
+	UserID    uint     `json:"user_id"`
+	RoomID    *uint    `json:"room_id"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	Active    bool     `json:"active"`
+	Secret    string   `json:"secret,omitempty"` // só retornado na criação
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             uint    `json:"id"`
+	WebhookID      uint    `json:"webhook_id"`
+	EventID        string  `json:"event_id"`
+	EventType      string  `json:"event_type"`
+	Payload        string  `json:"payload"`
+	Status         string  `json:"status" enums:"pending,succeeded,dead"`
+	Attempts       int     `json:"attempts"`
+	NextAttemptAt  *string `json:"next_attempt_at"`
+	LastAttemptAt  *string `json:"last_attempt_at"`
+	ResponseStatus int     `json:"response_status,omitempty"`
+	LastError      string  `json:"last_error,omitempty"`
+	CreatedAt      string  `json:"created_at"`
+}
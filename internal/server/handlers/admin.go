@@ -0,0 +1,762 @@
+package handlers
+
+import (
+	"api-go/internal/audit"
+	"api-go/internal/events"
+	"api-go/internal/jobs"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/server/dtos"
+	"api-go/internal/server/middlewares"
+	"api-go/internal/storage"
+	"api-go/internal/utils"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAdminUsersLimit = 50
+	maxAdminUsersLimit     = 200
+)
+
+// errUserUnchanged desfaz a transação quando a atualização condicional do
+// usuário não encontra nada a mudar.
+var errUserUnchanged = errors.New("user unchanged")
+
+// AdminHandler expõe a moderação e a administração do sistema, restritas aos
+// administradores globais.
+type AdminHandler struct {
+	UserRepository        *repository.UserRepository
+	RoomsRepository       *repository.RoomsRepository
+	NotesRepository       *repository.NotesRepository
+	TrashRepository       *repository.TrashRepository
+	AttachmentsRepository *repository.AttachmentsRepository
+	AdminRepository       *repository.AdminRepository
+	BlobStore             storage.BlobStore
+	Outbox                *jobs.Outbox
+	Audit                 *audit.Log
+
+	// PasswordResetTTL é por quanto tempo o token de redefinição de senha
+	// vale.
+	PasswordResetTTL time.Duration
+}
+
+func (ah *AdminHandler) RegisterAdminRoutes(r chi.Router) {
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(middlewares.RequireAdmin)
+
+		r.Get("/users", ah.GetUsersHandler)
+		r.Get("/users/{user_id}", ah.GetUserHandler)
+		r.Put("/users/{user_id}/role", ah.UpdateUserRoleHandler)
+		r.Post("/users/{user_id}/suspend", ah.SuspendUserHandler)
+		r.Post("/users/{user_id}/reactivate", ah.ReactivateUserHandler)
+		r.Post("/users/{user_id}/password-reset", ah.ForcePasswordResetHandler)
+		r.Get("/notes", ah.GetNotesHandler)
+		r.Delete("/notes/{note_id}", ah.DeleteNoteHandler)
+		r.Delete("/rooms/{room_id}", ah.DeleteRoomHandler)
+		r.Get("/stats", ah.GetStatsHandler)
+	})
+}
+
+// GetUsersHandler lists and searches users
+//
+//	@Summary		List users
+//	@Description	List accounts, newest first, searching by name or email (admin only). Deleted accounts are not listed.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			q		query		string	false	"Text searched in name and email"
+//	@Param			status	query		string	false	"Account status"	Enums(active, suspended)
+//	@Param			role	query		string	false	"Global role"		Enums(user, admin)
+//	@Param			limit	query		int		false	"Maximum number of users (default 50, max 200)"
+//	@Param			offset	query		int		false	"Number of users to skip"
+//	@Success		200		{object}	dtos.AdminUserListResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/users [get]
+func (ah *AdminHandler) GetUsersHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := repository.UserFilter{
+		Query:  strings.TrimSpace(query.Get("q")),
+		Status: query.Get("status"),
+		Role:   query.Get("role"),
+		Limit:  defaultAdminUsersLimit,
+	}
+	if filter.Status != "" && filter.Status != "active" && filter.Status != "suspended" {
+		utils.RespondWithError(w, http.StatusBadRequest, "status must be active or suspended")
+		return
+	}
+	if filter.Role != "" && !models.IsValidUserRole(filter.Role) {
+		utils.RespondWithError(w, http.StatusBadRequest, "role must be user or admin")
+		return
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		filter.Limit = min(parsed, maxAdminUsersLimit)
+	}
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		parsed, err := strconv.Atoi(offsetStr)
+		if err != nil || parsed < 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid offset")
+			return
+		}
+		filter.Offset = parsed
+	}
+
+	users, total, err := ah.UserRepository.Search(filter)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get users")
+		return
+	}
+
+	response := dtos.AdminUserListResponse{
+		Users: make([]dtos.AdminUserResponse, len(users)),
+		Total: total,
+	}
+	for i, user := range users {
+		response.Users[i] = toAdminUserResponse(user)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetUserHandler gets a user
+//
+//	@Summary		Get user
+//	@Description	Get an account with its role and status (admin only)
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			user_id	path		int	true	"User ID"
+//	@Success		200		{object}	dtos.AdminUserResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/users/{user_id} [get]
+func (ah *AdminHandler) GetUserHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := ah.loadUser(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toAdminUserResponse(*user))
+}
+
+// UpdateUserRoleHandler changes a user's global role
+//
+//	@Summary		Update user role
+//	@Description	Promote a user to admin or demote an admin (admin only). Admins cannot demote themselves. The change applies on the user's next sign-in; a demoted admin's sessions stop working immediately.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			user_id	path		int							true	"User ID"
+//	@Param			request	body		dtos.UpdateUserRoleRequest	true	"New role"
+//	@Success		200		{object}	dtos.AdminUserResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/users/{user_id}/role [put]
+func (ah *AdminHandler) UpdateUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middlewares.GetUserFromContext(r.Context())
+
+	var req dtos.UpdateUserRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if !models.IsValidUserRole(req.Role) {
+		utils.RespondWithError(w, http.StatusBadRequest, "role must be user or admin")
+		return
+	}
+
+	user, ok := ah.loadUser(w, r)
+	if !ok {
+		return
+	}
+	if user.ID == claims.UserID && req.Role != models.UserRoleAdmin {
+		utils.RespondWithError(w, http.StatusConflict, "You cannot remove your own admin role")
+		return
+	}
+
+	entry := audit.Entry{
+		Action:     audit.ActionUserRoleChanged,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID,
+		Before:     map[string]string{"role": user.Role},
+		After:      map[string]string{"role": req.Role},
+	}
+	err := audited(ah.Outbox, ah.Audit, r, entry, func(tx *gorm.DB) error {
+		if err := ah.UserRepository.WithTx(tx).SetRole(user.ID, req.Role); err != nil {
+			return err
+		}
+		if user.Role != models.UserRoleAdmin || req.Role == models.UserRoleAdmin {
+			return nil
+		}
+		// Os tokens de administrador deixam de valer.
+		return ah.Outbox.Publish(tx, events.Event{
+			Type:    events.UserSessionsRevoked,
+			ActorID: claims.UserID,
+			UserID:  user.ID,
+		})
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update role")
+		return
+	}
+	user.Role = req.Role
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toAdminUserResponse(*user))
+}
+
+// SuspendUserHandler suspends a user
+//
+//	@Summary		Suspend user
+//	@Description	Suspend an account (admin only). A suspended user cannot sign in and their current sessions stop working until the account is reactivated. Admins cannot suspend themselves.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			user_id	path		int						true	"User ID"
+//	@Param			request	body		dtos.SuspendUserRequest	false	"Reason for the suspension"
+//	@Success		200		{object}	dtos.AdminUserResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/users/{user_id}/suspend [post]
+func (ah *AdminHandler) SuspendUserHandler(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middlewares.GetUserFromContext(r.Context())
+
+	var req dtos.SuspendUserRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+	}
+
+	user, ok := ah.loadUser(w, r)
+	if !ok {
+		return
+	}
+	if user.ID == claims.UserID {
+		utils.RespondWithError(w, http.StatusConflict, "You cannot suspend your own account")
+		return
+	}
+
+	now := time.Now()
+	entry := audit.Entry{
+		Action:     audit.ActionUserSuspended,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID,
+		After:      map[string]string{"reason": strings.TrimSpace(req.Reason)},
+	}
+	err := audited(ah.Outbox, ah.Audit, r, entry, func(tx *gorm.DB) error {
+		suspended, err := ah.UserRepository.WithTx(tx).Suspend(user.ID, strings.TrimSpace(req.Reason), now)
+		if err == nil && !suspended {
+			return errUserUnchanged
+		}
+		if err != nil {
+			return err
+		}
+		return ah.Outbox.Publish(tx, events.Event{
+			Type:    events.UserSuspended,
+			ActorID: claims.UserID,
+			UserID:  user.ID,
+		})
+	})
+	if errors.Is(err, errUserUnchanged) {
+		utils.RespondWithError(w, http.StatusConflict, "User is already suspended")
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to suspend user")
+		return
+	}
+	user.SuspendedAt = &now
+	user.SuspensionReason = strings.TrimSpace(req.Reason)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toAdminUserResponse(*user))
+}
+
+// ReactivateUserHandler lifts a suspension
+//
+//	@Summary		Reactivate user
+//	@Description	Lift the suspension of an account (admin only)
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			user_id	path		int	true	"User ID"
+//	@Success		200		{object}	dtos.AdminUserResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/users/{user_id}/reactivate [post]
+func (ah *AdminHandler) ReactivateUserHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := ah.loadUser(w, r)
+	if !ok {
+		return
+	}
+
+	entry := audit.Entry{
+		Action:     audit.ActionUserReactivated,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID,
+		Before:     map[string]string{"reason": user.SuspensionReason},
+	}
+	err := audited(ah.Outbox, ah.Audit, r, entry, func(tx *gorm.DB) error {
+		reactivated, err := ah.UserRepository.WithTx(tx).Reactivate(user.ID)
+		if err == nil && !reactivated {
+			return errUserUnchanged
+		}
+		return err
+	})
+	if errors.Is(err, errUserUnchanged) {
+		utils.RespondWithError(w, http.StatusConflict, "User is not suspended")
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to reactivate user")
+		return
+	}
+	user.SuspendedAt = nil
+	user.SuspensionReason = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toAdminUserResponse(*user))
+}
+
+// ForcePasswordResetHandler forces a user to choose a new password
+//
+//	@Summary		Force password reset
+//	@Description	Sign the user out everywhere and block sign-in until a new password is set with POST /auth/password-reset (admin only). The returned reset_token is shown only once and must be handed to the user; requesting a new one invalidates the previous token.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			user_id	path		int	true	"User ID"
+//	@Success		200		{object}	dtos.PasswordResetResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/users/{user_id}/password-reset [post]
+func (ah *AdminHandler) ForcePasswordResetHandler(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middlewares.GetUserFromContext(r.Context())
+
+	user, ok := ah.loadUser(w, r)
+	if !ok {
+		return
+	}
+
+	token, err := newPasswordResetToken()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to generate reset token")
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ah.PasswordResetTTL)
+	entry := audit.Entry{
+		Action:     audit.ActionPasswordResetRequired,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID,
+	}
+	err = audited(ah.Outbox, ah.Audit, r, entry, func(tx *gorm.DB) error {
+		updated, err := ah.UserRepository.WithTx(tx).RequirePasswordReset(user.ID, hashPasswordResetToken(token), expiresAt, now)
+		if err == nil && !updated {
+			return errUserUnchanged
+		}
+		if err != nil {
+			return err
+		}
+		return ah.Outbox.Publish(tx, events.Event{
+			Type:    events.UserSessionsRevoked,
+			ActorID: claims.UserID,
+			UserID:  user.ID,
+		})
+	})
+	if errors.Is(err, errUserUnchanged) {
+		utils.RespondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dtos.PasswordResetResponse{
+		ResetToken: token,
+		ExpiresAt:  expiresAt.Format("2006-01-02T15:04:05Z07:00"),
+	})
+}
+
+// GetNotesHandler lists every note
+//
+//	@Summary		List all notes
+//	@Description	List the notes of every room with their authors (admin only)
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		dtos.NoteResponse
+//	@Failure		403	{object}	dtos.ErrorResponse
+//	@Failure		500	{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/notes [get]
+func (ah *AdminHandler) GetNotesHandler(w http.ResponseWriter, r *http.Request) {
+	notes, err := ah.NotesRepository.GetAll()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get notes")
+		return
+	}
+
+	response := make([]dtos.NoteResponse, len(notes))
+	for i, note := range notes {
+		response[i] = dtos.NoteResponse{
+			ID:          note.ID,
+			UserID:      note.UserID,
+			RoomID:      note.RoomID,
+			Title:       note.Title,
+			Content:     note.Content,
+			Format:      note.Format,
+			ContentHTML: note.ContentHTML,
+			Excerpt:     note.Excerpt,
+			TOC:         toNoteHeadingsResponse(note.TableOfContents),
+			UserName:    note.User.Name,
+			UserEmail:   note.User.Email,
+			RoomName:    note.Room.Name,
+			CreatedAt:   note.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt:   note.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteNoteHandler deletes any note
+//
+//	@Summary		Delete any note
+//	@Description	Move any note to the trash with its attachments (admin only), even in archived rooms. With purge=true the note is removed for good instead, including from the trash, so its author cannot restore it.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			note_id	path		int		true	"Note ID"
+//	@Param			purge	query		bool	false	"Remove the note for good"
+//	@Success		200		{object}	map[string]string
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/notes/{note_id} [delete]
+func (ah *AdminHandler) DeleteNoteHandler(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middlewares.GetUserFromContext(r.Context())
+	purge := r.URL.Query().Get("purge") == "true"
+
+	noteID, err := strconv.ParseUint(chi.URLParam(r, "note_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid note ID")
+		return
+	}
+
+	note, err := ah.NotesRepository.GetByID(uint(noteID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get note")
+		return
+	}
+	active := note != nil
+	if note == nil && purge {
+		note, err = ah.TrashRepository.GetDeletedNote(uint(noteID))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get note")
+			return
+		}
+	}
+	if note == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Note not found")
+		return
+	}
+
+	var keys []string
+	err = ah.Outbox.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if purge {
+			keys, err = ah.TrashRepository.WithTx(tx).PurgeNote(note.ID)
+		} else {
+			err = ah.NotesRepository.WithTx(tx).Delete(note.ID)
+		}
+		if err != nil {
+			return err
+		}
+		action := audit.ActionNoteDeleted
+		if purge {
+			action = audit.ActionNotePurged
+		}
+		err = ah.Audit.Record(tx, auditMeta(r), audit.Entry{
+			Action:     action,
+			TargetType: models.AuditTargetNote,
+			TargetID:   note.ID,
+			RoomID:     note.RoomID,
+			Before:     noteAuditSnapshot(*note),
+		})
+		if err != nil || !active {
+			return err
+		}
+		return ah.Outbox.Publish(tx, events.Event{
+			Type:    events.NoteDeleted,
+			ActorID: claims.UserID,
+			RoomID:  note.RoomID,
+			NoteID:  note.ID,
+			Data: map[string]any{
+				"actor_name": claims.Name,
+				"note_title": note.Title,
+			},
+		})
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete note")
+		return
+	}
+
+	if purge {
+		collectOrphanBlobs(r.Context(), ah.AttachmentsRepository, ah.BlobStore, keys)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Note purged successfully"}`))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Note deleted successfully"}`))
+}
+
+// DeleteRoomHandler deletes any room
+//
+//	@Summary		Delete any room
+//	@Description	Move any room to the trash with its notes and memberships (admin only). With purge=true the room and everything in it are removed for good instead, including from the trash, so its owner cannot restore it.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int		true	"Room ID"
+//	@Param			purge	query		bool	false	"Remove the room for good"
+//	@Success		200		{object}	map[string]string
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/rooms/{room_id} [delete]
+func (ah *AdminHandler) DeleteRoomHandler(w http.ResponseWriter, r *http.Request) {
+	purge := r.URL.Query().Get("purge") == "true"
+
+	roomID, err := strconv.ParseUint(chi.URLParam(r, "room_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid room ID")
+		return
+	}
+
+	room, err := ah.RoomsRepository.FindByID(uint(roomID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return
+	}
+	if room == nil && purge {
+		room, err = ah.TrashRepository.GetDeletedRoom(uint(roomID))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+			return
+		}
+	}
+	if room == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found")
+		return
+	}
+
+	entry := audit.Entry{
+		Action:     audit.ActionRoomDeleted,
+		TargetType: models.AuditTargetRoom,
+		TargetID:   room.ID,
+		RoomID:     room.ID,
+		Before:     toRoomResponse(*room),
+	}
+
+	if purge {
+		var keys []string
+		entry.Action = audit.ActionRoomPurged
+		err := audited(ah.Outbox, ah.Audit, r, entry, func(tx *gorm.DB) error {
+			var err error
+			keys, err = ah.TrashRepository.WithTx(tx).PurgeRoom(room.ID)
+			return err
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to purge room")
+			return
+		}
+		collectOrphanBlobs(r.Context(), ah.AttachmentsRepository, ah.BlobStore, keys)
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Room purged successfully"}`))
+		return
+	}
+
+	err = audited(ah.Outbox, ah.Audit, r, entry, func(tx *gorm.DB) error {
+		return ah.RoomsRepository.WithTx(tx).Delete(room.ID)
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete room")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Room deleted successfully"}`))
+}
+
+// GetStatsHandler gets system statistics
+//
+//	@Summary		Get system statistics
+//	@Description	Count users, rooms, notes, reservations and background jobs (admin only). Deleted rows are not counted.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	dtos.SystemStatsResponse
+//	@Failure		403	{object}	dtos.ErrorResponse
+//	@Failure		500	{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/stats [get]
+func (ah *AdminHandler) GetStatsHandler(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	stats, err := ah.AdminRepository.GetStats(now)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get statistics")
+		return
+	}
+
+	response := dtos.SystemStatsResponse{
+		Users: dtos.UserStatsResponse{
+			Total:            stats.Users,
+			Active:           stats.ActiveUsers,
+			Suspended:        stats.SuspendedUsers,
+			Admins:           stats.Admins,
+			PendingDeletions: stats.PendingDeletions,
+		},
+		Rooms: dtos.RoomStatsResponse{
+			Total:    stats.Rooms,
+			Archived: stats.ArchivedRooms,
+		},
+		Notes: dtos.NoteStatsResponse{
+			Total:        stats.Notes,
+			Attachments:  stats.Attachments,
+			StorageBytes: stats.StorageBytes,
+		},
+		Reservations: dtos.ReservationStatsResponse{
+			Total:    stats.Reservations,
+			Upcoming: stats.UpcomingReservations,
+		},
+		Jobs: dtos.JobStatsResponse{
+			Pending: stats.PendingJobs,
+			Dead:    stats.DeadJobs,
+		},
+		GeneratedAt: now.Format("2006-01-02T15:04:05Z07:00"),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// loadUser busca o usuário do user_id da rota, respondendo com o erro se ele
+// for inválido ou não existir. Contas excluídas não são administráveis.
+func (ah *AdminHandler) loadUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	userID, err := strconv.ParseUint(chi.URLParam(r, "user_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid user_id")
+		return nil, false
+	}
+
+	user, err := ah.UserRepository.FindByID(uint(userID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get user")
+		return nil, false
+	}
+	if user == nil || user.AnonymizedAt != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "User not found")
+		return nil, false
+	}
+	return user, true
+}
+
+// newPasswordResetToken gera o token entregue ao usuário para redefinir a
+// senha. Só o hash dele fica guardado.
+func newPasswordResetToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashPasswordResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func toAdminUserResponse(user models.User) dtos.AdminUserResponse {
+	response := dtos.AdminUserResponse{
+		ID:                    user.ID,
+		Name:                  user.Name,
+		Email:                 user.Email,
+		Role:                  user.Role,
+		TimeZone:              user.TimeZone,
+		NoShows:               user.NoShows,
+		SuspensionReason:      user.SuspensionReason,
+		PasswordResetRequired: user.PasswordResetRequired,
+		CreatedAt:             user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if user.SuspendedAt != nil {
+		suspendedAt := user.SuspendedAt.Format("2006-01-02T15:04:05Z07:00")
+		response.SuspendedAt = &suspendedAt
+	}
+	if user.DeletionScheduledAt != nil {
+		scheduledAt := user.DeletionScheduledAt.Format("2006-01-02T15:04:05Z07:00")
+		response.DeletionScheduledAt = &scheduledAt
+	}
+	return response
+}
@@ -0,0 +1,409 @@
+package handlers
+
+import (
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/server/dtos"
+	"api-go/internal/server/middlewares"
+	"api-go/internal/storage"
+	"api-go/internal/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// defaultMaxAttachmentSize é usado quando ATTACHMENT_MAX_SIZE não está definido.
+const defaultMaxAttachmentSize = 10 << 20 // 10 MiB
+
+// allowedAttachmentTypes lista os tipos aceitos. O tipo é detectado a partir
+// do conteúdo do arquivo e não do cabeçalho enviado pelo cliente.
+var allowedAttachmentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"text/plain":      true,
+}
+
+var maxAttachmentSize = func() int64 {
+	size, err := strconv.ParseInt(os.Getenv("ATTACHMENT_MAX_SIZE"), 10, 64)
+	if err != nil || size <= 0 {
+		return defaultMaxAttachmentSize
+	}
+	return size
+}()
+
+type AttachmentsHandler struct {
+	AttachmentsRepository *repository.AttachmentsRepository
+	NotesRepository       *repository.NotesRepository
+	RoomsRepository       *repository.RoomsRepository
+	BlobStore             storage.BlobStore
+}
+
+func (ah *AttachmentsHandler) RegisterAttachmentsRoutes(r chi.Router) {
+	r.Route("/notes/{note_id}/attachments", func(r chi.Router) {
+		r.Post("/", ah.UploadAttachmentHandler)
+		r.Get("/", ah.GetNoteAttachmentsHandler)
+		r.Get("/{attachment_id}", ah.DownloadAttachmentHandler)
+		r.Delete("/{attachment_id}", ah.DeleteAttachmentHandler)
+	})
+}
+
+// UploadAttachmentHandler uploads a file to a note
+//
+//	@Summary		Upload attachment
+//	@Description	Upload a PDF, image or text file to a note (multipart field "file")
+//	@Tags			attachments
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			note_id	path		int		true	"Note ID"
+//	@Param			file	formData	file	true	"File to upload"
+//	@Success		201		{object}	dtos.AttachmentResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		413		{object}	dtos.ErrorResponse
+//	@Failure		415		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/notes/{note_id}/attachments [post]
+func (ah *AttachmentsHandler) UploadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	userID := claims.UserID
+	note, ok := ah.loadNote(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if !checkRoomWritable(w, &note.Room) {
+		return
+	}
+
+	// Reserva uma folga para os cabeçalhos do multipart.
+	r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentSize+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.RespondWithError(w, http.StatusRequestEntityTooLarge, "File is too large")
+			return
+		}
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid multipart form")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Field 'file' is required")
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxAttachmentSize {
+		utils.RespondWithError(w, http.StatusRequestEntityTooLarge, "File is too large")
+		return
+	}
+	if header.Size == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "File is empty")
+		return
+	}
+
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(file, sniff)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		utils.RespondWithError(w, http.StatusBadRequest, "Failed to read file")
+		return
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(sniff[:n]))
+	if !allowedAttachmentTypes[contentType] {
+		utils.RespondWithError(w, http.StatusUnsupportedMediaType, "File type not allowed: "+contentType)
+		return
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to read file")
+		return
+	}
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to read file")
+		return
+	}
+	checksum := hex.EncodeToString(hasher.Sum(nil))
+
+	// O storage é endereçado pelo conteúdo: arquivos iguais compartilham o mesmo blob.
+	key := "attachments/" + checksum[:2] + "/" + checksum
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to read file")
+		return
+	}
+	attachment := &models.Attachment{
+		NoteID:      note.ID,
+		UserID:      userID,
+		FileName:    sanitizeFileName(header.Filename),
+		ContentType: contentType,
+		Size:        header.Size,
+		Checksum:    checksum,
+		StorageKey:  key,
+	}
+	// O blob e o anexo são gravados com a chave travada, para que a coleta de
+	// um blob igual que ficou órfão não o remova entre as duas gravações.
+	var putErr error
+	err = ah.AttachmentsRepository.WithContext(r.Context()).CreateWithBlob(attachment, func() error {
+		putErr = ah.BlobStore.Put(r.Context(), key, file, header.Size, contentType)
+		return putErr
+	})
+	if putErr != nil {
+		log.Printf("failed to store attachment blob %s: %v", key, putErr)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to store file")
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to save attachment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toAttachmentResponse(*attachment))
+}
+
+// GetNoteAttachmentsHandler lists the attachments of a note
+//
+//	@Summary		List attachments
+//	@Description	List all attachments of a note
+//	@Tags			attachments
+//	@Accept			json
+//	@Produce		json
+//	@Param			note_id	path	int	true	"Note ID"
+//	@Success		200		{array}		dtos.AttachmentResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/notes/{note_id}/attachments [get]
+func (ah *AttachmentsHandler) GetNoteAttachmentsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	note, ok := ah.loadNote(w, r, claims.UserID)
+	if !ok {
+		return
+	}
+
+	attachments, err := ah.AttachmentsRepository.WithContext(r.Context()).GetByNoteID(note.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get attachments")
+		return
+	}
+
+	response := make([]dtos.AttachmentResponse, len(attachments))
+	for i, attachment := range attachments {
+		response[i] = toAttachmentResponse(attachment)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// DownloadAttachmentHandler streams an attachment
+//
+//	@Summary		Download attachment
+//	@Description	Download an attachment. Supports HTTP Range requests.
+//	@Tags			attachments
+//	@Produce		octet-stream
+//	@Param			note_id			path	int	true	"Note ID"
+//	@Param			attachment_id	path	int	true	"Attachment ID"
+//	@Success		200				{file}		file
+//	@Success		206				{file}		file
+//	@Failure		400				{object}	dtos.ErrorResponse
+//	@Failure		403				{object}	dtos.ErrorResponse
+//	@Failure		404				{object}	dtos.ErrorResponse
+//	@Failure		500				{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/notes/{note_id}/attachments/{attachment_id} [get]
+func (ah *AttachmentsHandler) DownloadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	note, ok := ah.loadNote(w, r, claims.UserID)
+	if !ok {
+		return
+	}
+
+	attachment, ok := ah.loadAttachment(w, r, note.ID)
+	if !ok {
+		return
+	}
+
+	blob, err := ah.BlobStore.Get(r.Context(), attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			utils.RespondWithError(w, http.StatusNotFound, "Attachment content not found")
+			return
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to read attachment")
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", `"`+attachment.Checksum+`"`)
+
+	// ServeContent trata Range, If-Range e If-None-Match.
+	http.ServeContent(w, r, attachment.FileName, attachment.CreatedAt, blob)
+}
+
+// DeleteAttachmentHandler deletes an attachment
+//
+//	@Summary		Delete attachment
+//	@Description	Delete an attachment (only by the uploader or the note creator)
+//	@Tags			attachments
+//	@Accept			json
+//	@Produce		json
+//	@Param			note_id			path	int	true	"Note ID"
+//	@Param			attachment_id	path	int	true	"Attachment ID"
+//	@Success		200				{object}	map[string]string
+//	@Failure		400				{object}	dtos.ErrorResponse
+//	@Failure		403				{object}	dtos.ErrorResponse
+//	@Failure		404				{object}	dtos.ErrorResponse
+//	@Failure		500				{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/notes/{note_id}/attachments/{attachment_id} [delete]
+func (ah *AttachmentsHandler) DeleteAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	userID := claims.UserID
+	note, ok := ah.loadNote(w, r, userID)
+	if !ok {
+		return
+	}
+
+	attachment, ok := ah.loadAttachment(w, r, note.ID)
+	if !ok {
+		return
+	}
+
+	if attachment.UserID != userID && note.UserID != userID {
+		utils.RespondWithError(w, http.StatusForbidden, "Only the uploader or the note creator can delete the attachment")
+		return
+	}
+
+	if !checkRoomWritable(w, &note.Room) {
+		return
+	}
+
+	if err := ah.AttachmentsRepository.WithContext(r.Context()).Delete(attachment.ID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete attachment")
+		return
+	}
+	collectOrphanBlobs(r.Context(), ah.AttachmentsRepository, ah.BlobStore, []string{attachment.StorageKey})
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Attachment deleted successfully"}`))
+}
+
+// loadNote busca a nota da URL e garante que o usuário é membro da sala.
+// Em caso de falha a resposta de erro já foi escrita.
+func (ah *AttachmentsHandler) loadNote(w http.ResponseWriter, r *http.Request, userID uint) (*models.Note, bool) {
+	noteID, err := strconv.ParseUint(chi.URLParam(r, "note_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid note ID")
+		return nil, false
+	}
+
+	note, err := ah.NotesRepository.WithContext(r.Context()).GetByID(uint(noteID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get note")
+		return nil, false
+	}
+	if note == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Note not found")
+		return nil, false
+	}
+
+	if !ah.RoomsRepository.WithContext(r.Context()).IsUserInRoom(userID, note.RoomID) {
+		utils.RespondWithError(w, http.StatusForbidden, "User is not a member of this room")
+		return nil, false
+	}
+	return note, true
+}
+
+func (ah *AttachmentsHandler) loadAttachment(w http.ResponseWriter, r *http.Request, noteID uint) (*models.Attachment, bool) {
+	attachmentID, err := strconv.ParseUint(chi.URLParam(r, "attachment_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid attachment ID")
+		return nil, false
+	}
+
+	attachment, err := ah.AttachmentsRepository.WithContext(r.Context()).GetByID(uint(attachmentID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get attachment")
+		return nil, false
+	}
+	if attachment == nil || attachment.NoteID != noteID {
+		utils.RespondWithError(w, http.StatusNotFound, "Attachment not found")
+		return nil, false
+	}
+	return attachment, true
+}
+
+// collectOrphanBlobs remove do storage os blobs que não são mais
+// referenciados por nenhum anexo.
+func collectOrphanBlobs(ctx context.Context, repo *repository.AttachmentsRepository, store storage.BlobStore, keys []string) {
+	storage.CollectOrphans(ctx, store, keys, repo.CollectOrphan)
+}
+
+func sanitizeFileName(name string) string {
+	name = filepath.Base(filepath.Clean("/" + name))
+	if name == "/" || name == "." {
+		name = "file"
+	}
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+	return name
+}
+
+func toAttachmentResponse(attachment models.Attachment) dtos.AttachmentResponse {
+	return dtos.AttachmentResponse{
+		ID:          attachment.ID,
+		NoteID:      attachment.NoteID,
+		UserID:      attachment.UserID,
+		FileName:    attachment.FileName,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		Checksum:    attachment.Checksum,
+		CreatedAt:   attachment.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
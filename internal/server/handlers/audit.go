@@ -0,0 +1,466 @@
+package handlers
+
+import (
+	"api-go/internal/audit"
+	"api-go/internal/jobs"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/server/dtos"
+	"api-go/internal/server/middlewares"
+	"api-go/internal/utils"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 200
+)
+
+// AuditHandler expõe a consulta da trilha de auditoria: a trilha inteira
+// para os administradores globais e a de cada sala para o dono dela.
+type AuditHandler struct {
+	AuditRepository *repository.AuditRepository
+	RoomsRepository *repository.RoomsRepository
+	TrashRepository *repository.TrashRepository
+}
+
+func (ah *AuditHandler) RegisterAuditRoutes(r chi.Router) {
+	r.Route("/admin/audit", func(r chi.Router) {
+		r.Use(middlewares.RequireAdmin)
+		r.Get("/", ah.GetAuditHandler)
+		r.Get("/export", ah.ExportAuditHandler)
+		r.Get("/verify", ah.VerifyAuditHandler)
+	})
+}
+
+// RegisterRoomAuditRoutes registra a consulta da trilha de uma sala, que
+// fica entre as rotas da organização.
+func (ah *AuditHandler) RegisterRoomAuditRoutes(r chi.Router) {
+	r.Route("/rooms/{room_id}/audit", func(r chi.Router) {
+		r.Get("/", ah.GetRoomAuditHandler)
+		r.Get("/export", ah.ExportRoomAuditHandler)
+	})
+}
+
+// GetAuditHandler lists audit entries
+//
+//	@Summary		List audit entries
+//	@Description	List the audit log, newest first (admin only)
+//	@Tags			audit
+//	@Accept			json
+//	@Produce		json
+//	@Param			actor_id	query		int		false	"Who performed the action"
+//	@Param			action		query		string	false	"Action, such as room.deleted"
+//	@Param			target_type	query		string	false	"Target type"	Enums(user, room, note)
+//	@Param			target_id	query		int		false	"Target ID"
+//	@Param			room_id		query		int		false	"Room the action refers to"
+//	@Param			from		query		string	false	"Entries at or after this time (RFC 3339)"
+//	@Param			to			query		string	false	"Entries before this time (RFC 3339)"
+//	@Param			limit		query		int		false	"Maximum number of entries (default 50, max 200)"
+//	@Param			offset		query		int		false	"Number of entries to skip"
+//	@Success		200			{object}	dtos.AuditEntryListResponse
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		403			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/audit [get]
+func (ah *AuditHandler) GetAuditHandler(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseAuditFilter(w, r)
+	if !ok {
+		return
+	}
+	ah.respondWithEntries(w, filter, true)
+}
+
+// ExportAuditHandler exports audit entries as CSV
+//
+//	@Summary		Export audit entries
+//	@Description	Download every audit entry matching the filters as CSV, oldest first (admin only)
+//	@Tags			audit
+//	@Produce		text/csv
+//	@Param			actor_id	query		int		false	"Who performed the action"
+//	@Param			action		query		string	false	"Action, such as room.deleted"
+//	@Param			target_type	query		string	false	"Target type"	Enums(user, room, note)
+//	@Param			target_id	query		int		false	"Target ID"
+//	@Param			room_id		query		int		false	"Room the action refers to"
+//	@Param			from		query		string	false	"Entries at or after this time (RFC 3339)"
+//	@Param			to			query		string	false	"Entries before this time (RFC 3339)"
+//	@Success		200			{file}		file
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		403			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/audit/export [get]
+func (ah *AuditHandler) ExportAuditHandler(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseAuditFilter(w, r)
+	if !ok {
+		return
+	}
+	ah.exportEntries(w, filter, "audit.csv", true)
+}
+
+// VerifyAuditHandler checks the audit hash chain
+//
+//	@Summary		Verify audit log
+//	@Description	Recompute the hash chains of the audit log (one per room, plus one for entries without a room) and report the first entry that does not match (admin only). last_hash summarizes the heads of all chains; keep it somewhere else to also detect the removal of the newest entries.
+//	@Tags			audit
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	dtos.AuditVerificationResponse
+//	@Failure		403	{object}	dtos.ErrorResponse
+//	@Failure		500	{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/admin/audit/verify [get]
+func (ah *AuditHandler) VerifyAuditHandler(w http.ResponseWriter, r *http.Request) {
+	result, err := ah.AuditRepository.Verify()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to verify audit log")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dtos.AuditVerificationResponse{
+		Valid:    result.BrokenAt == nil,
+		Checked:  result.Checked,
+		BrokenAt: result.BrokenAt,
+		LastHash: result.LastHash,
+	})
+}
+
+// GetRoomAuditHandler lists the audit entries of a room
+//
+//	@Summary		List room audit entries
+//	@Description	List the audit entries of a room, newest first (only by the room owner or admins). Deleted rooms can still be audited by their owner until purged. IP addresses and user agents are left out.
+//	@Tags			audit
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id		path		int		true	"Room ID"
+//	@Param			actor_id	query		int		false	"Who performed the action"
+//	@Param			action		query		string	false	"Action, such as room.member_left"
+//	@Param			target_type	query		string	false	"Target type"	Enums(user, room, note)
+//	@Param			target_id	query		int		false	"Target ID"
+//	@Param			from		query		string	false	"Entries at or after this time (RFC 3339)"
+//	@Param			to			query		string	false	"Entries before this time (RFC 3339)"
+//	@Param			limit		query		int		false	"Maximum number of entries (default 50, max 200)"
+//	@Param			offset		query		int		false	"Number of entries to skip"
+//	@Success		200			{object}	dtos.AuditEntryListResponse
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		403			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/audit [get]
+func (ah *AuditHandler) GetRoomAuditHandler(w http.ResponseWriter, r *http.Request) {
+	filter, full, ok := ah.roomAuditFilter(w, r)
+	if !ok {
+		return
+	}
+	ah.respondWithEntries(w, filter, full)
+}
+
+// ExportRoomAuditHandler exports the audit entries of a room as CSV
+//
+//	@Summary		Export room audit entries
+//	@Description	Download every audit entry of a room matching the filters as CSV, oldest first (only by the room owner or admins)
+//	@Tags			audit
+//	@Produce		text/csv
+//	@Param			room_id		path		int		true	"Room ID"
+//	@Param			actor_id	query		int		false	"Who performed the action"
+//	@Param			action		query		string	false	"Action, such as room.member_left"
+//	@Param			target_type	query		string	false	"Target type"	Enums(user, room, note)
+//	@Param			target_id	query		int		false	"Target ID"
+//	@Param			from		query		string	false	"Entries at or after this time (RFC 3339)"
+//	@Param			to			query		string	false	"Entries before this time (RFC 3339)"
+//	@Success		200			{file}		file
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		403			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/audit/export [get]
+func (ah *AuditHandler) ExportRoomAuditHandler(w http.ResponseWriter, r *http.Request) {
+	filter, full, ok := ah.roomAuditFilter(w, r)
+	if !ok {
+		return
+	}
+	ah.exportEntries(w, filter, fmt.Sprintf("room-%d-audit.csv", *filter.RoomID), full)
+}
+
+// roomAuditFilter confere que quem pede é o dono da sala, ou administrador
+// global, e restringe o filtro à sala. full indica se IP e user agent podem
+// ser mostrados.
+func (ah *AuditHandler) roomAuditFilter(w http.ResponseWriter, r *http.Request) (filter repository.AuditFilter, full, ok bool) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return filter, false, false
+	}
+
+	roomID, err := strconv.ParseUint(chi.URLParam(r, "room_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid room ID")
+		return filter, false, false
+	}
+
+	room, err := ah.RoomsRepository.WithContext(r.Context()).FindByID(uint(roomID))
+	if err == nil && room == nil {
+		room, err = ah.TrashRepository.WithContext(r.Context()).GetDeletedRoom(uint(roomID))
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return filter, false, false
+	}
+	if room == nil && !claims.IsAdmin() {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found")
+		return filter, false, false
+	}
+	if room != nil && room.CreatedBy != claims.UserID && !claims.IsAdmin() {
+		utils.RespondWithError(w, http.StatusForbidden, "Only the room owner can see its audit log")
+		return filter, false, false
+	}
+
+	filter, ok = parseAuditFilter(w, r)
+	if !ok {
+		return filter, false, false
+	}
+	id := uint(roomID)
+	filter.RoomID = &id
+	return filter, claims.IsAdmin(), true
+}
+
+// parseAuditFilter lê os filtros da query string, respondendo com o erro se
+// algum for inválido.
+func parseAuditFilter(w http.ResponseWriter, r *http.Request) (repository.AuditFilter, bool) {
+	query := r.URL.Query()
+	filter := repository.AuditFilter{
+		Action:     query.Get("action"),
+		TargetType: query.Get("target_type"),
+		Limit:      defaultAuditLimit,
+	}
+
+	ids := []struct {
+		name string
+		dest func(id uint)
+	}{
+		{"actor_id", func(id uint) { filter.ActorID = &id }},
+		{"target_id", func(id uint) { filter.TargetID = id }},
+		{"room_id", func(id uint) { filter.RoomID = &id }},
+	}
+	for _, param := range ids {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid "+param.name)
+			return filter, false
+		}
+		param.dest(uint(id))
+	}
+
+	times := []struct {
+		name string
+		dest **time.Time
+	}{
+		{"from", &filter.From},
+		{"to", &filter.To},
+	}
+	for _, param := range times {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid "+param.name+", expected RFC 3339 such as 2025-01-31T09:00:00Z")
+			return filter, false
+		}
+		*param.dest = &parsed
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid limit")
+			return filter, false
+		}
+		filter.Limit = min(parsed, maxAuditLimit)
+	}
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		parsed, err := strconv.Atoi(offsetStr)
+		if err != nil || parsed < 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid offset")
+			return filter, false
+		}
+		filter.Offset = parsed
+	}
+	return filter, true
+}
+
+func (ah *AuditHandler) respondWithEntries(w http.ResponseWriter, filter repository.AuditFilter, full bool) {
+	entries, total, err := ah.AuditRepository.Search(filter)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get audit entries")
+		return
+	}
+
+	response := dtos.AuditEntryListResponse{
+		Entries: make([]dtos.AuditEntryResponse, len(entries)),
+		Total:   total,
+	}
+	for i, entry := range entries {
+		response.Entries[i] = toAuditEntryResponse(entry, full)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+var auditCSVHeader = []string{
+	"id", "created_at", "actor_id", "action", "target_type", "target_id", "room_id",
+	"before", "after", "ip", "user_agent", "request_id", "prev_hash", "hash",
+}
+
+// exportEntries escreve as entradas em CSV à medida que são lidas. Depois
+// do primeiro lote o status já foi enviado, então uma falha no meio só pode
+// ser registrada no log.
+func (ah *AuditHandler) exportEntries(w http.ResponseWriter, filter repository.AuditFilter, fileName string, full bool) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	writer := csv.NewWriter(w)
+	writer.Write(auditCSVHeader)
+	err := ah.AuditRepository.Each(filter, func(entries []models.AuditEntry) error {
+		for _, entry := range entries {
+			if !full {
+				entry.IP, entry.UserAgent = "", ""
+			}
+			record := []string{
+				strconv.FormatUint(uint64(entry.ID), 10),
+				entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+				optionalID(entry.ActorID),
+				entry.Action,
+				entry.TargetType,
+				strconv.FormatUint(uint64(entry.TargetID), 10),
+				optionalID(entry.RoomID),
+				entry.Before,
+				entry.After,
+				entry.IP,
+				entry.UserAgent,
+				entry.RequestID,
+				entry.PrevHash,
+				entry.Hash,
+			}
+			for i := range record {
+				record[i] = escapeCSVFormula(record[i])
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+	writer.Flush()
+	if err != nil {
+		log.Printf("audit export failed: %v", err)
+	}
+}
+
+// escapeCSVFormula impede que planilhas interpretem o campo como fórmula.
+func escapeCSVFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func optionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
+
+func toAuditEntryResponse(entry models.AuditEntry, full bool) dtos.AuditEntryResponse {
+	response := dtos.AuditEntryResponse{
+		ID:         entry.ID,
+		ActorID:    entry.ActorID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		RoomID:     entry.RoomID,
+		RequestID:  entry.RequestID,
+		PrevHash:   entry.PrevHash,
+		Hash:       entry.Hash,
+		CreatedAt:  entry.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if entry.Before != "" {
+		response.Before = json.RawMessage(entry.Before)
+	}
+	if entry.After != "" {
+		response.After = json.RawMessage(entry.After)
+	}
+	if full {
+		response.IP = entry.IP
+		response.UserAgent = entry.UserAgent
+	}
+	return response
+}
+
+// auditMeta identifica o autor e a origem da requisição para a trilha de
+// auditoria. O IP é o da conexão, ou o informado pelo proxy quando
+// TRUST_PROXY_HEADERS está ligado.
+func auditMeta(r *http.Request) audit.Meta {
+	meta := audit.Meta{
+		IP:        r.RemoteAddr,
+		UserAgent: r.UserAgent(),
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		meta.IP = host
+	}
+	if claims, ok := middlewares.GetUserFromContext(r.Context()); ok {
+		actorID := claims.UserID
+		meta.ActorID = &actorID
+	}
+	return meta
+}
+
+// audited executa change e grava a entrada de auditoria na mesma transação.
+func audited(outbox *jobs.Outbox, auditLog *audit.Log, r *http.Request, entry audit.Entry, change func(tx *gorm.DB) error) error {
+	return outbox.Transaction(func(tx *gorm.DB) error {
+		if err := change(tx); err != nil {
+			return err
+		}
+		return auditLog.Record(tx, auditMeta(r), entry)
+	})
+}
+
+// noteAuditSnapshot fotografa a nota para a trilha. O conteúdo fica de fora:
+// a trilha não pode ser apagada, e ele guardaria o que o autor excluiu.
+func noteAuditSnapshot(note models.Note) map[string]any {
+	return map[string]any{
+		"title":   note.Title,
+		"user_id": note.UserID,
+		"room_id": note.RoomID,
+	}
+}
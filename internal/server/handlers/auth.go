@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"api-go/internal/audit"
 	"api-go/internal/auth"
 	"api-go/internal/models"
 	"api-go/internal/repository"
@@ -8,22 +9,32 @@ import (
 	"api-go/internal/server/middlewares"
 	"api-go/internal/utils"
 	"encoding/json"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
 
 type AuthHandler struct {
-	UserRepository *repository.UserRepository
+	UserRepository          *repository.UserRepository
+	OrganizationsRepository *repository.OrganizationsRepository
+	Audit                   *audit.Log
+
+	// DefaultOrganization é o slug da organização em que os novos usuários
+	// entram ao se cadastrar. Vazio ou inexistente os deixa sem organização.
+	DefaultOrganization string
 }
 
 func (ah *AuthHandler) RegisterAuthRoutes(r chi.Router) {
 	r.Route("/auth", func(r chi.Router) {
 		r.Post("/login", ah.LoginHandler)
 		r.Post("/register", ah.RegisterHandler)
+		r.Post("/password-reset", ah.ResetPasswordHandler)
 
 		r.Group(func(r chi.Router) {
 			r.Use(middlewares.AuthMiddleware)
+			r.Use(middlewares.ActiveUser(ah.UserRepository))
 			r.Get("/profile", ah.GetProfileHandler)
 		})
 	})
@@ -40,6 +51,7 @@ func (ah *AuthHandler) RegisterAuthRoutes(r chi.Router) {
 //	@Success		200		{object}	dtos.AuthLoginResponse
 //	@Failure		400		{object}	dtos.ErrorResponse
 //	@Failure		401		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
 //	@Failure		500		{object}	dtos.ErrorResponse
 //	@Router			/auth/login [post]
 func (ah *AuthHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
@@ -53,23 +65,47 @@ func (ah *AuthHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 
 	user, _ := ah.UserRepository.GetByEmail(loginRequest.Email)
 
-	if !utils.CheckPasswordHash(loginRequest.Password, user.Password) {
+	if user == nil || !utils.CheckPasswordHash(loginRequest.Password, user.Password) {
+		ah.recordLoginFailure(r, user, loginRequest.Email, "invalid_credentials")
 		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	token, err := auth.GenerateToken(user)
+	if user.SuspendedAt != nil {
+		ah.recordLoginFailure(r, user, loginRequest.Email, "suspended")
+		utils.RespondWithError(w, http.StatusForbidden, "Account suspended")
+		return
+	}
+	if user.PasswordResetRequired {
+		ah.recordLoginFailure(r, user, loginRequest.Email, "password_reset_required")
+		utils.RespondWithError(w, http.StatusForbidden, "Password reset required")
+		return
+	}
+
+	token, err := auth.GenerateToken(user, ah.defaultOrganizationID(user.ID))
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Could not generate token")
 		return
 	}
 
+	meta := auditMeta(r)
+	meta.ActorID = &user.ID
+	err = ah.Audit.Record(nil, meta, audit.Entry{
+		Action:     audit.ActionLogin,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID,
+	})
+	if err != nil {
+		log.Printf("failed to audit login of user %d: %v", user.ID, err)
+	}
+
 	response := dtos.AuthLoginResponse{
 		Token: token,
 		User: dtos.UserResponse{
-			ID:    user.ID,
-			Name:  user.Name,
-			Email: user.Email,
+			ID:       user.ID,
+			Name:     user.Name,
+			Email:    user.Email,
+			TimeZone: user.TimeZone,
 		},
 	}
 
@@ -136,7 +172,18 @@ func (ah *AuthHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := auth.GenerateToken(createdUser)
+	var orgID uint
+	if org, err := ah.OrganizationsRepository.FindBySlug(ah.DefaultOrganization); err != nil {
+		log.Printf("failed to fetch the default organization: %v", err)
+	} else if org != nil {
+		if _, err := ah.OrganizationsRepository.AddMember(org.ID, createdUser.ID, models.OrgRoleMember); err != nil {
+			log.Printf("failed to add user %d to organization %d: %v", createdUser.ID, org.ID, err)
+		} else {
+			orgID = org.ID
+		}
+	}
+
+	token, err := auth.GenerateToken(createdUser, orgID)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Could not generate token")
 		return
@@ -145,9 +192,10 @@ func (ah *AuthHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	response := dtos.AuthLoginResponse{
 		Token: token,
 		User: dtos.UserResponse{
-			ID:    createdUser.ID,
-			Name:  createdUser.Name,
-			Email: createdUser.Email,
+			ID:       createdUser.ID,
+			Name:     createdUser.Name,
+			Email:    createdUser.Email,
+			TimeZone: createdUser.TimeZone,
 		},
 	}
 
@@ -159,6 +207,133 @@ func (ah *AuthHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ResetPasswordHandler sets a new password with a reset token
+//
+//	@Summary		Reset password
+//	@Description	Set a new password with the reset token handed out by an admin. The token works once; afterwards the user is signed in with the new password.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dtos.ResetPasswordRequest	true	"Reset token and new password"
+//	@Success		200		{object}	dtos.AuthLoginResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		401		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Router			/auth/password-reset [post]
+func (ah *AuthHandler) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var req dtos.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.Token == "" || req.NewPassword == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "token and new_password are required")
+		return
+	}
+
+	tokenHash := hashPasswordResetToken(req.Token)
+	user, err := ah.UserRepository.GetByPasswordResetToken(tokenHash)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not fetch user")
+		return
+	}
+	if user == nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Invalid or expired reset token")
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not hash password")
+		return
+	}
+
+	now := time.Now()
+	reset, err := ah.UserRepository.ResetPassword(user.ID, tokenHash, hashedPassword, now)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not reset password")
+		return
+	}
+	if !reset {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Invalid or expired reset token")
+		return
+	}
+
+	meta := auditMeta(r)
+	meta.ActorID = &user.ID
+	err = ah.Audit.Record(nil, meta, audit.Entry{
+		Action:     audit.ActionPasswordReset,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID,
+	})
+	if err != nil {
+		log.Printf("failed to audit password reset of user %d: %v", user.ID, err)
+	}
+
+	if user.SuspendedAt != nil {
+		utils.RespondWithError(w, http.StatusForbidden, "Account suspended")
+		return
+	}
+
+	token, err := auth.GenerateToken(user, ah.defaultOrganizationID(user.ID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not generate token")
+		return
+	}
+
+	response := dtos.AuthLoginResponse{
+		Token: token,
+		User: dtos.UserResponse{
+			ID:       user.ID,
+			Name:     user.Name,
+			Email:    user.Email,
+			TimeZone: user.TimeZone,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not encode response")
+		return
+	}
+}
+
+// defaultOrganizationID retorna a organização ativa do token emitido no
+// login: a mais antiga do usuário, ou zero se ele não participar de nenhuma.
+func (ah *AuthHandler) defaultOrganizationID(userID uint) uint {
+	member, err := ah.OrganizationsRepository.GetDefaultMembership(userID)
+	if err != nil {
+		log.Printf("failed to fetch organizations of user %d: %v", userID, err)
+		return 0
+	}
+	if member == nil {
+		return 0
+	}
+	return member.OrganizationID
+}
+
+// recordLoginFailure registra a tentativa de login recusada. Sem a conta
+// (email desconhecido), o alvo fica zerado e o email tentado vai no after.
+// Falhas na gravação só vão para o log, para não mudar a resposta do login.
+func (ah *AuthHandler) recordLoginFailure(r *http.Request, user *models.User, email, reason string) {
+	entry := audit.Entry{
+		Action:     audit.ActionLoginFailed,
+		TargetType: models.AuditTargetUser,
+		After: map[string]string{
+			"email":  email,
+			"reason": reason,
+		},
+	}
+	if user != nil {
+		entry.TargetID = user.ID
+	}
+	if err := ah.Audit.Record(nil, auditMeta(r), entry); err != nil {
+		log.Printf("failed to audit login failure: %v", err)
+	}
+}
+
 // GetProfileHandler gets current user profile
 //
 //	@Summary		Get user profile
@@ -196,9 +371,14 @@ func (ah *AuthHandler) GetProfileHandler(w http.ResponseWriter, r *http.Request)
 		UserID:    user.ID,
 		Name:      user.Name,
 		Email:     user.Email,
+		Role:      user.Role,
 		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
+	if user.DeletionScheduledAt != nil {
+		scheduledAt := user.DeletionScheduledAt.Format("2006-01-02T15:04:05Z07:00")
+		response.DeletionScheduledAt = &scheduledAt
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
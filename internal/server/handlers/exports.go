@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"api-go/internal/accounts"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/server/dtos"
+	"api-go/internal/server/middlewares"
+	"api-go/internal/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ExportsHandler struct {
+	ExportsRepository *repository.ExportsRepository
+	Accounts          *accounts.Service
+}
+
+func (eh *ExportsHandler) RegisterExportsRoutes(r chi.Router) {
+	r.Route("/users/{user_id}/exports", func(r chi.Router) {
+		r.Post("/", eh.CreateExportHandler)
+		r.Get("/", eh.GetExportsHandler)
+		r.Get("/{export_id}", eh.GetExportHandler)
+	})
+}
+
+// RegisterExportDownloadRoutes registra o download pelo link de uso único,
+// que não exige o header Authorization para poder ser aberto no navegador.
+func (eh *ExportsHandler) RegisterExportDownloadRoutes(r chi.Router) {
+	r.Get("/exports/{token}", eh.DownloadExportHandler)
+}
+
+// CreateExportHandler requests an export of the user's data
+//
+//	@Summary		Request data export
+//	@Description	Start generating an archive with the user's profile, rooms, memberships, notes and reservations. The archive is built in the background; the user is notified when it is ready. The returned download_url is shown only once and works a single time, until the export expires.
+//	@Tags			exports
+//	@Accept			json
+//	@Produce		json
+//	@Param			user_id	path		int								true	"User ID"
+//	@Param			request	body		dtos.CreateDataExportRequest	false	"Export format"
+//	@Success		202		{object}	dtos.DataExportResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/users/{user_id}/exports [post]
+func (eh *ExportsHandler) CreateExportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizeAccountOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.CreateDataExportRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+	}
+
+	export, token, err := eh.Accounts.RequestExport(userID, req.Format)
+	switch {
+	case errors.Is(err, accounts.ErrInvalidExportFormat):
+		utils.RespondWithError(w, http.StatusBadRequest, "format must be zip or json")
+		return
+	case errors.Is(err, accounts.ErrExportInProgress):
+		utils.RespondWithError(w, http.StatusConflict, "An export is already being generated")
+		return
+	case err != nil:
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to request export")
+		return
+	}
+
+	response := toDataExportResponse(*export)
+	response.DownloadURL = "/api/exports/" + token
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetExportsHandler lists the user's data exports
+//
+//	@Summary		List data exports
+//	@Description	List the user's data exports, most recent first
+//	@Tags			exports
+//	@Accept			json
+//	@Produce		json
+//	@Param			user_id	path		int	true	"User ID"
+//	@Success		200		{array}		dtos.DataExportResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/users/{user_id}/exports [get]
+func (eh *ExportsHandler) GetExportsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizeAccountOwner(w, r)
+	if !ok {
+		return
+	}
+
+	exports, err := eh.ExportsRepository.GetByUserID(userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get exports")
+		return
+	}
+
+	response := make([]dtos.DataExportResponse, len(exports))
+	for i, export := range exports {
+		response[i] = toDataExportResponse(export)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetExportHandler gets a data export
+//
+//	@Summary		Get data export
+//	@Description	Get the status of one of the user's data exports
+//	@Tags			exports
+//	@Accept			json
+//	@Produce		json
+//	@Param			user_id		path		int	true	"User ID"
+//	@Param			export_id	path		int	true	"Export ID"
+//	@Success		200			{object}	dtos.DataExportResponse
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		403			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/users/{user_id}/exports/{export_id} [get]
+func (eh *ExportsHandler) GetExportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizeAccountOwner(w, r)
+	if !ok {
+		return
+	}
+
+	exportID, err := strconv.ParseUint(chi.URLParam(r, "export_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid export_id")
+		return
+	}
+
+	export, err := eh.ExportsRepository.GetByID(uint(exportID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get export")
+		return
+	}
+	if export == nil || export.UserID != userID {
+		utils.RespondWithError(w, http.StatusNotFound, "Export not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toDataExportResponse(*export))
+}
+
+// DownloadExportHandler downloads a data export
+//
+//	@Summary		Download data export
+//	@Description	Download the export archive through its one-time link. The link stops working after the first download or when the export expires, and the archive is then removed.
+//	@Tags			exports
+//	@Produce		application/zip
+//	@Produce		application/json
+//	@Param			token	path		string	true	"Download token"
+//	@Success		200		{file}		binary
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		410		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Router			/exports/{token} [get]
+func (eh *ExportsHandler) DownloadExportHandler(w http.ResponseWriter, r *http.Request) {
+	export, blob, err := eh.Accounts.OpenExport(r.Context(), chi.URLParam(r, "token"))
+	switch {
+	case errors.Is(err, accounts.ErrExportNotFound):
+		utils.RespondWithError(w, http.StatusNotFound, "Export not found")
+		return
+	case errors.Is(err, accounts.ErrExportNotReady):
+		utils.RespondWithError(w, http.StatusConflict, "Export is not ready yet")
+		return
+	case errors.Is(err, accounts.ErrExportUnavailable):
+		utils.RespondWithError(w, http.StatusGone, "Export link was already used or has expired")
+		return
+	case err != nil:
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to open export")
+		return
+	}
+	defer eh.Accounts.DiscardExport(context.WithoutCancel(r.Context()), export)
+	defer blob.Close()
+
+	contentType := "application/json"
+	if export.Format == models.ExportFormatZIP {
+		contentType = "application/zip"
+	}
+	fileName := fmt.Sprintf("export-%d.%s", export.ID, export.Format)
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	w.Header().Set("Content-Length", strconv.FormatInt(blob.Size(), 10))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, blob); err != nil {
+		log.Printf("failed to send export %d: %v", export.ID, err)
+	}
+}
+
+// authorizeAccountOwner confere que o user_id da rota é o do usuário
+// autenticado, já que só o próprio usuário mexe na conta.
+func authorizeAccountOwner(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return 0, false
+	}
+
+	userID, err := strconv.ParseUint(chi.URLParam(r, "user_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid user_id")
+		return 0, false
+	}
+	if claims.UserID != uint(userID) {
+		utils.RespondWithError(w, http.StatusForbidden, "You can only manage your own account")
+		return 0, false
+	}
+	return uint(userID), true
+}
+
+func toDataExportResponse(export models.DataExport) dtos.DataExportResponse {
+	response := dtos.DataExportResponse{
+		ID:        export.ID,
+		Format:    export.Format,
+		Status:    export.Status,
+		Size:      export.Size,
+		Error:     export.Error,
+		CreatedAt: export.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if export.ReadyAt != nil {
+		readyAt := export.ReadyAt.Format("2006-01-02T15:04:05Z07:00")
+		response.ReadyAt = &readyAt
+	}
+	if export.ExpiresAt != nil {
+		expiresAt := export.ExpiresAt.Format("2006-01-02T15:04:05Z07:00")
+		response.ExpiresAt = &expiresAt
+	}
+	if export.DownloadedAt != nil {
+		downloadedAt := export.DownloadedAt.Format("2006-01-02T15:04:05Z07:00")
+		response.DownloadedAt = &downloadedAt
+	}
+	return response
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"api-go/internal/auth"
+	"api-go/internal/models"
+	"api-go/internal/server/middlewares"
+	"api-go/internal/tenant"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// Os fixtures gravam direto no banco, sem organização no contexto, como a
+// migração e as tarefas do sistema.
+
+func createOrg(t *testing.T, db *gorm.DB, slug string) *models.Organization {
+	t.Helper()
+	org := models.Organization{Name: slug, Slug: slug}
+	if err := db.Create(&org).Error; err != nil {
+		t.Fatalf("failed to create organization: %v", err)
+	}
+	return &org
+}
+
+// createUser cria o usuário como membro da organização.
+func createUser(t *testing.T, db *gorm.DB, org *models.Organization, name, email string) *models.User {
+	t.Helper()
+	user := models.User{Name: name, Email: email, Role: models.UserRoleUser}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	member := models.OrganizationMember{OrganizationID: org.ID, UserID: user.ID, Role: models.OrgRoleMember}
+	if err := db.Create(&member).Error; err != nil {
+		t.Fatalf("failed to add user to organization: %v", err)
+	}
+	return &user
+}
+
+func addOrgMember(t *testing.T, db *gorm.DB, org *models.Organization, user *models.User) {
+	t.Helper()
+	member := models.OrganizationMember{OrganizationID: org.ID, UserID: user.ID, Role: models.OrgRoleMember}
+	if err := db.Create(&member).Error; err != nil {
+		t.Fatalf("failed to add user to organization: %v", err)
+	}
+}
+
+// createRoom cria a sala com o dono como admin.
+func createRoom(t *testing.T, db *gorm.DB, org *models.Organization, owner *models.User, name, visibility string) *models.Room {
+	t.Helper()
+	room := models.Room{Name: name, Capacity: 10, CreatedBy: owner.ID, OrganizationID: org.ID, Visibility: visibility}
+	if err := db.Create(&room).Error; err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	addRoomMember(t, db, room, owner, models.RoomRoleAdmin)
+	return &room
+}
+
+func addRoomMember(t *testing.T, db *gorm.DB, room models.Room, user *models.User, role string) {
+	t.Helper()
+	member := models.RoomMember{RoomID: room.ID, UserID: user.ID, Role: role}
+	if err := db.Create(&member).Error; err != nil {
+		t.Fatalf("failed to add room member: %v", err)
+	}
+}
+
+func createNote(t *testing.T, db *gorm.DB, room *models.Room, author *models.User, title string) *models.Note {
+	t.Helper()
+	note := models.Note{RoomID: room.ID, UserID: author.ID, Title: title, Content: title, Format: "plain"}
+	if err := db.Create(&note).Error; err != nil {
+		t.Fatalf("failed to create note: %v", err)
+	}
+	return &note
+}
+
+// createReservation reserva a sala por uma hora, começando em start.
+func createReservation(t *testing.T, db *gorm.DB, room *models.Room, user *models.User, start time.Time) *models.Reservation {
+	t.Helper()
+	reservation := models.Reservation{RoomID: room.ID, UserID: user.ID, StartTime: start, EndTime: start.Add(time.Hour), Status: models.ReservationApproved}
+	if err := db.Create(&reservation).Error; err != nil {
+		t.Fatalf("failed to create reservation: %v", err)
+	}
+	return &reservation
+}
+
+// createGroup cria o grupo da organização com os membros.
+func createGroup(t *testing.T, db *gorm.DB, org *models.Organization, name string, members ...*models.User) *models.UserGroup {
+	t.Helper()
+	group := models.UserGroup{OrganizationID: org.ID, Name: name}
+	if err := db.Create(&group).Error; err != nil {
+		t.Fatalf("failed to create group: %v", err)
+	}
+	for _, user := range members {
+		if err := db.Create(&models.UserGroupMember{GroupID: group.ID, UserID: user.ID}).Error; err != nil {
+			t.Fatalf("failed to add group member: %v", err)
+		}
+	}
+	return &group
+}
+
+func grantRoom(t *testing.T, db *gorm.DB, room *models.Room, group *models.UserGroup, role string) {
+	t.Helper()
+	grant := models.RoomGroupGrant{RoomID: room.ID, GroupID: group.ID, Role: role}
+	if err := db.Create(&grant).Error; err != nil {
+		t.Fatalf("failed to grant room to group: %v", err)
+	}
+}
+
+func claimsOf(user *models.User) *auth.Claims {
+	return &auth.Claims{UserID: user.ID, Name: user.Name, Email: user.Email, Role: user.Role}
+}
+
+// serve executa o handler como se a rota pattern tivesse recebido a
+// requisição, autenticada com claims e na organização orgID (zero deixa a
+// requisição sem organização).
+func serve(handler http.HandlerFunc, method, pattern, target string, claims *auth.Claims, orgID uint) *httptest.ResponseRecorder {
+	return serveJSON(handler, method, pattern, target, nil, claims, orgID)
+}
+
+// serveJSON é serve com body serializado em JSON como corpo da requisição.
+func serveJSON(handler http.HandlerFunc, method, pattern, target string, body any, claims *auth.Claims, orgID uint) *httptest.ResponseRecorder {
+	router := chi.NewRouter()
+	router.MethodFunc(method, pattern, handler)
+
+	var reader io.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	ctx := middlewares.NewContext(req.Context(), claims)
+	if orgID != 0 {
+		ctx = tenant.NewContext(ctx, orgID)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req.WithContext(ctx))
+	return rec
+}
+
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var value T
+	if err := json.Unmarshal(rec.Body.Bytes(), &value); err != nil {
+		t.Fatalf("failed to decode response %q: %v", rec.Body.String(), err)
+	}
+	return value
+}
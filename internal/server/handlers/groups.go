@@ -0,0 +1,932 @@
+package handlers
+
+import (
+	"api-go/internal/audit"
+	"api-go/internal/auth"
+	"api-go/internal/jobs"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/server/dtos"
+	"api-go/internal/server/middlewares"
+	"api-go/internal/tenant"
+	"api-go/internal/utils"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// GroupsHandler gerencia os grupos de usuários da organização da requisição
+// e os acessos deles às salas. Os membros de um grupo participam das salas a
+// que ele tem acesso como se tivessem entrado nelas, com o papel do acesso.
+type GroupsHandler struct {
+	GroupsRepository        *repository.GroupsRepository
+	RoomsRepository         *repository.RoomsRepository
+	OrganizationsRepository *repository.OrganizationsRepository
+	UserRepository          *repository.UserRepository
+	Outbox                  *jobs.Outbox
+	Audit                   *audit.Log
+}
+
+func (gh *GroupsHandler) RegisterGroupsRoutes(r chi.Router) {
+	r.Route("/groups", func(r chi.Router) {
+		r.Get("/", gh.ListGroupsHandler)
+		r.Post("/", gh.CreateGroupHandler)
+		r.Get("/{group_id}", gh.GetGroupHandler)
+		r.Put("/{group_id}", gh.UpdateGroupHandler)
+		r.Delete("/{group_id}", gh.DeleteGroupHandler)
+		r.Post("/{group_id}/members", gh.AddGroupMemberHandler)
+		r.Delete("/{group_id}/members/{user_id}", gh.RemoveGroupMemberHandler)
+	})
+	r.Route("/rooms/{room_id}/groups", func(r chi.Router) {
+		r.Get("/", gh.ListRoomGroupsHandler)
+		r.Post("/", gh.GrantRoomGroupHandler)
+		r.Put("/{group_id}", gh.UpdateRoomGroupHandler)
+		r.Delete("/{group_id}", gh.RevokeRoomGroupHandler)
+	})
+}
+
+// ListGroupsHandler lists the groups of the organization
+//
+//	@Summary		List groups
+//	@Description	List the user groups of the request's organization, by name
+//	@Tags			groups
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		dtos.GroupResponse
+//	@Failure		500	{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/groups [get]
+func (gh *GroupsHandler) ListGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	orgID, _ := tenant.FromContext(r.Context())
+	groupsRepo := gh.GroupsRepository.WithContext(r.Context())
+
+	groups, err := groupsRepo.List(orgID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get groups")
+		return
+	}
+
+	ids := make([]uint, len(groups))
+	for i, group := range groups {
+		ids[i] = group.ID
+	}
+	counts, err := groupsRepo.CountMembers(ids)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get groups")
+		return
+	}
+
+	response := make([]dtos.GroupResponse, len(groups))
+	for i, group := range groups {
+		response[i] = toGroupResponse(group, counts[group.ID])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateGroupHandler creates a group
+//
+//	@Summary		Create group
+//	@Description	Create a user group in the request's organization (only by its owners and admins)
+//	@Tags			groups
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dtos.CreateGroupRequest	true	"Group"
+//	@Success		201		{object}	dtos.GroupResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/groups [post]
+func (gh *GroupsHandler) CreateGroupHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	orgID, ok := gh.checkCanManageGroups(w, r, claims)
+	if !ok {
+		return
+	}
+
+	var req dtos.CreateGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	groupsRepo := gh.GroupsRepository.WithContext(r.Context())
+	existing, err := groupsRepo.FindByName(orgID, req.Name)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create group")
+		return
+	}
+	if existing != nil {
+		utils.RespondWithError(w, http.StatusConflict, "A group with this name already exists")
+		return
+	}
+
+	group := models.UserGroup{
+		OrganizationID: orgID,
+		Name:           req.Name,
+		Description:    strings.TrimSpace(req.Description),
+		CreatedBy:      claims.UserID,
+	}
+	if err := groupsRepo.Create(&group); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create group")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toGroupResponse(group, 0))
+}
+
+// GetGroupHandler gets a group with its members
+//
+//	@Summary		Get group
+//	@Description	Get a group of the request's organization with its members, by name. Emails are masked unless the caller manages the organization.
+//	@Tags			groups
+//	@Accept			json
+//	@Produce		json
+//	@Param			group_id	path		int	true	"Group ID"
+//	@Success		200			{object}	dtos.GroupResponse
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/groups/{group_id} [get]
+func (gh *GroupsHandler) GetGroupHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	group, ok := gh.loadGroup(w, r)
+	if !ok {
+		return
+	}
+
+	manages, err := gh.managesGroups(r, claims)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get group")
+		return
+	}
+
+	response := toGroupResponse(*group, int64(len(group.Members)))
+	response.Members = make([]dtos.GroupMemberResponse, len(group.Members))
+	for i, member := range group.Members {
+		response.Members[i] = dtos.GroupMemberResponse{
+			UserID:   member.UserID,
+			UserName: member.User.Name,
+			Email:    visibleEmail(claims, member.UserID, member.User.Email, manages),
+			AddedAt:  member.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// UpdateGroupHandler renames a group
+//
+//	@Summary		Update group
+//	@Description	Change the name and description of a group (only by the organization's owners and admins)
+//	@Tags			groups
+//	@Accept			json
+//	@Produce		json
+//	@Param			group_id	path		int						true	"Group ID"
+//	@Param			request		body		dtos.UpdateGroupRequest	true	"Group"
+//	@Success		200			{object}	dtos.GroupResponse
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		403			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		409			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/groups/{group_id} [put]
+func (gh *GroupsHandler) UpdateGroupHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	group, ok := gh.loadGroup(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := gh.checkCanManageGroups(w, r, claims); !ok {
+		return
+	}
+
+	var req dtos.UpdateGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+	if req.Name == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	groupsRepo := gh.GroupsRepository.WithContext(r.Context())
+	if req.Name != group.Name {
+		existing, err := groupsRepo.FindByName(group.OrganizationID, req.Name)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update group")
+			return
+		}
+		if existing != nil {
+			utils.RespondWithError(w, http.StatusConflict, "A group with this name already exists")
+			return
+		}
+	}
+
+	if err := groupsRepo.Update(group.ID, req.Name, req.Description); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update group")
+		return
+	}
+	group.Name = req.Name
+	group.Description = req.Description
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toGroupResponse(*group, int64(len(group.Members))))
+}
+
+// DeleteGroupHandler deletes a group
+//
+//	@Summary		Delete group
+//	@Description	Delete a group and its room grants (only by the organization's owners and admins). Members who were in a room only through the group lose access to it.
+//	@Tags			groups
+//	@Accept			json
+//	@Produce		json
+//	@Param			group_id	path	int	true	"Group ID"
+//	@Success		204
+//	@Failure		400	{object}	dtos.ErrorResponse
+//	@Failure		403	{object}	dtos.ErrorResponse
+//	@Failure		404	{object}	dtos.ErrorResponse
+//	@Failure		500	{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/groups/{group_id} [delete]
+func (gh *GroupsHandler) DeleteGroupHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	group, ok := gh.loadGroup(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := gh.checkCanManageGroups(w, r, claims); !ok {
+		return
+	}
+
+	err := audited(gh.Outbox, gh.Audit, r, audit.Entry{
+		Action:     audit.ActionGroupDeleted,
+		TargetType: models.AuditTargetGroup,
+		TargetID:   group.ID,
+		Before:     map[string]any{"name": group.Name, "members": len(group.Members)},
+	}, func(tx *gorm.DB) error {
+		groupsRepo := gh.GroupsRepository.WithContext(r.Context()).WithTx(tx)
+		memberIDs, err := groupsRepo.GetMemberIDs(group.ID)
+		if err != nil {
+			return err
+		}
+		// Sem membros, o grupo não dá participação a ninguém.
+		if len(memberIDs) == 0 {
+			return groupsRepo.Delete(group.ID)
+		}
+		return gh.changeMemberships(r, tx, memberIDs, nil, func(groupsRepo *repository.GroupsRepository) error {
+			return groupsRepo.Delete(group.ID)
+		})
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete group")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddGroupMemberHandler adds a user to a group
+//
+//	@Summary		Add group member
+//	@Description	Add a member of the organization to the group (only by the organization's owners and admins). The user joins every room the group has access to, so each of them must have room for the user.
+//	@Tags			groups
+//	@Accept			json
+//	@Produce		json
+//	@Param			group_id	path		int							true	"Group ID"
+//	@Param			request		body		dtos.AddGroupMemberRequest	true	"User"
+//	@Success		201			{object}	dtos.GroupMemberResponse
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		403			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		409			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/groups/{group_id}/members [post]
+func (gh *GroupsHandler) AddGroupMemberHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	group, ok := gh.loadGroup(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := gh.checkCanManageGroups(w, r, claims); !ok {
+		return
+	}
+
+	var req dtos.AddGroupMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "user_id is required")
+		return
+	}
+
+	user, err := gh.UserRepository.GetByID(req.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get user")
+		return
+	}
+	if user == nil || user.AnonymizedAt != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	orgMember, err := gh.OrganizationsRepository.GetMembership(group.OrganizationID, user.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to add member")
+		return
+	}
+	if orgMember == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "User is not a member of the organization")
+		return
+	}
+
+	groupsRepo := gh.GroupsRepository.WithContext(r.Context())
+	isMember, err := groupsRepo.IsMember(group.ID, req.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to add member")
+		return
+	}
+	if isMember {
+		utils.RespondWithError(w, http.StatusConflict, "User is already in the group")
+		return
+	}
+
+	// O usuário passa a ocupar uma vaga em cada sala do grupo de que ainda
+	// não participa.
+	grants, err := groupsRepo.GetGroupGrants(group.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to add member")
+		return
+	}
+	roomsRepo := gh.RoomsRepository.WithContext(r.Context())
+	for _, grant := range grants {
+		if roomsRepo.IsUserInRoom(req.UserID, grant.RoomID) {
+			continue
+		}
+		if !checkRoomCapacity(w, roomsRepo, &grant.Room) {
+			return
+		}
+	}
+
+	var member *models.UserGroupMember
+	err = audited(gh.Outbox, gh.Audit, r, audit.Entry{
+		Action:     audit.ActionGroupMemberAdded,
+		TargetType: models.AuditTargetGroup,
+		TargetID:   group.ID,
+		After:      map[string]any{"user_id": req.UserID},
+	}, func(tx *gorm.DB) error {
+		return gh.changeMemberships(r, tx, []uint{req.UserID}, nil, func(groupsRepo *repository.GroupsRepository) error {
+			var err error
+			member, err = groupsRepo.AddMember(group.ID, req.UserID)
+			return err
+		})
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to add member")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(dtos.GroupMemberResponse{
+		UserID:   user.ID,
+		UserName: user.Name,
+		Email:    user.Email,
+		AddedAt:  member.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	})
+}
+
+// RemoveGroupMemberHandler removes a user from a group
+//
+//	@Summary		Remove group member
+//	@Description	Remove a user from the group (by the organization's owners and admins, or by the member to leave). The user loses access to the rooms they were in only through the group.
+//	@Tags			groups
+//	@Accept			json
+//	@Produce		json
+//	@Param			group_id	path	int	true	"Group ID"
+//	@Param			user_id		path	int	true	"User ID"
+//	@Success		204
+//	@Failure		400	{object}	dtos.ErrorResponse
+//	@Failure		403	{object}	dtos.ErrorResponse
+//	@Failure		404	{object}	dtos.ErrorResponse
+//	@Failure		500	{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/groups/{group_id}/members/{user_id} [delete]
+func (gh *GroupsHandler) RemoveGroupMemberHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	group, ok := gh.loadGroup(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := strconv.ParseUint(chi.URLParam(r, "user_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+	if uint(userID) != claims.UserID {
+		if _, ok := gh.checkCanManageGroups(w, r, claims); !ok {
+			return
+		}
+	}
+
+	groupsRepo := gh.GroupsRepository.WithContext(r.Context())
+	isMember, err := groupsRepo.IsMember(group.ID, uint(userID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to remove member")
+		return
+	}
+	if !isMember {
+		utils.RespondWithError(w, http.StatusNotFound, "User not in group")
+		return
+	}
+
+	err = audited(gh.Outbox, gh.Audit, r, audit.Entry{
+		Action:     audit.ActionGroupMemberRemoved,
+		TargetType: models.AuditTargetGroup,
+		TargetID:   group.ID,
+		Before:     map[string]any{"user_id": userID},
+	}, func(tx *gorm.DB) error {
+		return gh.changeMemberships(r, tx, []uint{uint(userID)}, nil, func(groupsRepo *repository.GroupsRepository) error {
+			return groupsRepo.RemoveMember(group.ID, uint(userID))
+		})
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to remove member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListRoomGroupsHandler lists the groups with access to a room
+//
+//	@Summary		List room groups
+//	@Description	List the groups with access to the room, by name (only by room members)
+//	@Tags			groups
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int	true	"Room ID"
+//	@Success		200		{array}		dtos.RoomGroupGrantResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/groups [get]
+func (gh *GroupsHandler) ListRoomGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	room, ok := gh.loadRoom(w, r)
+	if !ok {
+		return
+	}
+	if !claims.IsAdmin() && !gh.RoomsRepository.WithContext(r.Context()).IsUserInRoom(claims.UserID, room.ID) {
+		utils.RespondWithError(w, http.StatusForbidden, "Only room members can see the room's groups")
+		return
+	}
+
+	groupsRepo := gh.GroupsRepository.WithContext(r.Context())
+	grants, err := groupsRepo.GetRoomGrants(room.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room groups")
+		return
+	}
+
+	ids := make([]uint, len(grants))
+	for i, grant := range grants {
+		ids[i] = grant.GroupID
+	}
+	counts, err := groupsRepo.CountMembers(ids)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room groups")
+		return
+	}
+
+	response := make([]dtos.RoomGroupGrantResponse, len(grants))
+	for i, grant := range grants {
+		response[i] = toRoomGroupGrantResponse(grant, counts[grant.GroupID])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GrantRoomGroupHandler gives a group access to a room
+//
+//	@Summary		Grant room to group
+//	@Description	Give the group's members access to the room with the given role (only by room creator or admins). Members already in the room count once against its capacity.
+//	@Tags			groups
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int									true	"Room ID"
+//	@Param			request	body		dtos.CreateRoomGroupGrantRequest	true	"Group and role"
+//	@Success		201		{object}	dtos.RoomGroupGrantResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/groups [post]
+func (gh *GroupsHandler) GrantRoomGroupHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	room, ok := gh.loadManagedRoom(w, r, claims)
+	if !ok {
+		return
+	}
+
+	var req dtos.CreateRoomGroupGrantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.RoomRoleMember
+	}
+	if req.Role != models.RoomRoleMember && req.Role != models.RoomRoleAdmin {
+		utils.RespondWithError(w, http.StatusBadRequest, "Role must be 'member' or 'admin'")
+		return
+	}
+
+	groupsRepo := gh.GroupsRepository.WithContext(r.Context())
+	group, err := groupsRepo.FindByID(req.GroupID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get group")
+		return
+	}
+	if group == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Group not found")
+		return
+	}
+
+	existing, err := groupsRepo.GetGrant(room.ID, group.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to grant room")
+		return
+	}
+	if existing != nil {
+		utils.RespondWithError(w, http.StatusConflict, "Group already has access to the room")
+		return
+	}
+
+	members, err := gh.RoomsRepository.WithContext(r.Context()).CountMembersWithGroup(room.ID, group.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to check room capacity")
+		return
+	}
+	if int(members) > room.Capacity {
+		utils.RespondWithError(w, http.StatusConflict, "The group's members do not fit in the room")
+		return
+	}
+
+	var grant *models.RoomGroupGrant
+	err = audited(gh.Outbox, gh.Audit, r, audit.Entry{
+		Action:     audit.ActionRoomGroupGranted,
+		TargetType: models.AuditTargetGroup,
+		TargetID:   group.ID,
+		RoomID:     room.ID,
+		After:      map[string]any{"role": req.Role},
+	}, func(tx *gorm.DB) error {
+		return gh.changeMemberships(r, tx, nil, []uint{room.ID}, func(groupsRepo *repository.GroupsRepository) error {
+			var err error
+			grant, err = groupsRepo.Grant(room.ID, group.ID, req.Role, claims.UserID)
+			return err
+		})
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to grant room")
+		return
+	}
+	grant.Group = *group
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toRoomGroupGrantResponse(*grant, int64(len(group.Members))))
+}
+
+// UpdateRoomGroupHandler changes the role a group has in a room
+//
+//	@Summary		Change room group role
+//	@Description	Change the role the group's members have in the room (only by room creator or admins)
+//	@Tags			groups
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id		path	int									true	"Room ID"
+//	@Param			group_id	path	int									true	"Group ID"
+//	@Param			request		body	dtos.UpdateRoomGroupGrantRequest	true	"New role"
+//	@Success		204
+//	@Failure		400	{object}	dtos.ErrorResponse
+//	@Failure		403	{object}	dtos.ErrorResponse
+//	@Failure		404	{object}	dtos.ErrorResponse
+//	@Failure		409	{object}	dtos.ErrorResponse
+//	@Failure		500	{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/groups/{group_id} [put]
+func (gh *GroupsHandler) UpdateRoomGroupHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	room, ok := gh.loadManagedRoom(w, r, claims)
+	if !ok {
+		return
+	}
+	grant, ok := gh.loadGrant(w, r, room.ID)
+	if !ok {
+		return
+	}
+
+	var req dtos.UpdateRoomGroupGrantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Role != models.RoomRoleMember && req.Role != models.RoomRoleAdmin {
+		utils.RespondWithError(w, http.StatusBadRequest, "Role must be 'member' or 'admin'")
+		return
+	}
+
+	if grant.Role != req.Role {
+		err := audited(gh.Outbox, gh.Audit, r, audit.Entry{
+			Action:     audit.ActionRoomGroupRoleChanged,
+			TargetType: models.AuditTargetGroup,
+			TargetID:   grant.GroupID,
+			RoomID:     room.ID,
+			Before:     map[string]any{"role": grant.Role},
+			After:      map[string]any{"role": req.Role},
+		}, func(tx *gorm.DB) error {
+			return gh.changeMemberships(r, tx, nil, []uint{room.ID}, func(groupsRepo *repository.GroupsRepository) error {
+				return groupsRepo.UpdateGrantRole(room.ID, grant.GroupID, req.Role)
+			})
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update room group")
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeRoomGroupHandler removes a group's access to a room
+//
+//	@Summary		Revoke room from group
+//	@Description	Remove the group's access to the room (only by room creator or admins). Members who were in the room only through the group lose access to it.
+//	@Tags			groups
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id		path	int	true	"Room ID"
+//	@Param			group_id	path	int	true	"Group ID"
+//	@Success		204
+//	@Failure		400	{object}	dtos.ErrorResponse
+//	@Failure		403	{object}	dtos.ErrorResponse
+//	@Failure		404	{object}	dtos.ErrorResponse
+//	@Failure		409	{object}	dtos.ErrorResponse
+//	@Failure		500	{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/groups/{group_id} [delete]
+func (gh *GroupsHandler) RevokeRoomGroupHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	room, ok := gh.loadManagedRoom(w, r, claims)
+	if !ok {
+		return
+	}
+	grant, ok := gh.loadGrant(w, r, room.ID)
+	if !ok {
+		return
+	}
+
+	err := audited(gh.Outbox, gh.Audit, r, audit.Entry{
+		Action:     audit.ActionRoomGroupRevoked,
+		TargetType: models.AuditTargetGroup,
+		TargetID:   grant.GroupID,
+		RoomID:     room.ID,
+		Before:     map[string]any{"role": grant.Role},
+	}, func(tx *gorm.DB) error {
+		return gh.changeMemberships(r, tx, nil, []uint{room.ID}, func(groupsRepo *repository.GroupsRepository) error {
+			return groupsRepo.Revoke(room.ID, grant.GroupID)
+		})
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to revoke room group")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// changeMemberships aplica change em tx e publica, na mesma transação, as
+// entradas, saídas e trocas de papel que ela causou entre os usuários userIDs
+// e as salas roomIDs (nil não filtra).
+func (gh *GroupsHandler) changeMemberships(r *http.Request, tx *gorm.DB, userIDs, roomIDs []uint, change func(groupsRepo *repository.GroupsRepository) error) error {
+	roomsRepo := gh.RoomsRepository.WithContext(r.Context()).WithTx(tx)
+	before, err := roomsRepo.GetEffectiveMemberships(userIDs, roomIDs)
+	if err != nil {
+		return err
+	}
+	if err := change(gh.GroupsRepository.WithContext(r.Context()).WithTx(tx)); err != nil {
+		return err
+	}
+	after, err := roomsRepo.GetEffectiveMemberships(userIDs, roomIDs)
+	if err != nil {
+		return err
+	}
+	return publishMembershipChanges(tx, gh.Outbox, before, after)
+}
+
+// managesGroups informa se quem consulta gerencia os grupos da organização
+// da requisição: os donos e os admins dela e os administradores globais.
+func (gh *GroupsHandler) managesGroups(r *http.Request, claims *auth.Claims) (bool, error) {
+	if claims.IsAdmin() {
+		return true, nil
+	}
+	orgID, _ := tenant.FromContext(r.Context())
+	member, err := gh.OrganizationsRepository.GetMembership(orgID, claims.UserID)
+	if err != nil {
+		return false, err
+	}
+	return canManageOrganization(claims, member), nil
+}
+
+// checkCanManageGroups responde com erro e retorna false se quem consulta
+// não gerencia os grupos. Retorna a organização da requisição.
+func (gh *GroupsHandler) checkCanManageGroups(w http.ResponseWriter, r *http.Request, claims *auth.Claims) (uint, bool) {
+	manages, err := gh.managesGroups(r, claims)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get organization")
+		return 0, false
+	}
+	if !manages {
+		utils.RespondWithError(w, http.StatusForbidden, "Only organization owners and admins can manage groups")
+		return 0, false
+	}
+	orgID, _ := tenant.FromContext(r.Context())
+	return orgID, true
+}
+
+func (gh *GroupsHandler) loadGroup(w http.ResponseWriter, r *http.Request) (*models.UserGroup, bool) {
+	groupID, err := strconv.ParseUint(chi.URLParam(r, "group_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid group ID")
+		return nil, false
+	}
+
+	group, err := gh.GroupsRepository.WithContext(r.Context()).FindByID(uint(groupID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get group")
+		return nil, false
+	}
+	if group == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Group not found")
+		return nil, false
+	}
+	return group, true
+}
+
+func (gh *GroupsHandler) loadRoom(w http.ResponseWriter, r *http.Request) (*models.Room, bool) {
+	roomID, err := strconv.ParseUint(chi.URLParam(r, "room_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid room ID")
+		return nil, false
+	}
+
+	room, err := gh.RoomsRepository.WithContext(r.Context()).FindByID(uint(roomID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return nil, false
+	}
+	if room == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found")
+		return nil, false
+	}
+	return room, true
+}
+
+// loadManagedRoom busca a sala da URL e confere que quem consulta a
+// administra e que ela aceita mudanças.
+func (gh *GroupsHandler) loadManagedRoom(w http.ResponseWriter, r *http.Request, claims *auth.Claims) (*models.Room, bool) {
+	room, ok := gh.loadRoom(w, r)
+	if !ok {
+		return nil, false
+	}
+	if !gh.RoomsRepository.WithContext(r.Context()).IsRoomAdmin(claims.UserID, room) {
+		utils.RespondWithError(w, http.StatusForbidden, "Only room creator or admins can manage the room's groups")
+		return nil, false
+	}
+	if !checkRoomWritable(w, room) {
+		return nil, false
+	}
+	return room, true
+}
+
+func (gh *GroupsHandler) loadGrant(w http.ResponseWriter, r *http.Request, roomID uint) (*models.RoomGroupGrant, bool) {
+	groupID, err := strconv.ParseUint(chi.URLParam(r, "group_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid group ID")
+		return nil, false
+	}
+
+	grant, err := gh.GroupsRepository.WithContext(r.Context()).GetGrant(roomID, uint(groupID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room group")
+		return nil, false
+	}
+	if grant == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Group has no access to the room")
+		return nil, false
+	}
+	return grant, true
+}
+
+func toGroupResponse(group models.UserGroup, memberCount int64) dtos.GroupResponse {
+	return dtos.GroupResponse{
+		ID:             group.ID,
+		OrganizationID: group.OrganizationID,
+		Name:           group.Name,
+		Description:    group.Description,
+		MemberCount:    memberCount,
+		CreatedBy:      group.CreatedBy,
+		CreatedAt:      group.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+func toRoomGroupGrantResponse(grant models.RoomGroupGrant, memberCount int64) dtos.RoomGroupGrantResponse {
+	return dtos.RoomGroupGrantResponse{
+		RoomID:      grant.RoomID,
+		GroupID:     grant.GroupID,
+		GroupName:   grant.Group.Name,
+		Role:        grant.Role,
+		MemberCount: memberCount,
+		GrantedBy:   grant.GrantedBy,
+		GrantedAt:   grant.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"api-go/internal/audit"
+	"api-go/internal/database/dbtest"
+	"api-go/internal/events"
+	"api-go/internal/jobs"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/server/dtos"
+	"api-go/internal/tenant"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// publishedMemberships lista, em ordem, as entradas, saídas e trocas de papel
+// publicadas no outbox, como "joined:sala:usuário".
+func publishedMemberships(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+	var outboxJobs []models.OutboxJob
+	if err := db.Where("kind = ?", jobs.KindPublishEvent).Order("id").Find(&outboxJobs).Error; err != nil {
+		t.Fatalf("failed to load outbox: %v", err)
+	}
+	var published []string
+	for _, job := range outboxJobs {
+		var event events.Event
+		if err := json.Unmarshal([]byte(job.Payload), &event); err != nil {
+			t.Fatalf("failed to decode event: %v", err)
+		}
+		switch event.Type {
+		case events.RoomMemberJoined:
+			published = append(published, fmt.Sprintf("joined:%d:%d", event.RoomID, event.UserID))
+		case events.RoomMemberLeft:
+			published = append(published, fmt.Sprintf("left:%d:%d", event.RoomID, event.UserID))
+		case events.RoomMemberRoleChanged:
+			published = append(published, fmt.Sprintf("role:%d:%d:%s", event.RoomID, event.UserID, event.String("role")))
+		}
+	}
+	return published
+}
+
+func TestGroupChangesPublishMembershipEvents(t *testing.T) {
+	db := dbtest.New(t)
+	org := createOrg(t, db, "acme")
+	alice := createUser(t, db, org, "Alice", "alice@example.com")
+	bob := createUser(t, db, org, "Bob", "bob@example.com")
+	carol := createUser(t, db, org, "Carol", "carol@example.com")
+
+	room := createRoom(t, db, org, alice, "Board", models.RoomPrivate)
+	addRoomMember(t, db, *room, carol, models.RoomRoleMember)
+	group := createGroup(t, db, org, "Directors", carol)
+
+	roomsRepo := repository.NewRoomsRepository(db)
+	gh := &GroupsHandler{
+		GroupsRepository:        repository.NewGroupsRepository(db),
+		RoomsRepository:         roomsRepo,
+		OrganizationsRepository: repository.NewOrganizationsRepository(db),
+		UserRepository:          repository.NewUserRepository(db),
+		Outbox:                  &jobs.Outbox{DB: db, Runner: jobs.NewRunner(db, 1)},
+		Audit:                   &audit.Log{Repository: repository.NewAuditRepository(db)},
+	}
+	admin := claimsOf(alice)
+	admin.Role = models.UserRoleAdmin
+
+	steps := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		pattern string
+		target  string
+		body    any
+		want    []string
+	}{
+		{
+			"grant", gh.GrantRoomGroupHandler, http.MethodPost, "/rooms/{room_id}/groups",
+			fmt.Sprintf("/rooms/%d/groups", room.ID), dtos.CreateRoomGroupGrantRequest{GroupID: group.ID},
+			nil, // Carol já participa diretamente.
+		},
+		{
+			"add member", gh.AddGroupMemberHandler, http.MethodPost, "/groups/{group_id}/members",
+			fmt.Sprintf("/groups/%d/members", group.ID), dtos.AddGroupMemberRequest{UserID: bob.ID},
+			[]string{fmt.Sprintf("joined:%d:%d", room.ID, bob.ID)},
+		},
+		{
+			"remove member", gh.RemoveGroupMemberHandler, http.MethodDelete, "/groups/{group_id}/members/{user_id}",
+			fmt.Sprintf("/groups/%d/members/%d", group.ID, bob.ID), nil,
+			[]string{fmt.Sprintf("left:%d:%d", room.ID, bob.ID)},
+		},
+		{
+			"add member again", gh.AddGroupMemberHandler, http.MethodPost, "/groups/{group_id}/members",
+			fmt.Sprintf("/groups/%d/members", group.ID), dtos.AddGroupMemberRequest{UserID: bob.ID},
+			[]string{fmt.Sprintf("joined:%d:%d", room.ID, bob.ID)},
+		},
+		{
+			"revoke", gh.RevokeRoomGroupHandler, http.MethodDelete, "/rooms/{room_id}/groups/{group_id}",
+			fmt.Sprintf("/rooms/%d/groups/%d", room.ID, group.ID), nil,
+			[]string{fmt.Sprintf("left:%d:%d", room.ID, bob.ID)},
+		},
+		{
+			"grant again", gh.GrantRoomGroupHandler, http.MethodPost, "/rooms/{room_id}/groups",
+			fmt.Sprintf("/rooms/%d/groups", room.ID), dtos.CreateRoomGroupGrantRequest{GroupID: group.ID},
+			[]string{fmt.Sprintf("joined:%d:%d", room.ID, bob.ID)},
+		},
+		{
+			// Carol, membro direto, passa a ser admin pelo grupo.
+			"promote group", gh.UpdateRoomGroupHandler, http.MethodPut, "/rooms/{room_id}/groups/{group_id}",
+			fmt.Sprintf("/rooms/%d/groups/%d", room.ID, group.ID), dtos.UpdateRoomGroupGrantRequest{Role: models.RoomRoleAdmin},
+			[]string{
+				fmt.Sprintf("role:%d:%d:admin", room.ID, bob.ID),
+				fmt.Sprintf("role:%d:%d:admin", room.ID, carol.ID),
+			},
+		},
+		{
+			"delete group", gh.DeleteGroupHandler, http.MethodDelete, "/groups/{group_id}",
+			fmt.Sprintf("/groups/%d", group.ID), nil,
+			[]string{
+				fmt.Sprintf("left:%d:%d", room.ID, bob.ID),
+				fmt.Sprintf("role:%d:%d:member", room.ID, carol.ID),
+			},
+		},
+	}
+
+	var seen int
+	for _, step := range steps {
+		rec := serveJSON(step.handler, step.method, step.pattern, step.target, step.body, admin, org.ID)
+		if rec.Code >= 300 {
+			t.Fatalf("%s: status = %d, body = %s", step.name, rec.Code, rec.Body)
+		}
+		published := publishedMemberships(t, db)
+		if got := published[seen:]; !slices.Equal(got, step.want) {
+			t.Errorf("%s published %v, want %v", step.name, got, step.want)
+		}
+		seen = len(published)
+
+		// A listagem das salas acompanha a participação por grupo.
+		rooms, err := roomsRepo.WithContext(tenant.NewContext(context.Background(), org.ID)).GetAll(repository.RoomFilter{VisibleTo: bob.ID})
+		if err != nil {
+			t.Fatalf("%s: failed to list rooms: %v", step.name, err)
+		}
+		visible := slices.ContainsFunc(rooms, func(r models.Room) bool { return r.ID == room.ID })
+		inRoom := roomsRepo.IsUserInRoom(bob.ID, room.ID)
+		if visible != inRoom {
+			t.Errorf("%s: private room visible = %v, but membership = %v", step.name, visible, inRoom)
+		}
+	}
+}
+
+// Um admin por grupo herda a sala antes de um membro direto e, ao herdar,
+// passa a ser admin direto.
+func TestGroupAdminSucceedsOwner(t *testing.T) {
+	db := dbtest.New(t)
+	org := createOrg(t, db, "acme")
+	alice := createUser(t, db, org, "Alice", "alice@example.com")
+	bob := createUser(t, db, org, "Bob", "bob@example.com")
+	carol := createUser(t, db, org, "Carol", "carol@example.com")
+
+	room := createRoom(t, db, org, alice, "Board", models.RoomPrivate)
+	addRoomMember(t, db, *room, bob, models.RoomRoleMember)
+	grantRoom(t, db, room, createGroup(t, db, org, "Directors", carol), models.RoomRoleAdmin)
+
+	roomsRepo := repository.NewRoomsRepository(db)
+	successor, err := roomsRepo.GetSuccessor(room.ID, alice.ID)
+	if err != nil || successor == nil {
+		t.Fatalf("GetSuccessor() = %v, %v", successor, err)
+	}
+	if successor.UserID != carol.ID || successor.IsDirect() || successor.User.Name != carol.Name {
+		t.Errorf("successor = user %d (direct %v), want group admin %d", successor.UserID, successor.IsDirect(), carol.ID)
+	}
+
+	if transferred, err := roomsRepo.TransferOwnership(room.ID, alice.ID, carol.ID); err != nil || !transferred {
+		t.Fatalf("TransferOwnership() = %v, %v", transferred, err)
+	}
+	if role, err := roomsRepo.GetDirectRole(carol.ID, room.ID); err != nil || role != models.RoomRoleAdmin {
+		t.Errorf("direct role of the new owner = %q, %v, want admin", role, err)
+	}
+}
@@ -0,0 +1,656 @@
+package handlers
+
+import (
+	"api-go/internal/audit"
+	"api-go/internal/events"
+	"api-go/internal/jobs"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/server/dtos"
+	"api-go/internal/server/middlewares"
+	"api-go/internal/utils"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// Erros que interrompem a transação quando outro pedido usou o convite ou
+// decidiu o pedido de entrada primeiro.
+var (
+	errInviteUnavailable = errors.New("invite expired or used up")
+	errJoinRequestGone   = errors.New("join request is no longer pending")
+)
+
+type InvitationsHandler struct {
+	InvitationsRepository *repository.InvitationsRepository
+	RoomsRepository       *repository.RoomsRepository
+	Outbox                *jobs.Outbox
+	Audit                 *audit.Log
+}
+
+func (ih *InvitationsHandler) RegisterInvitationsRoutes(r chi.Router) {
+	r.Route("/rooms/{room_id}/invites", func(r chi.Router) {
+		r.Post("/", ih.CreateInviteHandler)
+		r.Get("/", ih.GetInvitesHandler)
+		r.Delete("/{invite_id}", ih.DeleteInviteHandler)
+	})
+	r.Post("/invites/{code}/accept", ih.AcceptInviteHandler)
+	r.Route("/rooms/{room_id}/join-requests", func(r chi.Router) {
+		r.Post("/", ih.CreateJoinRequestHandler)
+		r.Get("/", ih.GetJoinRequestsHandler)
+		r.Post("/{request_id}/approve", ih.ApproveJoinRequestHandler)
+		r.Post("/{request_id}/deny", ih.DenyJoinRequestHandler)
+	})
+}
+
+// CreateInviteHandler creates an invite code
+//
+//	@Summary		Create invite
+//	@Description	Create an invite code that lets users join the room with a preassigned role, optionally expiring or limited in uses (only by room admins)
+//	@Tags			invitations
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int							true	"Room ID"
+//	@Param			request	body		dtos.CreateInviteRequest	true	"Invite details"
+//	@Success		201		{object}	dtos.InviteResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/invites [post]
+func (ih *InvitationsHandler) CreateInviteHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	room, ok := ih.loadAdminRoom(w, r, claims.UserID)
+	if !ok {
+		return
+	}
+
+	if !checkRoomWritable(w, room) {
+		return
+	}
+
+	var req dtos.CreateInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = models.RoomRoleMember
+	}
+	if req.Role != models.RoomRoleMember && req.Role != models.RoomRoleAdmin {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid role, must be 'member' or 'admin'")
+		return
+	}
+	if req.MaxUses < 0 || req.ExpiresInHours < 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "max_uses and expires_in_hours cannot be negative")
+		return
+	}
+
+	code, err := newInviteCode()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to generate invite code")
+		return
+	}
+
+	invite := models.RoomInvite{
+		RoomID:    room.ID,
+		Code:      code,
+		Role:      req.Role,
+		MaxUses:   req.MaxUses,
+		CreatedBy: claims.UserID,
+	}
+	if req.ExpiresInHours > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.ExpiresInHours) * time.Hour)
+		invite.ExpiresAt = &expiresAt
+	}
+
+	if err := ih.InvitationsRepository.WithContext(r.Context()).CreateInvite(&invite); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create invite")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toInviteResponse(invite))
+}
+
+// GetInvitesHandler lists the invites of a room
+//
+//	@Summary		Get invites
+//	@Description	List the active invites of a room (only by room admins)
+//	@Tags			invitations
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int	true	"Room ID"
+//	@Success		200		{array}		dtos.InviteResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/invites [get]
+func (ih *InvitationsHandler) GetInvitesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	room, ok := ih.loadAdminRoom(w, r, claims.UserID)
+	if !ok {
+		return
+	}
+
+	invites, err := ih.InvitationsRepository.WithContext(r.Context()).GetInvites(room.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get invites")
+		return
+	}
+
+	response := make([]dtos.InviteResponse, len(invites))
+	for i, invite := range invites {
+		response[i] = toInviteResponse(invite)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteInviteHandler revokes an invite
+//
+//	@Summary		Revoke invite
+//	@Description	Revoke an invite code (only by room admins)
+//	@Tags			invitations
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id		path		int	true	"Room ID"
+//	@Param			invite_id	path		int	true	"Invite ID"
+//	@Success		200			{object}	map[string]string
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		403			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/invites/{invite_id} [delete]
+func (ih *InvitationsHandler) DeleteInviteHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	room, ok := ih.loadAdminRoom(w, r, claims.UserID)
+	if !ok {
+		return
+	}
+
+	inviteID, err := strconv.ParseUint(chi.URLParam(r, "invite_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid invite ID")
+		return
+	}
+
+	invite, err := ih.InvitationsRepository.WithContext(r.Context()).GetInvite(uint(inviteID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get invite")
+		return
+	}
+	if invite == nil || invite.RoomID != room.ID {
+		utils.RespondWithError(w, http.StatusNotFound, "Invite not found")
+		return
+	}
+
+	if err := ih.InvitationsRepository.WithContext(r.Context()).DeleteInvite(invite.ID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to revoke invite")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Invite revoked successfully"}`))
+}
+
+// AcceptInviteHandler joins a room with an invite code
+//
+//	@Summary		Accept invite
+//	@Description	Join the room of an invite code with the role it carries
+//	@Tags			invitations
+//	@Accept			json
+//	@Produce		json
+//	@Param			code	path		string	true	"Invite code"
+//	@Success		200		{object}	dtos.RoomResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		410		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/invites/{code}/accept [post]
+func (ih *InvitationsHandler) AcceptInviteHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	invite, err := ih.InvitationsRepository.WithContext(r.Context()).GetInviteByCode(chi.URLParam(r, "code"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get invite")
+		return
+	}
+	if invite == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Invite not found")
+		return
+	}
+
+	room, err := ih.RoomsRepository.WithContext(r.Context()).FindByID(invite.RoomID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return
+	}
+	if room == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found")
+		return
+	}
+
+	if ih.RoomsRepository.WithContext(r.Context()).IsUserInRoom(claims.UserID, room.ID) {
+		utils.RespondWithError(w, http.StatusConflict, "User already in room")
+		return
+	}
+
+	if !checkRoomWritable(w, room) {
+		return
+	}
+
+	if !checkRoomCapacity(w, ih.RoomsRepository.WithContext(r.Context()), room) {
+		return
+	}
+
+	err = ih.Outbox.Transaction(func(tx *gorm.DB) error {
+		redeemed, err := ih.InvitationsRepository.WithContext(r.Context()).WithTx(tx).RedeemInvite(invite.ID, time.Now())
+		if err != nil {
+			return err
+		}
+		if !redeemed {
+			return errInviteUnavailable
+		}
+		return addMember(tx, ih.RoomsRepository.WithContext(r.Context()), ih.Outbox, ih.Audit, r, room, claims.UserID, claims.Name, invite.Role)
+	})
+	if errors.Is(err, errInviteUnavailable) {
+		utils.RespondWithError(w, http.StatusGone, "Invite has expired or reached its maximum uses")
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to join room")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toRoomResponse(*room))
+}
+
+// CreateJoinRequestHandler asks to join a room
+//
+//	@Summary		Request to join
+//	@Description	Ask the admins of an unlisted or private room to let the user in
+//	@Tags			invitations
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int								true	"Room ID"
+//	@Param			request	body		dtos.CreateJoinRequestRequest	false	"Message to the admins"
+//	@Success		201		{object}	dtos.JoinRequestResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/join-requests [post]
+func (ih *InvitationsHandler) CreateJoinRequestHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	room, ok := ih.loadRoom(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.CreateJoinRequestRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	if !checkRoomWritable(w, room) {
+		return
+	}
+
+	if room.Visibility == models.RoomPublic {
+		utils.RespondWithError(w, http.StatusConflict, "Room is public, join it directly")
+		return
+	}
+
+	if ih.RoomsRepository.WithContext(r.Context()).IsUserInRoom(claims.UserID, room.ID) {
+		utils.RespondWithError(w, http.StatusConflict, "User already in room")
+		return
+	}
+
+	pending, err := ih.InvitationsRepository.WithContext(r.Context()).HasPendingJoinRequest(claims.UserID, room.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to check join requests")
+		return
+	}
+	if pending {
+		utils.RespondWithError(w, http.StatusConflict, "User already has a pending join request for this room")
+		return
+	}
+
+	request := models.RoomJoinRequest{
+		RoomID:  room.ID,
+		UserID:  claims.UserID,
+		Message: strings.TrimSpace(req.Message),
+		Status:  models.JoinRequestPending,
+	}
+	err = ih.Outbox.Transaction(func(tx *gorm.DB) error {
+		if err := ih.InvitationsRepository.WithContext(r.Context()).WithTx(tx).CreateJoinRequest(&request); err != nil {
+			return err
+		}
+		return ih.Outbox.Publish(tx, events.Event{
+			Type:    events.RoomJoinRequested,
+			ActorID: claims.UserID,
+			RoomID:  room.ID,
+			UserID:  claims.UserID,
+			Data: map[string]any{
+				"actor_name":      claims.Name,
+				"room_name":       room.Name,
+				"join_request_id": request.ID,
+			},
+		})
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create join request")
+		return
+	}
+
+	request.User = models.User{Name: claims.Name}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toJoinRequestResponse(request))
+}
+
+// GetJoinRequestsHandler lists the join requests of a room
+//
+//	@Summary		Get join requests
+//	@Description	List the join requests of a room, oldest first (only by room admins)
+//	@Tags			invitations
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int		true	"Room ID"
+//	@Param			status	query		string	false	"Filter by status"	Enums(pending, approved, denied)
+//	@Success		200		{array}		dtos.JoinRequestResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/join-requests [get]
+func (ih *InvitationsHandler) GetJoinRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	room, ok := ih.loadAdminRoom(w, r, claims.UserID)
+	if !ok {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && status != models.JoinRequestPending && status != models.JoinRequestApproved && status != models.JoinRequestDenied {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid status")
+		return
+	}
+
+	requests, err := ih.InvitationsRepository.WithContext(r.Context()).GetJoinRequests(room.ID, status)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get join requests")
+		return
+	}
+
+	response := make([]dtos.JoinRequestResponse, len(requests))
+	for i, request := range requests {
+		response[i] = toJoinRequestResponse(request)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ApproveJoinRequestHandler approves a join request
+//
+//	@Summary		Approve join request
+//	@Description	Let the requester into the room as a member (only by room admins)
+//	@Tags			invitations
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id		path		int	true	"Room ID"
+//	@Param			request_id	path		int	true	"Join request ID"
+//	@Success		200			{object}	dtos.JoinRequestResponse
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		403			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		409			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/join-requests/{request_id}/approve [post]
+func (ih *InvitationsHandler) ApproveJoinRequestHandler(w http.ResponseWriter, r *http.Request) {
+	ih.decideJoinRequest(w, r, models.JoinRequestApproved)
+}
+
+// DenyJoinRequestHandler denies a join request
+//
+//	@Summary		Deny join request
+//	@Description	Refuse a join request (only by room admins)
+//	@Tags			invitations
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id		path		int	true	"Room ID"
+//	@Param			request_id	path		int	true	"Join request ID"
+//	@Success		200			{object}	dtos.JoinRequestResponse
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		403			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		409			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/join-requests/{request_id}/deny [post]
+func (ih *InvitationsHandler) DenyJoinRequestHandler(w http.ResponseWriter, r *http.Request) {
+	ih.decideJoinRequest(w, r, models.JoinRequestDenied)
+}
+
+// decideJoinRequest registra a decisão de um administrador sobre um pedido
+// pendente e, se aprovado, adiciona o usuário à sala.
+func (ih *InvitationsHandler) decideJoinRequest(w http.ResponseWriter, r *http.Request, status string) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	room, ok := ih.loadAdminRoom(w, r, claims.UserID)
+	if !ok {
+		return
+	}
+
+	if !checkRoomWritable(w, room) {
+		return
+	}
+
+	requestID, err := strconv.ParseUint(chi.URLParam(r, "request_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid join request ID")
+		return
+	}
+
+	request, err := ih.InvitationsRepository.WithContext(r.Context()).GetJoinRequest(uint(requestID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get join request")
+		return
+	}
+	if request == nil || request.RoomID != room.ID {
+		utils.RespondWithError(w, http.StatusNotFound, "Join request not found")
+		return
+	}
+
+	if request.Status != models.JoinRequestPending {
+		utils.RespondWithError(w, http.StatusConflict, "Join request is not pending")
+		return
+	}
+
+	eventType := events.RoomJoinDenied
+	if status == models.JoinRequestApproved {
+		eventType = events.RoomJoinApproved
+		if !checkRoomCapacity(w, ih.RoomsRepository.WithContext(r.Context()), room) {
+			return
+		}
+	}
+
+	err = ih.Outbox.Transaction(func(tx *gorm.DB) error {
+		decided, err := ih.InvitationsRepository.WithContext(r.Context()).WithTx(tx).DecideJoinRequest(request.ID, status, claims.UserID)
+		if err != nil {
+			return err
+		}
+		if !decided {
+			return errJoinRequestGone
+		}
+
+		// Quem entrou por outro caminho enquanto aguardava já é membro.
+		if status == models.JoinRequestApproved && !ih.RoomsRepository.WithContext(r.Context()).WithTx(tx).IsUserInRoom(request.UserID, room.ID) {
+			if err := addMember(tx, ih.RoomsRepository.WithContext(r.Context()), ih.Outbox, ih.Audit, r, room, request.UserID, request.User.Name, models.RoomRoleMember); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		request.Status = status
+		request.DecidedBy = &claims.UserID
+		request.DecidedAt = &now
+		return ih.Outbox.Publish(tx, events.Event{
+			Type:    eventType,
+			ActorID: claims.UserID,
+			RoomID:  room.ID,
+			UserID:  request.UserID,
+			Data: map[string]any{
+				"actor_name": claims.Name,
+				"room_name":  room.Name,
+			},
+		})
+	})
+	if errors.Is(err, errJoinRequestGone) {
+		utils.RespondWithError(w, http.StatusConflict, "Join request is not pending")
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to save decision")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toJoinRequestResponse(*request))
+}
+
+func (ih *InvitationsHandler) loadRoom(w http.ResponseWriter, r *http.Request) (*models.Room, bool) {
+	roomID, err := strconv.ParseUint(chi.URLParam(r, "room_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid room ID")
+		return nil, false
+	}
+
+	room, err := ih.RoomsRepository.WithContext(r.Context()).FindByID(uint(roomID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return nil, false
+	}
+	if room == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found")
+		return nil, false
+	}
+	return room, true
+}
+
+// loadAdminRoom busca a sala da URL, que precisa ser administrada pelo usuário.
+func (ih *InvitationsHandler) loadAdminRoom(w http.ResponseWriter, r *http.Request, userID uint) (*models.Room, bool) {
+	room, ok := ih.loadRoom(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	if !ih.RoomsRepository.WithContext(r.Context()).IsRoomAdmin(userID, room) {
+		utils.RespondWithError(w, http.StatusForbidden, "Only room creator or admins can manage invitations")
+		return nil, false
+	}
+	return room, true
+}
+
+// newInviteCode gera um código aleatório para um convite novo.
+func newInviteCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func toInviteResponse(invite models.RoomInvite) dtos.InviteResponse {
+	response := dtos.InviteResponse{
+		ID:        invite.ID,
+		RoomID:    invite.RoomID,
+		Code:      invite.Code,
+		Role:      invite.Role,
+		MaxUses:   invite.MaxUses,
+		Uses:      invite.Uses,
+		CreatedBy: invite.CreatedBy,
+		CreatedAt: invite.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if invite.ExpiresAt != nil {
+		expiresAt := invite.ExpiresAt.Format("2006-01-02T15:04:05Z07:00")
+		response.ExpiresAt = &expiresAt
+	}
+	return response
+}
+
+func toJoinRequestResponse(request models.RoomJoinRequest) dtos.JoinRequestResponse {
+	response := dtos.JoinRequestResponse{
+		ID:        request.ID,
+		RoomID:    request.RoomID,
+		UserID:    request.UserID,
+		UserName:  request.User.Name,
+		Message:   request.Message,
+		Status:    request.Status,
+		DecidedBy: request.DecidedBy,
+		CreatedAt: request.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if request.DecidedAt != nil {
+		decidedAt := request.DecidedAt.Format("2006-01-02T15:04:05Z07:00")
+		response.DecidedAt = &decidedAt
+	}
+	return response
+}
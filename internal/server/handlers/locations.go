@@ -0,0 +1,824 @@
+package handlers
+
+import (
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/server/dtos"
+	"api-go/internal/server/middlewares"
+	"api-go/internal/tenant"
+	"api-go/internal/utils"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// LocationsHandler gerencia a hierarquia local → prédio → andar da
+// organização da requisição. Qualquer usuário pode criar itens; só quem
+// criou pode alterá-los ou excluí-los.
+type LocationsHandler struct {
+	LocationsRepository *repository.LocationsRepository
+}
+
+func (lh *LocationsHandler) RegisterLocationsRoutes(r chi.Router) {
+	r.Route("/sites", func(r chi.Router) {
+		r.Post("/", lh.CreateSiteHandler)
+		r.Get("/", lh.GetSitesHandler)
+		r.Get("/{site_id}", lh.GetSiteHandler)
+		r.Put("/{site_id}", lh.UpdateSiteHandler)
+		r.Delete("/{site_id}", lh.DeleteSiteHandler)
+	})
+	r.Route("/buildings", func(r chi.Router) {
+		r.Post("/", lh.CreateBuildingHandler)
+		r.Get("/", lh.GetBuildingsHandler)
+		r.Get("/{building_id}", lh.GetBuildingHandler)
+		r.Put("/{building_id}", lh.UpdateBuildingHandler)
+		r.Delete("/{building_id}", lh.DeleteBuildingHandler)
+	})
+	r.Route("/floors", func(r chi.Router) {
+		r.Post("/", lh.CreateFloorHandler)
+		r.Get("/", lh.GetFloorsHandler)
+		r.Get("/{floor_id}", lh.GetFloorHandler)
+		r.Put("/{floor_id}", lh.UpdateFloorHandler)
+		r.Delete("/{floor_id}", lh.DeleteFloorHandler)
+	})
+}
+
+// CreateSiteHandler creates a site
+//
+//	@Summary		Create site
+//	@Description	Create a site, the top level of the room location hierarchy
+//	@Tags			locations
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dtos.SiteRequest	true	"Site details"
+//	@Success		201		{object}	dtos.SiteResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/sites [post]
+func (lh *LocationsHandler) CreateSiteHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req dtos.SiteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	orgID, _ := tenant.FromContext(r.Context())
+	site := models.Site{
+		OrganizationID: orgID,
+		Name:           strings.TrimSpace(req.Name),
+		Address:        strings.TrimSpace(req.Address),
+		CreatedBy:      claims.UserID,
+	}
+	if site.Name == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	if err := lh.LocationsRepository.WithContext(r.Context()).CreateSite(&site); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create site")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toSiteResponse(site))
+}
+
+// GetSitesHandler lists the sites
+//
+//	@Summary		Get sites
+//	@Description	List all sites
+//	@Tags			locations
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		dtos.SiteResponse
+//	@Failure		500	{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/sites [get]
+func (lh *LocationsHandler) GetSitesHandler(w http.ResponseWriter, r *http.Request) {
+	sites, err := lh.LocationsRepository.WithContext(r.Context()).GetSites()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get sites")
+		return
+	}
+
+	response := make([]dtos.SiteResponse, len(sites))
+	for i, site := range sites {
+		response[i] = toSiteResponse(site)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSiteHandler gets a site
+//
+//	@Summary		Get site
+//	@Description	Get a site with its buildings
+//	@Tags			locations
+//	@Accept			json
+//	@Produce		json
+//	@Param			site_id	path		int	true	"Site ID"
+//	@Success		200		{object}	dtos.SiteResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/sites/{site_id} [get]
+func (lh *LocationsHandler) GetSiteHandler(w http.ResponseWriter, r *http.Request) {
+	site, ok := lh.loadSite(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toSiteResponse(*site))
+}
+
+// UpdateSiteHandler updates a site
+//
+//	@Summary		Update site
+//	@Description	Update a site (only by its creator)
+//	@Tags			locations
+//	@Accept			json
+//	@Produce		json
+//	@Param			site_id	path		int					true	"Site ID"
+//	@Param			request	body		dtos.SiteRequest	true	"Site details"
+//	@Success		200		{object}	dtos.SiteResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/sites/{site_id} [put]
+func (lh *LocationsHandler) UpdateSiteHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	site, ok := lh.loadSite(w, r)
+	if !ok {
+		return
+	}
+
+	if site.CreatedBy != claims.UserID {
+		utils.RespondWithError(w, http.StatusForbidden, "Only the site creator can update the site")
+		return
+	}
+
+	var req dtos.SiteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	site.Name = strings.TrimSpace(req.Name)
+	site.Address = strings.TrimSpace(req.Address)
+	if site.Name == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	if err := lh.LocationsRepository.WithContext(r.Context()).UpdateSite(site); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update site")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toSiteResponse(*site))
+}
+
+// DeleteSiteHandler deletes a site
+//
+//	@Summary		Delete site
+//	@Description	Delete a site without buildings (only by its creator)
+//	@Tags			locations
+//	@Accept			json
+//	@Produce		json
+//	@Param			site_id	path		int	true	"Site ID"
+//	@Success		200		{object}	map[string]string
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/sites/{site_id} [delete]
+func (lh *LocationsHandler) DeleteSiteHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	site, ok := lh.loadSite(w, r)
+	if !ok {
+		return
+	}
+
+	if site.CreatedBy != claims.UserID {
+		utils.RespondWithError(w, http.StatusForbidden, "Only the site creator can delete the site")
+		return
+	}
+
+	hasBuildings, err := lh.LocationsRepository.WithContext(r.Context()).SiteHasBuildings(site.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to check buildings")
+		return
+	}
+	if hasBuildings {
+		utils.RespondWithError(w, http.StatusConflict, "Site still has buildings")
+		return
+	}
+
+	if err := lh.LocationsRepository.WithContext(r.Context()).DeleteSite(site.ID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete site")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Site deleted successfully"}`))
+}
+
+// CreateBuildingHandler creates a building
+//
+//	@Summary		Create building
+//	@Description	Create a building in a site
+//	@Tags			locations
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dtos.BuildingRequest	true	"Building details"
+//	@Success		201		{object}	dtos.BuildingResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/buildings [post]
+func (lh *LocationsHandler) CreateBuildingHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req dtos.BuildingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	orgID, _ := tenant.FromContext(r.Context())
+	building := models.Building{
+		OrganizationID: orgID,
+		SiteID:         req.SiteID,
+		Name:           strings.TrimSpace(req.Name),
+		CreatedBy:      claims.UserID,
+	}
+	if !lh.validateBuilding(w, r, &building) {
+		return
+	}
+
+	if err := lh.LocationsRepository.WithContext(r.Context()).CreateBuilding(&building); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create building")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toBuildingResponse(building))
+}
+
+// GetBuildingsHandler lists the buildings
+//
+//	@Summary		Get buildings
+//	@Description	List the buildings, optionally of a single site
+//	@Tags			locations
+//	@Accept			json
+//	@Produce		json
+//	@Param			site_id	query		int	false	"Site ID"
+//	@Success		200		{array}		dtos.BuildingResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/buildings [get]
+func (lh *LocationsHandler) GetBuildingsHandler(w http.ResponseWriter, r *http.Request) {
+	var siteID uint64
+	if value := r.URL.Query().Get("site_id"); value != "" {
+		var err error
+		if siteID, err = strconv.ParseUint(value, 10, 32); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid site_id")
+			return
+		}
+	}
+
+	buildings, err := lh.LocationsRepository.WithContext(r.Context()).GetBuildings(uint(siteID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get buildings")
+		return
+	}
+
+	response := make([]dtos.BuildingResponse, len(buildings))
+	for i, building := range buildings {
+		response[i] = toBuildingResponse(building)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetBuildingHandler gets a building
+//
+//	@Summary		Get building
+//	@Description	Get a building with its floors
+//	@Tags			locations
+//	@Accept			json
+//	@Produce		json
+//	@Param			building_id	path		int	true	"Building ID"
+//	@Success		200			{object}	dtos.BuildingResponse
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/buildings/{building_id} [get]
+func (lh *LocationsHandler) GetBuildingHandler(w http.ResponseWriter, r *http.Request) {
+	building, ok := lh.loadBuilding(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toBuildingResponse(*building))
+}
+
+// UpdateBuildingHandler updates a building
+//
+//	@Summary		Update building
+//	@Description	Update or move a building to another site (only by its creator)
+//	@Tags			locations
+//	@Accept			json
+//	@Produce		json
+//	@Param			building_id	path		int						true	"Building ID"
+//	@Param			request		body		dtos.BuildingRequest	true	"Building details"
+//	@Success		200			{object}	dtos.BuildingResponse
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		403			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/buildings/{building_id} [put]
+func (lh *LocationsHandler) UpdateBuildingHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	building, ok := lh.loadBuilding(w, r)
+	if !ok {
+		return
+	}
+
+	if building.CreatedBy != claims.UserID {
+		utils.RespondWithError(w, http.StatusForbidden, "Only the building creator can update the building")
+		return
+	}
+
+	var req dtos.BuildingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	building.SiteID = req.SiteID
+	building.Name = strings.TrimSpace(req.Name)
+	if !lh.validateBuilding(w, r, building) {
+		return
+	}
+
+	if err := lh.LocationsRepository.WithContext(r.Context()).UpdateBuilding(building); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update building")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toBuildingResponse(*building))
+}
+
+// DeleteBuildingHandler deletes a building
+//
+//	@Summary		Delete building
+//	@Description	Delete a building without floors (only by its creator)
+//	@Tags			locations
+//	@Accept			json
+//	@Produce		json
+//	@Param			building_id	path		int	true	"Building ID"
+//	@Success		200			{object}	map[string]string
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		403			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		409			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/buildings/{building_id} [delete]
+func (lh *LocationsHandler) DeleteBuildingHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	building, ok := lh.loadBuilding(w, r)
+	if !ok {
+		return
+	}
+
+	if building.CreatedBy != claims.UserID {
+		utils.RespondWithError(w, http.StatusForbidden, "Only the building creator can delete the building")
+		return
+	}
+
+	hasFloors, err := lh.LocationsRepository.WithContext(r.Context()).BuildingHasFloors(building.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to check floors")
+		return
+	}
+	if hasFloors {
+		utils.RespondWithError(w, http.StatusConflict, "Building still has floors")
+		return
+	}
+
+	if err := lh.LocationsRepository.WithContext(r.Context()).DeleteBuilding(building.ID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete building")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Building deleted successfully"}`))
+}
+
+// CreateFloorHandler creates a floor
+//
+//	@Summary		Create floor
+//	@Description	Create a floor in a building, optionally with a floor plan image to place rooms on
+//	@Tags			locations
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dtos.FloorRequest	true	"Floor details"
+//	@Success		201		{object}	dtos.FloorResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/floors [post]
+func (lh *LocationsHandler) CreateFloorHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req dtos.FloorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	orgID, _ := tenant.FromContext(r.Context())
+	floor := models.Floor{
+		OrganizationID: orgID,
+		BuildingID:     req.BuildingID,
+		Name:           strings.TrimSpace(req.Name),
+		Level:          req.Level,
+		PlanURL:        strings.TrimSpace(req.PlanURL),
+		CreatedBy:      claims.UserID,
+	}
+	if !lh.validateFloor(w, r, &floor) {
+		return
+	}
+
+	if err := lh.LocationsRepository.WithContext(r.Context()).CreateFloor(&floor); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create floor")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toFloorResponse(floor))
+}
+
+// GetFloorsHandler lists the floors
+//
+//	@Summary		Get floors
+//	@Description	List the floors, optionally of a single building
+//	@Tags			locations
+//	@Accept			json
+//	@Produce		json
+//	@Param			building_id	query		int	false	"Building ID"
+//	@Success		200			{array}		dtos.FloorResponse
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/floors [get]
+func (lh *LocationsHandler) GetFloorsHandler(w http.ResponseWriter, r *http.Request) {
+	var buildingID uint64
+	if value := r.URL.Query().Get("building_id"); value != "" {
+		var err error
+		if buildingID, err = strconv.ParseUint(value, 10, 32); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid building_id")
+			return
+		}
+	}
+
+	floors, err := lh.LocationsRepository.WithContext(r.Context()).GetFloors(uint(buildingID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get floors")
+		return
+	}
+
+	response := make([]dtos.FloorResponse, len(floors))
+	for i, floor := range floors {
+		response[i] = toFloorResponse(floor)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetFloorHandler gets a floor
+//
+//	@Summary		Get floor
+//	@Description	Get a floor
+//	@Tags			locations
+//	@Accept			json
+//	@Produce		json
+//	@Param			floor_id	path		int	true	"Floor ID"
+//	@Success		200			{object}	dtos.FloorResponse
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/floors/{floor_id} [get]
+func (lh *LocationsHandler) GetFloorHandler(w http.ResponseWriter, r *http.Request) {
+	floor, ok := lh.loadFloor(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toFloorResponse(*floor))
+}
+
+// UpdateFloorHandler updates a floor
+//
+//	@Summary		Update floor
+//	@Description	Update or move a floor to another building (only by its creator)
+//	@Tags			locations
+//	@Accept			json
+//	@Produce		json
+//	@Param			floor_id	path		int					true	"Floor ID"
+//	@Param			request		body		dtos.FloorRequest	true	"Floor details"
+//	@Success		200			{object}	dtos.FloorResponse
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		403			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/floors/{floor_id} [put]
+func (lh *LocationsHandler) UpdateFloorHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	floor, ok := lh.loadFloor(w, r)
+	if !ok {
+		return
+	}
+
+	if floor.CreatedBy != claims.UserID {
+		utils.RespondWithError(w, http.StatusForbidden, "Only the floor creator can update the floor")
+		return
+	}
+
+	var req dtos.FloorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	floor.BuildingID = req.BuildingID
+	floor.Name = strings.TrimSpace(req.Name)
+	floor.Level = req.Level
+	floor.PlanURL = strings.TrimSpace(req.PlanURL)
+	if !lh.validateFloor(w, r, floor) {
+		return
+	}
+
+	if err := lh.LocationsRepository.WithContext(r.Context()).UpdateFloor(floor); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update floor")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toFloorResponse(*floor))
+}
+
+// DeleteFloorHandler deletes a floor
+//
+//	@Summary		Delete floor
+//	@Description	Delete a floor without rooms (only by its creator)
+//	@Tags			locations
+//	@Accept			json
+//	@Produce		json
+//	@Param			floor_id	path		int	true	"Floor ID"
+//	@Success		200			{object}	map[string]string
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		403			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		409			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/floors/{floor_id} [delete]
+func (lh *LocationsHandler) DeleteFloorHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	floor, ok := lh.loadFloor(w, r)
+	if !ok {
+		return
+	}
+
+	if floor.CreatedBy != claims.UserID {
+		utils.RespondWithError(w, http.StatusForbidden, "Only the floor creator can delete the floor")
+		return
+	}
+
+	hasRooms, err := lh.LocationsRepository.WithContext(r.Context()).FloorHasRooms(floor.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to check rooms")
+		return
+	}
+	if hasRooms {
+		utils.RespondWithError(w, http.StatusConflict, "Floor still has rooms")
+		return
+	}
+
+	if err := lh.LocationsRepository.WithContext(r.Context()).DeleteFloor(floor.ID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete floor")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Floor deleted successfully"}`))
+}
+
+func (lh *LocationsHandler) loadSite(w http.ResponseWriter, r *http.Request) (*models.Site, bool) {
+	siteID, err := strconv.ParseUint(chi.URLParam(r, "site_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid site ID")
+		return nil, false
+	}
+
+	site, err := lh.LocationsRepository.WithContext(r.Context()).GetSite(uint(siteID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get site")
+		return nil, false
+	}
+	if site == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Site not found")
+		return nil, false
+	}
+	return site, true
+}
+
+func (lh *LocationsHandler) loadBuilding(w http.ResponseWriter, r *http.Request) (*models.Building, bool) {
+	buildingID, err := strconv.ParseUint(chi.URLParam(r, "building_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid building ID")
+		return nil, false
+	}
+
+	building, err := lh.LocationsRepository.WithContext(r.Context()).GetBuilding(uint(buildingID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get building")
+		return nil, false
+	}
+	if building == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Building not found")
+		return nil, false
+	}
+	return building, true
+}
+
+func (lh *LocationsHandler) loadFloor(w http.ResponseWriter, r *http.Request) (*models.Floor, bool) {
+	floorID, err := strconv.ParseUint(chi.URLParam(r, "floor_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid floor ID")
+		return nil, false
+	}
+
+	floor, err := lh.LocationsRepository.WithContext(r.Context()).GetFloor(uint(floorID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get floor")
+		return nil, false
+	}
+	if floor == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Floor not found")
+		return nil, false
+	}
+	return floor, true
+}
+
+// validateBuilding confere o nome e o local do prédio, respondendo com o
+// erro se algo for inválido.
+func (lh *LocationsHandler) validateBuilding(w http.ResponseWriter, r *http.Request, building *models.Building) bool {
+	if building.Name == "" || building.SiteID == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "name and site_id are required")
+		return false
+	}
+
+	site, err := lh.LocationsRepository.WithContext(r.Context()).GetSite(building.SiteID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get site")
+		return false
+	}
+	if site == nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Site not found")
+		return false
+	}
+	return true
+}
+
+// validateFloor confere o nome e o prédio do andar, respondendo com o erro
+// se algo for inválido.
+func (lh *LocationsHandler) validateFloor(w http.ResponseWriter, r *http.Request, floor *models.Floor) bool {
+	if floor.Name == "" || floor.BuildingID == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "name and building_id are required")
+		return false
+	}
+
+	building, err := lh.LocationsRepository.WithContext(r.Context()).GetBuilding(floor.BuildingID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get building")
+		return false
+	}
+	if building == nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Building not found")
+		return false
+	}
+	return true
+}
+
+func toSiteResponse(site models.Site) dtos.SiteResponse {
+	response := dtos.SiteResponse{
+		ID:        site.ID,
+		Name:      site.Name,
+		Address:   site.Address,
+		CreatedBy: site.CreatedBy,
+		CreatedAt: site.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	for _, building := range site.Buildings {
+		response.Buildings = append(response.Buildings, toBuildingResponse(building))
+	}
+	return response
+}
+
+func toBuildingResponse(building models.Building) dtos.BuildingResponse {
+	response := dtos.BuildingResponse{
+		ID:        building.ID,
+		SiteID:    building.SiteID,
+		Name:      building.Name,
+		CreatedBy: building.CreatedBy,
+		CreatedAt: building.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	for _, floor := range building.Floors {
+		response.Floors = append(response.Floors, toFloorResponse(floor))
+	}
+	return response
+}
+
+func toFloorResponse(floor models.Floor) dtos.FloorResponse {
+	return dtos.FloorResponse{
+		ID:         floor.ID,
+		BuildingID: floor.BuildingID,
+		Name:       floor.Name,
+		Level:      floor.Level,
+		PlanURL:    floor.PlanURL,
+		CreatedBy:  floor.CreatedBy,
+		CreatedAt:  floor.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
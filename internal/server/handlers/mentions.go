@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"api-go/internal/auth"
+	"api-go/internal/events"
+	"api-go/internal/mentions"
+	"api-go/internal/server/dtos"
+	"api-go/internal/server/middlewares"
+	"api-go/internal/utils"
+	"encoding/json"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// GetMentionedNotesHandler lists the notes that mention the current user
+//
+//	@Summary		Get notes mentioning me
+//	@Description	Get all notes in which the current user was mentioned with @name or @email
+//	@Tags			notes
+//	@Accept			json
+//	@Produce		json
+//	@Success		200		{array}		dtos.NoteResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/notes/mentions [get]
+func (nh *NotesHandler) GetMentionedNotesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	notes, err := nh.MentionsRepository.WithContext(r.Context()).GetNotesMentioningUser(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get mentioned notes")
+		return
+	}
+
+	roomAdmins := newRoomAdmins(nh.RoomsRepository.WithContext(r.Context()), claims.UserID)
+	var response []dtos.NoteResponse
+	for _, note := range notes {
+		// A menção só é visível enquanto o usuário continua na sala.
+		if !nh.RoomsRepository.WithContext(r.Context()).IsUserInRoom(claims.UserID, note.RoomID) {
+			continue
+		}
+		response = append(response, dtos.NoteResponse{
+			ID:          note.ID,
+			UserID:      note.UserID,
+			RoomID:      note.RoomID,
+			Title:       note.Title,
+			Content:     note.Content,
+			Format:      note.Format,
+			ContentHTML: note.ContentHTML,
+			Excerpt:     note.Excerpt,
+			TOC:         toNoteHeadingsResponse(note.TableOfContents),
+			UserName:    note.User.Name,
+			UserEmail:   visibleEmail(claims, note.UserID, note.User.Email, roomAdmins.is(&note.Room)),
+			RoomName:    note.Room.Name,
+			CreatedAt:   note.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt:   note.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// syncMentions resolve as menções do conteúdo contra os membros da sala,
+// persiste o resultado e publica um evento para cada usuário mencionado
+// pela primeira vez. Roda na mesma transação que salva a nota, para que
+// nota e menções nunca divirjam.
+func (nh *NotesHandler) syncMentions(r *http.Request, tx *gorm.DB, noteID, roomID uint, title, content string, author *auth.Claims) error {
+	members, err := nh.RoomsRepository.WithContext(r.Context()).WithTx(tx).GetMembers(roomID)
+	if err != nil {
+		return err
+	}
+
+	var userIDs []uint
+	for _, userID := range mentions.Resolve(mentions.Parse(content), members) {
+		if userID != author.UserID {
+			userIDs = append(userIDs, userID)
+		}
+	}
+
+	added, err := nh.MentionsRepository.WithContext(r.Context()).WithTx(tx).Sync(noteID, author.UserID, userIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range added {
+		err := nh.Outbox.Publish(tx, events.Event{
+			Type:    events.NoteMentioned,
+			ActorID: author.UserID,
+			RoomID:  roomID,
+			NoteID:  noteID,
+			UserID:  userID,
+			Data: map[string]any{
+				"actor_name": author.Name,
+				"note_title": title,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
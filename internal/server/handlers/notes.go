@@ -1,13 +1,15 @@
 package handlers
 
 import (
+	"api-go/internal/audit"
+	"api-go/internal/events"
+	"api-go/internal/jobs"
+	"api-go/internal/models"
 	"api-go/internal/repository"
 	"api-go/internal/server/dtos"
 	"api-go/internal/server/middlewares"
 	"api-go/internal/utils"
 	"encoding/json"
-	"errors"
-	"fmt"
 	"net/http"
 	"strconv"
 
@@ -16,19 +18,22 @@ import (
 )
 
 type NotesHandler struct {
-	NotesRepository *repository.NotesRepository
-	RoomsRepository *repository.RoomsRepository
+	NotesRepository    *repository.NotesRepository
+	RoomsRepository    *repository.RoomsRepository
+	MentionsRepository *repository.MentionsRepository
+	Outbox             *jobs.Outbox
+	Audit              *audit.Log
 }
 
 func (nh *NotesHandler) RegisterNotesRoutes(r chi.Router) {
 	r.Route("/notes", func(r chi.Router) {
 		r.Post("/", nh.CreateNoteHandler)
-		r.Get("/", nh.GetAllNotes)
 		r.Get("/{note_id}", nh.GetNoteByIDHandler)
 		r.Put("/{note_id}", nh.UpdateNoteHandler)
 		r.Delete("/{note_id}", nh.DeleteNoteHandler)
 		r.Get("/room/{room_id}", nh.GetNotesByRoomHandler)
 		r.Get("/my-notes", nh.GetUserNotesHandler)
+		r.Get("/mentions", nh.GetMentionedNotesHandler)
 	})
 }
 
@@ -67,25 +72,60 @@ func (nh *NotesHandler) CreateNoteHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if !nh.RoomsRepository.IsUserInRoom(userID, req.RoomID) {
+	if !isValidNoteFormat(req.Format) {
+		utils.RespondWithError(w, http.StatusBadRequest, "Format must be 'plain' or 'markdown'")
+		return
+	}
+
+	if !nh.RoomsRepository.WithContext(r.Context()).IsUserInRoom(userID, req.RoomID) {
 		utils.RespondWithError(w, http.StatusForbidden, "User is not a member of this room")
 		return
 	}
 
-	note, err := nh.NotesRepository.Create(userID, req.RoomID, req.Title, req.Content)
+	if nh.RoomsRepository.WithContext(r.Context()).IsArchived(req.RoomID) {
+		utils.RespondWithError(w, http.StatusConflict, "Room is archived and read-only")
+		return
+	}
+
+	var note *models.Note
+	err := nh.Outbox.Transaction(func(tx *gorm.DB) error {
+		var err error
+		note, err = nh.NotesRepository.WithContext(r.Context()).WithTx(tx).Create(userID, req.RoomID, req.Title, req.Content, req.Format)
+		if err != nil {
+			return err
+		}
+		err = nh.Outbox.Publish(tx, events.Event{
+			Type:    events.NoteCreated,
+			ActorID: userID,
+			RoomID:  note.RoomID,
+			NoteID:  note.ID,
+			Data: map[string]any{
+				"actor_name": claims.Name,
+				"note_title": note.Title,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		return nh.syncMentions(r, tx, note.ID, note.RoomID, note.Title, note.Content, claims)
+	})
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create note")
 		return
 	}
 
 	response := dtos.NoteResponse{
-		ID:        note.ID,
-		UserID:    note.UserID,
-		RoomID:    note.RoomID,
-		Title:     note.Title,
-		Content:   note.Content,
-		CreatedAt: note.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: note.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		ID:          note.ID,
+		UserID:      note.UserID,
+		RoomID:      note.RoomID,
+		Title:       note.Title,
+		Content:     note.Content,
+		Format:      note.Format,
+		ContentHTML: note.ContentHTML,
+		Excerpt:     note.Excerpt,
+		TOC:         toNoteHeadingsResponse(note.TableOfContents),
+		CreatedAt:   note.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:   note.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -123,7 +163,7 @@ func (nh *NotesHandler) GetNoteByIDHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	note, err := nh.NotesRepository.GetByID(uint(noteID))
+	note, err := nh.NotesRepository.WithContext(r.Context()).GetByID(uint(noteID))
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get note")
 		return
@@ -133,22 +173,31 @@ func (nh *NotesHandler) GetNoteByIDHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if !nh.RoomsRepository.IsUserInRoom(userID, note.RoomID) {
+	if !nh.RoomsRepository.WithContext(r.Context()).IsUserInRoom(userID, note.RoomID) {
 		utils.RespondWithError(w, http.StatusForbidden, "User is not a member of this room")
 		return
 	}
+	roomAdmin := nh.RoomsRepository.WithContext(r.Context()).IsRoomAdmin(userID, &note.Room)
 
 	response := dtos.NoteResponse{
-		ID:        note.ID,
-		UserID:    note.UserID,
-		RoomID:    note.RoomID,
-		Title:     note.Title,
-		Content:   note.Content,
-		UserName:  note.User.Name,
-		UserEmail: note.User.Email,
-		RoomName:  note.Room.Name,
-		CreatedAt: note.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: note.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		ID:          note.ID,
+		UserID:      note.UserID,
+		RoomID:      note.RoomID,
+		Title:       note.Title,
+		Content:     note.Content,
+		Format:      note.Format,
+		ContentHTML: note.ContentHTML,
+		Excerpt:     note.Excerpt,
+		TOC:         toNoteHeadingsResponse(note.TableOfContents),
+		UserName:    note.User.Name,
+		UserEmail:   visibleEmail(claims, note.UserID, note.User.Email, roomAdmin),
+		RoomName:    note.Room.Name,
+		CreatedAt:   note.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:   note.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+
+	for _, attachment := range note.Attachments {
+		response.Attachments = append(response.Attachments, toAttachmentResponse(attachment))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -186,7 +235,7 @@ func (nh *NotesHandler) UpdateNoteHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	note, err := nh.NotesRepository.GetByID(uint(noteID))
+	note, err := nh.NotesRepository.WithContext(r.Context()).GetByID(uint(noteID))
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get note")
 		return
@@ -201,6 +250,10 @@ func (nh *NotesHandler) UpdateNoteHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if !checkRoomWritable(w, &note.Room) {
+		return
+	}
+
 	var req dtos.UpdateNoteRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
@@ -212,7 +265,37 @@ func (nh *NotesHandler) UpdateNoteHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if err := nh.NotesRepository.Update(uint(noteID), req.Title, req.Content); err != nil {
+	if !isValidNoteFormat(req.Format) {
+		utils.RespondWithError(w, http.StatusBadRequest, "Format must be 'plain' or 'markdown'")
+		return
+	}
+
+	// Sem formato explícito, a nota mantém o formato atual.
+	format := req.Format
+	if format == "" {
+		format = note.Format
+	}
+
+	err = nh.Outbox.Transaction(func(tx *gorm.DB) error {
+		if err := nh.NotesRepository.WithContext(r.Context()).WithTx(tx).Update(uint(noteID), req.Title, req.Content, format); err != nil {
+			return err
+		}
+		err := nh.Outbox.Publish(tx, events.Event{
+			Type:    events.NoteUpdated,
+			ActorID: userID,
+			RoomID:  note.RoomID,
+			NoteID:  note.ID,
+			Data: map[string]any{
+				"actor_name": claims.Name,
+				"note_title": req.Title,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		return nh.syncMentions(r, tx, note.ID, note.RoomID, req.Title, req.Content, claims)
+	})
+	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update note")
 		return
 	}
@@ -224,7 +307,7 @@ func (nh *NotesHandler) UpdateNoteHandler(w http.ResponseWriter, r *http.Request
 // DeleteNoteHandler deletes a note
 //
 //	@Summary		Delete note
-//	@Description	Delete note (only by note creator)
+//	@Description	Move the note to the trash with its attachments (only by note creator)
 //	@Tags			notes
 //	@Accept			json
 //	@Produce		json
@@ -251,7 +334,7 @@ func (nh *NotesHandler) DeleteNoteHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	note, err := nh.NotesRepository.GetByID(uint(noteID))
+	note, err := nh.NotesRepository.WithContext(r.Context()).GetByID(uint(noteID))
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get note")
 		return
@@ -266,7 +349,36 @@ func (nh *NotesHandler) DeleteNoteHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if err := nh.NotesRepository.Delete(uint(noteID)); err != nil {
+	if !checkRoomWritable(w, &note.Room) {
+		return
+	}
+
+	err = nh.Outbox.Transaction(func(tx *gorm.DB) error {
+		if err := nh.NotesRepository.WithContext(r.Context()).WithTx(tx).Delete(uint(noteID)); err != nil {
+			return err
+		}
+		err := nh.Audit.Record(tx, auditMeta(r), audit.Entry{
+			Action:     audit.ActionNoteDeleted,
+			TargetType: models.AuditTargetNote,
+			TargetID:   note.ID,
+			RoomID:     note.RoomID,
+			Before:     noteAuditSnapshot(*note),
+		})
+		if err != nil {
+			return err
+		}
+		return nh.Outbox.Publish(tx, events.Event{
+			Type:    events.NoteDeleted,
+			ActorID: userID,
+			RoomID:  note.RoomID,
+			NoteID:  note.ID,
+			Data: map[string]any{
+				"actor_name": claims.Name,
+				"note_title": note.Title,
+			},
+		})
+	})
+	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete note")
 		return
 	}
@@ -286,6 +398,7 @@ func (nh *NotesHandler) DeleteNoteHandler(w http.ResponseWriter, r *http.Request
 //	@Success		200		{array}	dtos.NoteResponse
 //	@Failure		400		{object}	dtos.ErrorResponse
 //	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
 //	@Failure		500		{object}	dtos.ErrorResponse
 //	@Security		BearerAuth
 //	@Router			/notes/room/{room_id} [get]
@@ -304,12 +417,23 @@ func (nh *NotesHandler) GetNotesByRoomHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	if !nh.RoomsRepository.IsUserInRoom(userID, uint(roomID)) {
+	if !nh.RoomsRepository.WithContext(r.Context()).IsUserInRoom(userID, uint(roomID)) {
 		utils.RespondWithError(w, http.StatusForbidden, "User is not a member of this room")
 		return
 	}
 
-	notes, err := nh.NotesRepository.GetByRoomID(uint(roomID))
+	room, err := nh.RoomsRepository.WithContext(r.Context()).FindByID(uint(roomID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return
+	}
+	if room == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found")
+		return
+	}
+	roomAdmin := nh.RoomsRepository.WithContext(r.Context()).IsRoomAdmin(userID, room)
+
+	notes, err := nh.NotesRepository.WithContext(r.Context()).GetByRoomID(uint(roomID))
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get notes")
 		return
@@ -318,15 +442,19 @@ func (nh *NotesHandler) GetNotesByRoomHandler(w http.ResponseWriter, r *http.Req
 	var response []dtos.NoteResponse
 	for _, note := range notes {
 		response = append(response, dtos.NoteResponse{
-			ID:        note.ID,
-			UserID:    note.UserID,
-			RoomID:    note.RoomID,
-			Title:     note.Title,
-			Content:   note.Content,
-			UserName:  note.User.Name,
-			UserEmail: note.User.Email,
-			CreatedAt: note.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt: note.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			ID:          note.ID,
+			UserID:      note.UserID,
+			RoomID:      note.RoomID,
+			Title:       note.Title,
+			Content:     note.Content,
+			Format:      note.Format,
+			ContentHTML: note.ContentHTML,
+			Excerpt:     note.Excerpt,
+			TOC:         toNoteHeadingsResponse(note.TableOfContents),
+			UserName:    note.User.Name,
+			UserEmail:   visibleEmail(claims, note.UserID, note.User.Email, roomAdmin),
+			CreatedAt:   note.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt:   note.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		})
 	}
 
@@ -354,7 +482,7 @@ func (nh *NotesHandler) GetUserNotesHandler(w http.ResponseWriter, r *http.Reque
 
 	userID := claims.UserID
 
-	notes, err := nh.NotesRepository.GetByUserID(userID)
+	notes, err := nh.NotesRepository.WithContext(r.Context()).GetByUserID(userID)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get user notes")
 		return
@@ -363,14 +491,18 @@ func (nh *NotesHandler) GetUserNotesHandler(w http.ResponseWriter, r *http.Reque
 	var response []dtos.NoteResponse
 	for _, note := range notes {
 		response = append(response, dtos.NoteResponse{
-			ID:        note.ID,
-			UserID:    note.UserID,
-			RoomID:    note.RoomID,
-			Title:     note.Title,
-			Content:   note.Content,
-			RoomName:  note.Room.Name,
-			CreatedAt: note.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt: note.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			ID:          note.ID,
+			UserID:      note.UserID,
+			RoomID:      note.RoomID,
+			Title:       note.Title,
+			Content:     note.Content,
+			Format:      note.Format,
+			ContentHTML: note.ContentHTML,
+			Excerpt:     note.Excerpt,
+			TOC:         toNoteHeadingsResponse(note.TableOfContents),
+			RoomName:    note.Room.Name,
+			CreatedAt:   note.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt:   note.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		})
 	}
 
@@ -378,39 +510,18 @@ func (nh *NotesHandler) GetUserNotesHandler(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(response)
 }
 
-func (nh *NotesHandler) GetAllNotes(w http.ResponseWriter, r *http.Request) {
-
-	notes, err := nh.NotesRepository.GetAll()
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			utils.RespondWithError(w, http.StatusNotFound, "Nenhum usuario")
-			return
-		}
-		utils.RespondWithError(w, http.StatusInternalServerError, "Erro interno")
-		return
-	}
-
-	noteList := make([]dtos.NoteResponse, len(notes))
-	for i, note := range notes {
-		noteList[i] = dtos.NoteResponse{
-			ID:        note.ID,
-			UserID:    note.UserID,
-			RoomID:    note.RoomID,
-			Title:     note.Title,
-			Content:   note.Content,
-			UserName:  note.User.Name,
-			UserEmail: note.User.Email,
-			RoomName:  note.Room.Name,
-			CreatedAt: note.CreatedAt.GoString(),
-			UpdatedAt: note.UpdatedAt.GoString(),
-		}
-	}
+func isValidNoteFormat(format string) bool {
+	return format == "" || format == models.NoteFormatPlain || format == models.NoteFormatMarkdown
+}
 
-	response := noteList
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, fmt.Sprintf("Falha ao codificar resposta: %v", err), http.StatusInternalServerError)
+func toNoteHeadingsResponse(headings []models.NoteHeading) []dtos.NoteHeadingResponse {
+	var response []dtos.NoteHeadingResponse
+	for _, heading := range headings {
+		response = append(response, dtos.NoteHeadingResponse{
+			Level: heading.Level,
+			Text:  heading.Text,
+			ID:    heading.ID,
+		})
 	}
-
+	return response
 }
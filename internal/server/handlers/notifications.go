@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/server/dtos"
+	"api-go/internal/server/middlewares"
+	"api-go/internal/utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultNotificationsLimit = 50
+	maxNotificationsLimit     = 200
+)
+
+type NotificationsHandler struct {
+	NotificationsRepository *repository.NotificationsRepository
+}
+
+func (nh *NotificationsHandler) RegisterNotificationsRoutes(r chi.Router) {
+	r.Route("/notifications", func(r chi.Router) {
+		r.Get("/", nh.GetNotificationsHandler)
+		r.Get("/unread-count", nh.GetUnreadCountHandler)
+		r.Post("/read-all", nh.MarkAllReadHandler)
+		r.Post("/{notification_id}/read", nh.MarkReadHandler)
+		r.Get("/preferences", nh.GetPreferencesHandler)
+		r.Put("/preferences", nh.UpdatePreferencesHandler)
+	})
+}
+
+// GetNotificationsHandler lists the current user's notifications
+//
+//	@Summary		List notifications
+//	@Description	List the notifications of the current user, newest first
+//	@Tags			notifications
+//	@Accept			json
+//	@Produce		json
+//	@Param			unread	query		bool	false	"Only unread notifications"
+//	@Param			limit	query		int		false	"Maximum number of notifications (default 50, max 200)"
+//	@Success		200		{array}		dtos.NotificationResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/notifications [get]
+func (nh *NotificationsHandler) GetNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+
+	limit := defaultNotificationsLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = min(parsed, maxNotificationsLimit)
+	}
+
+	notifications, err := nh.NotificationsRepository.GetByUserID(claims.UserID, unreadOnly, limit)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get notifications")
+		return
+	}
+
+	response := make([]dtos.NotificationResponse, len(notifications))
+	for i, notification := range notifications {
+		response[i] = toNotificationResponse(notification)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetUnreadCountHandler counts the current user's unread notifications
+//
+//	@Summary		Count unread notifications
+//	@Description	Get the number of unread notifications of the current user
+//	@Tags			notifications
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	dtos.UnreadCountResponse
+//	@Failure		500	{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/notifications/unread-count [get]
+func (nh *NotificationsHandler) GetUnreadCountHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	count, err := nh.NotificationsRepository.CountUnread(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to count notifications")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dtos.UnreadCountResponse{Unread: count})
+}
+
+// MarkReadHandler marks a notification as read
+//
+//	@Summary		Mark notification as read
+//	@Description	Mark one of the current user's notifications as read
+//	@Tags			notifications
+//	@Accept			json
+//	@Produce		json
+//	@Param			notification_id	path		int	true	"Notification ID"
+//	@Success		200				{object}	map[string]string
+//	@Failure		400				{object}	dtos.ErrorResponse
+//	@Failure		404				{object}	dtos.ErrorResponse
+//	@Failure		500				{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/notifications/{notification_id}/read [post]
+func (nh *NotificationsHandler) MarkReadHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	notificationID, err := strconv.ParseUint(chi.URLParam(r, "notification_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid notification ID")
+		return
+	}
+
+	found, err := nh.NotificationsRepository.MarkRead(uint(notificationID), claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to mark notification as read")
+		return
+	}
+	if !found {
+		utils.RespondWithError(w, http.StatusNotFound, "Notification not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Notification marked as read"}`))
+}
+
+// MarkAllReadHandler marks all notifications as read
+//
+//	@Summary		Mark all notifications as read
+//	@Description	Mark every unread notification of the current user as read
+//	@Tags			notifications
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	map[string]string
+//	@Failure		500	{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/notifications/read-all [post]
+func (nh *NotificationsHandler) MarkAllReadHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	updated, err := nh.NotificationsRepository.MarkAllRead(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to mark notifications as read")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, `{"message": "%d notifications marked as read"}`, updated)
+}
+
+// GetPreferencesHandler gets the notification preferences
+//
+//	@Summary		Get notification preferences
+//	@Description	Get whether each notification type is enabled for the current user
+//	@Tags			notifications
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		dtos.NotificationPreferenceResponse
+//	@Failure		500	{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/notifications/preferences [get]
+func (nh *NotificationsHandler) GetPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	nh.respondWithPreferences(w, claims.UserID)
+}
+
+// UpdatePreferencesHandler updates the notification preferences
+//
+//	@Summary		Update notification preferences
+//	@Description	Enable or disable notification types for the current user
+//	@Tags			notifications
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dtos.UpdateNotificationPreferencesRequest	true	"Preferences to change"
+//	@Success		200		{array}		dtos.NotificationPreferenceResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/notifications/preferences [put]
+func (nh *NotificationsHandler) UpdatePreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req dtos.UpdateNotificationPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	for _, preference := range req.Preferences {
+		if !isNotificationType(preference.Type) {
+			utils.RespondWithError(w, http.StatusBadRequest, "Unknown notification type: "+preference.Type)
+			return
+		}
+	}
+
+	for _, preference := range req.Preferences {
+		if err := nh.NotificationsRepository.SetPreference(claims.UserID, preference.Type, preference.Enabled); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update preferences")
+			return
+		}
+	}
+
+	nh.respondWithPreferences(w, claims.UserID)
+}
+
+// respondWithPreferences responde com todos os tipos de notificação,
+// preenchendo com "ativo" os que o usuário nunca configurou.
+func (nh *NotificationsHandler) respondWithPreferences(w http.ResponseWriter, userID uint) {
+	preferences, err := nh.NotificationsRepository.GetPreferences(userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get preferences")
+		return
+	}
+
+	enabled := make(map[string]bool, len(preferences))
+	for _, preference := range preferences {
+		enabled[preference.Type] = preference.Enabled
+	}
+
+	response := make([]dtos.NotificationPreferenceResponse, len(models.NotificationTypes))
+	for i, notificationType := range models.NotificationTypes {
+		value, ok := enabled[notificationType]
+		response[i] = dtos.NotificationPreferenceResponse{
+			Type:    notificationType,
+			Enabled: !ok || value,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func isNotificationType(notificationType string) bool {
+	for _, t := range models.NotificationTypes {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+func toNotificationResponse(notification models.Notification) dtos.NotificationResponse {
+	response := dtos.NotificationResponse{
+		ID:            notification.ID,
+		Type:          notification.Type,
+		Title:         notification.Title,
+		Message:       notification.Message,
+		ActorID:       notification.ActorID,
+		RoomID:        notification.RoomID,
+		NoteID:        notification.NoteID,
+		ReservationID: notification.ReservationID,
+		Read:          notification.ReadAt != nil,
+		CreatedAt:     notification.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if notification.ReadAt != nil {
+		readAt := notification.ReadAt.Format("2006-01-02T15:04:05Z07:00")
+		response.ReadAt = &readAt
+	}
+	return response
+}
@@ -0,0 +1,724 @@
+package handlers
+
+import (
+	"api-go/internal/audit"
+	"api-go/internal/auth"
+	"api-go/internal/events"
+	"api-go/internal/jobs"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/server/dtos"
+	"api-go/internal/server/middlewares"
+	"api-go/internal/tenant"
+	"api-go/internal/utils"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// organizationSlug aceita os slugs que também servem de subdomínio. O slug
+// precisa ainda de uma letra, para não se confundir com um id no header
+// X-Organization.
+var organizationSlug = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// Erros que interrompem as mudanças nos membros dentro da transação.
+var (
+	errOrgMemberGone = errors.New("organization member not found")
+	errLastOrgOwner  = errors.New("organization must keep an owner")
+	errOwnsOrgRooms  = errors.New("member owns rooms in the organization")
+)
+
+type OrganizationsHandler struct {
+	OrganizationsRepository *repository.OrganizationsRepository
+	RoomsRepository         *repository.RoomsRepository
+	UserRepository          *repository.UserRepository
+	Outbox                  *jobs.Outbox
+	Audit                   *audit.Log
+}
+
+func (oh *OrganizationsHandler) RegisterOrganizationsRoutes(r chi.Router) {
+	r.Route("/organizations", func(r chi.Router) {
+		r.Get("/", oh.ListOrganizationsHandler)
+		r.Post("/", oh.CreateOrganizationHandler)
+		r.Get("/{org_id}", oh.GetOrganizationHandler)
+		r.Put("/{org_id}", oh.UpdateOrganizationHandler)
+		r.Post("/{org_id}/switch", oh.SwitchOrganizationHandler)
+		r.Get("/{org_id}/members", oh.ListMembersHandler)
+		r.Post("/{org_id}/members", oh.AddMemberHandler)
+		r.Put("/{org_id}/members/{user_id}", oh.UpdateMemberHandler)
+		r.Delete("/{org_id}/members/{user_id}", oh.RemoveMemberHandler)
+	})
+}
+
+// ListOrganizationsHandler lists the caller's organizations
+//
+//	@Summary		List my organizations
+//	@Description	List the organizations the caller is a member of, by name
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		dtos.OrganizationResponse
+//	@Failure		500	{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/organizations [get]
+func (oh *OrganizationsHandler) ListOrganizationsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	members, err := oh.OrganizationsRepository.GetUserMemberships(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get organizations")
+		return
+	}
+
+	response := make([]dtos.OrganizationResponse, len(members))
+	for i, member := range members {
+		response[i] = toOrganizationResponse(member.Organization, member.Role)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateOrganizationHandler creates an organization
+//
+//	@Summary		Create organization
+//	@Description	Create an organization with the caller as its owner
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dtos.CreateOrganizationRequest	true	"Organization"
+//	@Success		201		{object}	dtos.OrganizationResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/organizations [post]
+func (oh *OrganizationsHandler) CreateOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req dtos.CreateOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	req.Slug = strings.ToLower(strings.TrimSpace(req.Slug))
+	if req.Name == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if !organizationSlug.MatchString(req.Slug) || !strings.ContainsAny(req.Slug, "abcdefghijklmnopqrstuvwxyz") {
+		utils.RespondWithError(w, http.StatusBadRequest, "slug must have lowercase letters, digits and hyphens, with at least one letter")
+		return
+	}
+
+	existing, err := oh.OrganizationsRepository.FindBySlug(req.Slug)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create organization")
+		return
+	}
+	if existing != nil {
+		utils.RespondWithError(w, http.StatusConflict, "Slug already in use")
+		return
+	}
+
+	org := models.Organization{Name: req.Name, Slug: req.Slug}
+	if err := oh.OrganizationsRepository.Create(&org, claims.UserID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create organization")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toOrganizationResponse(org, models.OrgRoleOwner))
+}
+
+// GetOrganizationHandler gets an organization
+//
+//	@Summary		Get organization
+//	@Description	Get an organization (only by its members)
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			org_id	path		int	true	"Organization ID"
+//	@Success		200		{object}	dtos.OrganizationResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/organizations/{org_id} [get]
+func (oh *OrganizationsHandler) GetOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	org, caller, ok := oh.loadOrganization(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toOrganizationResponse(*org, memberRole(caller)))
+}
+
+// UpdateOrganizationHandler renames an organization
+//
+//	@Summary		Rename organization
+//	@Description	Rename an organization (only by its owners and admins). The slug does not change.
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			org_id	path		int								true	"Organization ID"
+//	@Param			request	body		dtos.UpdateOrganizationRequest	true	"New name"
+//	@Success		200		{object}	dtos.OrganizationResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/organizations/{org_id} [put]
+func (oh *OrganizationsHandler) UpdateOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	org, caller, ok := oh.loadOrganization(w, r)
+	if !ok {
+		return
+	}
+	if !canManageOrganization(claims, caller) {
+		utils.RespondWithError(w, http.StatusForbidden, "Only owners and admins can change the organization")
+		return
+	}
+
+	var req dtos.UpdateOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	if err := oh.OrganizationsRepository.Rename(org.ID, req.Name); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update organization")
+		return
+	}
+	org.Name = req.Name
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toOrganizationResponse(*org, memberRole(caller)))
+}
+
+// SwitchOrganizationHandler makes an organization the active one
+//
+//	@Summary		Switch organization
+//	@Description	Issue a new token with the organization as the active one. Requests without the X-Organization header then use it.
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			org_id	path		int	true	"Organization ID"
+//	@Success		200		{object}	dtos.SwitchOrganizationResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/organizations/{org_id}/switch [post]
+func (oh *OrganizationsHandler) SwitchOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	org, caller, ok := oh.loadOrganization(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := oh.UserRepository.FindByID(claims.UserID)
+	if err != nil || user == nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get user")
+		return
+	}
+	token, err := auth.GenerateToken(user, org.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not generate token")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dtos.SwitchOrganizationResponse{
+		Token:        token,
+		Organization: toOrganizationResponse(*org, memberRole(caller)),
+	})
+}
+
+// ListMembersHandler lists the members of an organization
+//
+//	@Summary		List organization members
+//	@Description	List the members of an organization, by name (only by its members). Emails are masked unless the caller manages the organization.
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			org_id	path		int	true	"Organization ID"
+//	@Success		200		{array}		dtos.OrganizationMemberResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/organizations/{org_id}/members [get]
+func (oh *OrganizationsHandler) ListMembersHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	org, caller, ok := oh.loadOrganization(w, r)
+	if !ok {
+		return
+	}
+
+	members, err := oh.OrganizationsRepository.GetMembers(org.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get members")
+		return
+	}
+
+	manages := canManageOrganization(claims, caller)
+	response := make([]dtos.OrganizationMemberResponse, len(members))
+	for i, member := range members {
+		response[i] = dtos.OrganizationMemberResponse{
+			UserID:   member.UserID,
+			UserName: member.User.Name,
+			Email:    visibleEmail(claims, member.UserID, member.User.Email, manages),
+			Role:     member.Role,
+			JoinedAt: member.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// AddMemberHandler adds a user to an organization
+//
+//	@Summary		Add organization member
+//	@Description	Add a user, found by email, to the organization (only by its owners and admins; only owners can add owners)
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			org_id	path		int									true	"Organization ID"
+//	@Param			request	body		dtos.AddOrganizationMemberRequest	true	"User and role"
+//	@Success		201		{object}	dtos.OrganizationMemberResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/organizations/{org_id}/members [post]
+func (oh *OrganizationsHandler) AddMemberHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	org, caller, ok := oh.loadOrganization(w, r)
+	if !ok {
+		return
+	}
+	if !canManageOrganization(claims, caller) {
+		utils.RespondWithError(w, http.StatusForbidden, "Only owners and admins can add members")
+		return
+	}
+
+	var req dtos.AddOrganizationMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.OrgRoleMember
+	}
+	if !models.IsValidOrgRole(req.Role) {
+		utils.RespondWithError(w, http.StatusBadRequest, "role must be owner, admin or member")
+		return
+	}
+	if req.Role == models.OrgRoleOwner && !isOrganizationOwner(claims, caller) {
+		utils.RespondWithError(w, http.StatusForbidden, "Only owners can add owners")
+		return
+	}
+
+	user, err := oh.UserRepository.GetByEmail(strings.TrimSpace(req.Email))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get user")
+		return
+	}
+	if user == nil || user.AnonymizedAt != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	existing, err := oh.OrganizationsRepository.GetMembership(org.ID, user.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to add member")
+		return
+	}
+	if existing != nil {
+		utils.RespondWithError(w, http.StatusConflict, "User is already a member")
+		return
+	}
+
+	var member *models.OrganizationMember
+	err = audited(oh.Outbox, oh.Audit, r, audit.Entry{
+		Action:     audit.ActionOrgMemberAdded,
+		TargetType: models.AuditTargetOrganization,
+		TargetID:   org.ID,
+		After:      map[string]any{"user_id": user.ID, "role": req.Role},
+	}, func(tx *gorm.DB) error {
+		var err error
+		member, err = oh.OrganizationsRepository.WithTx(tx).AddMember(org.ID, user.ID, req.Role)
+		return err
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to add member")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(dtos.OrganizationMemberResponse{
+		UserID:   user.ID,
+		UserName: user.Name,
+		Email:    user.Email,
+		Role:     member.Role,
+		JoinedAt: member.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	})
+}
+
+// UpdateMemberHandler changes the role of an organization member
+//
+//	@Summary		Change organization member role
+//	@Description	Change the role of a member (only by owners and admins; only owners can make or unmake owners). The last owner cannot be demoted.
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			org_id	path		int										true	"Organization ID"
+//	@Param			user_id	path		int										true	"User ID"
+//	@Param			request	body		dtos.UpdateOrganizationMemberRequest	true	"New role"
+//	@Success		204
+//	@Failure		400	{object}	dtos.ErrorResponse
+//	@Failure		403	{object}	dtos.ErrorResponse
+//	@Failure		404	{object}	dtos.ErrorResponse
+//	@Failure		409	{object}	dtos.ErrorResponse
+//	@Failure		500	{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/organizations/{org_id}/members/{user_id} [put]
+func (oh *OrganizationsHandler) UpdateMemberHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	org, caller, ok := oh.loadOrganization(w, r)
+	if !ok {
+		return
+	}
+	if !canManageOrganization(claims, caller) {
+		utils.RespondWithError(w, http.StatusForbidden, "Only owners and admins can change roles")
+		return
+	}
+
+	userID, ok := parseOrganizationUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.UpdateOrganizationMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !models.IsValidOrgRole(req.Role) {
+		utils.RespondWithError(w, http.StatusBadRequest, "role must be owner, admin or member")
+		return
+	}
+
+	target, err := oh.OrganizationsRepository.GetMembership(org.ID, userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get member")
+		return
+	}
+	if target == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Member not found")
+		return
+	}
+	if (req.Role == models.OrgRoleOwner || target.Role == models.OrgRoleOwner) && !isOrganizationOwner(claims, caller) {
+		utils.RespondWithError(w, http.StatusForbidden, "Only owners can make or unmake owners")
+		return
+	}
+
+	err = audited(oh.Outbox, oh.Audit, r, audit.Entry{
+		Action:     audit.ActionOrgMemberRoleChanged,
+		TargetType: models.AuditTargetOrganization,
+		TargetID:   org.ID,
+		Before:     map[string]any{"user_id": userID, "role": target.Role},
+		After:      map[string]any{"user_id": userID, "role": req.Role},
+	}, func(tx *gorm.DB) error {
+		orgs := oh.OrganizationsRepository.WithTx(tx)
+		if err := oh.checkOwnerLeaves(orgs, org.ID, userID, req.Role); err != nil {
+			return err
+		}
+		return orgs.UpdateMemberRole(org.ID, userID, req.Role)
+	})
+	if !oh.respondToMemberChange(w, err, "Failed to update member") {
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveMemberHandler removes a member from an organization
+//
+//	@Summary		Remove organization member
+//	@Description	Remove a member from the organization and from its rooms (by owners and admins, or by the member to leave; only owners can remove owners). The last owner cannot leave, and members who own rooms in the organization must transfer them first.
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			org_id	path	int	true	"Organization ID"
+//	@Param			user_id	path	int	true	"User ID"
+//	@Success		204
+//	@Failure		400	{object}	dtos.ErrorResponse
+//	@Failure		403	{object}	dtos.ErrorResponse
+//	@Failure		404	{object}	dtos.ErrorResponse
+//	@Failure		409	{object}	dtos.ErrorResponse
+//	@Failure		500	{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/organizations/{org_id}/members/{user_id} [delete]
+func (oh *OrganizationsHandler) RemoveMemberHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	org, caller, ok := oh.loadOrganization(w, r)
+	if !ok {
+		return
+	}
+
+	userID, ok := parseOrganizationUserID(w, r)
+	if !ok {
+		return
+	}
+	if userID != claims.UserID && !canManageOrganization(claims, caller) {
+		utils.RespondWithError(w, http.StatusForbidden, "Only owners and admins can remove members")
+		return
+	}
+
+	target, err := oh.OrganizationsRepository.GetMembership(org.ID, userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get member")
+		return
+	}
+	if target == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Member not found")
+		return
+	}
+	if userID != claims.UserID && target.Role == models.OrgRoleOwner && !isOrganizationOwner(claims, caller) {
+		utils.RespondWithError(w, http.StatusForbidden, "Only owners can remove owners")
+		return
+	}
+
+	err = audited(oh.Outbox, oh.Audit, r, audit.Entry{
+		Action:     audit.ActionOrgMemberRemoved,
+		TargetType: models.AuditTargetOrganization,
+		TargetID:   org.ID,
+		Before:     map[string]any{"user_id": userID, "role": target.Role},
+	}, func(tx *gorm.DB) error {
+		orgs := oh.OrganizationsRepository.WithTx(tx)
+		if err := oh.checkOwnerLeaves(orgs, org.ID, userID, ""); err != nil {
+			return err
+		}
+		owned, err := orgs.CountOwnedRooms(org.ID, userID)
+		if err != nil {
+			return err
+		}
+		if owned > 0 {
+			return errOwnsOrgRooms
+		}
+
+		// As salas que o usuário deixa são as da organização removida, que
+		// pode não ser a da requisição.
+		roomsRepo := oh.RoomsRepository.WithContext(tenant.NewContext(r.Context(), org.ID)).WithTx(tx)
+		before, err := roomsRepo.GetEffectiveMemberships([]uint{userID}, nil)
+		if err != nil {
+			return err
+		}
+		if err := orgs.RemoveMember(org.ID, userID); err != nil {
+			return err
+		}
+		after, err := roomsRepo.GetEffectiveMemberships([]uint{userID}, nil)
+		if err != nil {
+			return err
+		}
+		if err := publishMembershipChanges(tx, oh.Outbox, before, after); err != nil {
+			return err
+		}
+		return oh.Outbox.Publish(tx, events.Event{
+			Type:    events.OrganizationMemberRemoved,
+			ActorID: claims.UserID,
+			UserID:  userID,
+			Data:    map[string]any{"organization_id": org.ID},
+		})
+	})
+	if !oh.respondToMemberChange(w, err, "Failed to remove member") {
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkOwnerLeaves trava a organização e confere, com a participação atual
+// do usuário, que ela continua com um dono depois que ele passar a ter o
+// papel role (vazio quando ele sai).
+func (oh *OrganizationsHandler) checkOwnerLeaves(orgs *repository.OrganizationsRepository, orgID, userID uint, role string) error {
+	if err := orgs.Lock(orgID); err != nil {
+		return err
+	}
+	member, err := orgs.GetMembership(orgID, userID)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return errOrgMemberGone
+	}
+	if member.Role != models.OrgRoleOwner || role == models.OrgRoleOwner {
+		return nil
+	}
+	owners, err := orgs.CountOwners(orgID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return errLastOrgOwner
+	}
+	return nil
+}
+
+// respondToMemberChange responde aos erros da mudança num membro e retorna
+// true se ela deu certo.
+func (oh *OrganizationsHandler) respondToMemberChange(w http.ResponseWriter, err error, message string) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errOrgMemberGone):
+		utils.RespondWithError(w, http.StatusNotFound, "Member not found")
+	case errors.Is(err, errLastOrgOwner):
+		utils.RespondWithError(w, http.StatusConflict, "The organization must keep at least one owner")
+	case errors.Is(err, errOwnsOrgRooms):
+		utils.RespondWithError(w, http.StatusConflict, "Member owns rooms in the organization, transfer them first")
+	default:
+		utils.RespondWithError(w, http.StatusInternalServerError, message)
+	}
+	return false
+}
+
+// loadOrganization busca a organização da URL e a participação de quem
+// consulta. Quem não participa dela recebe 404, exceto os administradores
+// globais, para quem a participação volta nil.
+func (oh *OrganizationsHandler) loadOrganization(w http.ResponseWriter, r *http.Request) (*models.Organization, *models.OrganizationMember, bool) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return nil, nil, false
+	}
+
+	orgID, err := strconv.ParseUint(chi.URLParam(r, "org_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid organization ID")
+		return nil, nil, false
+	}
+
+	member, err := oh.OrganizationsRepository.GetMembership(uint(orgID), claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get organization")
+		return nil, nil, false
+	}
+	if member != nil {
+		return &member.Organization, member, true
+	}
+
+	if claims.IsAdmin() {
+		org, err := oh.OrganizationsRepository.FindByID(uint(orgID))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get organization")
+			return nil, nil, false
+		}
+		if org != nil {
+			return org, nil, true
+		}
+	}
+
+	utils.RespondWithError(w, http.StatusNotFound, "Organization not found")
+	return nil, nil, false
+}
+
+func parseOrganizationUserID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	userID, err := strconv.ParseUint(chi.URLParam(r, "user_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return 0, false
+	}
+	return uint(userID), true
+}
+
+// canManageOrganization informa se quem consulta gerencia os membros da
+// organização: os donos, os admins dela e os administradores globais.
+func canManageOrganization(claims *auth.Claims, member *models.OrganizationMember) bool {
+	if claims.IsAdmin() {
+		return true
+	}
+	return member != nil && (member.Role == models.OrgRoleOwner || member.Role == models.OrgRoleAdmin)
+}
+
+// isOrganizationOwner informa se quem consulta gerencia os donos da
+// organização: os próprios donos e os administradores globais.
+func isOrganizationOwner(claims *auth.Claims, member *models.OrganizationMember) bool {
+	return claims.IsAdmin() || (member != nil && member.Role == models.OrgRoleOwner)
+}
+
+func memberRole(member *models.OrganizationMember) string {
+	if member == nil {
+		return ""
+	}
+	return member.Role
+}
+
+func toOrganizationResponse(org models.Organization, role string) dtos.OrganizationResponse {
+	return dtos.OrganizationResponse{
+		ID:        org.ID,
+		Name:      org.Name,
+		Slug:      org.Slug,
+		Role:      role,
+		CreatedAt: org.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
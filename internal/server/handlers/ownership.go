@@ -0,0 +1,438 @@
+package handlers
+
+import (
+	"api-go/internal/audit"
+	"api-go/internal/events"
+	"api-go/internal/jobs"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/server/dtos"
+	"api-go/internal/server/middlewares"
+	"api-go/internal/utils"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// errTransferGone interrompe a aceitação quando a transferência deixou de
+// estar pendente ou a sala mudou de dono nesse meio tempo.
+var errTransferGone = errors.New("ownership transfer is no longer pending")
+
+type OwnershipHandler struct {
+	OwnershipRepository *repository.OwnershipRepository
+	RoomsRepository     *repository.RoomsRepository
+	Outbox              *jobs.Outbox
+	Audit               *audit.Log
+}
+
+func (oh *OwnershipHandler) RegisterOwnershipRoutes(r chi.Router) {
+	r.Route("/rooms/{room_id}/ownership-transfer", func(r chi.Router) {
+		r.Get("/", oh.GetTransferHandler)
+		r.Post("/", oh.CreateTransferHandler)
+		r.Delete("/", oh.CancelTransferHandler)
+		r.Post("/accept", oh.AcceptTransferHandler)
+		r.Post("/decline", oh.DeclineTransferHandler)
+	})
+}
+
+// GetTransferHandler gets the pending ownership transfer of a room
+//
+//	@Summary		Get ownership transfer
+//	@Description	Get the pending ownership transfer of a room (only by the room owner or the transfer target)
+//	@Tags			ownership
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int	true	"Room ID"
+//	@Success		200		{object}	dtos.OwnershipTransferResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/ownership-transfer [get]
+func (oh *OwnershipHandler) GetTransferHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	room, ok := oh.loadRoom(w, r)
+	if !ok {
+		return
+	}
+
+	transfer, ok := oh.loadPendingTransfer(w, r, room.ID)
+	if !ok {
+		return
+	}
+
+	if claims.UserID != room.CreatedBy && claims.UserID != transfer.ToUserID {
+		utils.RespondWithError(w, http.StatusForbidden, "Only the room owner or the transfer target can see the transfer")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toOwnershipTransferResponse(*transfer))
+}
+
+// CreateTransferHandler offers the room to another member
+//
+//	@Summary		Transfer ownership
+//	@Description	Offer the ownership of the room to another member, who must accept it (only by the room owner)
+//	@Tags			ownership
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int										true	"Room ID"
+//	@Param			request	body		dtos.CreateOwnershipTransferRequest	true	"New owner"
+//	@Success		201		{object}	dtos.OwnershipTransferResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/ownership-transfer [post]
+func (oh *OwnershipHandler) CreateTransferHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	room, ok := oh.loadRoom(w, r)
+	if !ok {
+		return
+	}
+
+	if room.CreatedBy != claims.UserID {
+		utils.RespondWithError(w, http.StatusForbidden, "Only the room owner can transfer ownership")
+		return
+	}
+
+	var req dtos.CreateOwnershipTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.UserID == 0 || req.UserID == claims.UserID {
+		utils.RespondWithError(w, http.StatusBadRequest, "user_id must be another member of the room")
+		return
+	}
+
+	// O novo dono precisa ser membro direto, para não perder a sala se
+	// sair do grupo.
+	role, err := oh.RoomsRepository.WithContext(r.Context()).GetDirectRole(req.UserID, room.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get member")
+		return
+	}
+	if role == "" {
+		utils.RespondWithError(w, http.StatusNotFound, "User not in room")
+		return
+	}
+
+	pending, err := oh.OwnershipRepository.WithContext(r.Context()).GetPending(room.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get ownership transfer")
+		return
+	}
+	if pending != nil {
+		utils.RespondWithError(w, http.StatusConflict, "An ownership transfer is already pending, cancel it first")
+		return
+	}
+
+	transfer := models.RoomOwnershipTransfer{
+		RoomID:     room.ID,
+		FromUserID: claims.UserID,
+		ToUserID:   req.UserID,
+		Status:     models.TransferPending,
+	}
+	err = oh.Outbox.Transaction(func(tx *gorm.DB) error {
+		if err := oh.OwnershipRepository.WithContext(r.Context()).WithTx(tx).Create(&transfer); err != nil {
+			return err
+		}
+		return oh.Outbox.Publish(tx, events.Event{
+			Type:    events.RoomOwnershipOffered,
+			ActorID: claims.UserID,
+			RoomID:  room.ID,
+			UserID:  req.UserID,
+			Data: map[string]any{
+				"actor_name": claims.Name,
+				"room_name":  room.Name,
+			},
+		})
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create ownership transfer")
+		return
+	}
+
+	created, err := oh.OwnershipRepository.WithContext(r.Context()).GetPending(room.ID)
+	if err != nil || created == nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get ownership transfer")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toOwnershipTransferResponse(*created))
+}
+
+// CancelTransferHandler withdraws a pending ownership transfer
+//
+//	@Summary		Cancel ownership transfer
+//	@Description	Withdraw the pending ownership transfer of a room (only by the room owner)
+//	@Tags			ownership
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int	true	"Room ID"
+//	@Success		200		{object}	map[string]string
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/ownership-transfer [delete]
+func (oh *OwnershipHandler) CancelTransferHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	room, ok := oh.loadRoom(w, r)
+	if !ok {
+		return
+	}
+
+	if room.CreatedBy != claims.UserID {
+		utils.RespondWithError(w, http.StatusForbidden, "Only the room owner can cancel the transfer")
+		return
+	}
+
+	transfer, ok := oh.loadPendingTransfer(w, r, room.ID)
+	if !ok {
+		return
+	}
+
+	cancelled, err := oh.OwnershipRepository.WithContext(r.Context()).Decide(transfer.ID, models.TransferCancelled, time.Now())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to cancel ownership transfer")
+		return
+	}
+	if !cancelled {
+		utils.RespondWithError(w, http.StatusConflict, "Ownership transfer is not pending")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Ownership transfer cancelled successfully"}`))
+}
+
+// AcceptTransferHandler accepts the ownership of a room
+//
+//	@Summary		Accept ownership transfer
+//	@Description	Become the owner of the room (only by the transfer target). The previous owner stays as an admin.
+//	@Tags			ownership
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int	true	"Room ID"
+//	@Success		200		{object}	dtos.OwnershipTransferResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/ownership-transfer/accept [post]
+func (oh *OwnershipHandler) AcceptTransferHandler(w http.ResponseWriter, r *http.Request) {
+	oh.decideTransfer(w, r, models.TransferAccepted)
+}
+
+// DeclineTransferHandler declines the ownership of a room
+//
+//	@Summary		Decline ownership transfer
+//	@Description	Refuse the ownership of the room (only by the transfer target)
+//	@Tags			ownership
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int	true	"Room ID"
+//	@Success		200		{object}	dtos.OwnershipTransferResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/ownership-transfer/decline [post]
+func (oh *OwnershipHandler) DeclineTransferHandler(w http.ResponseWriter, r *http.Request) {
+	oh.decideTransfer(w, r, models.TransferDeclined)
+}
+
+// decideTransfer registra a resposta de quem recebeu a oferta e, se ele
+// aceitou, passa a sala para ele.
+func (oh *OwnershipHandler) decideTransfer(w http.ResponseWriter, r *http.Request, status string) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	room, ok := oh.loadRoom(w, r)
+	if !ok {
+		return
+	}
+
+	transfer, ok := oh.loadPendingTransfer(w, r, room.ID)
+	if !ok {
+		return
+	}
+
+	if transfer.ToUserID != claims.UserID {
+		utils.RespondWithError(w, http.StatusForbidden, "Only the transfer target can answer the transfer")
+		return
+	}
+
+	if status == models.TransferAccepted {
+		role, err := oh.RoomsRepository.WithContext(r.Context()).GetDirectRole(claims.UserID, room.ID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get member")
+			return
+		}
+		if role == "" {
+			utils.RespondWithError(w, http.StatusConflict, "User is no longer in the room")
+			return
+		}
+	}
+
+	now := time.Now()
+	err := oh.Outbox.Transaction(func(tx *gorm.DB) error {
+		decided, err := oh.OwnershipRepository.WithContext(r.Context()).WithTx(tx).Decide(transfer.ID, status, now)
+		if err != nil {
+			return err
+		}
+		if !decided {
+			return errTransferGone
+		}
+
+		if status == models.TransferDeclined {
+			return oh.Outbox.Publish(tx, events.Event{
+				Type:    events.RoomOwnershipDeclined,
+				ActorID: claims.UserID,
+				RoomID:  room.ID,
+				UserID:  transfer.FromUserID,
+				Data: map[string]any{
+					"actor_name": claims.Name,
+					"room_name":  room.Name,
+				},
+			})
+		}
+
+		transferred, err := oh.RoomsRepository.WithContext(r.Context()).WithTx(tx).TransferOwnership(room.ID, transfer.FromUserID, claims.UserID)
+		if err != nil {
+			return err
+		}
+		if !transferred {
+			return errTransferGone
+		}
+		err = oh.Audit.Record(tx, auditMeta(r), audit.Entry{
+			Action:     audit.ActionOwnershipTransferred,
+			TargetType: models.AuditTargetRoom,
+			TargetID:   room.ID,
+			RoomID:     room.ID,
+			Before:     map[string]uint{"owner_id": transfer.FromUserID},
+			After:      map[string]uint{"owner_id": claims.UserID},
+		})
+		if err != nil {
+			return err
+		}
+		return publishOwnershipTransferred(tx, oh.Outbox, room, claims.UserID, claims.UserID, claims.Name, transfer.FromUser.Name)
+	})
+	if errors.Is(err, errTransferGone) {
+		utils.RespondWithError(w, http.StatusConflict, "Ownership transfer is not pending")
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to save ownership transfer")
+		return
+	}
+
+	transfer.Status = status
+	transfer.DecidedAt = &now
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toOwnershipTransferResponse(*transfer))
+}
+
+func (oh *OwnershipHandler) loadRoom(w http.ResponseWriter, r *http.Request) (*models.Room, bool) {
+	roomID, err := strconv.ParseUint(chi.URLParam(r, "room_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid room ID")
+		return nil, false
+	}
+
+	room, err := oh.RoomsRepository.WithContext(r.Context()).FindByID(uint(roomID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return nil, false
+	}
+	if room == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found")
+		return nil, false
+	}
+	return room, true
+}
+
+func (oh *OwnershipHandler) loadPendingTransfer(w http.ResponseWriter, r *http.Request, roomID uint) (*models.RoomOwnershipTransfer, bool) {
+	transfer, err := oh.OwnershipRepository.WithContext(r.Context()).GetPending(roomID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get ownership transfer")
+		return nil, false
+	}
+	if transfer == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "No pending ownership transfer")
+		return nil, false
+	}
+	return transfer, true
+}
+
+// publishOwnershipTransferred avisa que a sala mudou de dono, seja por uma
+// transferência aceita ou pela exclusão da conta do dono anterior.
+func publishOwnershipTransferred(tx *gorm.DB, outbox *jobs.Outbox, room *models.Room, actorID, newOwnerID uint, newOwnerName, previousOwnerName string) error {
+	return outbox.Publish(tx, events.Event{
+		Type:    events.RoomOwnershipTransferred,
+		ActorID: actorID,
+		RoomID:  room.ID,
+		UserID:  newOwnerID,
+		Data: map[string]any{
+			"room_name":           room.Name,
+			"new_owner_name":      newOwnerName,
+			"previous_owner_name": previousOwnerName,
+		},
+	})
+}
+
+func toOwnershipTransferResponse(transfer models.RoomOwnershipTransfer) dtos.OwnershipTransferResponse {
+	response := dtos.OwnershipTransferResponse{
+		ID:           transfer.ID,
+		RoomID:       transfer.RoomID,
+		FromUserID:   transfer.FromUserID,
+		FromUserName: transfer.FromUser.Name,
+		ToUserID:     transfer.ToUserID,
+		ToUserName:   transfer.ToUser.Name,
+		Status:       transfer.Status,
+		CreatedAt:    transfer.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if transfer.DecidedAt != nil {
+		decidedAt := transfer.DecidedAt.Format("2006-01-02T15:04:05Z07:00")
+		response.DecidedAt = &decidedAt
+	}
+	return response
+}
@@ -0,0 +1,468 @@
+package handlers
+
+import (
+	"api-go/internal/models"
+	"api-go/internal/policy"
+	"api-go/internal/repository"
+	"api-go/internal/server/dtos"
+	"api-go/internal/server/middlewares"
+	"api-go/internal/utils"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type PoliciesHandler struct {
+	PoliciesRepository *repository.PoliciesRepository
+	RoomsRepository    *repository.RoomsRepository
+}
+
+func (ph *PoliciesHandler) RegisterPoliciesRoutes(r chi.Router) {
+	r.Route("/rooms/{room_id}/policy", func(r chi.Router) {
+		r.Get("/", ph.GetPolicyHandler)
+		r.Put("/", ph.UpdatePolicyHandler)
+	})
+	r.Route("/rooms/{room_id}/blackouts", func(r chi.Router) {
+		r.Get("/", ph.GetBlackoutsHandler)
+		r.Post("/", ph.CreateBlackoutHandler)
+		r.Delete("/{blackout_id}", ph.DeleteBlackoutHandler)
+	})
+}
+
+// GetPolicyHandler gets the booking policy of a room
+//
+//	@Summary		Get booking policy
+//	@Description	Get the booking rules of a room (only by room members)
+//	@Tags			policies
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int	true	"Room ID"
+//	@Success		200		{object}	dtos.BookingPolicy
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/policy [get]
+func (ph *PoliciesHandler) GetPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	room, ok := ph.loadRoom(w, r)
+	if !ok {
+		return
+	}
+
+	if !ph.RoomsRepository.WithContext(r.Context()).IsUserInRoom(claims.UserID, room.ID) {
+		utils.RespondWithError(w, http.StatusForbidden, "User is not a member of this room")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toBookingPolicyResponse(room.Policy))
+}
+
+// UpdatePolicyHandler replaces the booking policy of a room
+//
+//	@Summary		Update booking policy
+//	@Description	Replace the booking rules of a room (only by room admins). Zero values mean no limit; without opening hours the room can be booked at any time.
+//	@Tags			policies
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int					true	"Room ID"
+//	@Param			request	body		dtos.BookingPolicy	true	"Booking rules"
+//	@Success		200		{object}	dtos.BookingPolicy
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/policy [put]
+func (ph *PoliciesHandler) UpdatePolicyHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	room, ok := ph.loadRoom(w, r)
+	if !ok {
+		return
+	}
+
+	if !ph.RoomsRepository.WithContext(r.Context()).IsRoomAdmin(claims.UserID, room) {
+		utils.RespondWithError(w, http.StatusForbidden, "Only room creator or admins can change the booking policy")
+		return
+	}
+
+	if !checkRoomWritable(w, room) {
+		return
+	}
+
+	var req dtos.BookingPolicy
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	bookingPolicy := models.BookingPolicy{
+		MinDurationMinutes:    req.MinDurationMinutes,
+		MaxDurationMinutes:    req.MaxDurationMinutes,
+		MinNoticeMinutes:      req.MinNoticeMinutes,
+		MaxAdvanceDays:        req.MaxAdvanceDays,
+		BufferMinutes:         req.BufferMinutes,
+		MaxHoursPerWeek:       req.MaxHoursPerWeek,
+		MaxActiveReservations: req.MaxActiveReservations,
+	}
+	for _, hours := range req.OpeningHours {
+		bookingPolicy.OpeningHours = append(bookingPolicy.OpeningHours, models.OpeningHours{
+			Weekday: time.Weekday(hours.Weekday),
+			Open:    hours.Open,
+			Close:   hours.Close,
+		})
+	}
+
+	if msg := validateBookingPolicy(bookingPolicy); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	if err := ph.PoliciesRepository.WithContext(r.Context()).UpdatePolicy(room.ID, bookingPolicy); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update booking policy")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toBookingPolicyResponse(bookingPolicy))
+}
+
+// GetBlackoutsHandler lists the blackouts of a room
+//
+//	@Summary		Get blackouts
+//	@Description	List the current and upcoming periods in which the room cannot be booked (only by room members)
+//	@Tags			policies
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int	true	"Room ID"
+//	@Success		200		{array}		dtos.BlackoutResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/blackouts [get]
+func (ph *PoliciesHandler) GetBlackoutsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	room, ok := ph.loadRoom(w, r)
+	if !ok {
+		return
+	}
+
+	if !ph.RoomsRepository.WithContext(r.Context()).IsUserInRoom(claims.UserID, room.ID) {
+		utils.RespondWithError(w, http.StatusForbidden, "User is not a member of this room")
+		return
+	}
+
+	blackouts, err := ph.PoliciesRepository.WithContext(r.Context()).GetBlackouts(room.ID, time.Now())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get blackouts")
+		return
+	}
+
+	response := make([]dtos.BlackoutResponse, len(blackouts))
+	for i, blackout := range blackouts {
+		response[i] = toBlackoutResponse(blackout)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateBlackoutHandler blocks a period of a room
+//
+//	@Summary		Create blackout
+//	@Description	Block a period, such as a holiday, in which the room cannot be booked (only by room admins). Existing reservations are kept.
+//	@Tags			policies
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int							true	"Room ID"
+//	@Param			request	body		dtos.CreateBlackoutRequest	true	"Blocked period"
+//	@Success		201		{object}	dtos.BlackoutResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/blackouts [post]
+func (ph *PoliciesHandler) CreateBlackoutHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	room, ok := ph.loadRoom(w, r)
+	if !ok {
+		return
+	}
+
+	if !ph.RoomsRepository.WithContext(r.Context()).IsRoomAdmin(claims.UserID, room) {
+		utils.RespondWithError(w, http.StatusForbidden, "Only room creator or admins can manage blackouts")
+		return
+	}
+
+	if !checkRoomWritable(w, room) {
+		return
+	}
+
+	var req dtos.CreateBlackoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.StartTime.IsZero() || req.EndTime.IsZero() {
+		utils.RespondWithError(w, http.StatusBadRequest, "start_time and end_time are required")
+		return
+	}
+	if !req.EndTime.After(req.StartTime) {
+		utils.RespondWithError(w, http.StatusBadRequest, "end_time must be after start_time")
+		return
+	}
+
+	blackout := models.RoomBlackout{
+		RoomID:    room.ID,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Reason:    strings.TrimSpace(req.Reason),
+		CreatedBy: claims.UserID,
+	}
+	if err := ph.PoliciesRepository.WithContext(r.Context()).CreateBlackout(&blackout); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create blackout")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toBlackoutResponse(blackout))
+}
+
+// DeleteBlackoutHandler removes a blackout
+//
+//	@Summary		Delete blackout
+//	@Description	Remove a blocked period of a room (only by room admins)
+//	@Tags			policies
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id		path		int	true	"Room ID"
+//	@Param			blackout_id	path		int	true	"Blackout ID"
+//	@Success		200			{object}	map[string]string
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		403			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/blackouts/{blackout_id} [delete]
+func (ph *PoliciesHandler) DeleteBlackoutHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	room, ok := ph.loadRoom(w, r)
+	if !ok {
+		return
+	}
+
+	if !ph.RoomsRepository.WithContext(r.Context()).IsRoomAdmin(claims.UserID, room) {
+		utils.RespondWithError(w, http.StatusForbidden, "Only room creator or admins can manage blackouts")
+		return
+	}
+
+	if !checkRoomWritable(w, room) {
+		return
+	}
+
+	blackoutID, err := strconv.ParseUint(chi.URLParam(r, "blackout_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid blackout ID")
+		return
+	}
+
+	blackout, err := ph.PoliciesRepository.WithContext(r.Context()).GetBlackout(uint(blackoutID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get blackout")
+		return
+	}
+	if blackout == nil || blackout.RoomID != room.ID {
+		utils.RespondWithError(w, http.StatusNotFound, "Blackout not found")
+		return
+	}
+
+	if err := ph.PoliciesRepository.WithContext(r.Context()).DeleteBlackout(blackout.ID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete blackout")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Blackout deleted successfully"}`))
+}
+
+func (ph *PoliciesHandler) loadRoom(w http.ResponseWriter, r *http.Request) (*models.Room, bool) {
+	roomID, err := strconv.ParseUint(chi.URLParam(r, "room_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid room ID")
+		return nil, false
+	}
+
+	room, err := ph.RoomsRepository.WithContext(r.Context()).FindByID(uint(roomID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return nil, false
+	}
+	if room == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found")
+		return nil, false
+	}
+	return room, true
+}
+
+// policyViolationError interrompe a transação de uma reserva que viola a
+// política da sala.
+type policyViolationError struct {
+	violations []policy.Violation
+}
+
+func (e *policyViolationError) Error() string {
+	return "reservation violates the room's booking policy"
+}
+
+// bookingPolicyError valida o período contra a política da sala e retorna
+// um *policyViolationError se houver violações. excludeID é a reserva sendo
+// remarcada, que não conta nas cotas. As cotas só ficam garantidas com a
+// sala travada (ReservationsRepository.LockRoom) na mesma transação da
+// gravação.
+func bookingPolicyError(policies *repository.PoliciesRepository, reservations *repository.ReservationsRepository,
+	room *models.Room, userID, excludeID uint, startTime, endTime time.Time) error {
+	loc := room.Location()
+
+	blackouts, err := policies.GetOverlappingBlackouts(room.ID, startTime, endTime)
+	if err != nil {
+		return err
+	}
+
+	weekStart, weekEnd := policy.WeekBounds(startTime, loc)
+	weekHours, active, err := reservations.GetUsage(userID, room.ID, weekStart, weekEnd, excludeID)
+	if err != nil {
+		return err
+	}
+
+	violations := policy.Check(room.Policy, policy.Request{
+		StartTime: startTime,
+		EndTime:   endTime,
+		Now:       time.Now(),
+		Location:  loc,
+		Blackouts: blackouts,
+		Usage:     policy.Usage{WeekHours: weekHours, Active: active},
+	})
+	if len(violations) > 0 {
+		return &policyViolationError{violations: violations}
+	}
+	return nil
+}
+
+// checkBookingPolicy valida o período contra a política da sala, fora de
+// uma transação. Se houver violações, responde com elas e retorna false.
+func checkBookingPolicy(w http.ResponseWriter, policies *repository.PoliciesRepository, reservations *repository.ReservationsRepository,
+	room *models.Room, userID, excludeID uint, startTime, endTime time.Time) bool {
+	err := bookingPolicyError(policies, reservations, room, userID, excludeID, startTime, endTime)
+	if err == nil {
+		return true
+	}
+
+	var violation *policyViolationError
+	if errors.As(err, &violation) {
+		respondWithPolicyViolations(w, violation.violations)
+	} else {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to check booking policy")
+	}
+	return false
+}
+
+func respondWithPolicyViolations(w http.ResponseWriter, violations []policy.Violation) {
+	response := dtos.PolicyErrorResponse{
+		Message: "Reservation violates the room's booking policy",
+		Status:  http.StatusUnprocessableEntity,
+	}
+	for _, violation := range violations {
+		response.Violations = append(response.Violations, dtos.PolicyViolation{
+			Rule:    violation.Rule,
+			Message: violation.Message,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(response)
+}
+
+// validateBookingPolicy retorna a mensagem de erro da política, ou "" se ela for válida.
+func validateBookingPolicy(p models.BookingPolicy) string {
+	if p.MinDurationMinutes < 0 || p.MaxDurationMinutes < 0 || p.MinNoticeMinutes < 0 || p.MaxAdvanceDays < 0 ||
+		p.BufferMinutes < 0 || p.MaxHoursPerWeek < 0 || p.MaxActiveReservations < 0 {
+		return "Policy limits cannot be negative"
+	}
+	if p.MaxDurationMinutes > 0 && p.MinDurationMinutes > p.MaxDurationMinutes {
+		return "min_duration_minutes cannot be greater than max_duration_minutes"
+	}
+	if err := policy.ValidateOpeningHours(p.OpeningHours); err != nil {
+		return "Invalid opening_hours: " + err.Error()
+	}
+	return ""
+}
+
+func toBookingPolicyResponse(p models.BookingPolicy) dtos.BookingPolicy {
+	response := dtos.BookingPolicy{
+		OpeningHours:          []dtos.OpeningHours{},
+		MinDurationMinutes:    p.MinDurationMinutes,
+		MaxDurationMinutes:    p.MaxDurationMinutes,
+		MinNoticeMinutes:      p.MinNoticeMinutes,
+		MaxAdvanceDays:        p.MaxAdvanceDays,
+		BufferMinutes:         p.BufferMinutes,
+		MaxHoursPerWeek:       p.MaxHoursPerWeek,
+		MaxActiveReservations: p.MaxActiveReservations,
+	}
+	for _, hours := range p.OpeningHours {
+		response.OpeningHours = append(response.OpeningHours, dtos.OpeningHours{
+			Weekday: int(hours.Weekday),
+			Open:    hours.Open,
+			Close:   hours.Close,
+		})
+	}
+	return response
+}
+
+func toBlackoutResponse(blackout models.RoomBlackout) dtos.BlackoutResponse {
+	return dtos.BlackoutResponse{
+		ID:        blackout.ID,
+		RoomID:    blackout.RoomID,
+		StartTime: blackout.StartTime.Format("2006-01-02T15:04:05Z07:00"),
+		EndTime:   blackout.EndTime.Format("2006-01-02T15:04:05Z07:00"),
+		Reason:    blackout.Reason,
+		CreatedBy: blackout.CreatedBy,
+	}
+}
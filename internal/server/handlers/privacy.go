@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"api-go/internal/auth"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/utils"
+)
+
+// visibleEmail aplica a regra de exposição dos emails: o completo aparece
+// para o próprio usuário, para os administradores globais e quando
+// canSee (quem consulta administra uma sala em que o dono do email está);
+// os demais veem o email mascarado.
+func visibleEmail(viewer *auth.Claims, ownerID uint, email string, canSee bool) string {
+	if canSee || viewer.IsAdmin() || viewer.UserID == ownerID {
+		return email
+	}
+	return utils.MaskEmail(email)
+}
+
+// roomAdmins memoriza, por sala, se quem consulta é administrador dela, para
+// mascarar os emails de listas que misturam salas sem repetir a consulta.
+type roomAdmins struct {
+	rooms  *repository.RoomsRepository
+	userID uint
+	known  map[uint]bool
+}
+
+func newRoomAdmins(rooms *repository.RoomsRepository, userID uint) *roomAdmins {
+	return &roomAdmins{rooms: rooms, userID: userID, known: map[uint]bool{}}
+}
+
+func (ra *roomAdmins) is(room *models.Room) bool {
+	admin, ok := ra.known[room.ID]
+	if !ok {
+		admin = ra.rooms.IsRoomAdmin(ra.userID, room)
+		ra.known[room.ID] = admin
+	}
+	return admin
+}
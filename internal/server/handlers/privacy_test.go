@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"api-go/internal/database/dbtest"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/server/dtos"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func directoryNames(users []dtos.UserResponse) []string {
+	names := make([]string, len(users))
+	for i, u := range users {
+		names[i] = u.Name
+	}
+	return names
+}
+
+func TestDirectoryOnlyListsUsersSharingARoom(t *testing.T) {
+	db := dbtest.New(t)
+	org := createOrg(t, db, "acme")
+	alice := createUser(t, db, org, "Alice", "alice@example.com")
+	bob := createUser(t, db, org, "Bob", "bob@example.com")
+	createUser(t, db, org, "Carol", "carol@example.com")
+
+	room := createRoom(t, db, org, bob, "Design", models.RoomPublic)
+	addRoomMember(t, db, *room, alice, models.RoomRoleMember)
+
+	uh := &UserHandler{UserRepository: repository.NewUserRepository(db)}
+	rec := serve(uh.GetDirectoryHandler, http.MethodGet, "/users/directory", "/users/directory", claimsOf(alice), org.ID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+
+	users := decode[dtos.UserListResponse](t, rec).Users
+	if names := directoryNames(users); !slices.Equal(names, []string{"Alice", "Bob"}) {
+		t.Fatalf("directory = %v, want [Alice Bob]", names)
+	}
+	for _, u := range users {
+		if u.ID == bob.ID && u.Email != "b***@example.com" {
+			t.Errorf("Bob's email = %q, want it masked", u.Email)
+		}
+		if u.ID == alice.ID && u.Email != alice.Email {
+			t.Errorf("own email = %q, want it in full", u.Email)
+		}
+	}
+}
+
+func TestDirectoryShowsEmailsToAdmins(t *testing.T) {
+	db := dbtest.New(t)
+	org := createOrg(t, db, "acme")
+	alice := createUser(t, db, org, "Alice", "alice@example.com")
+	bob := createUser(t, db, org, "Bob", "bob@example.com")
+	room := createRoom(t, db, org, bob, "Design", models.RoomPublic)
+	addRoomMember(t, db, *room, alice, models.RoomRoleMember)
+
+	uh := &UserHandler{UserRepository: repository.NewUserRepository(db)}
+
+	// O dono da sala administra Alice.
+	rec := serve(uh.GetDirectoryHandler, http.MethodGet, "/users/directory", "/users/directory", claimsOf(bob), org.ID)
+	for _, u := range decode[dtos.UserListResponse](t, rec).Users {
+		if u.ID == alice.ID && u.Email != alice.Email {
+			t.Errorf("room admin sees %q, want the full email", u.Email)
+		}
+	}
+
+	admin := claimsOf(alice)
+	admin.Role = models.UserRoleAdmin
+	rec = serve(uh.GetDirectoryHandler, http.MethodGet, "/users/directory", "/users/directory", admin, org.ID)
+	for _, u := range decode[dtos.UserListResponse](t, rec).Users {
+		if u.ID == bob.ID && u.Email != bob.Email {
+			t.Errorf("global admin sees %q, want the full email", u.Email)
+		}
+	}
+}
+
+func TestDirectoryMatchesOnlyFullEmails(t *testing.T) {
+	db := dbtest.New(t)
+	org := createOrg(t, db, "acme")
+	alice := createUser(t, db, org, "Alice", "alice@example.com")
+	bob := createUser(t, db, org, "Bob", "bob.secret@example.com")
+	room := createRoom(t, db, org, alice, "Design", models.RoomPublic)
+	addRoomMember(t, db, *room, bob, models.RoomRoleMember)
+
+	uh := &UserHandler{UserRepository: repository.NewUserRepository(db)}
+	search := func(q string) []string {
+		target := "/users/directory?q=" + url.QueryEscape(q)
+		rec := serve(uh.GetDirectoryHandler, http.MethodGet, "/users/directory", target, claimsOf(alice), org.ID)
+		return directoryNames(decode[dtos.UserListResponse](t, rec).Users)
+	}
+
+	for _, q := range []string{"secret", "bob.s", "@example.com"} {
+		if names := search(q); slices.Contains(names, "Bob") {
+			t.Errorf("q=%q found Bob by part of the email", q)
+		}
+	}
+	if names := search("BOB.Secret@Example.com"); !slices.Equal(names, []string{"Bob"}) {
+		t.Errorf("full email search = %v, want [Bob]", names)
+	}
+	if names := search("bo"); !slices.Equal(names, []string{"Bob"}) {
+		t.Errorf("name search = %v, want [Bob]", names)
+	}
+}
+
+func TestRoomDetailsForNonMembers(t *testing.T) {
+	db := dbtest.New(t)
+	org := createOrg(t, db, "acme")
+	owner := createUser(t, db, org, "Owner", "owner@example.com")
+	member := createUser(t, db, org, "Member", "member@example.com")
+	outsider := createUser(t, db, org, "Outsider", "outsider@example.com")
+
+	public := createRoom(t, db, org, owner, "Lobby", models.RoomPublic)
+	addRoomMember(t, db, *public, member, models.RoomRoleMember)
+	private := createRoom(t, db, org, owner, "Board", models.RoomPrivate)
+
+	rh := &RoomsHandler{RoomsRepository: repository.NewRoomsRepository(db)}
+	get := func(user *models.User, room *models.Room) *httptest.ResponseRecorder {
+		return serve(rh.GetRoomByIDHandler, http.MethodGet, "/rooms/{room_id}", fmt.Sprintf("/rooms/%d", room.ID), claimsOf(user), org.ID)
+	}
+
+	rec := get(outsider, public)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("outsider status = %d, body = %s", rec.Code, rec.Body)
+	}
+	if response := decode[dtos.RoomResponse](t, rec); len(response.Members) != 0 || len(response.Notes) != 0 {
+		t.Errorf("outsider got %d members and %d notes, want only public metadata", len(response.Members), len(response.Notes))
+	}
+
+	if rec := get(outsider, private); rec.Code != http.StatusForbidden {
+		t.Errorf("outsider on private room status = %d, want 403", rec.Code)
+	}
+
+	rec = get(member, public)
+	members := decode[dtos.RoomResponse](t, rec).Members
+	if len(members) != 2 {
+		t.Fatalf("member got %d members, want 2", len(members))
+	}
+	for _, m := range members {
+		if m.UserID == owner.ID && m.UserEmail != "o***@example.com" {
+			t.Errorf("member sees owner email %q, want it masked", m.UserEmail)
+		}
+	}
+}
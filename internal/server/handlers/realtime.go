@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"api-go/internal/auth"
+	"api-go/internal/models"
+	"api-go/internal/realtime"
+	"api-go/internal/repository"
+	"api-go/internal/server/middlewares"
+	"api-go/internal/utils"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type RealtimeHandler struct {
+	Hub            *realtime.Hub
+	Stream         *realtime.Stream
+	UserRepository *repository.UserRepository
+}
+
+func (rh *RealtimeHandler) RegisterRealtimeRoutes(r chi.Router) {
+	r.Get("/ws", rh.WebSocketHandler)
+	r.Get("/events/stream", rh.EventStreamHandler)
+}
+
+// PublishNotification envia a notificação recém-criada pelo stream do usuário.
+func (rh *RealtimeHandler) PublishNotification(notification models.Notification) {
+	rh.Stream.NotifyUser(notification.UserID, realtime.StreamNotification, toNotificationResponse(notification))
+}
+
+// WebSocketHandler opens a WebSocket connection for real-time room updates
+//
+//	@Summary		Real-time room updates
+//	@Description	Upgrade to a WebSocket connection. Send {"action": "subscribe", "room_id": 1} to follow a room you belong to
+//	@Description	and {"action": "unsubscribe", "room_id": 1} to stop. The server pushes member, note and reservation events
+//	@Description	of the subscribed rooms as {"type": "<event type>", "room_id": 1, "event": {...}} and the users currently
+//	@Description	viewing each room as {"type": "presence", "room_id": 1, "users": [...]}. Browsers may pass the JWT in the
+//	@Description	"token" query parameter, since they cannot set the Authorization header on the handshake.
+//	@Tags			realtime
+//	@Param			token	query	string	false	"JWT, when the Authorization header cannot be sent"
+//	@Success		101
+//	@Failure		400	{object}	dtos.ErrorResponse
+//	@Failure		401	{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/ws [get]
+func (rh *RealtimeHandler) WebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	rh.Hub.Serve(w, r, claims.UserID, claims.Name, rh.sessionCheck(claims))
+}
+
+// EventStreamHandler streams the user's events as Server-Sent Events
+//
+//	@Summary		Event stream
+//	@Description	Stream, as Server-Sent Events, the current user's notifications (event "notification") and the member,
+//	@Description	note and reservation events of the rooms they belong to (event named after the event type). Every event
+//	@Description	has an ID; reconnecting with the Last-Event-ID header (or the last_event_id query parameter) replays the
+//	@Description	events missed since then. When they are no longer buffered a "reset" event is sent and the client should
+//	@Description	reload its state. Keep-alive comments are sent every 15 seconds. Browsers may pass the JWT in the "token"
+//	@Description	query parameter, since EventSource cannot set the Authorization header.
+//	@Tags			realtime
+//	@Produce		text/event-stream
+//	@Param			token			query		string	false	"JWT, when the Authorization header cannot be sent"
+//	@Param			last_event_id	query		int		false	"Last event received, when the Last-Event-ID header cannot be sent"
+//	@Param			Last-Event-ID	header		int		false	"Last event received"
+//	@Success		200				{string}	string	"Event stream"
+//	@Failure		400				{object}	dtos.ErrorResponse
+//	@Failure		401				{object}	dtos.ErrorResponse
+//	@Failure		500				{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/events/stream [get]
+func (rh *RealtimeHandler) EventStreamHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	rh.Stream.Serve(w, r, claims.UserID, rh.sessionCheck(claims))
+}
+
+// sessionCheck refaz, durante a conexão, as verificações do ActiveUser. Uma
+// falha ao consultar a conta não derruba a conexão.
+func (rh *RealtimeHandler) sessionCheck(claims *auth.Claims) realtime.SessionCheck {
+	return func() bool {
+		err := middlewares.CheckAccount(rh.UserRepository, claims)
+		var accountErr *middlewares.AccountError
+		if errors.As(err, &accountErr) {
+			return false
+		}
+		if err != nil {
+			log.Printf("failed to check session of user %d: %v", claims.UserID, err)
+		}
+		return true
+	}
+}
@@ -1,14 +1,36 @@
 package handlers
 
 import (
+	"api-go/internal/events"
+	"api-go/internal/jobs"
+	"api-go/internal/models"
 	"api-go/internal/repository"
+	"api-go/internal/server/dtos"
+	"api-go/internal/server/middlewares"
+	"api-go/internal/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
 )
 
 type ReservationsHandler struct {
 	ReservationsRepository *repository.ReservationsRepository
+	RoomsRepository        *repository.RoomsRepository
+	PoliciesRepository     *repository.PoliciesRepository
+	UserRepository         *repository.UserRepository
+	Outbox                 *jobs.Outbox
+
+	// Janela de check-in: abre CheckInOpensBefore antes do início da reserva
+	// e fecha CheckInGrace depois dele.
+	CheckInOpensBefore time.Duration
+	CheckInGrace       time.Duration
 }
 
 func (rh *ReservationsHandler) RegisterReservationsRoutes(r chi.Router) {
@@ -17,31 +39,769 @@ func (rh *ReservationsHandler) RegisterReservationsRoutes(r chi.Router) {
 		r.Get("/{reservation_id}", rh.GetReservationByIDHandler)
 		r.Put("/{reservation_id}", rh.UpdateReservationHandler)
 		r.Delete("/{reservation_id}", rh.DeleteReservationHandler)
+		r.Post("/{reservation_id}/check-in", rh.CheckInReservationHandler)
+		r.Post("/{reservation_id}/approve", rh.ApproveReservationHandler)
+		r.Post("/{reservation_id}/reject", rh.RejectReservationHandler)
 		r.Get("/by-user/{user_id}", rh.GetReservationsByUserIDHandler)
+		r.Get("/by-user/{user_id}/no-shows", rh.GetNoShowsHandler)
 		r.Get("/by-room/{room_id}", rh.GetReservationsByRoomIDHandler)
 	})
 }
 
+// CreateReservationHandler creates a new reservation
+//
+//	@Summary		Create reservation
+//	@Description	Book a seat in a room for a period. Overlapping reservations are limited by the room capacity and the period must follow the room's booking policy.
+//	@Tags			reservations
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dtos.CreateReservationRequest	true	"Reservation details"
+//	@Success		201		{object}	dtos.ReservationResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		422		{object}	dtos.PolicyErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/reservations [post]
 func (rh *ReservationsHandler) CreateReservationHandler(w http.ResponseWriter, r *http.Request) {
-	//
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	userID := claims.UserID
+	var req dtos.CreateReservationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.RoomID == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "room_id is required")
+		return
+	}
+
+	room, err := rh.RoomsRepository.WithContext(r.Context()).FindByID(req.RoomID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return
+	}
+	if room == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found")
+		return
+	}
+
+	preferred := preferredLocation(rh.UserRepository, userID)
+	startTime, endTime, msg := resolvePeriod(req.TimeZone, preferred, room, req.StartTime, req.EndTime)
+	if msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	if !rh.RoomsRepository.WithContext(r.Context()).IsUserInRoom(userID, room.ID) {
+		utils.RespondWithError(w, http.StatusForbidden, "User is not a member of this room")
+		return
+	}
+
+	if !checkRoomWritable(w, room) {
+		return
+	}
+
+	status := initialStatus(rh.RoomsRepository.WithContext(r.Context()), userID, room)
+
+	var reservation *models.Reservation
+	err = rh.Outbox.Transaction(func(tx *gorm.DB) error {
+		// As cotas são conferidas com a sala travada, para que reservas
+		// simultâneas do mesmo usuário não passem juntas do limite.
+		reservations := rh.ReservationsRepository.WithContext(r.Context()).WithTx(tx)
+		if err := reservations.LockRoom(room.ID); err != nil {
+			return err
+		}
+		err := bookingPolicyError(rh.PoliciesRepository.WithContext(r.Context()).WithTx(tx), reservations, room, userID, 0, startTime, endTime)
+		if err != nil {
+			return err
+		}
+
+		reservation, err = reservations.Create(userID, room.ID, startTime, endTime, status)
+		if err != nil {
+			return err
+		}
+		return rh.publish(tx, events.ReservationCreated, claims.UserID, claims.Name, room, reservation)
+	})
+	if err != nil {
+		respondWithReservationError(w, err, "Failed to create reservation")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toReservationResponse(*reservation, localZone(preferred, room)))
 }
 
+// GetReservationByIDHandler gets reservation by ID
+//
+//	@Summary		Get reservation by ID
+//	@Description	Retrieve a reservation (only by room members)
+//	@Tags			reservations
+//	@Accept			json
+//	@Produce		json
+//	@Param			reservation_id	path		int	true	"Reservation ID"
+//	@Success		200				{object}	dtos.ReservationResponse
+//	@Failure		400				{object}	dtos.ErrorResponse
+//	@Failure		403				{object}	dtos.ErrorResponse
+//	@Failure		404				{object}	dtos.ErrorResponse
+//	@Failure		500				{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/reservations/{reservation_id} [get]
 func (rh *ReservationsHandler) GetReservationByIDHandler(w http.ResponseWriter, r *http.Request) {
-	//
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	reservation, ok := rh.loadReservation(w, r)
+	if !ok {
+		return
+	}
+
+	if reservation.UserID != claims.UserID && !rh.RoomsRepository.WithContext(r.Context()).IsUserInRoom(claims.UserID, reservation.RoomID) {
+		utils.RespondWithError(w, http.StatusForbidden, "User is not a member of this room")
+		return
+	}
+
+	zoneOf := localZones(rh.UserRepository, rh.RoomsRepository.WithContext(r.Context()), claims.UserID, []uint{reservation.RoomID})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toReservationResponse(*reservation, zoneOf(reservation.RoomID)))
 }
 
+// UpdateReservationHandler reschedules a reservation
+//
+//	@Summary		Update reservation
+//	@Description	Change the period of a reservation (only by the booker)
+//	@Tags			reservations
+//	@Accept			json
+//	@Produce		json
+//	@Param			reservation_id	path		int								true	"Reservation ID"
+//	@Param			request			body		dtos.UpdateReservationRequest	true	"New period"
+//	@Success		200				{object}	dtos.ReservationResponse
+//	@Failure		400				{object}	dtos.ErrorResponse
+//	@Failure		403				{object}	dtos.ErrorResponse
+//	@Failure		404				{object}	dtos.ErrorResponse
+//	@Failure		409				{object}	dtos.ErrorResponse
+//	@Failure		422				{object}	dtos.PolicyErrorResponse
+//	@Failure		500				{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/reservations/{reservation_id} [put]
 func (rh *ReservationsHandler) UpdateReservationHandler(w http.ResponseWriter, r *http.Request) {
-	//
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	reservation, ok := rh.loadReservation(w, r)
+	if !ok {
+		return
+	}
+
+	if reservation.UserID != claims.UserID {
+		utils.RespondWithError(w, http.StatusForbidden, "Only the booker can update the reservation")
+		return
+	}
+
+	var req dtos.UpdateReservationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	room, err := rh.RoomsRepository.WithContext(r.Context()).FindByID(reservation.RoomID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return
+	}
+	if room == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found")
+		return
+	}
+
+	preferred := preferredLocation(rh.UserRepository, claims.UserID)
+	startTime, endTime, msg := resolvePeriod(req.TimeZone, preferred, room, req.StartTime, req.EndTime)
+	if msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	if !checkRoomWritable(w, room) {
+		return
+	}
+
+	if reservation.Status != models.ReservationPending && reservation.Status != models.ReservationApproved {
+		utils.RespondWithError(w, http.StatusConflict, "Only pending or approved reservations can be rescheduled")
+		return
+	}
+
+	// Nas salas com aprovação, o novo horário precisa ser aprovado de novo.
+	status := initialStatus(rh.RoomsRepository.WithContext(r.Context()), claims.UserID, room)
+
+	err = rh.Outbox.Transaction(func(tx *gorm.DB) error {
+		reservations := rh.ReservationsRepository.WithContext(r.Context()).WithTx(tx)
+		if err := reservations.LockRoom(room.ID); err != nil {
+			return err
+		}
+		err := bookingPolicyError(rh.PoliciesRepository.WithContext(r.Context()).WithTx(tx), reservations, room, claims.UserID, reservation.ID, startTime, endTime)
+		if err != nil {
+			return err
+		}
+
+		if err := reservations.Update(reservation.ID, startTime, endTime, status); err != nil {
+			return err
+		}
+
+		reservation.StartTime = startTime
+		reservation.EndTime = endTime
+		reservation.Status = status
+		reservation.ReminderSentAt = nil
+		reservation.CheckedInAt = nil
+		return rh.publish(tx, events.ReservationUpdated, claims.UserID, claims.Name, room, reservation)
+	})
+	if err != nil {
+		respondWithReservationError(w, err, "Failed to update reservation")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toReservationResponse(*reservation, localZone(preferred, room)))
 }
 
+// DeleteReservationHandler cancels a reservation
+//
+//	@Summary		Cancel reservation
+//	@Description	Cancel a reservation (by the booker or room admins)
+//	@Tags			reservations
+//	@Accept			json
+//	@Produce		json
+//	@Param			reservation_id	path		int	true	"Reservation ID"
+//	@Success		200				{object}	map[string]string
+//	@Failure		400				{object}	dtos.ErrorResponse
+//	@Failure		403				{object}	dtos.ErrorResponse
+//	@Failure		404				{object}	dtos.ErrorResponse
+//	@Failure		500				{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/reservations/{reservation_id} [delete]
 func (rh *ReservationsHandler) DeleteReservationHandler(w http.ResponseWriter, r *http.Request) {
-	//
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	reservation, ok := rh.loadReservation(w, r)
+	if !ok {
+		return
+	}
+
+	room, err := rh.RoomsRepository.WithContext(r.Context()).FindByID(reservation.RoomID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return
+	}
+
+	isAdmin := room != nil && rh.RoomsRepository.WithContext(r.Context()).IsRoomAdmin(claims.UserID, room)
+	if reservation.UserID != claims.UserID && !isAdmin {
+		utils.RespondWithError(w, http.StatusForbidden, "Only the booker or room admins can cancel the reservation")
+		return
+	}
+
+	err = rh.Outbox.Transaction(func(tx *gorm.DB) error {
+		if err := rh.ReservationsRepository.WithContext(r.Context()).WithTx(tx).Delete(reservation.ID); err != nil {
+			return err
+		}
+		if room == nil {
+			return nil
+		}
+		return rh.publish(tx, events.ReservationCancelled, claims.UserID, claims.Name, room, reservation)
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to cancel reservation")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Reservation cancelled successfully"}`))
+}
+
+// CheckInReservationHandler checks in to a reservation
+//
+//	@Summary		Check in
+//	@Description	Confirm that the booker showed up. Only allowed within the check-in window around the start time, by the booker or room admins. In rooms that require check-in, reservations without it are released after the grace period.
+//	@Tags			reservations
+//	@Accept			json
+//	@Produce		json
+//	@Param			reservation_id	path		int	true	"Reservation ID"
+//	@Success		200				{object}	dtos.ReservationResponse
+//	@Failure		400				{object}	dtos.ErrorResponse
+//	@Failure		403				{object}	dtos.ErrorResponse
+//	@Failure		404				{object}	dtos.ErrorResponse
+//	@Failure		409				{object}	dtos.ErrorResponse
+//	@Failure		500				{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/reservations/{reservation_id}/check-in [post]
+func (rh *ReservationsHandler) CheckInReservationHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	reservation, ok := rh.loadReservation(w, r)
+	if !ok {
+		return
+	}
+
+	room, err := rh.RoomsRepository.WithContext(r.Context()).FindByID(reservation.RoomID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return
+	}
+	if room == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found")
+		return
+	}
+
+	if reservation.UserID != claims.UserID && !rh.RoomsRepository.WithContext(r.Context()).IsRoomAdmin(claims.UserID, room) {
+		utils.RespondWithError(w, http.StatusForbidden, "Only the booker or room admins can check in")
+		return
+	}
+
+	if reservation.Status != models.ReservationApproved {
+		utils.RespondWithError(w, http.StatusConflict, "Only approved reservations can be checked in")
+		return
+	}
+
+	if reservation.CheckedInAt != nil {
+		utils.RespondWithError(w, http.StatusConflict, "Reservation is already checked in")
+		return
+	}
+
+	now := time.Now()
+	opens := reservation.StartTime.Add(-rh.CheckInOpensBefore)
+	closes := reservation.StartTime.Add(rh.CheckInGrace)
+	if reservation.EndTime.Before(closes) {
+		closes = reservation.EndTime
+	}
+	if now.Before(opens) || now.After(closes) {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Check-in is only allowed between %s and %s",
+			opens.Format(time.RFC3339), closes.Format(time.RFC3339)))
+		return
+	}
+
+	err = rh.Outbox.Transaction(func(tx *gorm.DB) error {
+		checkedIn, err := rh.ReservationsRepository.WithContext(r.Context()).WithTx(tx).CheckIn(reservation.ID)
+		if err != nil {
+			return err
+		}
+		if !checkedIn {
+			return errAlreadyCheckedIn
+		}
+		return rh.publish(tx, events.ReservationCheckedIn, claims.UserID, claims.Name, room, reservation)
+	})
+	if errors.Is(err, errAlreadyCheckedIn) {
+		utils.RespondWithError(w, http.StatusConflict, "Reservation is already checked in")
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to check in")
+		return
+	}
+
+	reservation.CheckedInAt = &now
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toReservationResponse(*reservation, localZone(preferredLocation(rh.UserRepository, claims.UserID), room)))
+}
+
+// ApproveReservationHandler approves a pending reservation
+//
+//	@Summary		Approve reservation
+//	@Description	Approve a pending reservation in a room that requires approval (only by room admins)
+//	@Tags			reservations
+//	@Accept			json
+//	@Produce		json
+//	@Param			reservation_id	path		int								true	"Reservation ID"
+//	@Param			request			body		dtos.ReservationDecisionRequest	false	"Optional reason"
+//	@Success		200				{object}	dtos.ReservationResponse
+//	@Failure		400				{object}	dtos.ErrorResponse
+//	@Failure		403				{object}	dtos.ErrorResponse
+//	@Failure		404				{object}	dtos.ErrorResponse
+//	@Failure		409				{object}	dtos.ErrorResponse
+//	@Failure		500				{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/reservations/{reservation_id}/approve [post]
+func (rh *ReservationsHandler) ApproveReservationHandler(w http.ResponseWriter, r *http.Request) {
+	rh.decide(w, r, models.ReservationApproved)
+}
+
+// RejectReservationHandler rejects a pending reservation
+//
+//	@Summary		Reject reservation
+//	@Description	Reject a pending reservation, freeing its hold (only by room admins). A reason is required.
+//	@Tags			reservations
+//	@Accept			json
+//	@Produce		json
+//	@Param			reservation_id	path		int								true	"Reservation ID"
+//	@Param			request			body		dtos.ReservationDecisionRequest	true	"Reason for the rejection"
+//	@Success		200				{object}	dtos.ReservationResponse
+//	@Failure		400				{object}	dtos.ErrorResponse
+//	@Failure		403				{object}	dtos.ErrorResponse
+//	@Failure		404				{object}	dtos.ErrorResponse
+//	@Failure		409				{object}	dtos.ErrorResponse
+//	@Failure		500				{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/reservations/{reservation_id}/reject [post]
+func (rh *ReservationsHandler) RejectReservationHandler(w http.ResponseWriter, r *http.Request) {
+	rh.decide(w, r, models.ReservationRejected)
 }
 
+// decide registra a decisão de um administrador sobre uma reserva pendente
+// e avisa quem a pediu.
+func (rh *ReservationsHandler) decide(w http.ResponseWriter, r *http.Request, status string) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	reservation, ok := rh.loadReservation(w, r)
+	if !ok {
+		return
+	}
+
+	room, err := rh.RoomsRepository.WithContext(r.Context()).FindByID(reservation.RoomID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return
+	}
+	if room == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found")
+		return
+	}
+
+	if !rh.RoomsRepository.WithContext(r.Context()).IsRoomAdmin(claims.UserID, room) {
+		utils.RespondWithError(w, http.StatusForbidden, "Only room creator or admins can decide on reservations")
+		return
+	}
+
+	var req dtos.ReservationDecisionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+
+	if status == models.ReservationRejected && req.Reason == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+
+	if reservation.Status != models.ReservationPending {
+		utils.RespondWithError(w, http.StatusConflict, "Reservation is not pending")
+		return
+	}
+
+	eventType := events.ReservationApproved
+	if status == models.ReservationRejected {
+		eventType = events.ReservationRejected
+	}
+
+	err = rh.Outbox.Transaction(func(tx *gorm.DB) error {
+		decided, err := rh.ReservationsRepository.WithContext(r.Context()).WithTx(tx).Decide(reservation.ID, status, &claims.UserID, req.Reason)
+		if err != nil {
+			return err
+		}
+		if !decided {
+			return errNotPending
+		}
+
+		now := time.Now()
+		reservation.Status = status
+		reservation.DecidedBy = &claims.UserID
+		reservation.DecidedAt = &now
+		reservation.DecisionReason = req.Reason
+		return rh.publish(tx, eventType, claims.UserID, claims.Name, room, reservation)
+	})
+	if errors.Is(err, errNotPending) {
+		utils.RespondWithError(w, http.StatusConflict, "Reservation is not pending")
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to save decision")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toReservationResponse(*reservation, localZone(preferredLocation(rh.UserRepository, claims.UserID), room)))
+}
+
+// GetReservationsByUserIDHandler lists a user's reservations
+//
+//	@Summary		Get reservations by user
+//	@Description	List the reservations of a user (only the user themself)
+//	@Tags			reservations
+//	@Accept			json
+//	@Produce		json
+//	@Param			user_id	path		int	true	"User ID"
+//	@Success		200		{array}		dtos.ReservationResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/reservations/by-user/{user_id} [get]
 func (rh *ReservationsHandler) GetReservationsByUserIDHandler(w http.ResponseWriter, r *http.Request) {
-	//
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	userID, err := strconv.ParseUint(chi.URLParam(r, "user_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if uint(userID) != claims.UserID {
+		utils.RespondWithError(w, http.StatusForbidden, "You can only list your own reservations")
+		return
+	}
+
+	reservations, err := rh.ReservationsRepository.WithContext(r.Context()).GetByUserID(uint(userID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get reservations")
+		return
+	}
+
+	respondWithReservations(w, reservations, localZones(rh.UserRepository, rh.RoomsRepository.WithContext(r.Context()), claims.UserID, roomIDsOf(reservations)))
+}
+
+// GetNoShowsHandler returns how many reservations of a user were released for missing check-in
+//
+//	@Summary		Get no-shows of user
+//	@Description	Count the reservations of a user released for missing check-in (only the user themself)
+//	@Tags			reservations
+//	@Accept			json
+//	@Produce		json
+//	@Param			user_id	path		int	true	"User ID"
+//	@Success		200		{object}	dtos.NoShowsResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/reservations/by-user/{user_id}/no-shows [get]
+func (rh *ReservationsHandler) GetNoShowsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	userID, err := strconv.ParseUint(chi.URLParam(r, "user_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if uint(userID) != claims.UserID {
+		utils.RespondWithError(w, http.StatusForbidden, "You can only see your own no-shows")
+		return
+	}
+
+	noShows, err := rh.ReservationsRepository.WithContext(r.Context()).GetNoShows(uint(userID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get no-shows")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dtos.NoShowsResponse{UserID: uint(userID), NoShows: noShows})
 }
 
+// GetReservationsByRoomIDHandler lists a room's reservations
+//
+//	@Summary		Get reservations by room
+//	@Description	List the reservations of a room (only by room members), optionally filtered by status
+//	@Tags			reservations
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int		true	"Room ID"
+//	@Param			status	query		string	false	"Filter by status"	Enums(pending, approved, rejected, expired, held)
+//	@Success		200		{array}		dtos.ReservationResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/reservations/by-room/{room_id} [get]
 func (rh *ReservationsHandler) GetReservationsByRoomIDHandler(w http.ResponseWriter, r *http.Request) {
-	//
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	roomID, err := strconv.ParseUint(chi.URLParam(r, "room_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid room ID")
+		return
+	}
+
+	if !rh.RoomsRepository.WithContext(r.Context()).IsUserInRoom(claims.UserID, uint(roomID)) {
+		utils.RespondWithError(w, http.StatusForbidden, "User is not a member of this room")
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && !isReservationStatus(status) {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid status")
+		return
+	}
+
+	reservations, err := rh.ReservationsRepository.WithContext(r.Context()).GetByRoomID(uint(roomID), status)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get reservations")
+		return
+	}
+
+	respondWithReservations(w, reservations, localZones(rh.UserRepository, rh.RoomsRepository.WithContext(r.Context()), claims.UserID, roomIDsOf(reservations)))
+}
+
+func (rh *ReservationsHandler) loadReservation(w http.ResponseWriter, r *http.Request) (*models.Reservation, bool) {
+	reservationID, err := strconv.ParseUint(chi.URLParam(r, "reservation_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid reservation ID")
+		return nil, false
+	}
+
+	reservation, err := rh.ReservationsRepository.WithContext(r.Context()).GetByID(uint(reservationID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get reservation")
+		return nil, false
+	}
+	if reservation == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Reservation not found")
+		return nil, false
+	}
+	return reservation, true
+}
+
+func (rh *ReservationsHandler) publish(tx *gorm.DB, eventType string, actorID uint, actorName string, room *models.Room, reservation *models.Reservation) error {
+	return rh.Outbox.Publish(tx, events.Event{
+		Type:          eventType,
+		ActorID:       actorID,
+		RoomID:        room.ID,
+		ReservationID: reservation.ID,
+		UserID:        reservation.UserID,
+		Data: map[string]any{
+			"actor_name": actorName,
+			"room_name":  room.Name,
+			"time_zone":  room.Location().String(),
+			"start_time": reservation.StartTime.Format(time.RFC3339),
+			"end_time":   reservation.EndTime.Format(time.RFC3339),
+			"status":     reservation.Status,
+			"reason":     reservation.DecisionReason,
+		},
+	})
+}
+
+// Erros que interrompem a transação quando outro pedido alterou a reserva
+// primeiro.
+var (
+	errAlreadyCheckedIn = errors.New("reservation already checked in")
+	errNotPending       = errors.New("reservation is not pending")
+)
+
+// initialStatus decide o estado de uma reserva nova, remarcada ou
+// confirmada da lista de espera: nas salas que exigem aprovação ela fica
+// pendente, exceto quando é de um administrador da sala.
+func initialStatus(roomsRepo *repository.RoomsRepository, userID uint, room *models.Room) string {
+	if room.RequiresApproval && !roomsRepo.IsRoomAdmin(userID, room) {
+		return models.ReservationPending
+	}
+	return models.ReservationApproved
+}
+
+func isReservationStatus(status string) bool {
+	switch status {
+	case models.ReservationPending, models.ReservationApproved, models.ReservationRejected, models.ReservationExpired, models.ReservationHeld:
+		return true
+	}
+	return false
+}
+
+// validateReservationPeriod retorna a mensagem de erro do período, ou "" se ele for válido.
+func validateReservationPeriod(start, end time.Time) string {
+	if start.IsZero() || end.IsZero() {
+		return "start_time and end_time are required"
+	}
+	if !end.After(start) {
+		return "end_time must be after start_time"
+	}
+	if start.Before(time.Now()) {
+		return "start_time must be in the future"
+	}
+	return ""
+}
+
+func respondWithReservationError(w http.ResponseWriter, err error, fallback string) {
+	var violation *policyViolationError
+	switch {
+	case errors.As(err, &violation):
+		respondWithPolicyViolations(w, violation.violations)
+	case errors.Is(err, repository.ErrReservationConflict), errors.Is(err, repository.ErrReservationOverlap):
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+	default:
+		utils.RespondWithError(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+func respondWithReservations(w http.ResponseWriter, reservations []models.Reservation, zoneOf func(roomID uint) *time.Location) {
+	response := make([]dtos.ReservationResponse, len(reservations))
+	for i, reservation := range reservations {
+		response[i] = toReservationResponse(reservation, zoneOf(reservation.RoomID))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// toReservationResponse devolve os horários em UTC e no fuso loc.
+func toReservationResponse(reservation models.Reservation, loc *time.Location) dtos.ReservationResponse {
+	response := dtos.ReservationResponse{
+		ID:         reservation.ID,
+		UserID:     reservation.UserID,
+		RoomID:     reservation.RoomID,
+		StartTime:  reservation.StartTime.UTC().Format("2006-01-02T15:04:05Z07:00"),
+		EndTime:    reservation.EndTime.UTC().Format("2006-01-02T15:04:05Z07:00"),
+		TimeZone:   loc.String(),
+		LocalStart: reservation.StartTime.In(loc).Format("2006-01-02T15:04:05Z07:00"),
+		LocalEnd:   reservation.EndTime.In(loc).Format("2006-01-02T15:04:05Z07:00"),
+		Status:     reservation.Status,
+		DecidedBy:  reservation.DecidedBy,
+		Reason:     reservation.DecisionReason,
+		CreatedAt:  reservation.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:  reservation.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if reservation.DecidedAt != nil {
+		decidedAt := reservation.DecidedAt.Format("2006-01-02T15:04:05Z07:00")
+		response.DecidedAt = &decidedAt
+	}
+	if reservation.CheckedInAt != nil {
+		checkedInAt := reservation.CheckedInAt.Format("2006-01-02T15:04:05Z07:00")
+		response.CheckedInAt = &checkedInAt
+	}
+	return response
 }
@@ -1,19 +1,30 @@
 package handlers
 
 import (
+	"api-go/internal/audit"
+	"api-go/internal/events"
+	"api-go/internal/jobs"
+	"api-go/internal/models"
 	"api-go/internal/repository"
 	"api-go/internal/server/dtos"
 	"api-go/internal/server/middlewares"
+	"api-go/internal/tenant"
 	"api-go/internal/utils"
 	"encoding/json"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
 )
 
 type RoomsHandler struct {
-	RoomsRepository *repository.RoomsRepository
+	RoomsRepository     *repository.RoomsRepository
+	LocationsRepository *repository.LocationsRepository
+	Outbox              *jobs.Outbox
+	Audit               *audit.Log
 }
 
 func (rh *RoomsHandler) RegisterRoomsRoutes(r chi.Router) {
@@ -22,9 +33,14 @@ func (rh *RoomsHandler) RegisterRoomsRoutes(r chi.Router) {
 		r.Get("/", rh.GetAllRoomsHandler)
 		r.Get("/{room_id}", rh.GetRoomByIDHandler)
 		r.Put("/{room_id}", rh.UpdateRoomsHandler)
+		r.Put("/{room_id}/settings", rh.UpdateRoomSettingsHandler)
+		r.Put("/{room_id}/attributes", rh.UpdateRoomAttributesHandler)
 		r.Delete("/{room_id}", rh.DeleteRoomsHandler)
+		r.Post("/{room_id}/archive", rh.ArchiveRoomHandler)
+		r.Post("/{room_id}/unarchive", rh.UnarchiveRoomHandler)
 		r.Post("/{room_id}/join", rh.JoinRoomHandler)
 		r.Delete("/{room_id}/leave", rh.LeaveRoomHandler)
+		r.Put("/{room_id}/members/{user_id}/role", rh.UpdateMemberRoleHandler)
 		r.Get("/my-rooms", rh.GetUserRoomsHandler)
 	})
 }
@@ -66,27 +82,36 @@ func (rh *RoomsHandler) CreateRoomsHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	room, err := rh.RoomsRepository.Create(req.Name, req.Description, req.Subject, req.Capacity, userID)
+	if req.TimeZone == "" {
+		req.TimeZone = models.DefaultTimeZone
+	}
+	if !models.IsValidTimeZone(req.TimeZone) {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid time_zone, expected an IANA name such as America/Sao_Paulo")
+		return
+	}
+
+	if req.Visibility == "" {
+		req.Visibility = models.RoomPublic
+	}
+	if !models.IsRoomVisibility(req.Visibility) {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid visibility, must be public, unlisted or private")
+		return
+	}
+
+	// A sala nasce na organização da requisição.
+	orgID, _ := tenant.FromContext(r.Context())
+	room, err := rh.RoomsRepository.WithContext(r.Context()).Create(orgID, req.Name, req.Description, req.Subject, req.Capacity, userID, req.TimeZone, req.Visibility)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create room")
 		return
 	}
 
-	if err := rh.RoomsRepository.JoinRoom(userID, room.ID); err != nil {
+	if err := rh.RoomsRepository.WithContext(r.Context()).JoinRoom(userID, room.ID, models.RoomRoleAdmin); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to join created room")
 		return
 	}
 
-	response := dtos.RoomResponse{
-		ID:          room.ID,
-		Name:        room.Name,
-		Description: room.Description,
-		Subject:     room.Subject,
-		Capacity:    room.Capacity,
-		CreatedBy:   room.CreatedBy,
-		CreatedAt:   room.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:   room.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
+	response := toRoomResponse(*room)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -96,16 +121,63 @@ func (rh *RoomsHandler) CreateRoomsHandler(w http.ResponseWriter, r *http.Reques
 // GetAllRoomsHandler gets all rooms
 //
 //	@Summary		Get all rooms
-//	@Description	Retrieve list of all available rooms
+//	@Description	Retrieve list of public rooms and rooms the user is a member of, except archived ones, optionally filtered by location and amenities. Rooms must have every amenity given.
 //	@Tags			rooms
 //	@Accept			json
 //	@Produce		json
-//	@Success		200		{array}		dtos.RoomResponse
-//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Param			site_id		query		int			false	"Site ID"
+//	@Param			building_id	query		int			false	"Building ID"
+//	@Param			floor_id	query		int			false	"Floor ID"
+//	@Param			amenity		query		[]string	false	"Amenities (repeat or separate with commas)"	collectionFormat(multi)
+//	@Success		200			{array}		dtos.RoomResponse
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
 //	@Security		BearerAuth
 //	@Router			/rooms [get]
 func (rh *RoomsHandler) GetAllRoomsHandler(w http.ResponseWriter, r *http.Request) {
-	rooms, err := rh.RoomsRepository.GetAll()
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	query := r.URL.Query()
+
+	filter := repository.RoomFilter{VisibleTo: claims.UserID}
+	for name, target := range map[string]*uint{
+		"site_id":     &filter.SiteID,
+		"building_id": &filter.BuildingID,
+		"floor_id":    &filter.FloorID,
+	} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid "+name)
+			return
+		}
+		*target = uint(id)
+	}
+
+	for _, value := range query["amenity"] {
+		for _, amenity := range strings.Split(value, ",") {
+			amenity = strings.TrimSpace(amenity)
+			if amenity == "" {
+				continue
+			}
+			if !models.IsAmenity(amenity) {
+				utils.RespondWithError(w, http.StatusBadRequest, "Unknown amenity: "+amenity)
+				return
+			}
+			if !slices.Contains(filter.Amenities, amenity) {
+				filter.Amenities = append(filter.Amenities, amenity)
+			}
+		}
+	}
+
+	rooms, err := rh.RoomsRepository.WithContext(r.Context()).GetAll(filter)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get rooms")
 		return
@@ -113,16 +185,7 @@ func (rh *RoomsHandler) GetAllRoomsHandler(w http.ResponseWriter, r *http.Reques
 
 	var response []dtos.RoomResponse
 	for _, room := range rooms {
-		response = append(response, dtos.RoomResponse{
-			ID:          room.ID,
-			Name:        room.Name,
-			Description: room.Description,
-			Subject:     room.Subject,
-			Capacity:    room.Capacity,
-			CreatedBy:   room.CreatedBy,
-			CreatedAt:   room.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt:   room.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		})
+		response = append(response, toRoomResponse(room))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -132,18 +195,25 @@ func (rh *RoomsHandler) GetAllRoomsHandler(w http.ResponseWriter, r *http.Reques
 // GetRoomByIDHandler gets room by ID
 //
 //	@Summary		Get room by ID
-//	@Description	Retrieve detailed room information including members and notes
+//	@Description	Retrieve detailed room information. Members (and admins) also get the members and notes; other users only get the room's public metadata, and private rooms are hidden from them. Member emails are masked unless the caller administers the room.
 //	@Tags			rooms
 //	@Accept			json
 //	@Produce		json
 //	@Param			room_id	path		int	true	"Room ID"
 //	@Success		200		{object}	dtos.RoomResponse
 //	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
 //	@Failure		404		{object}	dtos.ErrorResponse
 //	@Failure		500		{object}	dtos.ErrorResponse
 //	@Security		BearerAuth
 //	@Router			/rooms/{room_id} [get]
 func (rh *RoomsHandler) GetRoomByIDHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
 	roomIDStr := chi.URLParam(r, "room_id")
 	roomID, err := strconv.ParseUint(roomIDStr, 10, 32)
 	if err != nil {
@@ -151,7 +221,7 @@ func (rh *RoomsHandler) GetRoomByIDHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	room, err := rh.RoomsRepository.GetByID(uint(roomID))
+	room, err := rh.RoomsRepository.WithContext(r.Context()).GetByID(uint(roomID))
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
 		return
@@ -161,38 +231,58 @@ func (rh *RoomsHandler) GetRoomByIDHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	response := dtos.RoomResponse{
-		ID:          room.ID,
-		Name:        room.Name,
-		Description: room.Description,
-		Subject:     room.Subject,
-		Capacity:    room.Capacity,
-		CreatedBy:   room.CreatedBy,
-		CreatedAt:   room.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:   room.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
-
-	for _, member := range room.Members {
-		response.Members = append(response.Members, dtos.RoomMemberResponse{
+	canSeeAll := claims.IsAdmin() || rh.RoomsRepository.WithContext(r.Context()).IsUserInRoom(claims.UserID, room.ID)
+	if room.Visibility == models.RoomPrivate && !canSeeAll {
+		utils.RespondWithError(w, http.StatusForbidden, "Room is private")
+		return
+	}
+
+	response := toRoomResponse(*room)
+
+	// Quem não é membro vê só os dados públicos da sala.
+	if !canSeeAll {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	members, err := rh.RoomsRepository.WithContext(r.Context()).GetMembers(room.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room members")
+		return
+	}
+
+	roomAdmin := rh.RoomsRepository.WithContext(r.Context()).IsRoomAdmin(claims.UserID, room)
+	for _, member := range members {
+		memberResponse := dtos.RoomMemberResponse{
 			UserID:    member.UserID,
 			UserName:  member.User.Name,
-			UserEmail: member.User.Email,
+			UserEmail: visibleEmail(claims, member.UserID, member.User.Email, roomAdmin),
 			Role:      member.Role,
-			JoinedAt:  member.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		})
+			Direct:    member.IsDirect(),
+			GroupIDs:  member.GroupIDs,
+		}
+		if member.IsDirect() {
+			memberResponse.JoinedAt = member.CreatedAt.Format("2006-01-02T15:04:05Z07:00")
+		}
+		response.Members = append(response.Members, memberResponse)
 	}
 
 	for _, note := range room.Notes {
 		response.Notes = append(response.Notes, dtos.NoteResponse{
-			ID:        note.ID,
-			UserID:    note.UserID,
-			RoomID:    note.RoomID,
-			Title:     note.Title,
-			Content:   note.Content,
-			UserName:  note.User.Name,
-			UserEmail: note.User.Email,
-			CreatedAt: note.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt: note.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			ID:          note.ID,
+			UserID:      note.UserID,
+			RoomID:      note.RoomID,
+			Title:       note.Title,
+			Content:     note.Content,
+			Format:      note.Format,
+			ContentHTML: note.ContentHTML,
+			Excerpt:     note.Excerpt,
+			TOC:         toNoteHeadingsResponse(note.TableOfContents),
+			UserName:    note.User.Name,
+			UserEmail:   visibleEmail(claims, note.UserID, note.User.Email, roomAdmin),
+			CreatedAt:   note.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt:   note.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		})
 	}
 
@@ -231,7 +321,7 @@ func (rh *RoomsHandler) UpdateRoomsHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	room, err := rh.RoomsRepository.GetByID(uint(roomID))
+	room, err := rh.RoomsRepository.WithContext(r.Context()).GetByID(uint(roomID))
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
 		return
@@ -246,6 +336,10 @@ func (rh *RoomsHandler) UpdateRoomsHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if !checkRoomWritable(w, room) {
+		return
+	}
+
 	var req dtos.UpdateRoomRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
@@ -258,7 +352,7 @@ func (rh *RoomsHandler) UpdateRoomsHandler(w http.ResponseWriter, r *http.Reques
 	}
 
 	if req.Capacity > 0 {
-		currentMembers, err := rh.RoomsRepository.GetRoomMemberCount(uint(roomID))
+		currentMembers, err := rh.RoomsRepository.WithContext(r.Context()).GetRoomMemberCount(uint(roomID))
 		if err != nil {
 			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to check current member count")
 			return
@@ -270,7 +364,10 @@ func (rh *RoomsHandler) UpdateRoomsHandler(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
-	if err := rh.RoomsRepository.Update(uint(roomID), req.Name, req.Description, req.Subject, req.Capacity); err != nil {
+	_, err = rh.updateRoom(r, room, audit.ActionRoomUpdated, func(roomsRepo *repository.RoomsRepository) error {
+		return roomsRepo.Update(room.ID, req.Name, req.Description, req.Subject, req.Capacity)
+	})
+	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update room")
 		return
 	}
@@ -279,10 +376,203 @@ func (rh *RoomsHandler) UpdateRoomsHandler(w http.ResponseWriter, r *http.Reques
 	w.Write([]byte(`{"message": "Room updated successfully"}`))
 }
 
+// UpdateRoomSettingsHandler updates the booking settings of a room
+//
+//	@Summary		Update room settings
+//	@Description	Change the booking settings of a room, such as requiring check-in or approval or its time zone (only by room admins). Omitted fields are kept.
+//	@Tags			rooms
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int								true	"Room ID"
+//	@Param			request	body		dtos.UpdateRoomSettingsRequest	true	"Settings to change"
+//	@Success		200		{object}	dtos.RoomResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/settings [put]
+func (rh *RoomsHandler) UpdateRoomSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	roomID, err := strconv.ParseUint(chi.URLParam(r, "room_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid room ID")
+		return
+	}
+
+	room, err := rh.RoomsRepository.WithContext(r.Context()).FindByID(uint(roomID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return
+	}
+	if room == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found")
+		return
+	}
+
+	if !rh.RoomsRepository.WithContext(r.Context()).IsRoomAdmin(claims.UserID, room) {
+		utils.RespondWithError(w, http.StatusForbidden, "Only room creator or admins can change room settings")
+		return
+	}
+
+	if !checkRoomWritable(w, room) {
+		return
+	}
+
+	var req dtos.UpdateRoomSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	settings := map[string]any{}
+	if req.CheckInRequired != nil {
+		settings["check_in_required"] = *req.CheckInRequired
+	}
+	if req.RequiresApproval != nil {
+		settings["requires_approval"] = *req.RequiresApproval
+	}
+	if req.TimeZone != nil {
+		if !models.IsValidTimeZone(*req.TimeZone) {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid time_zone, expected an IANA name such as America/Sao_Paulo")
+			return
+		}
+		settings["time_zone"] = *req.TimeZone
+	}
+	if req.Visibility != nil {
+		if !models.IsRoomVisibility(*req.Visibility) {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid visibility, must be public, unlisted or private")
+			return
+		}
+		settings["visibility"] = *req.Visibility
+	}
+
+	room, err = rh.updateRoom(r, room, audit.ActionRoomUpdated, func(roomsRepo *repository.RoomsRepository) error {
+		return roomsRepo.UpdateSettings(room.ID, settings)
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update room settings")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toRoomResponse(*room))
+}
+
+// UpdateRoomAttributesHandler updates the location and amenities of a room
+//
+//	@Summary		Update room attributes
+//	@Description	Replace the floor, floor-plan position (0 to 1 from the top-left corner of the plan) and amenities of a room (only by room admins)
+//	@Tags			rooms
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int									true	"Room ID"
+//	@Param			request	body		dtos.UpdateRoomAttributesRequest	true	"Room attributes"
+//	@Success		200		{object}	dtos.RoomResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/attributes [put]
+func (rh *RoomsHandler) UpdateRoomAttributesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	roomID, err := strconv.ParseUint(chi.URLParam(r, "room_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid room ID")
+		return
+	}
+
+	room, err := rh.RoomsRepository.WithContext(r.Context()).FindByID(uint(roomID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return
+	}
+	if room == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found")
+		return
+	}
+
+	if !rh.RoomsRepository.WithContext(r.Context()).IsRoomAdmin(claims.UserID, room) {
+		utils.RespondWithError(w, http.StatusForbidden, "Only room creator or admins can change room attributes")
+		return
+	}
+
+	if !checkRoomWritable(w, room) {
+		return
+	}
+
+	var req dtos.UpdateRoomAttributesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// O andar precisa ser da organização da sala; os de outras não aparecem.
+	if req.FloorID != nil {
+		floor, err := rh.LocationsRepository.WithContext(r.Context()).GetFloor(*req.FloorID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get floor")
+			return
+		}
+		if floor == nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Floor not found")
+			return
+		}
+	}
+
+	if (req.PlanX == nil) != (req.PlanY == nil) {
+		utils.RespondWithError(w, http.StatusBadRequest, "plan_x and plan_y must be given together")
+		return
+	}
+	if req.PlanX != nil {
+		if req.FloorID == nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "floor_id is required to place the room on a floor plan")
+			return
+		}
+		if *req.PlanX < 0 || *req.PlanX > 1 || *req.PlanY < 0 || *req.PlanY > 1 {
+			utils.RespondWithError(w, http.StatusBadRequest, "plan_x and plan_y must be between 0 and 1")
+			return
+		}
+	}
+
+	var amenities []string
+	for _, amenity := range req.Amenities {
+		if !models.IsAmenity(amenity) {
+			utils.RespondWithError(w, http.StatusBadRequest, "Unknown amenity: "+amenity)
+			return
+		}
+		if !slices.Contains(amenities, amenity) {
+			amenities = append(amenities, amenity)
+		}
+	}
+
+	room, err = rh.updateRoom(r, room, audit.ActionRoomUpdated, func(roomsRepo *repository.RoomsRepository) error {
+		return roomsRepo.UpdateAttributes(room.ID, req.FloorID, req.PlanX, req.PlanY, amenities)
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update room attributes")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toRoomResponse(*room))
+}
+
 // DeleteRoomsHandler deletes a room
 //
 //	@Summary		Delete room
-//	@Description	Delete room (only by room creator)
+//	@Description	Move the room to the trash with its notes and memberships (only by room creator)
 //	@Tags			rooms
 //	@Accept			json
 //	@Produce		json
@@ -309,7 +599,7 @@ func (rh *RoomsHandler) DeleteRoomsHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	room, err := rh.RoomsRepository.GetByID(uint(roomID))
+	room, err := rh.RoomsRepository.WithContext(r.Context()).GetByID(uint(roomID))
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
 		return
@@ -324,7 +614,19 @@ func (rh *RoomsHandler) DeleteRoomsHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if err := rh.RoomsRepository.Delete(uint(roomID)); err != nil {
+	err = rh.Outbox.Transaction(func(tx *gorm.DB) error {
+		if err := rh.RoomsRepository.WithContext(r.Context()).WithTx(tx).Delete(room.ID); err != nil {
+			return err
+		}
+		return rh.Audit.Record(tx, auditMeta(r), audit.Entry{
+			Action:     audit.ActionRoomDeleted,
+			TargetType: models.AuditTargetRoom,
+			TargetID:   room.ID,
+			RoomID:     room.ID,
+			Before:     toRoomResponse(*room),
+		})
+	})
+	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete room")
 		return
 	}
@@ -333,16 +635,110 @@ func (rh *RoomsHandler) DeleteRoomsHandler(w http.ResponseWriter, r *http.Reques
 	w.Write([]byte(`{"message": "Room deleted successfully"}`))
 }
 
+// ArchiveRoomHandler archives a room
+//
+//	@Summary		Archive room
+//	@Description	Archive a room (only by room creator). Archived rooms are hidden from listings and read-only, but keep their notes, members and reservations.
+//	@Tags			rooms
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int	true	"Room ID"
+//	@Success		200		{object}	dtos.RoomResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/archive [post]
+func (rh *RoomsHandler) ArchiveRoomHandler(w http.ResponseWriter, r *http.Request) {
+	rh.setArchived(w, r, true)
+}
+
+// UnarchiveRoomHandler unarchives a room
+//
+//	@Summary		Unarchive room
+//	@Description	Bring an archived room back to listings and make it writable again (only by room creator)
+//	@Tags			rooms
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int	true	"Room ID"
+//	@Success		200		{object}	dtos.RoomResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/unarchive [post]
+func (rh *RoomsHandler) UnarchiveRoomHandler(w http.ResponseWriter, r *http.Request) {
+	rh.setArchived(w, r, false)
+}
+
+func (rh *RoomsHandler) setArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	roomID, err := strconv.ParseUint(chi.URLParam(r, "room_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid room ID")
+		return
+	}
+
+	room, err := rh.RoomsRepository.WithContext(r.Context()).FindByID(uint(roomID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return
+	}
+	if room == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found")
+		return
+	}
+
+	if room.CreatedBy != claims.UserID {
+		utils.RespondWithError(w, http.StatusForbidden, "Only room creator can archive the room")
+		return
+	}
+
+	if room.IsArchived() == archived {
+		if archived {
+			utils.RespondWithError(w, http.StatusConflict, "Room is already archived")
+		} else {
+			utils.RespondWithError(w, http.StatusConflict, "Room is not archived")
+		}
+		return
+	}
+
+	action := audit.ActionRoomUnarchived
+	if archived {
+		action = audit.ActionRoomArchived
+	}
+	room, err = rh.updateRoom(r, room, action, func(roomsRepo *repository.RoomsRepository) error {
+		return roomsRepo.SetArchived(room.ID, archived)
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update room")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toRoomResponse(*room))
+}
+
 // JoinRoomHandler joins a room
 //
 //	@Summary		Join room
-//	@Description	Join an existing room
+//	@Description	Join a public or unlisted room. Private rooms are joined through an invite or an approved join request.
 //	@Tags			rooms
 //	@Accept			json
 //	@Produce		json
 //	@Param			room_id	path	int	true	"Room ID"
 //	@Success		200		{object}	map[string]string
 //	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
 //	@Failure		404		{object}	dtos.ErrorResponse
 //	@Failure		409		{object}	dtos.ErrorResponse
 //	@Failure		500		{object}	dtos.ErrorResponse
@@ -363,7 +759,7 @@ func (rh *RoomsHandler) JoinRoomHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	room, err := rh.RoomsRepository.GetByID(uint(roomID))
+	room, err := rh.RoomsRepository.WithContext(r.Context()).GetByID(uint(roomID))
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
 		return
@@ -373,23 +769,28 @@ func (rh *RoomsHandler) JoinRoomHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if rh.RoomsRepository.IsUserInRoom(userID, uint(roomID)) {
+	if rh.RoomsRepository.WithContext(r.Context()).IsUserInRoom(userID, uint(roomID)) {
 		utils.RespondWithError(w, http.StatusConflict, "User already in room")
 		return
 	}
 
-	currentMembers, err := rh.RoomsRepository.GetRoomMemberCount(uint(roomID))
-	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to check room capacity")
+	if !checkRoomWritable(w, room) {
 		return
 	}
 
-	if int(currentMembers) >= room.Capacity {
-		utils.RespondWithError(w, http.StatusConflict, "Room is at full capacity")
+	if room.Visibility == models.RoomPrivate {
+		utils.RespondWithError(w, http.StatusForbidden, "Room is private, join with an invite or request to join")
 		return
 	}
 
-	if err := rh.RoomsRepository.JoinRoom(userID, uint(roomID)); err != nil {
+	if !checkRoomCapacity(w, rh.RoomsRepository.WithContext(r.Context()), room) {
+		return
+	}
+
+	err = rh.Outbox.Transaction(func(tx *gorm.DB) error {
+		return addMember(tx, rh.RoomsRepository.WithContext(r.Context()), rh.Outbox, rh.Audit, r, room, userID, claims.Name, models.RoomRoleMember)
+	})
+	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to join room")
 		return
 	}
@@ -401,7 +802,7 @@ func (rh *RoomsHandler) JoinRoomHandler(w http.ResponseWriter, r *http.Request)
 // LeaveRoomHandler leaves a room
 //
 //	@Summary		Leave room
-//	@Description	Leave a room you're currently in
+//	@Description	Leave a room you're currently in. The room owner must transfer ownership first.
 //	@Tags			rooms
 //	@Accept			json
 //	@Produce		json
@@ -409,6 +810,7 @@ func (rh *RoomsHandler) JoinRoomHandler(w http.ResponseWriter, r *http.Request)
 //	@Success		200		{object}	map[string]string
 //	@Failure		400		{object}	dtos.ErrorResponse
 //	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
 //	@Failure		500		{object}	dtos.ErrorResponse
 //	@Security		BearerAuth
 //	@Router			/rooms/{room_id}/leave [delete]
@@ -427,12 +829,62 @@ func (rh *RoomsHandler) LeaveRoomHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if !rh.RoomsRepository.IsUserInRoom(userID, uint(roomID)) {
+	if !rh.RoomsRepository.WithContext(r.Context()).IsUserInRoom(userID, uint(roomID)) {
 		utils.RespondWithError(w, http.StatusNotFound, "User not in room")
 		return
 	}
 
-	if err := rh.RoomsRepository.LeaveRoom(userID, uint(roomID)); err != nil {
+	room, err := rh.RoomsRepository.WithContext(r.Context()).FindByID(uint(roomID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return
+	}
+	if room != nil && room.CreatedBy == userID {
+		utils.RespondWithError(w, http.StatusConflict, "The room owner cannot leave, transfer ownership first")
+		return
+	}
+
+	// Quem participa só por grupos sai da sala saindo dos grupos.
+	directRole, err := rh.RoomsRepository.WithContext(r.Context()).GetDirectRole(userID, uint(roomID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get member")
+		return
+	}
+	if directRole == "" {
+		utils.RespondWithError(w, http.StatusConflict, "You are in this room through a group, leave the group instead")
+		return
+	}
+
+	err = rh.Outbox.Transaction(func(tx *gorm.DB) error {
+		roomsRepo := rh.RoomsRepository.WithContext(r.Context()).WithTx(tx)
+		role, err := roomsRepo.GetDirectRole(userID, uint(roomID))
+		if err != nil {
+			return err
+		}
+		if err := roomsRepo.LeaveRoom(userID, uint(roomID)); err != nil {
+			return err
+		}
+		err = rh.Audit.Record(tx, auditMeta(r), audit.Entry{
+			Action:     audit.ActionMemberLeft,
+			TargetType: models.AuditTargetUser,
+			TargetID:   userID,
+			RoomID:     uint(roomID),
+			Before:     map[string]string{"role": role},
+		})
+		if err != nil {
+			return err
+		}
+		return rh.Outbox.Publish(tx, events.Event{
+			Type:    events.RoomMemberLeft,
+			ActorID: userID,
+			RoomID:  uint(roomID),
+			UserID:  userID,
+			Data: map[string]any{
+				"actor_name": claims.Name,
+			},
+		})
+	})
+	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to leave room")
 		return
 	}
@@ -461,7 +913,7 @@ func (rh *RoomsHandler) GetUserRoomsHandler(w http.ResponseWriter, r *http.Reque
 
 	userID := claims.UserID
 
-	rooms, err := rh.RoomsRepository.GetUserRooms(userID)
+	rooms, err := rh.RoomsRepository.WithContext(r.Context()).GetUserRooms(userID)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get user rooms")
 		return
@@ -469,18 +921,309 @@ func (rh *RoomsHandler) GetUserRoomsHandler(w http.ResponseWriter, r *http.Reque
 
 	var response []dtos.RoomResponse
 	for _, room := range rooms {
-		response = append(response, dtos.RoomResponse{
-			ID:          room.ID,
-			Name:        room.Name,
-			Description: room.Description,
-			Subject:     room.Subject,
-			Capacity:    room.Capacity,
-			CreatedBy:   room.CreatedBy,
-			CreatedAt:   room.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt:   room.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		})
+		response = append(response, toRoomResponse(room))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// UpdateMemberRoleHandler changes the role of a room member
+//
+//	@Summary		Change member role
+//	@Description	Promote or demote a room member (only by room creator or admins)
+//	@Tags			rooms
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int							true	"Room ID"
+//	@Param			user_id	path		int							true	"User ID"
+//	@Param			request	body		dtos.UpdateMemberRoleRequest	true	"New role"
+//	@Success		200		{object}	map[string]string
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/rooms/{room_id}/members/{user_id}/role [put]
+func (rh *RoomsHandler) UpdateMemberRoleHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	userID := claims.UserID
+	roomID, err := strconv.ParseUint(chi.URLParam(r, "room_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid room ID")
+		return
+	}
+
+	memberID, err := strconv.ParseUint(chi.URLParam(r, "user_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req dtos.UpdateMemberRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Role != models.RoomRoleMember && req.Role != models.RoomRoleAdmin {
+		utils.RespondWithError(w, http.StatusBadRequest, "Role must be 'member' or 'admin'")
+		return
+	}
+
+	room, err := rh.RoomsRepository.WithContext(r.Context()).GetByID(uint(roomID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return
+	}
+	if room == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found")
+		return
+	}
+
+	if !rh.RoomsRepository.WithContext(r.Context()).IsRoomAdmin(userID, room) {
+		utils.RespondWithError(w, http.StatusForbidden, "Only room creator or admins can change roles")
+		return
+	}
+
+	if !checkRoomWritable(w, room) {
+		return
+	}
+
+	if uint(memberID) == room.CreatedBy {
+		utils.RespondWithError(w, http.StatusForbidden, "The role of the room creator cannot be changed")
+		return
+	}
+
+	// O papel herdado de um grupo muda no acesso do grupo à sala.
+	currentRole, err := rh.RoomsRepository.WithContext(r.Context()).GetDirectRole(uint(memberID), room.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get member")
+		return
+	}
+	if currentRole == "" {
+		if rh.RoomsRepository.WithContext(r.Context()).IsUserInRoom(uint(memberID), room.ID) {
+			utils.RespondWithError(w, http.StatusConflict, "User is in this room through a group, change the group's role instead")
+			return
+		}
+		utils.RespondWithError(w, http.StatusNotFound, "User not in room")
+		return
+	}
+
+	if currentRole != req.Role {
+		err := rh.Outbox.Transaction(func(tx *gorm.DB) error {
+			if err := rh.RoomsRepository.WithContext(r.Context()).WithTx(tx).UpdateMemberRole(uint(memberID), room.ID, req.Role); err != nil {
+				return err
+			}
+			err := rh.Audit.Record(tx, auditMeta(r), audit.Entry{
+				Action:     audit.ActionMemberRoleChanged,
+				TargetType: models.AuditTargetUser,
+				TargetID:   uint(memberID),
+				RoomID:     room.ID,
+				Before:     map[string]string{"role": currentRole},
+				After:      map[string]string{"role": req.Role},
+			})
+			if err != nil {
+				return err
+			}
+			return rh.Outbox.Publish(tx, events.Event{
+				Type:    events.RoomMemberRoleChanged,
+				ActorID: userID,
+				RoomID:  room.ID,
+				UserID:  uint(memberID),
+				Data: map[string]any{
+					"actor_name":    claims.Name,
+					"room_name":     room.Name,
+					"role":          req.Role,
+					"previous_role": currentRole,
+				},
+			})
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update role")
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Role updated successfully"}`))
+}
+
+func toRoomResponse(room models.Room) dtos.RoomResponse {
+	response := dtos.RoomResponse{
+		ID:               room.ID,
+		Name:             room.Name,
+		Description:      room.Description,
+		Subject:          room.Subject,
+		Capacity:         room.Capacity,
+		CreatedBy:        room.CreatedBy,
+		OrganizationID:   room.OrganizationID,
+		TimeZone:         room.TimeZone,
+		Visibility:       room.Visibility,
+		CheckInRequired:  room.CheckInRequired,
+		RequiresApproval: room.RequiresApproval,
+		FloorID:          room.FloorID,
+		PlanX:            room.PlanX,
+		PlanY:            room.PlanY,
+		Amenities:        []string{},
+		CreatedAt:        room.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:        room.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	for _, amenity := range room.Amenities {
+		response.Amenities = append(response.Amenities, amenity.Amenity)
+	}
+	if room.ArchivedAt != nil {
+		archivedAt := room.ArchivedAt.Format("2006-01-02T15:04:05Z07:00")
+		response.ArchivedAt = &archivedAt
+	}
+	return response
+}
+
+// checkRoomWritable responde com erro e retorna false se a sala estiver
+// arquivada, e portanto só para leitura.
+func checkRoomWritable(w http.ResponseWriter, room *models.Room) bool {
+	if room.IsArchived() {
+		utils.RespondWithError(w, http.StatusConflict, "Room is archived and read-only")
+		return false
+	}
+	return true
+}
+
+// checkRoomCapacity responde com erro e retorna false se a sala estiver cheia.
+func checkRoomCapacity(w http.ResponseWriter, roomsRepo *repository.RoomsRepository, room *models.Room) bool {
+	currentMembers, err := roomsRepo.GetRoomMemberCount(room.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to check room capacity")
+		return false
+	}
+
+	if int(currentMembers) >= room.Capacity {
+		utils.RespondWithError(w, http.StatusConflict, "Room is at full capacity")
+		return false
+	}
+	return true
+}
+
+// updateRoom aplica update à sala e audita a mudança com a sala antes e
+// depois dela, na mesma transação. Retorna a sala atualizada.
+func (rh *RoomsHandler) updateRoom(r *http.Request, room *models.Room, action string, update func(roomsRepo *repository.RoomsRepository) error) (*models.Room, error) {
+	var updated *models.Room
+	err := rh.Outbox.Transaction(func(tx *gorm.DB) error {
+		roomsRepo := rh.RoomsRepository.WithContext(r.Context()).WithTx(tx)
+		if err := update(roomsRepo); err != nil {
+			return err
+		}
+		var err error
+		if updated, err = roomsRepo.FindByID(room.ID); err != nil {
+			return err
+		}
+		if updated == nil {
+			return gorm.ErrRecordNotFound
+		}
+		return rh.Audit.Record(tx, auditMeta(r), audit.Entry{
+			Action:     action,
+			TargetType: models.AuditTargetRoom,
+			TargetID:   room.ID,
+			RoomID:     room.ID,
+			Before:     toRoomResponse(*room),
+			After:      toRoomResponse(*updated),
+		})
+	})
+	return updated, err
+}
+
+// addMember adiciona o usuário à sala, publica a entrada e a audita, na
+// transação tx.
+func addMember(tx *gorm.DB, roomsRepo *repository.RoomsRepository, outbox *jobs.Outbox, auditLog *audit.Log, r *http.Request, room *models.Room, userID uint, userName, role string) error {
+	if err := roomsRepo.WithTx(tx).JoinRoom(userID, room.ID, role); err != nil {
+		return err
+	}
+	err := auditLog.Record(tx, auditMeta(r), audit.Entry{
+		Action:     audit.ActionMemberJoined,
+		TargetType: models.AuditTargetUser,
+		TargetID:   userID,
+		RoomID:     room.ID,
+		After:      map[string]string{"role": role},
+	})
+	if err != nil {
+		return err
+	}
+	return outbox.Publish(tx, events.Event{
+		Type:    events.RoomMemberJoined,
+		ActorID: userID,
+		RoomID:  room.ID,
+		UserID:  userID,
+		Data: map[string]any{
+			"actor_name": userName,
+			"room_name":  room.Name,
+			"role":       role,
+		},
+	})
+}
+
+// publishMembershipChanges compara as participações efetivas de antes e de
+// depois de uma mudança feita em tx e publica a saída de quem deixou de
+// participar de uma sala, a entrada de quem passou a participar e a troca de
+// papel de quem continua com outro papel efetivo. Os dois lados devem ter sido
+// lidos na mesma transação, com os mesmos filtros.
+func publishMembershipChanges(tx *gorm.DB, outbox *jobs.Outbox, before, after []repository.EffectiveMembership) error {
+	type key struct{ userID, roomID uint }
+	had := make(map[key]string, len(before))
+	for _, m := range before {
+		had[key{m.UserID, m.RoomID}] = m.Role
+	}
+	has := make(map[key]bool, len(after))
+	for _, m := range after {
+		has[key{m.UserID, m.RoomID}] = true
+	}
+
+	for _, m := range before {
+		if has[key{m.UserID, m.RoomID}] {
+			continue
+		}
+		err := outbox.Publish(tx, events.Event{
+			Type:    events.RoomMemberLeft,
+			ActorID: m.UserID,
+			RoomID:  m.RoomID,
+			UserID:  m.UserID,
+			Data: map[string]any{
+				"actor_name": m.UserName,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, m := range after {
+		previous, ok := had[key{m.UserID, m.RoomID}]
+		if ok && previous == m.Role {
+			continue
+		}
+		event := events.Event{
+			Type:    events.RoomMemberJoined,
+			ActorID: m.UserID,
+			RoomID:  m.RoomID,
+			UserID:  m.UserID,
+			Data: map[string]any{
+				"actor_name": m.UserName,
+				"room_name":  m.RoomName,
+				"role":       m.Role,
+			},
+		}
+		if ok {
+			event.Type = events.RoomMemberRoleChanged
+			event.Data["previous_role"] = previous
+		}
+		err := outbox.Publish(tx, event)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
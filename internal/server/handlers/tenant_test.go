@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"api-go/internal/database/dbtest"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/tenant"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestOrganizationsAreIsolated monta duas organizações com uma usuária que
+// participa das duas, para que a resposta dependa só da organização da
+// requisição e não das permissões dela.
+func TestOrganizationsAreIsolated(t *testing.T) {
+	db := dbtest.New(t)
+	orgA := createOrg(t, db, "acme")
+	orgB := createOrg(t, db, "globex")
+	alice := createUser(t, db, orgA, "Alice", "alice@example.com")
+	addOrgMember(t, db, orgB, alice)
+	carol := createUser(t, db, orgB, "Carol", "carol@example.com")
+
+	roomA := createRoom(t, db, orgA, alice, "Lobby", models.RoomPublic)
+	roomB := createRoom(t, db, orgB, alice, "Board", models.RoomPublic)
+	noteB := createNote(t, db, roomB, alice, "Minutes")
+	reservationB := createReservation(t, db, roomB, alice, time.Now().Add(24*time.Hour).Truncate(time.Hour))
+	groupB := createGroup(t, db, orgB, "Directors", carol)
+	grantRoom(t, db, roomB, groupB, models.RoomRoleMember)
+
+	siteB := models.Site{OrganizationID: orgB.ID, Name: "HQ", CreatedBy: carol.ID}
+	if err := db.Create(&siteB).Error; err != nil {
+		t.Fatalf("failed to create site: %v", err)
+	}
+	buildingB := models.Building{OrganizationID: orgB.ID, SiteID: siteB.ID, Name: "Tower", CreatedBy: carol.ID}
+	if err := db.Create(&buildingB).Error; err != nil {
+		t.Fatalf("failed to create building: %v", err)
+	}
+	floorB := models.Floor{OrganizationID: orgB.ID, BuildingID: buildingB.ID, Name: "Ground", CreatedBy: carol.ID}
+	if err := db.Create(&floorB).Error; err != nil {
+		t.Fatalf("failed to create floor: %v", err)
+	}
+
+	roomsRepo := repository.NewRoomsRepository(db)
+	locationsRepo := repository.NewLocationsRepository(db)
+	rh := &RoomsHandler{RoomsRepository: roomsRepo, LocationsRepository: locationsRepo}
+	lh := &LocationsHandler{LocationsRepository: locationsRepo}
+	nh := &NotesHandler{NotesRepository: repository.NewNotesRepository(db), RoomsRepository: roomsRepo}
+	resh := &ReservationsHandler{
+		ReservationsRepository: repository.NewReservationsRepository(db),
+		RoomsRepository:        roomsRepo,
+		UserRepository:         repository.NewUserRepository(db),
+	}
+	gh := &GroupsHandler{
+		GroupsRepository:        repository.NewGroupsRepository(db),
+		RoomsRepository:         roomsRepo,
+		OrganizationsRepository: repository.NewOrganizationsRepository(db),
+		UserRepository:          repository.NewUserRepository(db),
+	}
+
+	requests := []struct {
+		name    string
+		handler http.HandlerFunc
+		pattern string
+		target  string
+	}{
+		{"room", rh.GetRoomByIDHandler, "/rooms/{room_id}", fmt.Sprintf("/rooms/%d", roomB.ID)},
+		{"note", nh.GetNoteByIDHandler, "/notes/{note_id}", fmt.Sprintf("/notes/%d", noteB.ID)},
+		{"reservation", resh.GetReservationByIDHandler, "/reservations/{reservation_id}", fmt.Sprintf("/reservations/%d", reservationB.ID)},
+		{"group", gh.GetGroupHandler, "/groups/{group_id}", fmt.Sprintf("/groups/%d", groupB.ID)},
+		{"room groups", gh.ListRoomGroupsHandler, "/rooms/{room_id}/groups", fmt.Sprintf("/rooms/%d/groups", roomB.ID)},
+		{"site", lh.GetSiteHandler, "/sites/{site_id}", fmt.Sprintf("/sites/%d", siteB.ID)},
+		{"building", lh.GetBuildingHandler, "/buildings/{building_id}", fmt.Sprintf("/buildings/%d", buildingB.ID)},
+		{"floor", lh.GetFloorHandler, "/floors/{floor_id}", fmt.Sprintf("/floors/%d", floorB.ID)},
+	}
+	for _, req := range requests {
+		if rec := serve(req.handler, http.MethodGet, req.pattern, req.target, claimsOf(alice), orgB.ID); rec.Code != http.StatusOK {
+			t.Errorf("%s from its own organization: status = %d, body = %s", req.name, rec.Code, rec.Body)
+		}
+		if rec := serve(req.handler, http.MethodGet, req.pattern, req.target, claimsOf(alice), orgA.ID); rec.Code != http.StatusNotFound {
+			t.Errorf("%s from another organization: status = %d, want 404", req.name, rec.Code)
+		}
+	}
+
+	rec := serve(rh.GetUserRoomsHandler, http.MethodGet, "/rooms/my-rooms", "/rooms/my-rooms", claimsOf(alice), orgA.ID)
+	var names []string
+	for _, room := range decode[[]struct{ Name string }](t, rec) {
+		names = append(names, room.Name)
+	}
+	if !slices.Equal(names, []string{roomA.Name}) {
+		t.Errorf("my rooms in %s = %v, want [%s]", orgA.Slug, names, roomA.Name)
+	}
+
+	// As listagens de locais só trazem os da organização da requisição.
+	locations := []struct {
+		name   string
+		serve  http.HandlerFunc
+		target string
+	}{
+		{"sites", lh.GetSitesHandler, "/sites"},
+		{"buildings", lh.GetBuildingsHandler, "/buildings"},
+		{"floors", lh.GetFloorsHandler, "/floors"},
+	}
+	for _, list := range locations {
+		rec := serve(list.serve, http.MethodGet, list.target, list.target, claimsOf(alice), orgA.ID)
+		if items := decode[[]struct{ ID uint }](t, rec); len(items) != 0 {
+			t.Errorf("%s of another organization are listed: %v", list.name, items)
+		}
+	}
+
+	// Uma sala não pode ser posta num andar de outra organização.
+	rec = serveJSON(rh.UpdateRoomAttributesHandler, http.MethodPut, "/rooms/{room_id}/attributes",
+		fmt.Sprintf("/rooms/%d/attributes", roomA.ID), map[string]any{"floor_id": floorB.ID}, claimsOf(alice), orgA.ID)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("room on another organization's floor: status = %d, want 400", rec.Code)
+	}
+
+	// As participações, diretas e por grupo, também ficam na organização.
+	inA := roomsRepo.WithContext(tenant.NewContext(context.Background(), orgA.ID))
+	if inA.IsUserInRoom(alice.ID, roomB.ID) {
+		t.Error("direct membership of another organization's room is visible")
+	}
+	if inA.IsUserInRoom(carol.ID, roomB.ID) {
+		t.Error("group membership of another organization's room is visible")
+	}
+	if ids, err := inA.GetUserRoomIDs(alice.ID); err != nil || !slices.Equal(ids, []uint{roomA.ID}) {
+		t.Errorf("room IDs = %v, %v, want [%d]", ids, err, roomA.ID)
+	}
+	if members, err := inA.GetMembers(roomB.ID); err != nil || len(members) != 0 {
+		t.Errorf("members of another organization's room = %d, %v, want none", len(members), err)
+	}
+	if count, err := inA.GetRoomMemberCount(roomB.ID); err != nil || count != 0 {
+		t.Errorf("member count of another organization's room = %d, %v, want 0", count, err)
+	}
+
+	inB := roomsRepo.WithContext(tenant.NewContext(context.Background(), orgB.ID))
+	if !inB.IsUserInRoom(carol.ID, roomB.ID) {
+		t.Error("group membership is not visible from its own organization")
+	}
+}
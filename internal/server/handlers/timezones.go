@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/server/dtos"
+	"log"
+	"time"
+)
+
+// preferredLocation retorna o fuso preferido do usuário, ou nil se ele não
+// escolheu um.
+func preferredLocation(users *repository.UserRepository, userID uint) *time.Location {
+	user, err := users.GetByID(userID)
+	if err != nil {
+		return nil
+	}
+	return user.Location()
+}
+
+// localZone escolhe o fuso da representação local das reservas da sala: o
+// preferido de quem consulta ou, se ele não tiver um, o da própria sala.
+func localZone(preferred *time.Location, room *models.Room) *time.Location {
+	if preferred != nil {
+		return preferred
+	}
+	return room.Location()
+}
+
+// localZones faz o mesmo que localZone para reservas de várias salas,
+// buscando os fusos das salas de uma vez.
+func localZones(users *repository.UserRepository, rooms *repository.RoomsRepository, userID uint, roomIDs []uint) func(roomID uint) *time.Location {
+	if preferred := preferredLocation(users, userID); preferred != nil {
+		return func(uint) *time.Location { return preferred }
+	}
+
+	zones, err := rooms.GetTimeZones(roomIDs)
+	if err != nil {
+		log.Printf("failed to load time zones of rooms %v: %v", roomIDs, err)
+	}
+	return func(roomID uint) *time.Location { return models.LoadLocation(zones[roomID]) }
+}
+
+// resolvePeriod converte os horários pedidos em instantes e valida o
+// período. Horários sem fuso são interpretados em timeZone, no fuso
+// preferido do usuário ou no fuso da sala, nesta ordem. Retorna a mensagem
+// de erro, ou "" se o período for válido.
+func resolvePeriod(timeZone string, preferred *time.Location, room *models.Room, start, end dtos.LocalTime) (time.Time, time.Time, string) {
+	loc := localZone(preferred, room)
+	if timeZone != "" {
+		if !models.IsValidTimeZone(timeZone) {
+			return time.Time{}, time.Time{}, "Invalid time_zone, expected an IANA name such as America/Sao_Paulo"
+		}
+		loc = models.LoadLocation(timeZone)
+	}
+
+	startTime, ok := start.Resolve(loc)
+	if !ok {
+		return time.Time{}, time.Time{}, "start_time does not exist in " + loc.String() + " because of a daylight saving time change"
+	}
+	endTime, ok := end.Resolve(loc)
+	if !ok {
+		return time.Time{}, time.Time{}, "end_time does not exist in " + loc.String() + " because of a daylight saving time change"
+	}
+
+	return startTime, endTime, validateReservationPeriod(startTime, endTime)
+}
+
+// roomIDsOf lista as salas das reservas, sem repetição.
+func roomIDsOf(reservations []models.Reservation) []uint {
+	seen := make(map[uint]bool)
+	var ids []uint
+	for _, reservation := range reservations {
+		if !seen[reservation.RoomID] {
+			seen[reservation.RoomID] = true
+			ids = append(ids, reservation.RoomID)
+		}
+	}
+	return ids
+}
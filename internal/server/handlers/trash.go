@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"api-go/internal/audit"
+	"api-go/internal/jobs"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/server/dtos"
+	"api-go/internal/server/middlewares"
+	"api-go/internal/storage"
+	"api-go/internal/utils"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type TrashHandler struct {
+	TrashRepository       *repository.TrashRepository
+	AttachmentsRepository *repository.AttachmentsRepository
+	BlobStore             storage.BlobStore
+	Outbox                *jobs.Outbox
+	Audit                 *audit.Log
+
+	// Retention é por quanto tempo os itens ficam na lixeira antes de serem
+	// removidos de vez pela tarefa agendada.
+	Retention time.Duration
+}
+
+func (th *TrashHandler) RegisterTrashRoutes(r chi.Router) {
+	r.Route("/trash", func(r chi.Router) {
+		r.Get("/", th.GetTrashHandler)
+		r.Post("/rooms/{room_id}/restore", th.RestoreRoomHandler)
+		r.Delete("/rooms/{room_id}", th.PurgeRoomHandler)
+		r.Post("/notes/{note_id}/restore", th.RestoreNoteHandler)
+		r.Delete("/notes/{note_id}", th.PurgeNoteHandler)
+	})
+}
+
+// GetTrashHandler lists the user's deleted rooms and notes
+//
+//	@Summary		Get trash
+//	@Description	List the rooms and notes owned by the current user that were deleted in the last days. Items are purged for good after the retention period.
+//	@Tags			trash
+//	@Accept			json
+//	@Produce		json
+//	@Param			days	query		int	false	"How many days back to look (defaults to the retention period)"
+//	@Success		200		{object}	dtos.TrashResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/trash [get]
+func (th *TrashHandler) GetTrashHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	window := th.Retention
+	if value := r.URL.Query().Get("days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days <= 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "days must be a positive number")
+			return
+		}
+		window = time.Duration(days) * 24 * time.Hour
+	}
+	since := time.Now().Add(-window)
+
+	rooms, err := th.TrashRepository.WithContext(r.Context()).GetDeletedRooms(claims.UserID, since)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get deleted rooms")
+		return
+	}
+
+	notes, err := th.TrashRepository.WithContext(r.Context()).GetDeletedNotes(claims.UserID, since)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get deleted notes")
+		return
+	}
+
+	response := dtos.TrashResponse{
+		Rooms: make([]dtos.TrashedRoomResponse, len(rooms)),
+		Notes: make([]dtos.TrashedNoteResponse, len(notes)),
+	}
+	for i, room := range rooms {
+		response.Rooms[i] = dtos.TrashedRoomResponse{
+			ID:        room.ID,
+			Name:      room.Name,
+			Subject:   room.Subject,
+			DeletedAt: room.DeletedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
+			PurgeAt:   room.DeletedAt.Time.Add(th.Retention).Format("2006-01-02T15:04:05Z07:00"),
+		}
+	}
+	for i, note := range notes {
+		response.Notes[i] = dtos.TrashedNoteResponse{
+			ID:          note.ID,
+			RoomID:      note.RoomID,
+			RoomName:    note.Room.Name,
+			RoomDeleted: note.Room.DeletedAt.Valid,
+			Title:       note.Title,
+			DeletedAt:   note.DeletedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
+			PurgeAt:     note.DeletedAt.Time.Add(th.Retention).Format("2006-01-02T15:04:05Z07:00"),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RestoreRoomHandler restores a deleted room
+//
+//	@Summary		Restore room
+//	@Description	Restore a deleted room together with the notes and memberships deleted with it (only by room creator)
+//	@Tags			trash
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int	true	"Room ID"
+//	@Success		200		{object}	map[string]string
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/trash/rooms/{room_id}/restore [post]
+func (th *TrashHandler) RestoreRoomHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	roomID, err := strconv.ParseUint(chi.URLParam(r, "room_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid room ID")
+		return
+	}
+
+	room, err := th.TrashRepository.WithContext(r.Context()).GetDeletedRoom(uint(roomID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return
+	}
+	if room == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found in trash")
+		return
+	}
+
+	if room.CreatedBy != claims.UserID {
+		utils.RespondWithError(w, http.StatusForbidden, "Only room creator can restore the room")
+		return
+	}
+
+	entry := audit.Entry{
+		Action:     audit.ActionRoomRestored,
+		TargetType: models.AuditTargetRoom,
+		TargetID:   room.ID,
+		RoomID:     room.ID,
+	}
+	err = audited(th.Outbox, th.Audit, r, entry, func(tx *gorm.DB) error {
+		return th.TrashRepository.WithContext(r.Context()).WithTx(tx).RestoreRoom(room)
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to restore room")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Room restored successfully"}`))
+}
+
+// PurgeRoomHandler permanently deletes a room
+//
+//	@Summary		Purge room
+//	@Description	Permanently delete a room in the trash with its notes, attachments, memberships and reservations (only by room creator)
+//	@Tags			trash
+//	@Accept			json
+//	@Produce		json
+//	@Param			room_id	path		int	true	"Room ID"
+//	@Success		200		{object}	map[string]string
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/trash/rooms/{room_id} [delete]
+func (th *TrashHandler) PurgeRoomHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	roomID, err := strconv.ParseUint(chi.URLParam(r, "room_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid room ID")
+		return
+	}
+
+	room, err := th.TrashRepository.WithContext(r.Context()).GetDeletedRoom(uint(roomID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return
+	}
+	if room == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found in trash")
+		return
+	}
+
+	if room.CreatedBy != claims.UserID {
+		utils.RespondWithError(w, http.StatusForbidden, "Only room creator can purge the room")
+		return
+	}
+
+	var keys []string
+	entry := audit.Entry{
+		Action:     audit.ActionRoomPurged,
+		TargetType: models.AuditTargetRoom,
+		TargetID:   room.ID,
+		RoomID:     room.ID,
+		Before:     toRoomResponse(*room),
+	}
+	err = audited(th.Outbox, th.Audit, r, entry, func(tx *gorm.DB) error {
+		var err error
+		keys, err = th.TrashRepository.WithContext(r.Context()).WithTx(tx).PurgeRoom(room.ID)
+		return err
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to purge room")
+		return
+	}
+	collectOrphanBlobs(r.Context(), th.AttachmentsRepository, th.BlobStore, keys)
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Room purged successfully"}`))
+}
+
+// RestoreNoteHandler restores a deleted note
+//
+//	@Summary		Restore note
+//	@Description	Restore a deleted note together with its attachments (only by note creator). The room must not be deleted or archived.
+//	@Tags			trash
+//	@Accept			json
+//	@Produce		json
+//	@Param			note_id	path		int	true	"Note ID"
+//	@Success		200		{object}	map[string]string
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/trash/notes/{note_id}/restore [post]
+func (th *TrashHandler) RestoreNoteHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	noteID, err := strconv.ParseUint(chi.URLParam(r, "note_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid note ID")
+		return
+	}
+
+	note, err := th.TrashRepository.WithContext(r.Context()).GetDeletedNote(uint(noteID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get note")
+		return
+	}
+	if note == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Note not found in trash")
+		return
+	}
+
+	if note.UserID != claims.UserID {
+		utils.RespondWithError(w, http.StatusForbidden, "Only note creator can restore the note")
+		return
+	}
+
+	if note.Room.DeletedAt.Valid {
+		utils.RespondWithError(w, http.StatusConflict, "The room of this note is deleted, restore the room first")
+		return
+	}
+
+	if !checkRoomWritable(w, &note.Room) {
+		return
+	}
+
+	entry := audit.Entry{
+		Action:     audit.ActionNoteRestored,
+		TargetType: models.AuditTargetNote,
+		TargetID:   note.ID,
+		RoomID:     note.RoomID,
+	}
+	err = audited(th.Outbox, th.Audit, r, entry, func(tx *gorm.DB) error {
+		return th.TrashRepository.WithContext(r.Context()).WithTx(tx).RestoreNote(note)
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to restore note")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Note restored successfully"}`))
+}
+
+// PurgeNoteHandler permanently deletes a note
+//
+//	@Summary		Purge note
+//	@Description	Permanently delete a note in the trash with its attachments (only by note creator)
+//	@Tags			trash
+//	@Accept			json
+//	@Produce		json
+//	@Param			note_id	path		int	true	"Note ID"
+//	@Success		200		{object}	map[string]string
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/trash/notes/{note_id} [delete]
+func (th *TrashHandler) PurgeNoteHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	noteID, err := strconv.ParseUint(chi.URLParam(r, "note_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid note ID")
+		return
+	}
+
+	note, err := th.TrashRepository.WithContext(r.Context()).GetDeletedNote(uint(noteID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get note")
+		return
+	}
+	if note == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Note not found in trash")
+		return
+	}
+
+	if note.UserID != claims.UserID {
+		utils.RespondWithError(w, http.StatusForbidden, "Only note creator can purge the note")
+		return
+	}
+
+	var keys []string
+	entry := audit.Entry{
+		Action:     audit.ActionNotePurged,
+		TargetType: models.AuditTargetNote,
+		TargetID:   note.ID,
+		RoomID:     note.RoomID,
+		Before:     noteAuditSnapshot(*note),
+	}
+	err = audited(th.Outbox, th.Audit, r, entry, func(tx *gorm.DB) error {
+		var err error
+		keys, err = th.TrashRepository.WithContext(r.Context()).WithTx(tx).PurgeNote(note.ID)
+		return err
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to purge note")
+		return
+	}
+	collectOrphanBlobs(r.Context(), th.AttachmentsRepository, th.BlobStore, keys)
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Note purged successfully"}`))
+}
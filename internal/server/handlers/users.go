@@ -1,6 +1,10 @@
 package handlers
 
 import (
+	"api-go/internal/accounts"
+	"api-go/internal/audit"
+	"api-go/internal/auth"
+	"api-go/internal/models"
 	"api-go/internal/repository"
 	"api-go/internal/server/dtos"
 	"api-go/internal/server/middlewares"
@@ -8,29 +12,45 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"gorm.io/gorm"
 )
 
+const (
+	defaultDirectoryLimit = 20
+	maxDirectoryLimit     = 50
+)
+
 type UserHandler struct {
 	UserRepository *repository.UserRepository
+	Accounts       *accounts.Service
+	Audit          *audit.Log
 }
 
 func (uh *UserHandler) RegisterUserRoutes(r chi.Router) {
 	r.Route("/users", func(r chi.Router) {
 		r.Post("/", uh.CreateUserHandler)
 		r.Get("/", uh.GetUserByIDHandler)
-		r.Get("/", uh.GetAllUsersHandler)
 		r.Put("/{user_id}", uh.UpdateUserHandler)
 		r.Delete("/{user_id}", uh.DeleteUserHandler)
+		r.Post("/{user_id}/restore", uh.RestoreUserHandler)
 		r.Get("/by-email", uh.GetUserByEmailHandler)
 	})
 }
 
+// RegisterDirectoryRoutes registra a busca no diretório, que fica entre as
+// rotas da organização: só aparecem os colegas das salas dela.
+func (uh *UserHandler) RegisterDirectoryRoutes(r chi.Router) {
+	r.Get("/users/directory", uh.GetDirectoryHandler)
+}
+
 // CreateUserHandler creates a new user
 //
 //	@Summary		Create user
@@ -69,9 +89,10 @@ func (uh *UserHandler) CreateUserHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	response := dtos.UserResponse{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
+		ID:       user.ID,
+		Name:     user.Name,
+		Email:    user.Email,
+		TimeZone: user.TimeZone,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -84,7 +105,7 @@ func (uh *UserHandler) CreateUserHandler(w http.ResponseWriter, r *http.Request)
 // GetUserByEmailHandler gets user by email
 //
 //	@Summary		Get user by email
-//	@Description	Retrieve user information by email address
+//	@Description	Retrieve a user by email address. Only users who share a room with the caller are found (admins find anyone), so the endpoint cannot be used to check which emails have accounts.
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
@@ -96,27 +117,33 @@ func (uh *UserHandler) CreateUserHandler(w http.ResponseWriter, r *http.Request)
 //	@Security		BearerAuth
 //	@Router			/users/by-email [get]
 func (uh *UserHandler) GetUserByEmailHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Usuário não autorizado")
+		return
+	}
+
 	email := r.URL.Query().Get("email")
 	if email == "" {
 		utils.RespondWithError(w, http.StatusBadRequest, "Parâmetro 'email' é obrigatório")
 		return
 	}
 
-	user, err := uh.UserRepository.GetByEmail(email)
+	user, err := uh.UserRepository.FindByEmail(email)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			utils.RespondWithError(w, http.StatusNotFound, "Usuário não encontrado")
-			return
-		}
-
 		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Falha ao buscar usuário: %v", err))
 		return
 	}
+	if !uh.canFind(w, claims, user) {
+		return
+	}
 
+	// Quem buscou já conhece o email, então ele não é mascarado.
 	response := dtos.UserResponse{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
+		ID:       user.ID,
+		Name:     user.Name,
+		Email:    user.Email,
+		TimeZone: user.TimeZone,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -129,7 +156,7 @@ func (uh *UserHandler) GetUserByEmailHandler(w http.ResponseWriter, r *http.Requ
 // GetUserByIDHandler gets user by ID
 //
 //	@Summary		Get user by ID
-//	@Description	Retrieve user information by user ID
+//	@Description	Retrieve a user by ID. Only users who share a room with the caller are found (admins find anyone). The email is masked unless it is the caller's own, the caller is an admin, or the caller administers a room the user is in.
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
@@ -141,6 +168,12 @@ func (uh *UserHandler) GetUserByEmailHandler(w http.ResponseWriter, r *http.Requ
 //	@Security		BearerAuth
 //	@Router			/users [get]
 func (uh *UserHandler) GetUserByIDHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Usuário não autorizado")
+		return
+	}
+
 	ID := r.URL.Query().Get("user_id")
 
 	if ID == "" {
@@ -155,22 +188,26 @@ func (uh *UserHandler) GetUserByIDHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	user, err := uh.UserRepository.GetByID(uint(userID))
-
+	user, err := uh.UserRepository.FindByID(uint(userID))
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			utils.RespondWithError(w, http.StatusNotFound, "Usuário não encontrado")
-			return
-		}
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Falha ao buscar usuário: %v", err))
+		return
+	}
+	if !uh.canFind(w, claims, user) {
+		return
+	}
 
+	managed, err := uh.UserRepository.GetManagedIDs(claims.UserID, []uint{user.ID})
+	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Falha ao buscar usuário: %v", err))
 		return
 	}
 
 	response := dtos.UserResponse{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
+		ID:       user.ID,
+		Name:     user.Name,
+		Email:    visibleEmail(claims, user.ID, user.Email, len(managed) > 0),
+		TimeZone: user.TimeZone,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -180,35 +217,92 @@ func (uh *UserHandler) GetUserByIDHandler(w http.ResponseWriter, r *http.Request
 	}
 }
 
-func (uh *UserHandler) GetAllUsersHandler(w http.ResponseWriter, r *http.Request) {
+// GetDirectoryHandler searches the user directory
+//
+//	@Summary		Search user directory
+//	@Description	Search by part of the name or by the full email (case-insensitive) the users who share at least one room of the current organization with the caller, in alphabetical order. Emails are masked unless the caller is an admin or administers a room the user is in.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			q		query		string	false	"Text searched in the name, or the full email"
+//	@Param			limit	query		int		false	"Maximum number of users (default 20, max 50)"
+//	@Success		200		{object}	dtos.UserListResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/users/directory [get]
+func (uh *UserHandler) GetDirectoryHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Usuário não autorizado")
+		return
+	}
 
-	users, err := uh.UserRepository.GetAll()
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			utils.RespondWithError(w, http.StatusNotFound, "Nenhum usuario")
+	limit := defaultDirectoryLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "Parâmetro 'limit' deve ser um número positivo")
 			return
 		}
-		utils.RespondWithError(w, http.StatusInternalServerError, "Erro interno")
+		limit = min(parsed, maxDirectoryLimit)
+	}
+
+	users, err := uh.UserRepository.WithContext(r.Context()).GetDirectory(claims.UserID, strings.TrimSpace(r.URL.Query().Get("q")), limit)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Falha ao buscar usuários: %v", err))
+		return
+	}
+
+	ids := make([]uint, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	managed, err := uh.UserRepository.WithContext(r.Context()).GetManagedIDs(claims.UserID, ids)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Falha ao buscar usuários: %v", err))
 		return
 	}
 
 	userList := make([]dtos.UserResponse, len(users))
 	for i, u := range users {
 		userList[i] = dtos.UserResponse{
-			ID:    u.ID,
-			Name:  u.Name,
-			Email: u.Email,
+			ID:       u.ID,
+			Name:     u.Name,
+			Email:    visibleEmail(claims, u.ID, u.Email, slices.Contains(managed, u.ID)),
+			TimeZone: u.TimeZone,
 		}
 	}
-	response := dtos.UserListResponse{
-		Users: userList,
-	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
+	if err := json.NewEncoder(w).Encode(dtos.UserListResponse{Users: userList}); err != nil {
 		http.Error(w, fmt.Sprintf("Falha ao codificar resposta: %v", err), http.StatusInternalServerError)
 	}
+}
 
+// canFind responde 404 e retorna false se o usuário não existir ou se quem
+// busca não puder encontrá-lo: só se encontram usuários com alguma sala em
+// comum, para que a busca não revele quem tem conta.
+func (uh *UserHandler) canFind(w http.ResponseWriter, claims *auth.Claims, user *models.User) bool {
+	if user == nil || user.AnonymizedAt != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Usuário não encontrado")
+		return false
+	}
+	if user.ID == claims.UserID || claims.IsAdmin() {
+		return true
+	}
+
+	shares, err := uh.UserRepository.SharesRoom(claims.UserID, user.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Falha ao buscar usuário: %v", err))
+		return false
+	}
+	if !shares {
+		utils.RespondWithError(w, http.StatusNotFound, "Usuário não encontrado")
+		return false
+	}
+	return true
 }
 
 // UpdateUserHandler updates user information
@@ -251,8 +345,8 @@ func (uh *UserHandler) UpdateUserHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if req.Email == "" && req.Name == "" && req.Password == "" {
-		utils.RespondWithError(w, http.StatusBadRequest, "Pelo menos um campo ('email', 'name', 'password') deve ser fornecido")
+	if req.Email == "" && req.Name == "" && req.Password == "" && req.TimeZone == nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Pelo menos um campo ('email', 'name', 'password', 'time_zone') deve ser fornecido")
 		return
 	}
 
@@ -300,6 +394,14 @@ func (uh *UserHandler) UpdateUserHandler(w http.ResponseWriter, r *http.Request)
 		user.Name = req.Name
 	}
 
+	if req.TimeZone != nil {
+		if *req.TimeZone != "" && !models.IsValidTimeZone(*req.TimeZone) {
+			utils.RespondWithError(w, http.StatusBadRequest, "Fuso horário inválido, use um nome IANA como 'America/Sao_Paulo'")
+			return
+		}
+		user.TimeZone = *req.TimeZone
+	}
+
 	if req.Password != "" {
 		hashedPassword, err := utils.HashPassword(req.Password)
 		if err != nil {
@@ -314,10 +416,22 @@ func (uh *UserHandler) UpdateUserHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if req.Password != "" {
+		err := uh.Audit.Record(nil, auditMeta(r), audit.Entry{
+			Action:     audit.ActionPasswordChanged,
+			TargetType: models.AuditTargetUser,
+			TargetID:   user.ID,
+		})
+		if err != nil {
+			log.Printf("Falha ao auditar a troca de senha do usuário %d: %v", user.ID, err)
+		}
+	}
+
 	response := dtos.UserResponse{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
+		ID:       user.ID,
+		Name:     user.Name,
+		Email:    user.Email,
+		TimeZone: user.TimeZone,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -327,55 +441,89 @@ func (uh *UserHandler) UpdateUserHandler(w http.ResponseWriter, r *http.Request)
 	}
 }
 
-// DeleteUserHandler deletes a user
+// DeleteUserHandler schedules the deletion of a user
 //
 //	@Summary		Delete user
-//	@Description	Delete user by user ID
+//	@Description	Schedule the deletion of the user's own account. Until the grace period ends the deletion can be undone with POST /users/{user_id}/restore. When it ends, rooms owned by the user pass to their longest-standing admin (or, with no admins, to the longest-standing member), upcoming reservations are cancelled, personal data is erased and the email is released for a new sign-up. content chooses what happens to the user's notes: anonymize keeps them under an anonymous author, delete moves them to the trash.
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
-//	@Param			user_id	path	int	true	"User ID"
-//	@Success		204		"No Content"
+//	@Param			user_id	path		int						true	"User ID"
+//	@Param			request	body		dtos.DeleteUserRequest	false	"What to do with the user's notes"
+//	@Success		202		{object}	dtos.AccountDeletionResponse
 //	@Failure		400		{object}	dtos.ErrorResponse
-//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
 //	@Failure		500		{object}	dtos.ErrorResponse
 //	@Security		BearerAuth
 //	@Router			/users/{user_id} [delete]
 func (uh *UserHandler) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
-	userID := chi.URLParam(r, "user_id")
-
-	if userID == "" {
-		utils.RespondWithError(w, http.StatusBadRequest, "Parâmetro 'user_id' é obrigatório")
+	userID, ok := authorizeAccountOwner(w, r)
+	if !ok {
 		return
 	}
 
-	claims, ok := middlewares.GetUserFromContext(r.Context())
-	if !ok {
-		utils.RespondWithError(w, http.StatusUnauthorized, "Usuário não autorizado")
+	var req dtos.DeleteUserRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			utils.RespondWithError(w, http.StatusBadRequest, "Falha ao decodificar o corpo da requisição")
+			return
+		}
+	}
+	if req.Content != "" && !models.IsValidDeletedContent(req.Content) {
+		utils.RespondWithError(w, http.StatusBadRequest, "Campo 'content' deve ser 'anonymize' ou 'delete'")
 		return
 	}
 
-	requestedUserID, err := strconv.ParseUint(userID, 10, 32)
+	scheduledAt, content, err := uh.Accounts.ScheduleDeletion(userID, req.Content)
 	if err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, "Invalid user_id")
+		if errors.Is(err, accounts.ErrDeletionScheduled) {
+			utils.RespondWithError(w, http.StatusConflict, "A exclusão da conta já está agendada")
+			return
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Falha ao agendar a exclusão do usuário: %v", err))
 		return
 	}
-	if claims.UserID != uint(requestedUserID) {
-		utils.RespondWithError(w, http.StatusForbidden, "Você não tem permissão para atualizar este usuário")
-		return
+
+	response := dtos.AccountDeletionResponse{
+		DeletionScheduledAt: scheduledAt.Format("2006-01-02T15:04:05Z07:00"),
+		Content:             content,
 	}
-	id, err := strconv.Atoi(userID)
-	if err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, "Parâmetro 'user_id' deve ser um número")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Falha ao codificar resposta JSON: %v", err)
+	}
+}
+
+// RestoreUserHandler undoes a scheduled deletion
+//
+//	@Summary		Restore user
+//	@Description	Cancel the scheduled deletion of the user's own account, while the grace period lasts
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			user_id	path	int	true	"User ID"
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/users/{user_id}/restore [post]
+func (uh *UserHandler) RestoreUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizeAccountOwner(w, r)
+	if !ok {
 		return
 	}
 
-	if err := uh.UserRepository.Delete(uint(id)); err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			utils.RespondWithError(w, http.StatusNotFound, "Usuário não encontrado")
+	if err := uh.Accounts.CancelDeletion(userID); err != nil {
+		if errors.Is(err, accounts.ErrNoDeletionScheduled) {
+			utils.RespondWithError(w, http.StatusConflict, "Nenhuma exclusão agendada para esta conta")
 			return
 		}
-		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Falha ao deletar usuário: %v", err))
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Falha ao desfazer a exclusão do usuário: %v", err))
 		return
 	}
 
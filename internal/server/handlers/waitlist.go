@@ -0,0 +1,404 @@
+package handlers
+
+import (
+	"api-go/internal/events"
+	"api-go/internal/jobs"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/server/dtos"
+	"api-go/internal/server/middlewares"
+	"api-go/internal/utils"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+var errOfferGone = errors.New("waitlist offer is no longer available")
+
+type WaitlistHandler struct {
+	WaitlistRepository     *repository.WaitlistRepository
+	ReservationsRepository *repository.ReservationsRepository
+	RoomsRepository        *repository.RoomsRepository
+	PoliciesRepository     *repository.PoliciesRepository
+	UserRepository         *repository.UserRepository
+	Outbox                 *jobs.Outbox
+}
+
+func (wh *WaitlistHandler) RegisterWaitlistRoutes(r chi.Router) {
+	r.Route("/waitlist", func(r chi.Router) {
+		r.Post("/", wh.JoinWaitlistHandler)
+		r.Get("/", wh.GetWaitlistHandler)
+		r.Delete("/{entry_id}", wh.LeaveWaitlistHandler)
+		r.Post("/{entry_id}/confirm", wh.ConfirmWaitlistHandler)
+	})
+}
+
+// JoinWaitlistHandler joins the waitlist of a room
+//
+//	@Summary		Join waitlist
+//	@Description	Wait for a seat in a fully booked room. When a seat frees up, the first entry that fits receives a tentative reservation that must be confirmed before the deadline.
+//	@Tags			waitlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dtos.JoinWaitlistRequest	true	"Room and period"
+//	@Success		201		{object}	dtos.WaitlistEntryResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		409		{object}	dtos.ErrorResponse
+//	@Failure		422		{object}	dtos.PolicyErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/waitlist [post]
+func (wh *WaitlistHandler) JoinWaitlistHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req dtos.JoinWaitlistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.RoomID == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "room_id is required")
+		return
+	}
+
+	room, err := wh.RoomsRepository.WithContext(r.Context()).FindByID(req.RoomID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return
+	}
+	if room == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found")
+		return
+	}
+
+	preferred := preferredLocation(wh.UserRepository, claims.UserID)
+	startTime, endTime, msg := resolvePeriod(req.TimeZone, preferred, room, req.StartTime, req.EndTime)
+	if msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	if !wh.RoomsRepository.WithContext(r.Context()).IsUserInRoom(claims.UserID, room.ID) {
+		utils.RespondWithError(w, http.StatusForbidden, "User is not a member of this room")
+		return
+	}
+
+	if !checkRoomWritable(w, room) {
+		return
+	}
+
+	// A reserva oferecida depois precisa respeitar a política da sala.
+	if !checkBookingPolicy(w, wh.PoliciesRepository.WithContext(r.Context()), wh.ReservationsRepository.WithContext(r.Context()), room, claims.UserID, 0, startTime, endTime) {
+		return
+	}
+
+	// Só faz sentido esperar por um período lotado.
+	err = wh.ReservationsRepository.WithContext(r.Context()).CheckAvailability(claims.UserID, room.ID, startTime, endTime)
+	switch {
+	case err == nil:
+		utils.RespondWithError(w, http.StatusConflict, "Room has free seats for this period, book it directly")
+		return
+	case errors.Is(err, repository.ErrReservationOverlap):
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+		return
+	case !errors.Is(err, repository.ErrReservationConflict):
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to check availability")
+		return
+	}
+
+	waiting, err := wh.WaitlistRepository.WithContext(r.Context()).HasActive(claims.UserID, room.ID, startTime, endTime)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to check waitlist")
+		return
+	}
+	if waiting {
+		utils.RespondWithError(w, http.StatusConflict, "User is already on the waitlist for this period")
+		return
+	}
+
+	entry := models.WaitlistEntry{
+		UserID:    claims.UserID,
+		RoomID:    room.ID,
+		StartTime: startTime,
+		EndTime:   endTime,
+		Status:    models.WaitlistWaiting,
+	}
+	if err := wh.WaitlistRepository.WithContext(r.Context()).Create(&entry); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to join waitlist")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toWaitlistEntryResponse(entry, localZone(preferred, room)))
+}
+
+// GetWaitlistHandler lists the user's waitlist entries
+//
+//	@Summary		Get my waitlist entries
+//	@Description	List the waitlist entries of the authenticated user
+//	@Tags			waitlist
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		dtos.WaitlistEntryResponse
+//	@Failure		500	{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/waitlist [get]
+func (wh *WaitlistHandler) GetWaitlistHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	entries, err := wh.WaitlistRepository.WithContext(r.Context()).GetByUserID(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get waitlist")
+		return
+	}
+
+	var roomIDs []uint
+	for _, entry := range entries {
+		roomIDs = append(roomIDs, entry.RoomID)
+	}
+	zoneOf := localZones(wh.UserRepository, wh.RoomsRepository.WithContext(r.Context()), claims.UserID, roomIDs)
+
+	response := make([]dtos.WaitlistEntryResponse, len(entries))
+	for i, entry := range entries {
+		response[i] = toWaitlistEntryResponse(entry, zoneOf(entry.RoomID))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// LeaveWaitlistHandler leaves the waitlist
+//
+//	@Summary		Leave waitlist
+//	@Description	Remove a waitlist entry. A pending offer is declined and passed to the next entry.
+//	@Tags			waitlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			entry_id	path		int	true	"Waitlist entry ID"
+//	@Success		200			{object}	map[string]string
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		409			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/waitlist/{entry_id} [delete]
+func (wh *WaitlistHandler) LeaveWaitlistHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	entry, ok := wh.loadEntry(w, r, claims.UserID)
+	if !ok {
+		return
+	}
+
+	if entry.Status != models.WaitlistWaiting && entry.Status != models.WaitlistOffered {
+		utils.RespondWithError(w, http.StatusConflict, "Waitlist entry is no longer active")
+		return
+	}
+
+	room, err := wh.RoomsRepository.WithContext(r.Context()).FindByID(entry.RoomID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return
+	}
+
+	err = wh.Outbox.Transaction(func(tx *gorm.DB) error {
+		left, err := wh.WaitlistRepository.WithContext(r.Context()).WithTx(tx).SetStatus(entry.ID, models.WaitlistCancelled, models.WaitlistWaiting, models.WaitlistOffered)
+		if err != nil {
+			return err
+		}
+		if !left {
+			return errOfferGone
+		}
+		if entry.Status != models.WaitlistOffered || entry.ReservationID == nil {
+			return nil
+		}
+
+		// Recusar a oferta libera a reserva provisória para a próxima entrada.
+		reservation, err := wh.ReservationsRepository.WithContext(r.Context()).WithTx(tx).GetByID(*entry.ReservationID)
+		if err != nil || reservation == nil || reservation.Status != models.ReservationHeld {
+			return err
+		}
+		if err := wh.ReservationsRepository.WithContext(r.Context()).WithTx(tx).Delete(reservation.ID); err != nil {
+			return err
+		}
+		if room == nil {
+			return nil
+		}
+		return wh.publish(tx, events.ReservationCancelled, claims.UserID, claims.Name, room, reservation)
+	})
+	if errors.Is(err, errOfferGone) {
+		utils.RespondWithError(w, http.StatusConflict, "Waitlist entry is no longer active")
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to leave waitlist")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Left waitlist successfully"}`))
+}
+
+// ConfirmWaitlistHandler confirms a waitlist offer
+//
+//	@Summary		Confirm waitlist offer
+//	@Description	Turn the tentative reservation offered to a waitlist entry into a regular one. In rooms that require approval it becomes pending.
+//	@Tags			waitlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			entry_id	path		int	true	"Waitlist entry ID"
+//	@Success		200			{object}	dtos.ReservationResponse
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		409			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/waitlist/{entry_id}/confirm [post]
+func (wh *WaitlistHandler) ConfirmWaitlistHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	entry, ok := wh.loadEntry(w, r, claims.UserID)
+	if !ok {
+		return
+	}
+
+	if entry.Status != models.WaitlistOffered || entry.ReservationID == nil ||
+		(entry.OfferExpiresAt != nil && time.Now().After(*entry.OfferExpiresAt)) {
+		utils.RespondWithError(w, http.StatusConflict, "There is no open offer for this waitlist entry")
+		return
+	}
+
+	reservation, err := wh.ReservationsRepository.WithContext(r.Context()).GetByID(*entry.ReservationID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get reservation")
+		return
+	}
+	if reservation == nil {
+		utils.RespondWithError(w, http.StatusConflict, "There is no open offer for this waitlist entry")
+		return
+	}
+
+	room, err := wh.RoomsRepository.WithContext(r.Context()).FindByID(entry.RoomID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+		return
+	}
+	if room == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Room not found")
+		return
+	}
+
+	status := initialStatus(wh.RoomsRepository.WithContext(r.Context()), claims.UserID, room)
+
+	err = wh.Outbox.Transaction(func(tx *gorm.DB) error {
+		accepted, err := wh.WaitlistRepository.WithContext(r.Context()).WithTx(tx).SetStatus(entry.ID, models.WaitlistAccepted, models.WaitlistOffered)
+		if err != nil {
+			return err
+		}
+		confirmed, err := wh.ReservationsRepository.WithContext(r.Context()).WithTx(tx).SetStatus(reservation.ID, models.ReservationHeld, status)
+		if err != nil {
+			return err
+		}
+		if !accepted || !confirmed {
+			return errOfferGone
+		}
+
+		reservation.Status = status
+		return wh.publish(tx, events.ReservationUpdated, claims.UserID, claims.Name, room, reservation)
+	})
+	if errors.Is(err, errOfferGone) {
+		utils.RespondWithError(w, http.StatusConflict, "There is no open offer for this waitlist entry")
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to confirm reservation")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toReservationResponse(*reservation, localZone(preferredLocation(wh.UserRepository, claims.UserID), room)))
+}
+
+// loadEntry busca a entrada da URL, que precisa ser do usuário.
+func (wh *WaitlistHandler) loadEntry(w http.ResponseWriter, r *http.Request, userID uint) (*models.WaitlistEntry, bool) {
+	entryID, err := strconv.ParseUint(chi.URLParam(r, "entry_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid waitlist entry ID")
+		return nil, false
+	}
+
+	entry, err := wh.WaitlistRepository.WithContext(r.Context()).GetByID(uint(entryID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get waitlist entry")
+		return nil, false
+	}
+	if entry == nil || entry.UserID != userID {
+		utils.RespondWithError(w, http.StatusNotFound, "Waitlist entry not found")
+		return nil, false
+	}
+	return entry, true
+}
+
+func (wh *WaitlistHandler) publish(tx *gorm.DB, eventType string, actorID uint, actorName string, room *models.Room, reservation *models.Reservation) error {
+	return wh.Outbox.Publish(tx, events.Event{
+		Type:          eventType,
+		ActorID:       actorID,
+		RoomID:        room.ID,
+		ReservationID: reservation.ID,
+		UserID:        reservation.UserID,
+		Data: map[string]any{
+			"actor_name": actorName,
+			"room_name":  room.Name,
+			"time_zone":  room.Location().String(),
+			"start_time": reservation.StartTime.Format(time.RFC3339),
+			"end_time":   reservation.EndTime.Format(time.RFC3339),
+			"status":     reservation.Status,
+		},
+	})
+}
+
+func toWaitlistEntryResponse(entry models.WaitlistEntry, loc *time.Location) dtos.WaitlistEntryResponse {
+	response := dtos.WaitlistEntryResponse{
+		ID:            entry.ID,
+		UserID:        entry.UserID,
+		RoomID:        entry.RoomID,
+		StartTime:     entry.StartTime.UTC().Format("2006-01-02T15:04:05Z07:00"),
+		EndTime:       entry.EndTime.UTC().Format("2006-01-02T15:04:05Z07:00"),
+		TimeZone:      loc.String(),
+		LocalStart:    entry.StartTime.In(loc).Format("2006-01-02T15:04:05Z07:00"),
+		LocalEnd:      entry.EndTime.In(loc).Format("2006-01-02T15:04:05Z07:00"),
+		Status:        entry.Status,
+		ReservationID: entry.ReservationID,
+		CreatedAt:     entry.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if entry.OfferExpiresAt != nil {
+		offerExpiresAt := entry.OfferExpiresAt.Format("2006-01-02T15:04:05Z07:00")
+		response.OfferExpiresAt = &offerExpiresAt
+	}
+	return response
+}
@@ -0,0 +1,452 @@
+package handlers
+
+import (
+	"api-go/internal/events"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/server/dtos"
+	"api-go/internal/server/middlewares"
+	"api-go/internal/utils"
+	"api-go/internal/webhooks"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 200
+)
+
+type WebhooksHandler struct {
+	WebhooksRepository *repository.WebhooksRepository
+	RoomsRepository    *repository.RoomsRepository
+	Dispatcher         *webhooks.Dispatcher
+}
+
+func (wh *WebhooksHandler) RegisterWebhooksRoutes(r chi.Router) {
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Post("/", wh.CreateWebhookHandler)
+		r.Get("/", wh.GetWebhooksHandler)
+		r.Get("/{webhook_id}", wh.GetWebhookHandler)
+		r.Put("/{webhook_id}", wh.UpdateWebhookHandler)
+		r.Delete("/{webhook_id}", wh.DeleteWebhookHandler)
+		r.Get("/{webhook_id}/deliveries", wh.GetDeliveriesHandler)
+		r.Post("/{webhook_id}/deliveries/{delivery_id}/redeliver", wh.RedeliverHandler)
+	})
+}
+
+// CreateWebhookHandler creates a webhook subscription
+//
+//	@Summary		Create webhook
+//	@Description	Subscribe a URL to room events. With room_id only that room's events are sent (room admins only);
+//	@Description	without it, the events of every room the user belongs to. Deliveries are POSTed as JSON and signed:
+//	@Description	X-Webhook-Signature is "sha256=" followed by the hex HMAC-SHA256 of "<X-Webhook-Timestamp>.<body>"
+//	@Description	using the webhook secret. The secret is only returned here. URLs that resolve to loopback, link-local
+//	@Description	or private addresses are rejected.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		dtos.CreateWebhookRequest	true	"Webhook details"
+//	@Success		201		{object}	dtos.WebhookResponse
+//	@Failure		400		{object}	dtos.ErrorResponse
+//	@Failure		403		{object}	dtos.ErrorResponse
+//	@Failure		404		{object}	dtos.ErrorResponse
+//	@Failure		500		{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/webhooks [post]
+func (wh *WebhooksHandler) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req dtos.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if message := validateWebhook(r.Context(), req.URL, req.Events); message != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	if req.RoomID != nil {
+		room, err := wh.RoomsRepository.WithContext(r.Context()).FindByID(*req.RoomID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get room")
+			return
+		}
+		if room == nil {
+			utils.RespondWithError(w, http.StatusNotFound, "Room not found")
+			return
+		}
+		if !wh.RoomsRepository.WithContext(r.Context()).IsRoomAdmin(claims.UserID, room) {
+			utils.RespondWithError(w, http.StatusForbidden, "Only room creator or admins can add room webhooks")
+			return
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		generated, err := webhooks.NewSecret()
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to generate secret")
+			return
+		}
+		secret = generated
+	}
+
+	webhook := models.Webhook{
+		UserID: claims.UserID,
+		RoomID: req.RoomID,
+		URL:    req.URL,
+		Secret: secret,
+		Events: req.Events,
+		Active: true,
+	}
+	if err := wh.WebhooksRepository.WithContext(r.Context()).Create(&webhook); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create webhook")
+		return
+	}
+
+	response := toWebhookResponse(webhook)
+	response.Secret = webhook.Secret
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetWebhooksHandler lists the current user's webhooks
+//
+//	@Summary		List webhooks
+//	@Description	List the webhooks created by the current user
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		dtos.WebhookResponse
+//	@Failure		500	{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/webhooks [get]
+func (wh *WebhooksHandler) GetWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	list, err := wh.WebhooksRepository.WithContext(r.Context()).GetByUserID(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get webhooks")
+		return
+	}
+
+	response := make([]dtos.WebhookResponse, len(list))
+	for i, webhook := range list {
+		response[i] = toWebhookResponse(webhook)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetWebhookHandler gets a webhook
+//
+//	@Summary		Get webhook
+//	@Description	Get one of the current user's webhooks
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			webhook_id	path		int	true	"Webhook ID"
+//	@Success		200			{object}	dtos.WebhookResponse
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/webhooks/{webhook_id} [get]
+func (wh *WebhooksHandler) GetWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := wh.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toWebhookResponse(*webhook))
+}
+
+// UpdateWebhookHandler updates a webhook
+//
+//	@Summary		Update webhook
+//	@Description	Change the URL, the event filter or enable/disable one of the current user's webhooks
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			webhook_id	path		int							true	"Webhook ID"
+//	@Param			request		body		dtos.UpdateWebhookRequest	true	"Webhook details"
+//	@Success		200			{object}	dtos.WebhookResponse
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/webhooks/{webhook_id} [put]
+func (wh *WebhooksHandler) UpdateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := wh.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.UpdateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if message := validateWebhook(r.Context(), req.URL, req.Events); message != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	webhook.URL = req.URL
+	webhook.Events = req.Events
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+
+	if err := wh.WebhooksRepository.WithContext(r.Context()).Update(webhook); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update webhook")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toWebhookResponse(*webhook))
+}
+
+// DeleteWebhookHandler deletes a webhook
+//
+//	@Summary		Delete webhook
+//	@Description	Delete one of the current user's webhooks and its delivery log
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			webhook_id	path		int	true	"Webhook ID"
+//	@Success		200			{object}	map[string]string
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/webhooks/{webhook_id} [delete]
+func (wh *WebhooksHandler) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := wh.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	if err := wh.WebhooksRepository.WithContext(r.Context()).Delete(webhook.ID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Webhook deleted successfully"}`))
+}
+
+// GetDeliveriesHandler lists the delivery log of a webhook
+//
+//	@Summary		List webhook deliveries
+//	@Description	List the deliveries of one of the current user's webhooks, newest first
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			webhook_id	path		int		true	"Webhook ID"
+//	@Param			status		query		string	false	"Filter by status"	Enums(pending, succeeded, dead)
+//	@Param			limit		query		int		false	"Maximum number of deliveries (default 50, max 200)"
+//	@Success		200			{array}		dtos.WebhookDeliveryResponse
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/webhooks/{webhook_id}/deliveries [get]
+func (wh *WebhooksHandler) GetDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := wh.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliverySucceeded, models.WebhookDeliveryDead:
+	default:
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid status")
+		return
+	}
+
+	limit := defaultDeliveriesLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = min(parsed, maxDeliveriesLimit)
+	}
+
+	deliveries, err := wh.WebhooksRepository.WithContext(r.Context()).GetDeliveries(webhook.ID, status, limit)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get deliveries")
+		return
+	}
+
+	response := make([]dtos.WebhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		response[i] = toWebhookDeliveryResponse(delivery)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RedeliverHandler sends a delivery again
+//
+//	@Summary		Redeliver webhook event
+//	@Description	Queue a new delivery of the same event, with the same event ID and payload. The original delivery stays in the log
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			webhook_id	path		int	true	"Webhook ID"
+//	@Param			delivery_id	path		int	true	"Delivery ID"
+//	@Success		202			{object}	dtos.WebhookDeliveryResponse
+//	@Failure		400			{object}	dtos.ErrorResponse
+//	@Failure		404			{object}	dtos.ErrorResponse
+//	@Failure		500			{object}	dtos.ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/webhooks/{webhook_id}/deliveries/{delivery_id}/redeliver [post]
+func (wh *WebhooksHandler) RedeliverHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := wh.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := strconv.ParseUint(chi.URLParam(r, "delivery_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid delivery ID")
+		return
+	}
+
+	original, err := wh.WebhooksRepository.WithContext(r.Context()).GetDelivery(uint(deliveryID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get delivery")
+		return
+	}
+	if original == nil || original.WebhookID != webhook.ID {
+		utils.RespondWithError(w, http.StatusNotFound, "Delivery not found")
+		return
+	}
+
+	delivery, err := wh.Dispatcher.Redeliver(original)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to redeliver")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(toWebhookDeliveryResponse(*delivery))
+}
+
+// loadWebhook carrega o webhook da URL, respondendo com o erro adequado se
+// ele não existir ou não pertencer ao usuário.
+func (wh *WebhooksHandler) loadWebhook(w http.ResponseWriter, r *http.Request) (*models.Webhook, bool) {
+	claims, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return nil, false
+	}
+
+	webhookID, err := strconv.ParseUint(chi.URLParam(r, "webhook_id"), 10, 32)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid webhook ID")
+		return nil, false
+	}
+
+	webhook, err := wh.WebhooksRepository.WithContext(r.Context()).GetByID(uint(webhookID))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get webhook")
+		return nil, false
+	}
+	// Webhooks de outros usuários são tratados como inexistentes.
+	if webhook == nil || webhook.UserID != claims.UserID {
+		utils.RespondWithError(w, http.StatusNotFound, "Webhook not found")
+		return nil, false
+	}
+
+	return webhook, true
+}
+
+func validateWebhook(ctx context.Context, rawURL string, eventTypes []string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return "URL must be an absolute http or https URL"
+	}
+	if err := webhooks.CheckHost(ctx, parsed.Hostname()); err != nil {
+		if errors.Is(err, webhooks.ErrForbiddenAddress) {
+			return "URL must not point to a loopback, link-local or private address"
+		}
+		return "URL host could not be resolved"
+	}
+
+	for _, eventType := range eventTypes {
+		if !events.IsRoomEvent(eventType) {
+			return "Unknown event type: " + eventType
+		}
+	}
+	return ""
+}
+
+func toWebhookResponse(webhook models.Webhook) dtos.WebhookResponse {
+	eventTypes := webhook.Events
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	return dtos.WebhookResponse{
+		ID:        webhook.ID,
+		UserID:    webhook.UserID,
+		RoomID:    webhook.RoomID,
+		URL:       webhook.URL,
+		Events:    eventTypes,
+		Active:    webhook.Active,
+		CreatedAt: webhook.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: webhook.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+func toWebhookDeliveryResponse(delivery models.WebhookDelivery) dtos.WebhookDeliveryResponse {
+	response := dtos.WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if delivery.NextAttemptAt != nil {
+		nextAttemptAt := delivery.NextAttemptAt.Format("2006-01-02T15:04:05Z07:00")
+		response.NextAttemptAt = &nextAttemptAt
+	}
+	if delivery.LastAttemptAt != nil {
+		lastAttemptAt := delivery.LastAttemptAt.Format("2006-01-02T15:04:05Z07:00")
+		response.LastAttemptAt = &lastAttemptAt
+	}
+	return response
+}
@@ -0,0 +1,92 @@
+package middlewares
+
+import (
+	"api-go/internal/auth"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/utils"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// AccountError é o motivo pelo qual a conta do token não pode mais usar a
+// API, com o status da resposta.
+type AccountError struct {
+	Status  int
+	Message string
+}
+
+func (e *AccountError) Error() string {
+	return e.Message
+}
+
+// CheckAccount confere que a conta do token ainda pode usar a API. O JWT
+// continua válido até expirar, então é aqui que valem a suspensão, a
+// exclusão da conta, o encerramento das sessões (troca de senha forçada) e
+// a perda do papel de administrador. Retorna um *AccountError quando a
+// conta foi barrada.
+func CheckAccount(users *repository.UserRepository, claims *auth.Claims) error {
+	user, err := users.FindByID(claims.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.AnonymizedAt != nil {
+		return &AccountError{Status: http.StatusUnauthorized, Message: "Account no longer exists"}
+	}
+	if user.SuspendedAt != nil {
+		return &AccountError{Status: http.StatusForbidden, Message: "Account suspended"}
+	}
+
+	// O iat do JWT tem precisão de segundos.
+	if user.TokensValidAfter != nil &&
+		(claims.IssuedAt == nil || claims.IssuedAt.Time.Before(user.TokensValidAfter.Truncate(time.Second))) {
+		return &AccountError{Status: http.StatusUnauthorized, Message: "Session expired, sign in again"}
+	}
+	if claims.IsAdmin() && user.Role != models.UserRoleAdmin {
+		return &AccountError{Status: http.StatusUnauthorized, Message: "Session expired, sign in again"}
+	}
+	return nil
+}
+
+// ActiveUser aplica o CheckAccount a cada requisição. Deve vir depois de
+// AuthMiddleware ou StreamAuthMiddleware.
+func ActiveUser(users *repository.UserRepository) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetUserFromContext(r.Context())
+			if !ok {
+				utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+				return
+			}
+
+			if err := CheckAccount(users, claims); err != nil {
+				var accountErr *AccountError
+				if errors.As(err, &accountErr) {
+					utils.RespondWithError(w, accountErr.Status, accountErr.Message)
+					return
+				}
+				utils.RespondWithError(w, http.StatusInternalServerError, "Failed to load user")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireAdmin restringe a rota aos administradores globais.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := GetUserFromContext(r.Context())
+		if !ok {
+			utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+			return
+		}
+		if !claims.IsAdmin() {
+			utils.RespondWithError(w, http.StatusForbidden, "Admin access required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
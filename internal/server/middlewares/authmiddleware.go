@@ -30,34 +30,72 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		tokenString := headerParts[1]
-		claims := &auth.Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			// Verifica se o método de assinatura é HMAC
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return jwtSecret, nil
-		})
-
+		claims, err := ParseToken(headerParts[1])
 		if err != nil {
 			utils.RespondWithError(w, http.StatusUnauthorized, "Invalid token: "+err.Error())
 			return
 		}
 
-		if !token.Valid {
-			utils.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
-			return
-		}
-
 		// Adiciona os claims ao contexto da requisição para uso posterior
-		ctx := context.WithValue(r.Context(), userContextKey, claims)
+		ctx := NewContext(r.Context(), claims)
 
 		// Chama o próximo handler com o novo contexto
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// StreamAuthMiddleware autentica conexões WebSocket e EventSource. Navegadores
+// não permitem definir o header Authorization nelas, então o token também é
+// aceito no parâmetro de query "token".
+func StreamAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			AuthMiddleware(next).ServeHTTP(w, r)
+			return
+		}
+
+		tokenString := r.URL.Query().Get("token")
+		if tokenString == "" {
+			utils.RespondWithError(w, http.StatusUnauthorized, "Token not found")
+			return
+		}
+
+		claims, err := ParseToken(tokenString)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusUnauthorized, "Invalid token: "+err.Error())
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), claims)))
+	})
+}
+
+// ParseToken valida o JWT e retorna os claims do usuário.
+func ParseToken(tokenString string) (*auth.Claims, error) {
+	claims := &auth.Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		// Verifica se o método de assinatura é HMAC
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return jwtSecret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
+	return claims, nil
+}
+
+// NewContext devolve uma cópia de ctx com os claims do usuário autenticado.
+func NewContext(ctx context.Context, claims *auth.Claims) context.Context {
+	return context.WithValue(ctx, userContextKey, claims)
+}
+
 func GetUserFromContext(ctx context.Context) (*auth.Claims, bool) {
 	claims, ok := ctx.Value(userContextKey).(*auth.Claims)
 	return claims, ok
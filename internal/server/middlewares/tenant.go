@@ -0,0 +1,119 @@
+package middlewares
+
+import (
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"api-go/internal/tenant"
+	"api-go/internal/utils"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// OrganizationHeader indica a organização da requisição, pelo slug ou pelo
+// id.
+const OrganizationHeader = "X-Organization"
+
+// Tenant resolve a organização da requisição e a põe no contexto, onde ela
+// restringe as consultas ao banco (ver o pacote tenant). A organização vem,
+// nesta ordem, do header X-Organization, do subdomínio de baseDomain (vazio
+// desliga), da organização ativa do token e, por fim, da participação mais
+// antiga do usuário. O usuário precisa participar dela; os administradores
+// globais entram em qualquer uma. Deve vir depois de AuthMiddleware.
+func Tenant(orgs *repository.OrganizationsRepository, baseDomain string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetUserFromContext(r.Context())
+			if !ok {
+				utils.RespondWithError(w, http.StatusUnauthorized, "User not found in context")
+				return
+			}
+
+			// Indicada explicitamente: precisa existir e o usuário precisa
+			// participar dela.
+			if ref := requestedOrganization(r, baseDomain); ref != "" {
+				org, err := findOrganization(orgs, ref)
+				if err != nil {
+					utils.RespondWithError(w, http.StatusInternalServerError, "Failed to load organization")
+					return
+				}
+				if org == nil {
+					utils.RespondWithError(w, http.StatusNotFound, "Organization not found")
+					return
+				}
+				if !claims.IsAdmin() {
+					member, err := orgs.GetMembership(org.ID, claims.UserID)
+					if err != nil {
+						utils.RespondWithError(w, http.StatusInternalServerError, "Failed to load organization")
+						return
+					}
+					if member == nil {
+						utils.RespondWithError(w, http.StatusForbidden, "You are not a member of this organization")
+						return
+					}
+				}
+				next.ServeHTTP(w, r.WithContext(tenant.NewContext(r.Context(), org.ID)))
+				return
+			}
+
+			// A do token vale enquanto o usuário participar dela; depois,
+			// a mais antiga dele.
+			var member *models.OrganizationMember
+			var err error
+			if claims.OrganizationID != 0 {
+				member, err = orgs.GetMembership(claims.OrganizationID, claims.UserID)
+				if err == nil && member == nil && claims.IsAdmin() {
+					if org, findErr := orgs.FindByID(claims.OrganizationID); findErr != nil {
+						err = findErr
+					} else if org != nil {
+						next.ServeHTTP(w, r.WithContext(tenant.NewContext(r.Context(), org.ID)))
+						return
+					}
+				}
+			}
+			if err == nil && member == nil {
+				member, err = orgs.GetDefaultMembership(claims.UserID)
+			}
+			if err != nil {
+				utils.RespondWithError(w, http.StatusInternalServerError, "Failed to load organization")
+				return
+			}
+			if member == nil {
+				utils.RespondWithError(w, http.StatusForbidden, "You are not a member of any organization")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(tenant.NewContext(r.Context(), member.OrganizationID)))
+		})
+	}
+}
+
+// requestedOrganization retorna a organização indicada pelo header ou pelo
+// subdomínio, ou "" se nenhum a indicar.
+func requestedOrganization(r *http.Request, baseDomain string) string {
+	if ref := strings.TrimSpace(r.Header.Get(OrganizationHeader)); ref != "" {
+		return ref
+	}
+	if baseDomain == "" {
+		return ""
+	}
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	suffix := "." + strings.ToLower(strings.TrimPrefix(baseDomain, "."))
+	if !strings.HasSuffix(host, suffix) {
+		return ""
+	}
+	return strings.TrimSuffix(host, suffix)
+}
+
+// findOrganization busca a organização pelo id, se ref for numérico, ou
+// pelo slug.
+func findOrganization(orgs *repository.OrganizationsRepository, ref string) (*models.Organization, error) {
+	if id, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		return orgs.FindByID(uint(id))
+	}
+	return orgs.FindBySlug(strings.ToLower(ref))
+}
@@ -1,9 +1,19 @@
 package server
 
 import (
+	"api-go/internal/accounts"
+	"api-go/internal/audit"
+	"api-go/internal/models"
+	"api-go/internal/notifications"
+	"api-go/internal/realtime"
 	"api-go/internal/repository"
+	"api-go/internal/scheduler"
 	"api-go/internal/server/handlers"
+	"api-go/internal/waitlist"
+	"api-go/internal/webhooks"
+	"log"
 	"net/http"
+	"time"
 
 	"api-go/internal/server/middlewares"
 
@@ -17,13 +27,24 @@ import (
 
 func (s *Server) RegisterRoutes() http.Handler {
 	r := chi.NewRouter()
+	r.Use(middleware.RequestID) // Identificador da requisição, gravado na trilha de auditoria
+	if envString("TRUST_PROXY_HEADERS", "false") == "true" {
+		// Atrás de um proxy, o IP do cliente vem de X-Forwarded-For/X-Real-IP.
+		r.Use(middleware.RealIP)
+	}
 	r.Use(middleware.Logger) // Middleware para logar as requisições
 
-	// Configuração do CORS
+	// Configuração do CORS. ALLOWED_ORIGINS também restringe o handshake do
+	// WebSocket, que não passa pelo CORS.
+	allowedOrigins := envList("ALLOWED_ORIGINS")
+	corsOrigins := allowedOrigins
+	if len(corsOrigins) == 0 {
+		corsOrigins = []string{"https://*", "http://*"}
+	}
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://*", "http://*"},
+		AllowedOrigins:   corsOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", middlewares.OrganizationHeader},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -39,33 +60,293 @@ func (s *Server) RegisterRoutes() http.Handler {
 	userRepo := repository.NewUserRepository(s.db.GetDB())
 	roomsRepo := repository.NewRoomsRepository(s.db.GetDB())
 	notesRepo := repository.NewNotesRepository(s.db.GetDB())
+	attachmentsRepo := repository.NewAttachmentsRepository(s.db.GetDB())
+	mentionsRepo := repository.NewMentionsRepository(s.db.GetDB())
+	notificationsRepo := repository.NewNotificationsRepository(s.db.GetDB())
+	reservationsRepo := repository.NewReservationsRepository(s.db.GetDB())
+	webhooksRepo := repository.NewWebhooksRepository(s.db.GetDB())
+	waitlistRepo := repository.NewWaitlistRepository(s.db.GetDB())
+	policiesRepo := repository.NewPoliciesRepository(s.db.GetDB())
+	locationsRepo := repository.NewLocationsRepository(s.db.GetDB())
+	invitationsRepo := repository.NewInvitationsRepository(s.db.GetDB())
+	ownershipRepo := repository.NewOwnershipRepository(s.db.GetDB())
+	trashRepo := repository.NewTrashRepository(s.db.GetDB())
+	exportsRepo := repository.NewExportsRepository(s.db.GetDB())
+	auditRepo := repository.NewAuditRepository(s.db.GetDB())
+	organizationsRepo := repository.NewOrganizationsRepository(s.db.GetDB())
+	groupsRepo := repository.NewGroupsRepository(s.db.GetDB())
+
+	auditLog := &audit.Log{Repository: auditRepo}
+
+	// Administradores iniciais, para que o papel possa ser gerenciado pela API.
+	if adminEmails := envList("ADMIN_EMAILS"); len(adminEmails) > 0 {
+		promoted, err := userRepo.GrantAdmin(adminEmails)
+		if err != nil {
+			log.Fatalf("Failed to grant admin role: %v", err)
+		}
+		if promoted > 0 {
+			log.Printf("Granted admin role to %d user(s) from ADMIN_EMAILS", promoted)
+		}
+	}
+
+	// Consumidores de eventos. Os eventos chegam pelo outbox, depois do
+	// commit da mudança que os originou.
+	s.jobs.RegisterEvents(s.events)
+
+	notificationsService := notifications.Service{
+		NotificationsRepository: notificationsRepo,
+		RoomsRepository:         roomsRepo,
+		ReservationsRepository:  reservationsRepo,
+	}
+	notificationsService.Register(s.events)
+
+	s.hub = realtime.NewHub(roomsRepo.IsUserInRoom, allowedOrigins)
+	s.hub.Register(s.events)
+
+	s.stream = realtime.NewStream(1024, roomsRepo.GetUserRoomIDs)
+	s.stream.Register(s.events)
+
+	s.webhooks = webhooks.NewDispatcher(webhooksRepo, s.outbox)
+	s.webhooks.Register(s.events, s.jobs)
+
+	waitlistService := waitlist.Service{
+		WaitlistRepository:     waitlistRepo,
+		ReservationsRepository: reservationsRepo,
+		RoomsRepository:        roomsRepo,
+		Outbox:                 s.outbox,
+		HoldFor:                time.Duration(envInt("WAITLIST_HOLD_MINUTES", 30)) * time.Minute,
+	}
+	waitlistService.Register(s.events)
+
+	accountsService := accounts.Service{
+		UserRepository:         userRepo,
+		RoomsRepository:        roomsRepo,
+		OwnershipRepository:    ownershipRepo,
+		NotesRepository:        notesRepo,
+		ReservationsRepository: reservationsRepo,
+		WaitlistRepository:     waitlistRepo,
+		WebhooksRepository:     webhooksRepo,
+		ExportsRepository:      exportsRepo,
+		Outbox:                 s.outbox,
+		BlobStore:              s.blobs,
+		Audit:                  auditLog,
+		DeletionGrace:          time.Duration(envInt("ACCOUNT_DELETION_GRACE_DAYS", 14)) * 24 * time.Hour,
+		DeletedContent:         envString("ACCOUNT_DELETION_CONTENT", models.DeletedContentAnonymize),
+		ExportTTL:              time.Duration(envInt("DATA_EXPORT_TTL_HOURS", 24)) * time.Hour,
+	}
+	if !models.IsValidDeletedContent(accountsService.DeletedContent) {
+		log.Fatalf("Invalid ACCOUNT_DELETION_CONTENT %q, use anonymize or delete", accountsService.DeletedContent)
+	}
+	accountsService.Register(s.jobs)
+
+	checkInOpensBefore := time.Duration(envInt("CHECK_IN_OPENS_MINUTES", 15)) * time.Minute
+	checkInGrace := time.Duration(envInt("CHECK_IN_GRACE_MINUTES", 15)) * time.Minute
+
+	retention := time.Duration(envInt("SOFT_DELETE_RETENTION_DAYS", 30)) * 24 * time.Hour
+
+	// Tarefas agendadas
+	tasks := scheduler.Tasks{
+		ReservationsRepository: reservationsRepo,
+		RoomsRepository:        roomsRepo,
+		MaintenanceRepository:  repository.NewMaintenanceRepository(s.db.GetDB()),
+		AttachmentsRepository:  attachmentsRepo,
+		BlobStore:              s.blobs,
+		Outbox:                 s.outbox,
+		Waitlist:               &waitlistService,
+		Accounts:               &accountsService,
+		ReminderBefore:         time.Duration(envInt("RESERVATION_REMINDER_MINUTES", 15)) * time.Minute,
+		Retention:              retention,
+		JobRetention:           time.Duration(envInt("JOBS_RETENTION_DAYS", 7)) * 24 * time.Hour,
+		CheckInGrace:           checkInGrace,
+		ApprovalTimeout:        time.Duration(envInt("RESERVATION_APPROVAL_TIMEOUT_HOURS", 24)) * time.Hour,
+	}
+	if err := tasks.Register(s.cron); err != nil {
+		log.Fatalf("Failed to schedule tasks: %v", err)
+	}
 
 	// Criação dos Handlers
 	userHandler := handlers.UserHandler{
 		UserRepository: userRepo,
+		Accounts:       &accountsService,
+		Audit:          auditLog,
+	}
+
+	exportsHandler := handlers.ExportsHandler{
+		ExportsRepository: exportsRepo,
+		Accounts:          &accountsService,
 	}
 
 	authHandler := handlers.AuthHandler{
-		UserRepository: userRepo,
+		UserRepository:          userRepo,
+		OrganizationsRepository: organizationsRepo,
+		Audit:                   auditLog,
+		DefaultOrganization:     envString("DEFAULT_ORGANIZATION", models.DefaultOrganizationSlug),
+	}
+
+	organizationsHandler := handlers.OrganizationsHandler{
+		OrganizationsRepository: organizationsRepo,
+		RoomsRepository:         roomsRepo,
+		UserRepository:          userRepo,
+		Outbox:                  s.outbox,
+		Audit:                   auditLog,
+	}
+
+	groupsHandler := handlers.GroupsHandler{
+		GroupsRepository:        groupsRepo,
+		RoomsRepository:         roomsRepo,
+		OrganizationsRepository: organizationsRepo,
+		UserRepository:          userRepo,
+		Outbox:                  s.outbox,
+		Audit:                   auditLog,
+	}
+
+	adminHandler := handlers.AdminHandler{
+		UserRepository:        userRepo,
+		RoomsRepository:       roomsRepo,
+		NotesRepository:       notesRepo,
+		TrashRepository:       trashRepo,
+		AttachmentsRepository: attachmentsRepo,
+		AdminRepository:       repository.NewAdminRepository(s.db.GetDB()),
+		BlobStore:             s.blobs,
+		Outbox:                s.outbox,
+		Audit:                 auditLog,
+		PasswordResetTTL:      time.Duration(envInt("PASSWORD_RESET_TTL_HOURS", 24)) * time.Hour,
 	}
 
 	roomsHandler := handlers.RoomsHandler{
+		RoomsRepository:     roomsRepo,
+		LocationsRepository: locationsRepo,
+		Outbox:              s.outbox,
+		Audit:               auditLog,
+	}
+
+	invitationsHandler := handlers.InvitationsHandler{
+		InvitationsRepository: invitationsRepo,
+		RoomsRepository:       roomsRepo,
+		Outbox:                s.outbox,
+		Audit:                 auditLog,
+	}
+
+	ownershipHandler := handlers.OwnershipHandler{
+		OwnershipRepository: ownershipRepo,
+		RoomsRepository:     roomsRepo,
+		Outbox:              s.outbox,
+		Audit:               auditLog,
+	}
+
+	trashHandler := handlers.TrashHandler{
+		TrashRepository:       trashRepo,
+		AttachmentsRepository: attachmentsRepo,
+		BlobStore:             s.blobs,
+		Outbox:                s.outbox,
+		Audit:                 auditLog,
+		Retention:             retention,
+	}
+
+	auditHandler := handlers.AuditHandler{
+		AuditRepository: auditRepo,
 		RoomsRepository: roomsRepo,
+		TrashRepository: trashRepo,
+	}
+
+	locationsHandler := handlers.LocationsHandler{
+		LocationsRepository: locationsRepo,
 	}
 
 	notesHandler := handlers.NotesHandler{
-		NotesRepository: notesRepo,
-		RoomsRepository: roomsRepo,
+		NotesRepository:    notesRepo,
+		RoomsRepository:    roomsRepo,
+		MentionsRepository: mentionsRepo,
+		Outbox:             s.outbox,
+		Audit:              auditLog,
+	}
+
+	attachmentsHandler := handlers.AttachmentsHandler{
+		AttachmentsRepository: attachmentsRepo,
+		NotesRepository:       notesRepo,
+		RoomsRepository:       roomsRepo,
+		BlobStore:             s.blobs,
+	}
+
+	notificationsHandler := handlers.NotificationsHandler{
+		NotificationsRepository: notificationsRepo,
 	}
 
+	reservationsHandler := handlers.ReservationsHandler{
+		ReservationsRepository: reservationsRepo,
+		RoomsRepository:        roomsRepo,
+		PoliciesRepository:     policiesRepo,
+		UserRepository:         userRepo,
+		Outbox:                 s.outbox,
+		CheckInOpensBefore:     checkInOpensBefore,
+		CheckInGrace:           checkInGrace,
+	}
+
+	waitlistHandler := handlers.WaitlistHandler{
+		WaitlistRepository:     waitlistRepo,
+		ReservationsRepository: reservationsRepo,
+		RoomsRepository:        roomsRepo,
+		PoliciesRepository:     policiesRepo,
+		UserRepository:         userRepo,
+		Outbox:                 s.outbox,
+	}
+
+	policiesHandler := handlers.PoliciesHandler{
+		PoliciesRepository: policiesRepo,
+		RoomsRepository:    roomsRepo,
+	}
+
+	webhooksHandler := handlers.WebhooksHandler{
+		WebhooksRepository: webhooksRepo,
+		RoomsRepository:    roomsRepo,
+		Dispatcher:         s.webhooks,
+	}
+
+	realtimeHandler := handlers.RealtimeHandler{
+		Hub:            s.hub,
+		Stream:         s.stream,
+		UserRepository: userRepo,
+	}
+	notificationsService.OnCreate = realtimeHandler.PublishNotification
+
 	// Registro das rotas
 	r.Route("/api", func(r chi.Router) {
 		authHandler.RegisterAuthRoutes(r)
+		exportsHandler.RegisterExportDownloadRoutes(r)
 		r.Group(func(r chi.Router) {
 			r.Use(middlewares.AuthMiddleware)
+			r.Use(middlewares.ActiveUser(userRepo))
+			adminHandler.RegisterAdminRoutes(r)
+			auditHandler.RegisterAuditRoutes(r)
 			userHandler.RegisterUserRoutes(r)
-			roomsHandler.RegisterRoomsRoutes(r)
-			notesHandler.RegisterNotesRoutes(r)
+			exportsHandler.RegisterExportsRoutes(r)
+			organizationsHandler.RegisterOrganizationsRoutes(r)
+			notificationsHandler.RegisterNotificationsRoutes(r)
+
+			// Rotas da organização: as consultas ficam restritas à
+			// organização da requisição.
+			r.Group(func(r chi.Router) {
+				r.Use(middlewares.Tenant(organizationsRepo, envString("TENANT_BASE_DOMAIN", "")))
+				userHandler.RegisterDirectoryRoutes(r)
+				roomsHandler.RegisterRoomsRoutes(r)
+				invitationsHandler.RegisterInvitationsRoutes(r)
+				ownershipHandler.RegisterOwnershipRoutes(r)
+				groupsHandler.RegisterGroupsRoutes(r)
+				locationsHandler.RegisterLocationsRoutes(r)
+				notesHandler.RegisterNotesRoutes(r)
+				trashHandler.RegisterTrashRoutes(r)
+				auditHandler.RegisterRoomAuditRoutes(r)
+				attachmentsHandler.RegisterAttachmentsRoutes(r)
+				reservationsHandler.RegisterReservationsRoutes(r)
+				waitlistHandler.RegisterWaitlistRoutes(r)
+				policiesHandler.RegisterPoliciesRoutes(r)
+				webhooksHandler.RegisterWebhooksRoutes(r)
+			})
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.StreamAuthMiddleware)
+			r.Use(middlewares.ActiveUser(userRepo))
+			realtimeHandler.RegisterRealtimeRoutes(r)
 		})
 	})
 
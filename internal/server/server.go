@@ -2,29 +2,64 @@ package server
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/joho/godotenv/autoload"
 
 	"api-go/internal/database"
+	"api-go/internal/events"
+	"api-go/internal/jobs"
+	"api-go/internal/realtime"
+	"api-go/internal/scheduler"
+	"api-go/internal/storage"
+	"api-go/internal/webhooks"
 )
 
 type Server struct {
 	port int
 
-	db database.Service
+	db       database.Service
+	blobs    storage.BlobStore
+	events   *events.Bus
+	jobs     *jobs.Runner
+	outbox   *jobs.Outbox
+	cron     *scheduler.Scheduler
+	hub      *realtime.Hub
+	stream   *realtime.Stream
+	webhooks *webhooks.Dispatcher
 }
 
-func NewServer() *http.Server {
+// NewServer monta o servidor HTTP, o pool de workers do outbox e o
+// agendador de tarefas. Os dois últimos já saem iniciados e precisam ser
+// encerrados junto com o servidor.
+func NewServer() (*http.Server, *jobs.Runner, *scheduler.Scheduler) {
 	port, _ := strconv.Atoi(os.Getenv("PORT"))
+
+	workers, err := strconv.Atoi(os.Getenv("JOBS_WORKERS"))
+	if err != nil || workers <= 0 {
+		workers = 4
+	}
+
+	blobs, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to initialize blob storage: %v", err)
+	}
+
 	NewServer := &Server{
 		port: port,
 
-		db: database.New(),
+		db:     database.New(),
+		blobs:  blobs,
+		events: events.NewBus(1024),
 	}
+	NewServer.jobs = jobs.NewRunner(NewServer.db.GetDB(), workers)
+	NewServer.outbox = &jobs.Outbox{DB: NewServer.db.GetDB(), Runner: NewServer.jobs}
+	NewServer.cron = scheduler.New(NewServer.db.GetDB())
 
 	// Declare Server config
 	server := &http.Server{
@@ -35,5 +70,47 @@ func NewServer() *http.Server {
 		WriteTimeout: 30 * time.Second,
 	}
 
-	return server
+	// Desconecta os WebSockets e streams SSE, que de outra forma segurariam o
+	// Shutdown até o prazo acabar.
+	server.RegisterOnShutdown(func() {
+		NewServer.hub.Close()
+		NewServer.stream.Close()
+		NewServer.events.Close()
+	})
+
+	NewServer.jobs.Start()
+	NewServer.cron.Start()
+
+	return server, NewServer.jobs, NewServer.cron
+}
+
+// envInt lê um inteiro do ambiente, usando o padrão se a variável estiver
+// ausente ou inválida.
+func envInt(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value < 0 {
+		return fallback
+	}
+	return value
+}
+
+// envString lê um texto do ambiente, usando o padrão se a variável estiver
+// ausente.
+func envString(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// envList lê uma lista separada por vírgulas do ambiente, ignorando os itens
+// vazios.
+func envList(name string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
 }
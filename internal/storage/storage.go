@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// ErrNotFound é retornado quando o blob solicitado não existe no storage.
+var ErrNotFound = errors.New("blob not found")
+
+// Blob é o conteúdo de um objeto armazenado. Ele implementa io.ReadSeeker
+// para que downloads possam ser servidos com suporte a Range.
+type Blob interface {
+	io.ReadSeekCloser
+	Size() int64
+	ModTime() time.Time
+}
+
+// BlobStore abstrai o armazenamento dos arquivos enviados pela API.
+type BlobStore interface {
+	// Put grava o conteúdo de r sob a chave informada.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Get abre o blob armazenado sob a chave. Retorna ErrNotFound se ele não existir.
+	Get(ctx context.Context, key string) (Blob, error)
+
+	// Exists informa se existe um blob armazenado sob a chave.
+	Exists(ctx context.Context, key string) (bool, error)
+
+	// Delete remove o blob. Remover uma chave inexistente não é um erro.
+	Delete(ctx context.Context, key string) error
+}
+
+// CollectOrphans remove do storage os blobs que não são mais referenciados.
+// collect decide, com a chave travada contra novos uploads do mesmo
+// conteúdo, se o blob ainda é referenciado e, se não for, chama remove.
+// Falhas são apenas logadas: um blob órfão não afeta a consistência da API.
+func CollectOrphans(ctx context.Context, store BlobStore, keys []string, collect func(key string, remove func() error) error) {
+	for _, key := range keys {
+		err := collect(key, func() error {
+			return store.Delete(ctx, key)
+		})
+		if err != nil {
+			log.Printf("failed to collect orphan blob %s: %v", key, err)
+		}
+	}
+}
+
+// NewFromEnv cria o BlobStore configurado pela variável BLOB_STORE
+// ("local" por padrão ou "s3").
+func NewFromEnv() (BlobStore, error) {
+	switch driver := os.Getenv("BLOB_STORE"); driver {
+	case "", "local":
+		dir := os.Getenv("BLOB_LOCAL_DIR")
+		if dir == "" {
+			dir = "./data/blobs"
+		}
+		return NewLocalStore(dir)
+	case "s3":
+		return NewS3Store(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Region:    os.Getenv("S3_REGION"),
+			UseSSL:    os.Getenv("S3_USE_SSL") == "true",
+		})
+	default:
+		return nil, fmt.Errorf("unknown BLOB_STORE driver %q", driver)
+	}
+}
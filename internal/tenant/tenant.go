@@ -0,0 +1,84 @@
+// Package tenant isola as organizações no banco. A organização da
+// requisição vai no contexto, e os callbacks registrados no gorm acrescentam
+// a condição da organização a toda consulta, alteração e exclusão dos
+// modelos que pertencem a uma (models.TenantScoped) feita com esse
+// contexto. Sem organização no contexto, como nas tarefas agendadas e nas
+// rotas de administração, as consultas não são restritas.
+package tenant
+
+import (
+	"api-go/internal/models"
+	"context"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type contextKey struct{}
+
+// NewContext retorna uma cópia de ctx com a organização orgID.
+func NewContext(ctx context.Context, orgID uint) context.Context {
+	return context.WithValue(ctx, contextKey{}, orgID)
+}
+
+// FromContext retorna a organização guardada em ctx.
+func FromContext(ctx context.Context) (uint, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	orgID, ok := ctx.Value(contextKey{}).(uint)
+	return orgID, ok && orgID != 0
+}
+
+// Without retorna uma cópia de ctx sem organização, para as consultas que
+// precisam enxergar todas elas.
+func Without(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, uint(0))
+}
+
+// Register instala em db os callbacks que restringem as consultas à
+// organização do contexto. Deve ser chamado uma vez, logo depois de abrir a
+// conexão.
+func Register(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:query", scope); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("tenant:row", scope); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:update", scope); err != nil {
+		return err
+	}
+	return callbacks.Delete().Before("gorm:delete").Register("tenant:delete", scope)
+}
+
+// scope acrescenta a condição da organização ao WHERE da instrução. As
+// condições que já estavam nele são agrupadas antes, para que um OR entre
+// elas não escape da restrição. SQL escrito à mão (Raw e Exec) não passa
+// por aqui.
+func scope(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || stmt.SQL.Len() > 0 {
+		return
+	}
+	orgID, ok := FromContext(stmt.Context)
+	if !ok {
+		return
+	}
+	model, ok := reflect.New(stmt.Schema.ModelType).Interface().(models.TenantScoped)
+	if !ok {
+		return
+	}
+
+	condition := model.TenantScope(orgID)
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+			c.Expression = clause.Where{Exprs: []clause.Expression{clause.And(where.Exprs...), condition}}
+			stmt.Clauses["WHERE"] = c
+			return
+		}
+	}
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{condition}})
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -34,6 +35,16 @@ func IsValidEmail(email string) bool {
 	return matched
 }
 
+// MaskEmail esconde o email deixando só a primeira letra do usuário e o
+// domínio, como em "j***@example.com".
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return "***"
+	}
+	return email[:1] + "***" + email[at:]
+}
+
 func RespondWithError(w http.ResponseWriter, status int, message string) {
 	w.Header().Set("content-type", "application/json")
 	w.WriteHeader(status)
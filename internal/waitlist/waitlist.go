@@ -0,0 +1,178 @@
+// Package waitlist promove as entradas da lista de espera quando um lugar
+// fica livre: a primeira entrada que couber recebe uma reserva provisória,
+// que precisa ser confirmada dentro do prazo ou passa para a próxima.
+package waitlist
+
+import (
+	"api-go/internal/events"
+	"api-go/internal/jobs"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HoldExpiredReason identifica, nos eventos de expiração, as reservas
+// provisórias que não foram confirmadas.
+const HoldExpiredReason = "hold_expired"
+
+var errNotWaiting = errors.New("waitlist entry is no longer waiting")
+
+type Service struct {
+	WaitlistRepository     *repository.WaitlistRepository
+	ReservationsRepository *repository.ReservationsRepository
+	RoomsRepository        *repository.RoomsRepository
+	Outbox                 *jobs.Outbox
+
+	// HoldFor é o prazo para confirmar uma reserva provisória.
+	HoldFor time.Duration
+}
+
+// Register inscreve o serviço nos eventos que liberam lugares na sala.
+func (s *Service) Register(bus *events.Bus) {
+	bus.Subscribe(events.ReservationCancelled, s.onSeatFreed)
+	bus.Subscribe(events.ReservationRejected, s.onSeatFreed)
+	bus.Subscribe(events.ReservationExpired, s.onSeatFreed)
+	bus.Subscribe(events.ReservationUpdated, s.onSeatFreed)
+}
+
+// onSeatFreed pode rodar de novo para o mesmo evento: a entrada só é
+// cancelada enquanto ainda está oferecida, e a promoção só oferece lugares
+// que continuam livres.
+func (s *Service) onSeatFreed(e events.Event) error {
+	// Uma reserva provisória cancelada encerra a oferta da entrada.
+	if e.Type == events.ReservationCancelled && e.ReservationID != 0 {
+		entry, err := s.WaitlistRepository.GetByReservationID(e.ReservationID)
+		if err != nil {
+			return fmt.Errorf("loading waitlist entry of reservation %d: %w", e.ReservationID, err)
+		}
+		if entry != nil {
+			if _, err := s.WaitlistRepository.SetStatus(entry.ID, models.WaitlistCancelled, models.WaitlistOffered); err != nil {
+				return fmt.Errorf("cancelling waitlist entry %d: %w", entry.ID, err)
+			}
+		}
+	}
+
+	if err := s.Promote(e.RoomID); err != nil {
+		return fmt.Errorf("promoting waitlist of room %d: %w", e.RoomID, err)
+	}
+	return nil
+}
+
+// Promote oferece os lugares livres da sala às entradas que aguardam, por
+// ordem de chegada. Entradas cujo período continua lotado são puladas.
+func (s *Service) Promote(roomID uint) error {
+	room, err := s.RoomsRepository.FindByID(roomID)
+	if err != nil || room == nil {
+		return err
+	}
+
+	entries, err := s.WaitlistRepository.GetWaiting(roomID)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		err := s.offer(room, &entry)
+		switch {
+		case err == nil:
+		case errors.Is(err, repository.ErrReservationConflict),
+			errors.Is(err, repository.ErrReservationOverlap),
+			errors.Is(err, errNotWaiting):
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// offer cria a reserva provisória e a associa à entrada, na mesma transação.
+func (s *Service) offer(room *models.Room, entry *models.WaitlistEntry) error {
+	expiresAt := time.Now().Add(s.HoldFor)
+	if entry.StartTime.Before(expiresAt) {
+		expiresAt = entry.StartTime
+	}
+
+	return s.Outbox.Transaction(func(tx *gorm.DB) error {
+		reservation, err := s.ReservationsRepository.WithTx(tx).Create(entry.UserID, room.ID, entry.StartTime, entry.EndTime, models.ReservationHeld)
+		if err != nil {
+			return err
+		}
+
+		offered, err := s.WaitlistRepository.WithTx(tx).Offer(entry.ID, reservation.ID, expiresAt)
+		if err != nil {
+			return err
+		}
+		if !offered {
+			return errNotWaiting
+		}
+
+		if err := s.Outbox.Publish(tx, events.ForReservation(events.ReservationCreated, room, reservation, nil)); err != nil {
+			return err
+		}
+		return s.Outbox.Publish(tx, events.ForReservation(events.WaitlistOffered, room, reservation, map[string]any{
+			"waitlist_entry_id": entry.ID,
+			"expires_at":        expiresAt.Format(time.RFC3339),
+		}))
+	})
+}
+
+// ExpireOffers encerra as ofertas não confirmadas a tempo, liberando o lugar
+// para a próxima entrada, e as entradas cujo período já começou.
+func (s *Service) ExpireOffers(ctx context.Context) error {
+	now := time.Now()
+	if _, err := s.WaitlistRepository.WithContext(ctx).ExpireStarted(now); err != nil {
+		return err
+	}
+
+	entries, err := s.WaitlistRepository.WithContext(ctx).GetExpiredOffers(now)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.expire(ctx, &entry); err != nil {
+			log.Printf("failed to expire waitlist offer %d: %v", entry.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) expire(ctx context.Context, entry *models.WaitlistEntry) error {
+	room, err := s.RoomsRepository.WithContext(ctx).FindByID(entry.RoomID)
+	if err != nil {
+		return err
+	}
+
+	return s.Outbox.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		expired, err := s.WaitlistRepository.WithTx(tx).SetStatus(entry.ID, models.WaitlistExpired, models.WaitlistOffered)
+		if err != nil || !expired || entry.ReservationID == nil {
+			return err
+		}
+
+		released, err := s.ReservationsRepository.WithTx(tx).SetStatus(*entry.ReservationID, models.ReservationHeld, models.ReservationExpired)
+		if err != nil || !released || room == nil {
+			return err
+		}
+
+		reservation := models.Reservation{
+			UserID:    entry.UserID,
+			RoomID:    entry.RoomID,
+			StartTime: entry.StartTime,
+			EndTime:   entry.EndTime,
+			Status:    models.ReservationExpired,
+		}
+		reservation.ID = *entry.ReservationID
+		return s.Outbox.Publish(tx, events.ForReservation(events.ReservationExpired, room, &reservation, map[string]any{
+			"reason": HoldExpiredReason,
+		}))
+	})
+}
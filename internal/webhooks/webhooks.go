@@ -0,0 +1,384 @@
+// Package webhooks entrega os eventos das salas para URLs externas, com
+// assinatura HMAC e novas tentativas com backoff exponencial.
+package webhooks
+
+import (
+	"api-go/internal/events"
+	"api-go/internal/jobs"
+	"api-go/internal/models"
+	"api-go/internal/repository"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Headers enviados em toda entrega.
+const (
+	HeaderEventID   = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const (
+	// MaxAttempts é o número de tentativas antes de a entrega ir para o
+	// estado "dead".
+	MaxAttempts = 8
+
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+
+	requestTimeout = 10 * time.Second
+	dialTimeout    = 5 * time.Second
+
+	// Quanto da resposta de erro é guardado no log de entregas.
+	maxErrorBody = 512
+)
+
+// Payload é o corpo enviado para o webhook.
+type Payload struct {
+	ID         string         `json:"id"`
+	Type       string         `json:"type"`
+	OccurredAt time.Time      `json:"occurred_at"`
+	ActorID    uint           `json:"actor_id,omitempty"`
+	RoomID     uint           `json:"room_id,omitempty"`
+	NoteID     uint           `json:"note_id,omitempty"`
+	UserID     uint           `json:"user_id,omitempty"`
+	Data       map[string]any `json:"data,omitempty"`
+}
+
+// Sign calcula a assinatura de uma entrega: HMAC-SHA256 de
+// "<timestamp>.<corpo>" com o segredo do webhook, em hexadecimal e prefixada
+// por "sha256=". Incluir o timestamp permite ao receptor recusar replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify confere a assinatura recebida em tempo constante.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// NewSecret gera um segredo aleatório para um webhook novo.
+func NewSecret() (string, error) {
+	return randomHex(32)
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ErrForbiddenAddress indica um destino na rede interna: loopback, link-local
+// (onde ficam os metadados das nuvens), faixas privadas ou não especificado.
+var ErrForbiddenAddress = errors.New("address not allowed for webhooks")
+
+// allowedIP informa se o endereço pode receber entregas.
+func allowedIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsPrivate() && !ip.IsUnspecified()
+}
+
+// CheckHost resolve o host da URL de um webhook e recusa, com
+// ErrForbiddenAddress, os que apontam para a rede interna. A resolução pode
+// mudar depois do cadastro, então a entrega confere de novo cada conexão.
+func CheckHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !allowedIP(ip) {
+			return fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !allowedIP(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrForbiddenAddress, host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// dialControl recusa a conexão com endereços internos já resolvidos, o que
+// vale também para redirecionamentos e para um DNS que mude de resposta
+// entre o cadastro e a entrega (DNS rebinding).
+func dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !allowedIP(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+	return nil
+}
+
+// newClient cria o cliente das entregas, que só conecta com endereços
+// públicos.
+func newClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Por um proxy, a conexão conferida seria a dele, e não a do destino.
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout: dialTimeout,
+		Control: dialControl,
+	}).DialContext
+	return &http.Client{Timeout: requestTimeout, Transport: transport}
+}
+
+// Backoff retorna a espera antes da próxima tentativa, dobrando a cada falha.
+func Backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// KindDeliver é o job que faz uma tentativa de entrega.
+const KindDeliver = "webhook.deliver"
+
+type Dispatcher struct {
+	WebhooksRepository *repository.WebhooksRepository
+	Outbox             *jobs.Outbox
+	Client             *http.Client
+}
+
+func NewDispatcher(repo *repository.WebhooksRepository, outbox *jobs.Outbox) *Dispatcher {
+	return &Dispatcher{
+		WebhooksRepository: repo,
+		Outbox:             outbox,
+		Client:             newClient(),
+	}
+}
+
+// Register inscreve o dispatcher no barramento e registra o job de entrega,
+// que herda as tentativas e o backoff das entregas.
+func (d *Dispatcher) Register(bus *events.Bus, runner *jobs.Runner) {
+	bus.SubscribeAll(d.onEvent)
+	runner.Register(KindDeliver, d.deliver, jobs.RetryPolicy{
+		MaxAttempts: MaxAttempts,
+		Backoff:     Backoff,
+	})
+}
+
+func (d *Dispatcher) onEvent(e events.Event) error {
+	if !events.IsRoomEvent(e.Type) || e.RoomID == 0 {
+		return nil
+	}
+
+	var affected []uint
+	if e.Type == events.RoomMemberLeft && e.UserID != 0 {
+		affected = append(affected, e.UserID)
+	}
+
+	webhooks, err := d.WebhooksRepository.GetForRoomEvent(e.RoomID, affected...)
+	if err != nil {
+		return fmt.Errorf("loading webhooks of room %d: %w", e.RoomID, err)
+	}
+
+	// O receptor usa o ID do evento para descartar entregas repetidas.
+	eventID := e.ID
+	if eventID == "" {
+		eventID, err = randomHex(16)
+		if err != nil {
+			return fmt.Errorf("generating webhook event id: %w", err)
+		}
+	}
+
+	body, err := json.Marshal(Payload{
+		ID:         eventID,
+		Type:       e.Type,
+		OccurredAt: e.OccurredAt,
+		ActorID:    e.ActorID,
+		RoomID:     e.RoomID,
+		NoteID:     e.NoteID,
+		UserID:     e.UserID,
+		Data:       e.Data,
+	})
+	if err != nil {
+		return fmt.Errorf("encoding %s webhook payload: %w", e.Type, err)
+	}
+
+	var errs []error
+	for _, webhook := range webhooks {
+		if !webhook.Accepts(e.Type) {
+			continue
+		}
+		delivery := models.WebhookDelivery{
+			WebhookID: webhook.ID,
+			EventID:   eventID,
+			EventType: e.Type,
+			Payload:   string(body),
+		}
+		if err := d.queue(&delivery); err != nil {
+			errs = append(errs, fmt.Errorf("queueing %s delivery for webhook %d: %w", e.Type, webhook.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// queue grava a entrega e o job que a executa na mesma transação. A primeira
+// entrega de cada evento é única por webhook, então um evento processado de
+// novo não gera uma segunda entrega; as reentregas ficam de fora do índice.
+func (d *Dispatcher) queue(delivery *models.WebhookDelivery) error {
+	now := time.Now()
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.NextAttemptAt = &now
+
+	return d.Outbox.Transaction(func(tx *gorm.DB) error {
+		created, err := d.WebhooksRepository.WithTx(tx).CreateDelivery(delivery)
+		if err != nil || !created {
+			return err
+		}
+		return d.Outbox.Enqueue(tx, jobs.Job{
+			Kind:           KindDeliver,
+			Payload:        delivery.ID,
+			IdempotencyKey: fmt.Sprintf("%s:%d", KindDeliver, delivery.ID),
+		})
+	})
+}
+
+// deliver executa o job de entrega. Entregas que já terminaram são ignoradas,
+// o que torna o job idempotente.
+func (d *Dispatcher) deliver(ctx context.Context, job *models.OutboxJob) error {
+	var deliveryID uint
+	if err := json.Unmarshal([]byte(job.Payload), &deliveryID); err != nil {
+		return fmt.Errorf("%w: %v", jobs.ErrPermanent, err)
+	}
+
+	delivery, err := d.WebhooksRepository.GetDelivery(deliveryID)
+	if err != nil {
+		return err
+	}
+	if delivery == nil || delivery.Status != models.WebhookDeliveryPending {
+		return nil
+	}
+
+	return d.attempt(ctx, delivery)
+}
+
+// attempt faz uma tentativa de entrega e grava o resultado. O erro devolvido
+// faz o runner agendar a próxima tentativa; na última, a entrega vai para
+// "dead".
+func (d *Dispatcher) attempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	webhook, err := d.WebhooksRepository.GetByID(delivery.WebhookID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var sendErr error
+	if webhook == nil || !webhook.Active {
+		record(delivery, now, 0, errWebhookDisabled)
+	} else {
+		var status int
+		status, sendErr = d.send(ctx, webhook, delivery)
+		record(delivery, now, status, sendErr)
+	}
+
+	if err := d.WebhooksRepository.SaveAttempt(delivery); err != nil {
+		return err
+	}
+	if delivery.Status == models.WebhookDeliveryPending {
+		return sendErr
+	}
+	return nil
+}
+
+var errWebhookDisabled = errors.New("webhook disabled")
+
+// record aplica à entrega o resultado de uma tentativa: sucesso, nova
+// tentativa com backoff ou, esgotadas as tentativas (ou com o webhook
+// desativado), "dead".
+func record(delivery *models.WebhookDelivery, now time.Time, status int, err error) {
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = status
+
+	switch {
+	case err == nil:
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.NextAttemptAt = nil
+		delivery.LastError = ""
+	case errors.Is(err, errWebhookDisabled) || delivery.Attempts >= MaxAttempts:
+		delivery.Status = models.WebhookDeliveryDead
+		delivery.NextAttemptAt = nil
+		delivery.LastError = err.Error()
+	default:
+		next := now.Add(Backoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+		delivery.LastError = err.Error()
+	}
+}
+
+// send envia a requisição assinada. Apenas respostas 2xx contam como sucesso.
+func (d *Dispatcher) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "api-go-webhooks/1.0")
+	req.Header.Set(HeaderEventID, delivery.EventID)
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, body))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+	return resp.StatusCode, nil
+}
+
+// Redeliver agenda uma nova entrega do mesmo evento, com as tentativas
+// zeradas. A entrega original continua no log.
+func (d *Dispatcher) Redeliver(original *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	delivery := models.WebhookDelivery{
+		WebhookID:  original.WebhookID,
+		EventID:    original.EventID,
+		EventType:  original.EventType,
+		Payload:    original.Payload,
+		Redelivery: true,
+	}
+	if err := d.queue(&delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
@@ -0,0 +1,150 @@
+package webhooks
+
+import (
+	"api-go/internal/models"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignKnownValue(t *testing.T) {
+	body := []byte(`{"id":"evt_1","type":"note.created"}`)
+	want := "sha256=78af11a0f0cdbd05549cca3b34850bfc5fb3fec83d62f8cb53064d6b32d84aae"
+
+	if got := Sign("whsec_test", 1700000000, body); got != want {
+		t.Fatalf("Sign() = %q, want %q", got, want)
+	}
+	if !Verify("whsec_test", 1700000000, body, want) {
+		t.Fatal("Verify() rejected a valid signature")
+	}
+	if Verify("whsec_other", 1700000000, body, want) {
+		t.Fatal("Verify() accepted a signature made with another secret")
+	}
+	if Verify("whsec_test", 1700000001, body, want) {
+		t.Fatal("Verify() accepted a signature made with another timestamp")
+	}
+}
+
+// newDelivery serve as respostas de handler e devolve o webhook e a entrega
+// que apontam para ele. O Dispatcher usa um cliente comum, já que o
+// httptest escuta no loopback.
+func newDelivery(t *testing.T, handler http.HandlerFunc) (*Dispatcher, *models.Webhook, *models.WebhookDelivery) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	webhook := &models.Webhook{URL: server.URL, Secret: "whsec_test", Active: true}
+	delivery := &models.WebhookDelivery{
+		EventID:   "evt_1",
+		EventType: "note.created",
+		Payload:   `{"id":"evt_1","type":"note.created"}`,
+		Status:    models.WebhookDeliveryPending,
+	}
+	return &Dispatcher{Client: server.Client()}, webhook, delivery
+}
+
+func TestSendSignsRequest(t *testing.T) {
+	var signatureOK bool
+	d, webhook, delivery := newDelivery(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		signatureOK = err == nil &&
+			r.Header.Get(HeaderEventID) == "evt_1" &&
+			r.Header.Get(HeaderEvent) == "note.created" &&
+			Verify("whsec_test", timestamp, body, r.Header.Get(HeaderSignature))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if _, err := d.send(context.Background(), webhook, delivery); err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+	if !signatureOK {
+		t.Fatal("request headers or signature did not match the body")
+	}
+}
+
+func TestAttemptSucceedsOn2xx(t *testing.T) {
+	d, webhook, delivery := newDelivery(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	now := time.Now()
+	status, err := d.send(context.Background(), webhook, delivery)
+	record(delivery, now, status, err)
+
+	if delivery.Status != models.WebhookDeliverySucceeded {
+		t.Fatalf("status = %q, want %q", delivery.Status, models.WebhookDeliverySucceeded)
+	}
+	if delivery.ResponseStatus != http.StatusOK || delivery.Attempts != 1 || delivery.NextAttemptAt != nil {
+		t.Fatalf("unexpected delivery state: %+v", delivery)
+	}
+}
+
+func TestAttemptSchedulesRetryOn5xx(t *testing.T) {
+	d, webhook, delivery := newDelivery(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	})
+
+	now := time.Now()
+	status, err := d.send(context.Background(), webhook, delivery)
+	if err == nil {
+		t.Fatal("send() accepted a 500 response")
+	}
+	record(delivery, now, status, err)
+
+	if delivery.Status != models.WebhookDeliveryPending {
+		t.Fatalf("status = %q, want %q", delivery.Status, models.WebhookDeliveryPending)
+	}
+	if delivery.ResponseStatus != http.StatusInternalServerError || delivery.LastError == "" {
+		t.Fatalf("unexpected delivery state: %+v", delivery)
+	}
+	if delivery.NextAttemptAt == nil || !delivery.NextAttemptAt.Equal(now.Add(Backoff(1))) {
+		t.Fatalf("next attempt = %v, want %v", delivery.NextAttemptAt, now.Add(Backoff(1)))
+	}
+}
+
+func TestRecordGivesUpAfterMaxAttempts(t *testing.T) {
+	delivery := &models.WebhookDelivery{Status: models.WebhookDeliveryPending, Attempts: MaxAttempts - 1}
+	record(delivery, time.Now(), http.StatusBadGateway, errors.New("unexpected status 502"))
+
+	if delivery.Status != models.WebhookDeliveryDead || delivery.NextAttemptAt != nil {
+		t.Fatalf("unexpected delivery state: %+v", delivery)
+	}
+}
+
+func TestCheckHostRejectsInternalAddresses(t *testing.T) {
+	for _, host := range []string{"127.0.0.1", "::1", "169.254.169.254", "10.0.0.5", "172.16.1.1", "192.168.0.10", "fd00::1", "0.0.0.0", "localhost"} {
+		if err := CheckHost(context.Background(), host); !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("CheckHost(%q) = %v, want ErrForbiddenAddress", host, err)
+		}
+	}
+	if err := CheckHost(context.Background(), "93.184.216.34"); err != nil {
+		t.Errorf("CheckHost(public IP) = %v", err)
+	}
+}
+
+func TestDeliveryClientRefusesLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	d := &Dispatcher{Client: newClient()}
+	webhook := &models.Webhook{URL: server.URL, Secret: "whsec_test", Active: true}
+	delivery := &models.WebhookDelivery{EventID: "evt_1", EventType: "note.created", Payload: "{}"}
+
+	_, err := d.send(context.Background(), webhook, delivery)
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("send() to loopback error = %v, want ErrForbiddenAddress", err)
+	}
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		t.Fatalf("send() error is not a dial error: %v", err)
+	}
+}
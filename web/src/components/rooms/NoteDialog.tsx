@@ -19,6 +19,7 @@ import type { Note, CreateNoteRequest, UpdateNoteRequest } from '@/types/api';
 const noteSchema = z.object({
   title: z.string().min(1, 'Título é obrigatório').max(100, 'Título deve ter menos de 100 caracteres'),
   content: z.string().min(1, 'Conteúdo é obrigatório').max(5000, 'Conteúdo deve ter menos de 5000 caracteres'),
+  format: z.enum(['plain', 'markdown']),
 });
 
 type NoteFormData = z.infer<typeof noteSchema>;
@@ -57,10 +58,12 @@ export const NoteDialog: React.FC<NoteDialogProps> = ({
       if (note) {
         setValue('title', note.title);
         setValue('content', note.content);
+        setValue('format', note.format ?? 'plain');
       } else {
         reset({
           title: '',
-          content: ''
+          content: '',
+          format: 'plain'
         });
       }
       setError(null);
@@ -76,6 +79,7 @@ export const NoteDialog: React.FC<NoteDialogProps> = ({
         const updateRequest: UpdateNoteRequest = {
           title: data.title,
           content: data.content,
+          format: data.format,
         };
         await notesApi.updateNote(note.id, updateRequest);
       } else {
@@ -83,6 +87,7 @@ export const NoteDialog: React.FC<NoteDialogProps> = ({
           room_id: roomId,
           title: data.title,
           content: data.content,
+          format: data.format,
         };
         await notesApi.createNote(createRequest);
       }
@@ -151,6 +156,14 @@ export const NoteDialog: React.FC<NoteDialogProps> = ({
               <Label htmlFor="content" className="text-sm font-semibold">
                 Conteúdo da Nota
               </Label>
+              <select
+                id="format"
+                className="rounded-md border border-input bg-background px-2 py-1 text-xs"
+                {...register('format')}
+              >
+                <option value="plain">Texto simples</option>
+                <option value="markdown">Markdown</option>
+              </select>
               <span className="text-xs text-muted-foreground">
                 {register('content').name && errors.content ? '0' : (document.getElementById('content') as HTMLTextAreaElement)?.value?.length || 0}/5000
               </span>
@@ -22,6 +22,7 @@ import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card';
 import { roomApi, notesApi } from '@/services/api';
 import type { Room, Note } from '@/types/api';
 import { NoteDialog } from './NoteDialog';
+import { useRoomUpdates } from '@/hooks/useRoomUpdates';
 
 export const RoomDetails: React.FC = () => {
   const { id } = useParams<{ id: string }>();
@@ -80,6 +81,18 @@ export const RoomDetails: React.FC = () => {
     }
   }, [isUserMember, roomId]);
 
+  const viewers = useRoomUpdates(roomId, isUserMember, async (event) => {
+    if (event.type.startsWith('note.')) {
+      fetchNotes();
+    } else if (event.type.startsWith('room.')) {
+      try {
+        setRoom(await roomApi.getRoomById(roomId));
+      } catch (err: any) {
+        console.error('Failed to refresh room:', err);
+      }
+    }
+  });
+
   const handleJoinRoom = async () => {
     try {
       await roomApi.joinRoom(roomId);
@@ -198,6 +211,11 @@ export const RoomDetails: React.FC = () => {
                       <Calendar className="h-3 w-3 ml-3 mr-1" />
                       {room.capacity} capacidade
                     </p>
+                    {viewers.length > 0 && (
+                      <p className="text-xs text-muted-foreground mt-1">
+                        Vendo agora: {viewers.map(viewer => viewer.name).join(', ')}
+                      </p>
+                    )}
                   </div>
                 </div>
               </div>
@@ -379,11 +397,14 @@ export const RoomDetails: React.FC = () => {
                             </div>
                             
                             <div className="prose prose-sm max-w-none mb-3">
-                              <p className="text-muted-foreground leading-relaxed">
-                                {expandedNote === note.id ? note.content : (
+                              <div className="text-muted-foreground leading-relaxed">
+                                {expandedNote === note.id ? (
+                                  // content_html é sanitizado pelo servidor
+                                  <div dangerouslySetInnerHTML={{ __html: note.content_html }} />
+                                ) : (
                                   <>
-                                    {note.content.substring(0, 200)}
-                                    {note.content.length > 200 && (
+                                    {note.excerpt}
+                                    {(note.content.length > 200 || note.format === 'markdown') && (
                                       <button
                                         onClick={() => setExpandedNote(note.id)}
                                         className="text-primary hover:underline ml-1 font-medium"
@@ -393,7 +414,7 @@ export const RoomDetails: React.FC = () => {
                                     )}
                                   </>
                                 )}
-                                {expandedNote === note.id && note.content.length > 200 && (
+                                {expandedNote === note.id && (note.content.length > 200 || note.format === 'markdown') && (
                                   <button
                                     onClick={() => setExpandedNote(null)}
                                     className="text-primary hover:underline ml-2 font-medium"
@@ -401,7 +422,7 @@ export const RoomDetails: React.FC = () => {
                                     Mostrar menos
                                   </button>
                                 )}
-                              </p>
+                              </div>
                             </div>
                             
                             <div className="flex items-center text-xs text-muted-foreground space-x-4">
@@ -0,0 +1,67 @@
+import { useEffect, useRef, useState } from 'react';
+import { WS_URL } from '@/services/api';
+import type { PresenceUser, RoomEvent, RealtimeMessage } from '@/types/api';
+
+const RECONNECT_DELAY = 3000;
+
+// Acompanha uma sala pelo WebSocket: repassa os eventos recebidos e mantém
+// a lista de quem está vendo a sala agora.
+export const useRoomUpdates = (
+  roomId: number,
+  enabled: boolean,
+  onEvent: (event: RoomEvent) => void,
+) => {
+  const [viewers, setViewers] = useState<PresenceUser[]>([]);
+  const onEventRef = useRef(onEvent);
+  onEventRef.current = onEvent;
+
+  useEffect(() => {
+    if (!roomId || !enabled) {
+      setViewers([]);
+      return;
+    }
+
+    let socket: WebSocket | null = null;
+    let reconnectTimer: ReturnType<typeof setTimeout> | undefined;
+    let stopped = false;
+
+    const connect = () => {
+      const token = localStorage.getItem('auth_token');
+      if (!token) return;
+
+      socket = new WebSocket(`${WS_URL}?token=${encodeURIComponent(token)}`);
+
+      socket.onopen = () => {
+        socket?.send(JSON.stringify({ action: 'subscribe', room_id: roomId }));
+      };
+
+      socket.onmessage = (message) => {
+        const data: RealtimeMessage = JSON.parse(message.data);
+        if (data.room_id !== roomId) return;
+
+        if (data.type === 'presence') {
+          setViewers(data.users || []);
+        } else if (data.event) {
+          onEventRef.current(data.event);
+        }
+      };
+
+      socket.onclose = () => {
+        setViewers([]);
+        if (!stopped) {
+          reconnectTimer = setTimeout(connect, RECONNECT_DELAY);
+        }
+      };
+    };
+
+    connect();
+
+    return () => {
+      stopped = true;
+      clearTimeout(reconnectTimer);
+      socket?.close();
+    };
+  }, [roomId, enabled]);
+
+  return viewers;
+};
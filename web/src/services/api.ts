@@ -12,6 +12,7 @@ import type {
 } from '../types/api';
 
 const API_BASE_URL = 'http://localhost:8080/api';
+export const WS_URL = API_BASE_URL.replace(/^http/, 'ws') + '/ws';
 
 const api = axios.create({
   baseURL: API_BASE_URL,
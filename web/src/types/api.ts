@@ -2,6 +2,7 @@ export interface User {
   id: number;
   name: string;
   email: string;
+  time_zone: string;
 }
 
 
@@ -13,6 +14,15 @@ export interface Room {
   subject: string;
   description?: string;
   created_by: number;
+  time_zone: string;
+  check_in_required: boolean;
+  requires_approval: boolean;
+  visibility: 'public' | 'unlisted' | 'private';
+  archived_at?: string | null;
+  floor_id?: number | null;
+  plan_x?: number | null;
+  plan_y?: number | null;
+  amenities?: string[];
   members?: RoomMember[];
   notes?: Note[];
   created_at: string;
@@ -25,6 +35,14 @@ export interface Reservation {
   room_id: number;
   start_time: string;
   end_time: string;
+  time_zone: string;
+  local_start_time: string;
+  local_end_time: string;
+  status: 'pending' | 'approved' | 'rejected' | 'expired' | 'held';
+  decided_by?: number | null;
+  decided_at?: string | null;
+  decision_reason?: string;
+  checked_in_at?: string | null;
   user?: User;
   room?: Room;
 }
@@ -55,12 +73,24 @@ export interface RoomMember {
   joined_at: string;
 }
 
+export type NoteFormat = 'plain' | 'markdown';
+
+export interface NoteHeading {
+  level: number;
+  text: string;
+  id: string;
+}
+
 export interface Note {
   id: number;
   user_id: number;
   room_id: number;
   title: string;
   content: string;
+  format: NoteFormat;
+  content_html: string;
+  excerpt: string;
+  table_of_contents?: NoteHeading[];
   user_name?: string;
   user_email?: string;
   room_name?: string;
@@ -72,13 +102,39 @@ export interface CreateNoteRequest {
   room_id: number;
   title: string;
   content: string;
+  format?: NoteFormat;
 }
 
 export interface UpdateNoteRequest {
   title: string;
   content: string;
+  format?: NoteFormat;
 }
 
 export interface ApiError {
   message: string;
 }
+
+export interface PresenceUser {
+  user_id: number;
+  name: string;
+}
+
+export interface RoomEvent {
+  type: string;
+  actor_id?: number;
+  room_id?: number;
+  note_id?: number;
+  reservation_id?: number;
+  user_id?: number;
+  data?: Record<string, unknown>;
+  occurred_at: string;
+}
+
+export interface RealtimeMessage {
+  type: string;
+  room_id?: number;
+  event?: RoomEvent;
+  users?: PresenceUser[];
+  message?: string;
+}